@@ -1,8 +1,8 @@
-package main
+package hk
 
 import (
-	"os"
 	"sort"
+	"strings"
 	"text/tabwriter"
 	"time"
 
@@ -11,44 +11,70 @@ import (
 
 var cmdAccess = &Command{
 	Run:      runAccess,
-	Usage:    "access",
+	Usage:    "access [-j]",
 	NeedsApp: true,
 	Category: "access",
 	Short:    "list access permissions" + extra,
 	Long: `
 List access permissions for an app. The owner is shown first, and
-collaborators are then listed alphabetically.
+collaborators are then listed alphabetically. For apps owned by an
+organization, each member's app permissions are shown too.
+
+Options:
+
+    -j, --json  print access permissions as JSON
 
 Examples:
 
     $ hk access
-    b@heroku.com    owner
-    max@heroku.com  collaborator
+    b@heroku.com    owner         Jan 2 12:34
+    max@heroku.com  collaborator  Jan 2 12:34
+
+    $ hk access -a acme-api
+    acme@herokumanager.com  owner         Jan 2 12:34
+    max@heroku.com          collaborator  Jan 2 12:34  deploy,view
 `,
 }
 
-func runAccess(cmd *Command, args []string) {
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+func init() {
+	registerList(cmdAccess, (*mergedAccess)(nil))
+}
+
+func runAccess(ctx *Context, args []string) {
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
 	defer w.Flush()
 
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
+	}
+	ma := getMergedAccess(ctx.MustApp())
+	if printList(ctx.Stdout, ma) {
+		return
 	}
-	ma := getMergedAccess(mustApp())
 	for _, m := range ma {
-		listRec(w,
-			m.User,
-			m.Role,
-			prettyTime{m.Time},
-		)
+		if m.Permissions == nil {
+			listRec(w,
+				m.User,
+				m.Role,
+				prettyTime{m.Time},
+			)
+		} else {
+			listRec(w,
+				m.User,
+				m.Role,
+				prettyTime{m.Time},
+				strings.Join(m.Permissions, ","),
+			)
+		}
 	}
 }
 
 type mergedAccess struct {
-	User string
-	Role string
-	Time time.Time
+	User        string    `json:"user"`
+	Role        string    `json:"role"`
+	Time        time.Time `json:"updated_at"`
+	Permissions []string  `json:"permissions,omitempty"` // org apps only
 }
 
 func getMergedAccess(appname string) []*mergedAccess {
@@ -71,7 +97,44 @@ func getMergedAccess(appname string) []*mergedAccess {
 	if err := <-ch; err != nil {
 		printFatal(err.Error())
 	}
-	return mergeAccess(app, collaborators)
+	ma := mergeAccess(app, collaborators)
+	if _, ok := appOrg(app); ok {
+		var members []teamCollaborator
+		must(client.Get(&members, "/teams/apps/"+appname+"/collaborators"))
+		addPermissions(ma, members)
+	}
+	return ma
+}
+
+// A teamCollaborator is a collaborator on an app owned by an
+// organization, as returned by the teams collaborator API.
+type teamCollaborator struct {
+	User struct {
+		Email string `json:"email"`
+	} `json:"user"`
+	Permissions []struct {
+		Name string `json:"name"`
+	} `json:"permissions"`
+}
+
+// addPermissions fills in the org app permissions of each user in ma.
+func addPermissions(ma []*mergedAccess, members []teamCollaborator) {
+	perms := make(map[string][]string)
+	for _, c := range members {
+		names := []string{}
+		for _, p := range c.Permissions {
+			names = append(names, p.Name)
+		}
+		sort.Strings(names)
+		perms[c.User.Email] = names
+	}
+	for _, m := range ma {
+		if p, ok := perms[m.User]; ok {
+			m.Permissions = p
+		} else {
+			m.Permissions = []string{}
+		}
+	}
 }
 
 type accessByRoleAndUser []*mergedAccess
@@ -102,7 +165,7 @@ func mergeAccess(app *heroku.App, collaborators []heroku.Collaborator) (ma []*me
 
 var cmdAccessAdd = &Command{
 	Run:      runAccessAdd,
-	Usage:    "access-add [-s] <email>",
+	Usage:    "access-add [-s] [--permissions <perms>] <email>",
 	NeedsApp: true,
 	Category: "access",
 	Short:    "give a user access to an app" + extra,
@@ -111,33 +174,109 @@ Give another Heroku user access to an app.
 
 Options:
 
-    -s  add user silently with no email notification
+    -s                     add user silently with no email
+                           notification
+    --permissions <perms>  comma-separated app permissions to give
+                           the user (e.g. deploy,operate,view), for
+                           apps owned by an organization
 
 Examples:
 
     $ hk access-add user@me.com
 
     $ hk access-add -s anotheruser@me.com
+
+    $ hk access-add -a acme-api --permissions deploy,view user@me.com
 `,
 }
 
-var flagSilent bool
+var (
+	flagSilent      bool
+	flagPermissions string
+)
 
 func init() {
 	cmdAccessAdd.Flag.BoolVar(&flagSilent, "s", false, "add user silently with no email notification")
+	cmdAccessAdd.Flag.StringVar(&flagPermissions, "permissions", "", "org app permissions")
+	cmdAccessUpdate.Flag.StringVar(&flagPermissions, "permissions", "", "org app permissions")
 }
 
-func runAccessAdd(cmd *Command, args []string) {
-	appname := mustApp()
+func runAccessAdd(ctx *Context, args []string) {
+	appname := ctx.MustApp()
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
+	}
+	if flagPermissions != "" {
+		mustBeOrgApp(appname)
+		body := struct {
+			User        string   `json:"user"`
+			Permissions []string `json:"permissions"`
+			Silent      bool     `json:"silent"`
+		}{args[0], splitPermissions(flagPermissions), flagSilent}
+		must(ctx.Client.Post(nil, "/teams/apps/"+appname+"/collaborators", body))
+		return
 	}
 	opts := heroku.CollaboratorCreateOpts{Silent: &flagSilent}
-	_, err := client.CollaboratorCreate(appname, args[0], &opts)
+	_, err := ctx.Client.CollaboratorCreate(appname, args[0], &opts)
 	must(err)
 }
 
+var cmdAccessUpdate = &Command{
+	Run:      runAccessUpdate,
+	Usage:    "access-update --permissions <perms> <email>",
+	NeedsApp: true,
+	Category: "access",
+	Short:    "change a user's app permissions" + extra,
+	Long: `
+Change the permissions a collaborator has on an app owned by an
+organization. The given permissions replace the user's current
+ones.
+
+Options:
+
+    --permissions <perms>  comma-separated app permissions (e.g.
+                           deploy,operate,view)
+
+Example:
+
+    $ hk access-update -a acme-api --permissions view user@me.com
+`,
+}
+
+func runAccessUpdate(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 || flagPermissions == "" {
+		ctx.printUsage()
+		exit(2)
+	}
+	mustBeOrgApp(appname)
+	body := struct {
+		Permissions []string `json:"permissions"`
+	}{splitPermissions(flagPermissions)}
+	must(ctx.Client.Patch(nil, "/teams/apps/"+appname+"/collaborators/"+args[0], body))
+}
+
+// mustBeOrgApp exits with an error unless an organization owns the app.
+func mustBeOrgApp(appname string) {
+	app, err := client.AppInfo(appname)
+	must(err)
+	if _, ok := appOrg(app); !ok {
+		printFatal("%s is not owned by an organization; --permissions only applies to organization apps.", appname)
+	}
+}
+
+// splitPermissions parses a comma-separated list of permission names.
+func splitPermissions(s string) []string {
+	var perms []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			perms = append(perms, p)
+		}
+	}
+	return perms
+}
+
 var cmdAccessRemove = &Command{
 	Run:      runAccessRemove,
 	Usage:    "access-remove <email>",
@@ -153,11 +292,11 @@ Examples:
 `,
 }
 
-func runAccessRemove(cmd *Command, args []string) {
-	appname := mustApp()
+func runAccessRemove(ctx *Context, args []string) {
+	appname := ctx.MustApp()
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	must(client.CollaboratorDelete(appname, args[0]))
+	must(ctx.Client.CollaboratorDelete(appname, args[0]))
 }
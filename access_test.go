@@ -0,0 +1,38 @@
+package hk
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestAddPermissions(t *testing.T) {
+	var members []teamCollaborator
+	err := json.Unmarshal([]byte(`[
+		{"user": {"email": "max@heroku.com"}, "permissions": [{"name": "view"}, {"name": "deploy"}]},
+		{"user": {"email": "acme@herokumanager.com"}, "permissions": []}
+	]`), &members)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ma := []*mergedAccess{
+		{User: "acme@herokumanager.com", Role: "owner"},
+		{User: "max@heroku.com", Role: "collaborator"},
+		{User: "new@heroku.com", Role: "collaborator"},
+	}
+	addPermissions(ma, members)
+	want := [][]string{{}, {"deploy", "view"}, {}}
+	for i, m := range ma {
+		if !reflect.DeepEqual(m.Permissions, want[i]) {
+			t.Errorf("%s permissions = %q, want %q", m.User, m.Permissions, want[i])
+		}
+	}
+}
+
+func TestSplitPermissions(t *testing.T) {
+	got := splitPermissions("deploy, operate,,view ")
+	want := []string{"deploy", "operate", "view"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitPermissions = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package hk
+
+import (
+	"os"
+	"strings"
+
+	"github.com/mgutz/ansi"
+)
+
+// accessibleOutput is set when output should suit screen readers: no
+// colors, text markers in place of color-only signals, and progress
+// reported in whole lines instead of redrawn in place.
+var accessibleOutput bool
+
+// initAccessibility turns on accessible output if HKACCESSIBLE is set, or
+// the config file sets accessible = true.
+func initAccessibility() {
+	switch strings.ToLower(os.Getenv("HKACCESSIBLE")) {
+	case "":
+		accessibleOutput = configValue("accessible") == "true"
+	case "0", "false", "no":
+		accessibleOutput = false
+	default:
+		accessibleOutput = true
+	}
+	if accessibleOutput {
+		ansi.DisableColors(true)
+	}
+}
+
+// textMarker returns the marker for a message prefix such as "error:",
+// e.g. "[ERROR]".
+func textMarker(prefix string) string {
+	return "[" + strings.ToUpper(strings.TrimSuffix(prefix, ":")) + "]"
+}
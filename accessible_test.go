@@ -0,0 +1,25 @@
+package hk
+
+import (
+	"testing"
+)
+
+func TestAccessibleMessages(t *testing.T) {
+	defer func(a bool) { accessibleOutput = a }(accessibleOutput)
+	accessibleOutput = true
+	tests := []struct {
+		color, prefix, want string
+	}{
+		{"red", "error:", "[ERROR] no app specified"},
+		{"yellow", "warning:", "[WARNING] no app specified"},
+		{"", "", "no app specified"},
+	}
+	for _, test := range tests {
+		if got := colorizeMessage(test.color, test.prefix, "no %s specified", "app"); got != test.want {
+			t.Errorf("colorizeMessage(%q, %q) => %q, want %q", test.color, test.prefix, got, test.want)
+		}
+	}
+	if got := statusValueFromColor("yellow"); got != "[WARNING] Minor issues (yellow)." {
+		t.Errorf("statusValueFromColor(yellow) => %q", got)
+	}
+}
@@ -0,0 +1,175 @@
+package hk
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+var cmdAccountDefaults = &Command{
+	Run:      runAccountDefaults,
+	Usage:    "account-defaults <name> [<key>=<value>...]",
+	Category: "hk",
+	Short:    "set flag defaults for an account profile" + extra,
+	Long: `
+Account-defaults sets defaults for flags that are often the same for
+every command run with an account profile, such as the org new apps
+are created in. Without any key=value pairs, it lists the profile's
+defaults. An empty value, as in org=, removes a default.
+
+Keys are:
+
+    org       --org for create
+    region    -r for create
+    size      -s for run
+    releases  -n for releases
+
+A default is used when the flag isn't given. Each key can also be
+set in the environment, which overrides the profile; see 'hk help
+environ'. Flags come first, then the environment, then the profile
+in use, and then hk's own defaults.
+
+Examples:
+
+    $ hk account-defaults client-a org=client-a region=eu size=Standard-2X
+    Set 3 defaults for account client-a.
+
+    $ hk account-defaults client-a
+    org     client-a
+    region  eu
+    size    Standard-2X
+`,
+}
+
+// A flagDefault is a flag of a command whose default can be set per
+// account profile, or in the environment.
+type flagDefault struct {
+	Key     string // key in account profiles
+	Env     string // environment variable
+	Command string
+	Flag    string
+	Int     bool // the flag takes an integer
+}
+
+var flagDefaults = []flagDefault{
+	{Key: "org", Env: "HKORG", Command: "create", Flag: "org"},
+	{Key: "region", Env: "HKREGION", Command: "create", Flag: "r"},
+	{Key: "size", Env: "HKSIZE", Command: "run", Flag: "s"},
+	{Key: "releases", Env: "HKRELEASES", Command: "releases", Flag: "n", Int: true},
+}
+
+func lookupFlagDefault(key string) (flagDefault, bool) {
+	for _, d := range flagDefaults {
+		if d.Key == key {
+			return d, true
+		}
+	}
+	return flagDefault{}, false
+}
+
+func (d flagDefault) check(value string) error {
+	if d.Int {
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%s must be a number, not %q", d.Key, value)
+		}
+	}
+	return nil
+}
+
+func runAccountDefaults(ctx *Context, args []string) {
+	if len(args) < 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	name := args[0]
+	accounts, err := loadAccounts()
+	must(err)
+	a, ok := accounts[name]
+	if !ok {
+		printFatal("no account named %s. Add it with `hk account-add`.", name)
+	}
+
+	if len(args) == 1 {
+		var keys []string
+		for k := range a.Defaults {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+		defer w.Flush()
+		for _, k := range keys {
+			listRec(w, k, a.Defaults[k])
+		}
+		return
+	}
+
+	if a.Defaults == nil {
+		a.Defaults = make(map[string]string)
+	}
+	for _, arg := range args[1:] {
+		i := strings.Index(arg, "=")
+		if i < 1 {
+			ctx.printUsage()
+			exit(2)
+		}
+		key, value := arg[:i], arg[i+1:]
+		d, ok := lookupFlagDefault(key)
+		if !ok {
+			printFatal("unknown default %s. See 'hk help account-defaults'.", key)
+		}
+		if value == "" {
+			delete(a.Defaults, key)
+			continue
+		}
+		if err := d.check(value); err != nil {
+			printFatal(err.Error())
+		}
+		a.Defaults[key] = value
+	}
+	accounts[name] = a
+	must(saveAccounts(accounts))
+	fmt.Fprintf(ctx.Stdout, "Set %d defaults for account %s.\n", len(args)-1, name)
+}
+
+// applyFlagDefaults sets the flags of cmd that have defaults in the
+// environment or the account profile in use. It runs before the command
+// line is parsed, so flags given there still win.
+func applyFlagDefaults(cmd *Command) {
+	var profile map[string]string
+	loaded := false
+	for _, d := range flagDefaults {
+		if d.Command != cmd.Name() {
+			continue
+		}
+		value, source := os.Getenv(d.Env), d.Env
+		if value == "" {
+			if !loaded {
+				profile, loaded = profileDefaults(), true
+			}
+			value, source = profile[d.Key], "account "+currentAccount()
+		}
+		if value == "" {
+			continue
+		}
+		if err := cmd.Flag.Set(d.Flag, value); err != nil {
+			printFatal("bad %s default from %s: %s", d.Key, source, err)
+		}
+	}
+}
+
+// profileDefaults returns the flag defaults of the account profile in
+// use, if any.
+func profileDefaults() map[string]string {
+	name := currentAccount()
+	if name == "" {
+		return nil
+	}
+	accounts, err := loadAccounts()
+	if err != nil {
+		return nil
+	}
+	return accounts[name].Defaults
+}
@@ -0,0 +1,52 @@
+package hk
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestApplyFlagDefaults(t *testing.T) {
+	home, err := ioutil.TempDir("", "hk-accounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+	defer os.Setenv("HKACCOUNT", "")
+	os.Setenv("HKACCOUNT", "client-a")
+	defer os.Setenv("HKREGION", "")
+
+	accounts := map[string]account{
+		"client-a": {Host: "api.heroku.com", Login: "ops@client-a.com", Password: "a-token", Defaults: map[string]string{"org": "client-a", "region": "eu"}},
+	}
+	if err := saveAccounts(accounts); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		hkregion    string
+		args        []string
+		org, region string
+	}{
+		{"", nil, "client-a", "eu"},
+		{"us", nil, "client-a", "us"},
+		{"us", []string{"-r", "eu", "--org", "acme"}, "acme", "eu"},
+	}
+	for _, test := range tests {
+		os.Setenv("HKREGION", test.hkregion)
+		cmd := &Command{Usage: "create"}
+		var org, region string
+		cmd.Flag.StringVar(&org, "org", "", "organization name")
+		cmd.Flag.StringVar(&region, "r", "", "region name")
+		applyFlagDefaults(cmd)
+		if err := cmd.Flag.Parse(test.args); err != nil {
+			t.Fatal(err)
+		}
+		if org != test.org || region != test.region {
+			t.Errorf("HKREGION=%q %v: org, region = %q, %q, want %q, %q",
+				test.hkregion, test.args, org, region, test.org, test.region)
+		}
+	}
+}
@@ -1,55 +1,52 @@
-package main
+package hk
 
 import (
 	"fmt"
-	"io"
 	"log"
-	"os"
-	"text/tabwriter"
 
 	"github.com/bgentry/heroku-go"
 )
 
 var cmdAccountFeatures = &Command{
 	Run:      runAccountFeatures,
-	Usage:    "account-features",
+	Usage:    "account-features [-j] [--enabled | --available]",
 	Category: "account",
 	Short:    "list account features" + extra,
 	Long: `
-Account-features lists Heroku Labs features for your account.
+Account-features lists Heroku Labs features for your account. Shows
+whether each feature is enabled (+), its name, state, description,
+and documentation URL.
+
+Options:
+
+    --enabled    only list enabled features
+    --available  only list features that aren't enabled
+    -j, --json   print features as JSON
 
 Example:
 
     $ hk account-features
-    +  pipelines
+    +  pipelines  beta  Pipelines for promoting slugs between apps  https://devcenter.heroku.com/articles/labs-pipelines
 `,
 }
 
-func runAccountFeatures(cmd *Command, args []string) {
-	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
-	}
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
-	defer w.Flush()
+func init() {
+	registerList(cmdAccountFeatures, labsFeature{})
+}
 
-	features, err := client.AccountFeatureList(&heroku.ListRange{Field: "name"})
+func runAccountFeatures(ctx *Context, args []string) {
+	if len(args) != 0 || flagFeaturesEnabled && flagFeaturesAvailable {
+		ctx.printUsage()
+		exit(2)
+	}
+	features, err := ctx.Client.AccountFeatureList(&heroku.ListRange{Field: "name"})
 	must(err)
 
-	listAccountFeatures(w, features)
-}
-
-func listAccountFeatures(w io.Writer, features []heroku.AccountFeature) {
-	for _, f := range features {
-		enabled := " "
-		if f.Enabled {
-			enabled = "+"
-		}
-		listRec(w,
-			enabled,
-			f.Name,
-		)
+	lf := make([]labsFeature, len(features))
+	for i, f := range features {
+		lf[i] = labsFeature{f.Name, f.State, f.Enabled, f.Description, f.DocURL}
 	}
+	printFeatures(ctx.Stdout, lf)
 }
 
 var cmdAccountFeatureInfo = &Command{
@@ -67,17 +64,17 @@ Example:
 `,
 }
 
-func runAccountFeatureInfo(cmd *Command, args []string) {
+func runAccountFeatureInfo(ctx *Context, args []string) {
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	feature, err := client.AccountFeatureInfo(args[0])
+	feature, err := ctx.Client.AccountFeatureInfo(args[0])
 	must(err)
-	fmt.Printf("Name:         %s\n", feature.Name)
-	fmt.Printf("Docs:         %s\n", feature.DocURL)
-	fmt.Printf("Enabled:      %t\n", feature.Enabled)
-	fmt.Printf("Description:  %s\n", feature.Description)
+	fmt.Fprintf(ctx.Stdout, "Name:         %s\n", feature.Name)
+	fmt.Fprintf(ctx.Stdout, "Docs:         %s\n", feature.DocURL)
+	fmt.Fprintf(ctx.Stdout, "Enabled:      %t\n", feature.Enabled)
+	fmt.Fprintf(ctx.Stdout, "Description:  %s\n", feature.Description)
 }
 
 var cmdAccountFeatureEnable = &Command{
@@ -95,13 +92,13 @@ Example:
 `,
 }
 
-func runAccountFeatureEnable(cmd *Command, args []string) {
+func runAccountFeatureEnable(ctx *Context, args []string) {
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	featureName := args[0]
-	feature, err := client.AccountFeatureUpdate(featureName, true)
+	feature, err := ctx.Client.AccountFeatureUpdate(featureName, true)
 	must(err)
 	log.Printf("Enabled %s.", feature.Name)
 }
@@ -121,13 +118,13 @@ Example:
 `,
 }
 
-func runAccountFeatureDisable(cmd *Command, args []string) {
+func runAccountFeatureDisable(ctx *Context, args []string) {
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	featureName := args[0]
-	feature, err := client.AccountFeatureUpdate(featureName, false)
+	feature, err := ctx.Client.AccountFeatureUpdate(featureName, false)
 	must(err)
 	log.Printf("Disabled %s.", feature.Name)
 }
@@ -0,0 +1,284 @@
+package hk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+var cmdAccounts = &Command{
+	Run:      runAccounts,
+	Usage:    "accounts",
+	Category: "hk",
+	Short:    "list account profiles" + extra,
+	Long: `
+Accounts lists the saved account profiles, marking the one in use
+with a *, and showing the proxy of any profile that has one. When no
+profile is in use, hk takes credentials from .netrc, shown as
+(netrc).
+
+Example:
+
+    $ hk accounts
+      (netrc)   user@example.com
+    * client-a  ops@client-a.com  via socks5://localhost:1080
+      client-b  ops@client-b.com
+`,
+}
+
+func runAccounts(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	accounts, err := loadAccounts()
+	must(err)
+	current := currentAccount()
+
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	netrcUser, _ := getCreds(apiURL)
+	listRec(w, accountMark(current == ""), "(netrc)", netrcUser)
+	var names []string
+	for name := range accounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a := accounts[name]
+		if a.Proxy != "" {
+			listRec(w, accountMark(name == current), name, a.Login, "via "+a.Proxy)
+		} else {
+			listRec(w, accountMark(name == current), name, a.Login)
+		}
+	}
+}
+
+func accountMark(inUse bool) string {
+	if inUse {
+		return "*"
+	}
+	return " "
+}
+
+var cmdAccountAdd = &Command{
+	Run:      runAccountAdd,
+	Usage:    "account-add [--proxy <url>] <name>",
+	Category: "hk",
+	Short:    "save credentials as an account profile" + extra,
+	Long: `
+Account-add saves the credentials hk is using now as an account
+profile called name, replacing any profile of that name but keeping
+its defaults (see 'hk help account-defaults'). Log in to
+an account, then add it:
+
+    $ hk login ops@client-a.com
+    Enter password: 
+    Logged in.
+    $ hk account-add client-a
+    Added account client-a (ops@client-a.com).
+
+With --proxy, hk connects through the given proxy whenever the
+profile is in use: API requests, updates, and the database
+connections of commands such as psql. The proxy is a socks5:// URL,
+such as the one 'ssh -D' listens on to reach a bastion host, or an
+http:// URL for a proxy that supports CONNECT. Without a profile in
+use, the proxy setting in the config file is used (see HKCONFIG in
+'hk help environ').
+
+    $ ssh -fN -D 1080 bastion.client-a.com
+    $ hk account-add --proxy socks5://localhost:1080 client-a
+    Added account client-a (ops@client-a.com).
+
+A profile added after logging in with a browser keeps the token's
+refresh token, and its token is refreshed before it expires, as
+for .netrc. Profiles are kept in $HOME/.hk/accounts.json.
+
+Options:
+
+    --proxy <url>  proxy to connect through with this profile
+`,
+}
+
+var flagAccountProxy string
+
+func init() {
+	cmdAccountAdd.Flag.StringVar(&flagAccountProxy, "proxy", "", "proxy URL")
+}
+
+func runAccountAdd(ctx *Context, args []string) {
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	name := args[0]
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		printFatal("invalid account name %q", name)
+	}
+	if apiClient.Username == "" || apiClient.Password == "" {
+		printFatal("no credentials to save. Log in with `hk login` first.")
+	}
+	u, err := url.Parse(apiURL)
+	must(err)
+	accounts, err := loadAccounts()
+	must(err)
+	if flagAccountProxy != "" {
+		if _, err := parseProxyURL(flagAccountProxy); err != nil {
+			printFatal(err.Error())
+		}
+	}
+	accounts[name] = account{
+		Host:     strings.Split(u.Host, ":")[0],
+		Login:    apiClient.Username,
+		Password: apiClient.Password,
+		Proxy:    flagAccountProxy,
+		OAuth:    usedOAuthToken(strings.Split(u.Host, ":")[0]),
+		Defaults: accounts[name].Defaults,
+	}
+	must(saveAccounts(accounts))
+	fmt.Fprintf(ctx.Stdout, "Added account %s (%s).\n", name, apiClient.Username)
+}
+
+var cmdSwitch = &Command{
+	Run:      runSwitch,
+	Usage:    "switch [<name>]",
+	Category: "hk",
+	Short:    "switch account profiles" + extra,
+	Long: `
+Switch makes hk use the credentials from the account profile called
+name in later commands. Without a name, hk goes back to taking
+credentials from .netrc.
+
+The HKACCOUNT environment variable overrides the profile chosen
+with switch.
+
+Examples:
+
+    $ hk switch client-b
+    Switched to account client-b (ops@client-b.com).
+
+    $ hk switch
+    Switched to .netrc credentials.
+`,
+}
+
+func runSwitch(ctx *Context, args []string) {
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	if len(args) == 0 {
+		err := os.Remove(currentAccountPath())
+		if err != nil && !os.IsNotExist(err) {
+			printFatal(err.Error())
+		}
+		fmt.Fprintln(ctx.Stdout, "Switched to .netrc credentials.")
+	} else {
+		name := args[0]
+		accounts, err := loadAccounts()
+		must(err)
+		a, ok := accounts[name]
+		if !ok {
+			printFatal("no account named %s. Add it with `hk account-add`.", name)
+		}
+		must(os.MkdirAll(hkHome(), 0700))
+		must(ioutil.WriteFile(currentAccountPath(), []byte(name+"\n"), 0600))
+		fmt.Fprintf(ctx.Stdout, "Switched to account %s (%s).\n", name, a.Login)
+	}
+	if s := os.Getenv("HKACCOUNT"); s != "" {
+		printWarning("HKACCOUNT is set, so hk uses account %s in this shell.", s)
+	}
+}
+
+// An account is a saved set of API credentials.
+type account struct {
+	Host     string `json:"host"` // API host the credentials are for
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Proxy    string `json:"proxy,omitempty"` // see networkProxy
+
+	OAuth *oauthToken `json:"oauth,omitempty"` // if from a browser login
+
+	Defaults map[string]string `json:"defaults,omitempty"` // see flagDefaults
+}
+
+func accountsPath() string {
+	return filepath.Join(hkHome(), "accounts.json")
+}
+
+func currentAccountPath() string {
+	return filepath.Join(hkHome(), "account")
+}
+
+func loadAccounts() (map[string]account, error) {
+	accounts := make(map[string]account)
+	b, err := ioutil.ReadFile(accountsPath())
+	if os.IsNotExist(err) {
+		return accounts, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &accounts); err != nil {
+		return nil, fmt.Errorf("%s: %s", accountsPath(), err)
+	}
+	return accounts, nil
+}
+
+func saveAccounts(accounts map[string]account) error {
+	b, err := json.MarshalIndent(accounts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hkHome(), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(accountsPath(), b, 0600)
+}
+
+// currentAccount returns the name of the account profile in use, from
+// HKACCOUNT or hk switch, or "" if credentials come from .netrc.
+func currentAccount() string {
+	if s := os.Getenv("HKACCOUNT"); s != "" {
+		return s
+	}
+	b, err := ioutil.ReadFile(currentAccountPath())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// accountCreds returns the credentials for API calls to u: those in u
+// itself, then those of the current account profile, then those in
+// .netrc. If the current profile doesn't exist, it warns and returns no
+// credentials, rather than acting as some other account.
+func accountCreds(u string) (user, pass string) {
+	pu, err := url.Parse(u)
+	if err != nil || pu.User != nil {
+		return getCreds(u)
+	}
+	name := currentAccount()
+	if name == "" {
+		return getCreds(u)
+	}
+	accounts, err := loadAccounts()
+	if err != nil {
+		printWarning("loading accounts: %s", err)
+		return "", ""
+	}
+	a, ok := accounts[name]
+	if !ok {
+		printWarning("no account named %s. Use `hk switch` to pick another.", name)
+		return "", ""
+	}
+	if host := strings.Split(pu.Host, ":")[0]; a.Host != host {
+		printWarning("account %s is for %s, not %s.", name, a.Host, host)
+	}
+	return a.Login, a.Password
+}
@@ -0,0 +1,160 @@
+package hk
+
+import (
+	"log"
+	"text/tabwriter"
+	"time"
+)
+
+var cmdACMEnable = &Command{
+	Run:      runACMEnable,
+	Usage:    "acm-enable",
+	NeedsApp: true,
+	Category: "ssl",
+	Short:    "turn on automatic SSL certificates" + extra,
+	Long: `
+Acm-enable turns on Automated Certificate Management, which gets and
+renews SSL certificates for the app's custom domains. Certificates
+are issued once each domain's DNS points at the app; follow the
+progress with 'hk acm-status'.
+
+Example:
+
+    $ hk acm-enable
+    Enabled automatic certificates on myapp.
+`,
+}
+
+func runACMEnable(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	must(ctx.Client.Post(nil, "/apps/"+appname+"/acm", nil))
+	log.Printf("Enabled automatic certificates on %s.", appname)
+}
+
+var cmdACMDisable = &Command{
+	Run:      runACMDisable,
+	Usage:    "acm-disable",
+	NeedsApp: true,
+	Category: "ssl",
+	Short:    "turn off automatic SSL certificates" + extra,
+	Long: `
+Acm-disable turns off Automated Certificate Management. Certificates
+it issued are removed, so add one with 'hk cert-add' to keep serving
+HTTPS on custom domains.
+
+Example:
+
+    $ hk acm-disable
+    Disabled automatic certificates on myapp.
+`,
+}
+
+func runACMDisable(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	must(ctx.Client.Delete("/apps/" + appname + "/acm"))
+	log.Printf("Disabled automatic certificates on %s.", appname)
+}
+
+var cmdACMStatus = &Command{
+	Run:      runACMStatus,
+	Usage:    "acm-status [--wait] [--interval <duration>]",
+	NeedsApp: true,
+	Category: "ssl",
+	Short:    "show automatic SSL certificate status" + extra,
+	Long: `
+Acm-status shows the Automated Certificate Management status of
+each of the app's custom domains, with the reason for any failure.
+
+With --wait, acm-status checks again every --interval until every
+domain has a certificate, and exits with status 1 if any domain
+fails.
+
+Options:
+
+    --wait                 wait until certificates are issued
+    --interval <duration>  how often to check with --wait (default
+                           10s)
+
+Example:
+
+    $ hk acm-status
+    www.example.com  cert issued
+    example.com      failing      CNAME points at the wrong target
+`,
+}
+
+var (
+	flagACMWait     bool
+	flagACMInterval time.Duration
+)
+
+func init() {
+	cmdACMStatus.Flag.BoolVar(&flagACMWait, "wait", false, "wait until certificates are issued")
+	cmdACMStatus.Flag.DurationVar(&flagACMInterval, "interval", 10*time.Second, "how often to check")
+}
+
+func runACMStatus(ctx *Context, args []string) {
+	if len(args) != 0 || flagACMInterval <= 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	for {
+		var domains []apiDomain
+		must(ctx.Client.Get(&domains, "/apps/"+appname+"/domains"))
+		var custom []apiDomain
+		for _, d := range domains {
+			if d.Kind != "heroku" {
+				custom = append(custom, d)
+			}
+		}
+		if len(custom) == 0 {
+			printFatal("%s has no custom domains. Add one with `hk domain-add`.", appname)
+		}
+		done, failed := acmProgress(custom)
+		if !flagACMWait || done || failed {
+			w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+			for _, d := range custom {
+				listRec(w, d.Hostname, d.status(), d.ACMStatusReason)
+			}
+			w.Flush()
+			if flagACMWait && failed {
+				exit(1)
+			}
+			return
+		}
+		time.Sleep(flagACMInterval)
+	}
+}
+
+func (d apiDomain) status() string {
+	if d.ACMStatus == "" {
+		return "not managed"
+	}
+	return d.ACMStatus
+}
+
+// acmProgress reports whether every domain has been issued a certificate,
+// and whether any has failed.
+func acmProgress(domains []apiDomain) (done, failed bool) {
+	done = true
+	for _, d := range domains {
+		switch d.ACMStatus {
+		case "cert issued":
+		case "failed", "failing":
+			failed = true
+			done = false
+		default:
+			done = false
+		}
+	}
+	return done, failed
+}
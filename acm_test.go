@@ -0,0 +1,25 @@
+package hk
+
+import "testing"
+
+func TestACMProgress(t *testing.T) {
+	tests := []struct {
+		statuses     []string
+		done, failed bool
+	}{
+		{[]string{"cert issued", "cert issued"}, true, false},
+		{[]string{"cert issued", "pending"}, false, false},
+		{[]string{"dns verified", "failing"}, false, true},
+		{[]string{""}, false, false},
+	}
+	for _, test := range tests {
+		var domains []apiDomain
+		for _, s := range test.statuses {
+			domains = append(domains, apiDomain{ACMStatus: s})
+		}
+		done, failed := acmProgress(domains)
+		if done != test.done || failed != test.failed {
+			t.Errorf("acmProgress(%q) = %v, %v, want %v, %v", test.statuses, done, failed, test.done, test.failed)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package hk
+
+import (
+	"log"
+	"text/tabwriter"
+	"time"
+)
+
+// An addonAttachment names an add-on within an app. Every add-on is
+// attached to the app that owns it, and may be attached to other apps to
+// share it. heroku-go has no attachment endpoints, so hk calls them
+// directly.
+type addonAttachment struct {
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Addon struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+		App  struct {
+			Name string `json:"name"`
+		} `json:"app"`
+	} `json:"addon"`
+	App struct {
+		Name string `json:"name"`
+	} `json:"app"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var cmdAddonAttachments = &Command{
+	Run:      runAddonAttachments,
+	Usage:    "addon-attachments [-j] [-a <app>] [<addon>]",
+	Category: "add-on",
+	Short:    "list addon attachments" + extra,
+	Long: `
+Lists the addon attachments of an app, or with an addon name, the
+apps an addon is attached to. Shows each attachment's name, the app
+it's on, the addon, and the app that owns the addon.
+
+Options:
+
+    -j, --json  print attachments as JSON
+    -a <app>    the app to list attachments of
+
+Examples:
+
+    $ hk addon-attachments
+    DATABASE      myapp  heroku-postgresql-round-4217  myapp      Jan 13 21:20
+    SHARED_REDIS  myapp  heroku-redis-lively-1283      myapp-ops  Jan 14 09:02
+
+    $ hk addon-attachments heroku-redis-lively-1283
+    REDIS         myapp-ops  heroku-redis-lively-1283  myapp-ops  Jan 10 16:44
+    SHARED_REDIS  myapp      heroku-redis-lively-1283  myapp-ops  Jan 14 09:02
+`,
+}
+
+func init() {
+	registerList(cmdAddonAttachments, addonAttachment{})
+}
+
+func init() {
+	cmdAddonAttachments.Flag.StringVar(&flagApp, "a", "", "app name")
+	cmdAddonAttachments.Flag.StringVar(&flagRemote, "r", "", "git remote of app")
+}
+
+func runAddonAttachments(ctx *Context, args []string) {
+	var path string
+	switch len(args) {
+	case 0:
+		path = "/apps/" + ctx.MustApp() + "/addon-attachments"
+	case 1:
+		path = "/addons/" + args[0] + "/addon-attachments"
+	default:
+		ctx.printUsage()
+		exit(2)
+	}
+	var attachments []addonAttachment
+	if err := ctx.Client.Get(&attachments, path); err != nil {
+		checkAddonError(err)
+	}
+	if printList(ctx.Stdout, attachments) {
+		return
+	}
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, a := range attachments {
+		listRec(w,
+			a.Name,
+			a.App.Name,
+			a.Addon.Name,
+			a.Addon.App.Name,
+			prettyTime{a.CreatedAt},
+		)
+	}
+}
+
+var cmdAddonAttach = &Command{
+	Run:      runAddonAttach,
+	Usage:    "addon-attach [--as <name>] <addon>",
+	NeedsApp: true,
+	Category: "add-on",
+	Short:    "attach an addon to another app" + extra,
+	Long: `
+Attaches an existing addon, such as a database owned by another app,
+to the app. The app gets env vars for the addon named after the
+attachment, e.g. SHARED_DATABASE_URL for an attachment named
+SHARED_DATABASE. Without --as, the addon's service picks the name.
+
+Options:
+
+    --as <name>  name of the attachment
+
+Examples:
+
+    $ hk addon-attach -a myapp-worker heroku-postgresql-round-4217
+    Attached heroku-postgresql-round-4217 to myapp-worker as HEROKU_POSTGRESQL_ROSE.
+
+    $ hk addon-attach --as SHARED_REDIS heroku-redis-lively-1283
+    Attached heroku-redis-lively-1283 to myapp as SHARED_REDIS.
+`,
+}
+
+var flagAddonAttachAs string
+
+func init() {
+	cmdAddonAttach.Flag.StringVar(&flagAddonAttachAs, "as", "", "attachment name")
+}
+
+func runAddonAttach(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	body := map[string]string{"addon": args[0], "app": appname}
+	if flagAddonAttachAs != "" {
+		body["name"] = flagAddonAttachAs
+	}
+	var a addonAttachment
+	checkAddonError(ctx.Client.Post(&a, "/addon-attachments", body))
+	log.Printf("Attached %s to %s as %s.", a.Addon.Name, appname, a.Name)
+}
+
+var cmdAddonDetach = &Command{
+	Run:      runAddonDetach,
+	Usage:    "addon-detach <attachment>",
+	NeedsApp: true,
+	Category: "add-on",
+	Short:    "detach an addon from an app" + extra,
+	Long: `
+Detaches an addon from the app, removing the attachment's env vars.
+The addon itself isn't removed; it stays on the app that owns it.
+Use addon-remove to remove an addon from its owner.
+
+Examples:
+
+    $ hk addon-detach SHARED_REDIS
+    Detached heroku-redis-lively-1283 (SHARED_REDIS) from myapp.
+`,
+}
+
+func runAddonDetach(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	var a addonAttachment
+	checkAddonError(ctx.Client.Get(&a, "/apps/"+appname+"/addon-attachments/"+args[0]))
+	checkAddonError(ctx.Client.Delete("/addon-attachments/" + a.Id))
+	log.Printf("Detached %s (%s) from %s.", a.Addon.Name, a.Name, appname)
+}
@@ -0,0 +1,46 @@
+package hk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/heroku/hk/hktest"
+)
+
+func TestAddonAttach(t *testing.T) {
+	srv := hktest.NewServer(hktest.Fixture{
+		Method: "POST", Path: "/addon-attachments", Status: 201,
+		Body: []byte(`{"id": "01234567-89ab-cdef-0123-456789abcdef", "name": "SHARED_REDIS", "addon": {"name": "heroku-redis-lively-1283", "app": {"name": "myapp-ops"}}, "app": {"name": "myapp"}}`),
+	})
+	defer srv.Close()
+	if _, status := runTestCommand(t, srv, "addon-attach", "-a", "myapp", "--as", "SHARED_REDIS", "heroku-redis-lively-1283"); status != 0 {
+		t.Fatalf("status = %d, want 0", status)
+	}
+	flagAddonAttachAs = ""
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("requests = %v, want 1", reqs)
+	}
+	for _, want := range []string{`"addon":"heroku-redis-lively-1283"`, `"app":"myapp"`, `"name":"SHARED_REDIS"`} {
+		if !strings.Contains(string(reqs[0].Body), want) {
+			t.Errorf("request body = %s, want it to contain %s", reqs[0].Body, want)
+		}
+	}
+}
+
+func TestAddonDetach(t *testing.T) {
+	srv := hktest.NewServer(
+		hktest.Fixture{
+			Method: "GET", Path: "/apps/myapp/addon-attachments/SHARED_REDIS",
+			Body: []byte(`{"id": "01234567-89ab-cdef-0123-456789abcdef", "name": "SHARED_REDIS", "addon": {"name": "heroku-redis-lively-1283", "app": {"name": "myapp-ops"}}, "app": {"name": "myapp"}}`),
+		},
+		hktest.Fixture{Method: "DELETE", Path: "/addon-attachments/01234567-89ab-cdef-0123-456789abcdef"},
+	)
+	defer srv.Close()
+	if _, status := runTestCommand(t, srv, "addon-detach", "-a", "myapp", "SHARED_REDIS"); status != 0 {
+		t.Fatalf("status = %d, want 0", status)
+	}
+	if u := srv.Unmatched(); len(u) != 0 {
+		t.Errorf("unmatched requests: %v", u)
+	}
+}
@@ -0,0 +1,146 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdAddonPlans = &Command{
+	Run:      runAddonPlans,
+	Usage:    "addon-plans [-j] <service>",
+	Category: "add-on",
+	Short:    "list an add-on service's plans" + extra,
+	Long: `
+Lists the plans of an add-on service with their prices, cheapest
+first. The service's default plan is marked with *.
+
+Options:
+
+    -j, --json  print plans as JSON
+
+Examples:
+
+    $ hk addon-plans heroku-redis
+    * heroku-redis:hobby-dev  free    Hobby Dev
+      heroku-redis:premium-0  $15/mo  Premium 0
+      heroku-redis:premium-1  $30/mo  Premium 1
+`,
+}
+
+func init() {
+	registerList(cmdAddonPlans, heroku.Plan{})
+}
+
+func runAddonPlans(ctx *Context, args []string) {
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	plans, err := ctx.Client.PlanList(args[0], nil)
+	must(err)
+	sort.Sort(plansByPrice(plans))
+	if printList(ctx.Stdout, plans) {
+		return
+	}
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, p := range plans {
+		mark := " "
+		if p.Default {
+			mark = "*"
+		}
+		listRec(w, mark+" "+p.Name, formatPlanPrice(p), p.Description)
+	}
+}
+
+// formatPlanPrice formats the price of p, e.g. "$15/mo" or "free".
+func formatPlanPrice(p heroku.Plan) string {
+	if p.Price.Cents == 0 {
+		return "free"
+	}
+	unit := p.Price.Unit
+	if unit == "month" {
+		unit = "mo"
+	}
+	if p.Price.Cents%100 == 0 {
+		return fmt.Sprintf("$%d/%s", p.Price.Cents/100, unit)
+	}
+	return fmt.Sprintf("$%.2f/%s", float64(p.Price.Cents)/100, unit)
+}
+
+type plansByPrice []heroku.Plan
+
+func (a plansByPrice) Len() int      { return len(a) }
+func (a plansByPrice) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a plansByPrice) Less(i, j int) bool {
+	if a[i].Price.Cents != a[j].Price.Cents {
+		return a[i].Price.Cents < a[j].Price.Cents
+	}
+	return a[i].Name < a[j].Name
+}
+
+var cmdAddonUpgrade = &Command{
+	Run:      runAddonChangePlan,
+	Usage:    "addon-upgrade <name> <plan>",
+	NeedsApp: true,
+	Category: "add-on",
+	Short:    "change an addon to a bigger plan" + extra,
+	Long: `
+Changes an addon to another plan of the same service, keeping its
+data and attachments. The plan may be given with or without the
+service name. See 'hk addon-plans' for the plans of a service.
+
+Examples:
+
+    $ hk addon-upgrade heroku-redis-round-4217 premium-0
+    Changed heroku-redis-round-4217 on myapp from heroku-redis:hobby-dev to heroku-redis:premium-0 ($15/mo).
+`,
+}
+
+var cmdAddonDowngrade = &Command{
+	Run:      runAddonChangePlan,
+	Usage:    "addon-downgrade <name> <plan>",
+	NeedsApp: true,
+	Category: "add-on",
+	Short:    "change an addon to a smaller plan" + extra,
+	Long: `
+Changes an addon to another plan of the same service. It is the
+same as addon-upgrade; see 'hk help addon-upgrade'. Some services
+can't move to a smaller plan that won't hold the addon's data.
+
+Examples:
+
+    $ hk addon-downgrade heroku-redis-round-4217 hobby-dev
+    Changed heroku-redis-round-4217 on myapp from heroku-redis:premium-0 to heroku-redis:hobby-dev (free).
+`,
+}
+
+func runAddonChangePlan(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 2 {
+		ctx.printUsage()
+		exit(2)
+	}
+	name, plan := args[0], args[1]
+	a, err := ctx.Client.AddonInfo(appname, name)
+	checkAddonError(err)
+	service, _ := splitProviderAndPlan(a.Plan.Name)
+	if !strings.Contains(plan, ":") {
+		plan = service + ":" + plan
+	}
+	if plan == a.Plan.Name {
+		printFatal("%s is already on %s.", name, plan)
+	}
+	updated, err := ctx.Client.AddonUpdate(appname, a.Id, plan)
+	checkAddonError(err)
+	msg := fmt.Sprintf("Changed %s on %s from %s to %s", name, appname, a.Plan.Name, updated.Plan.Name)
+	if p, err := ctx.Client.PlanInfo(service, updated.Plan.Name); err == nil {
+		msg += " (" + formatPlanPrice(*p) + ")"
+	}
+	log.Print(msg + ".")
+}
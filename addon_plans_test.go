@@ -0,0 +1,78 @@
+package hk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/hktest"
+)
+
+func TestFormatPlanPrice(t *testing.T) {
+	for _, tt := range []struct {
+		cents int
+		unit  string
+		want  string
+	}{
+		{0, "month", "free"},
+		{1500, "month", "$15/mo"},
+		{2250, "month", "$22.50/mo"},
+		{5, "hour", "$0.05/hour"},
+	} {
+		var p heroku.Plan
+		p.Price.Cents, p.Price.Unit = tt.cents, tt.unit
+		if got := formatPlanPrice(p); got != tt.want {
+			t.Errorf("formatPlanPrice(%d %s) = %q, want %q", tt.cents, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestAddonPlans(t *testing.T) {
+	srv := hktest.NewServer(hktest.Fixture{
+		Method: "GET", Path: "/addon-services/heroku-redis/plans",
+		Body: []byte(`[
+			{"name": "heroku-redis:premium-0", "description": "Premium 0", "price": {"cents": 1500, "unit": "month"}},
+			{"name": "heroku-redis:hobby-dev", "description": "Hobby Dev", "default": true, "price": {"cents": 0, "unit": "month"}}
+		]`),
+	})
+	defer srv.Close()
+	out, status := runTestCommand(t, srv, "addon-plans", "heroku-redis")
+	if status != 0 {
+		t.Fatalf("status = %d, want 0", status)
+	}
+	want := "* heroku-redis:hobby-dev  free    Hobby Dev\n  heroku-redis:premium-0  $15/mo  Premium 0\n"
+	if out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+}
+
+func TestAddonUpgrade(t *testing.T) {
+	srv := hktest.NewServer(
+		hktest.Fixture{
+			Method: "GET", Path: "/apps/myapp/addons/heroku-redis-round-4217",
+			Body: []byte(`{"id": "01234567-89ab-cdef-0123-456789abcdef", "name": "heroku-redis-round-4217", "plan": {"name": "heroku-redis:hobby-dev"}}`),
+		},
+		hktest.Fixture{
+			Method: "PATCH", Path: "/apps/myapp/addons/01234567-89ab-cdef-0123-456789abcdef",
+			Body: []byte(`{"id": "01234567-89ab-cdef-0123-456789abcdef", "name": "heroku-redis-round-4217", "plan": {"name": "heroku-redis:premium-0"}}`),
+		},
+		hktest.Fixture{
+			Method: "GET", Path: "/addon-services/heroku-redis/plans/heroku-redis:premium-0",
+			Body: []byte(`{"name": "heroku-redis:premium-0", "price": {"cents": 1500, "unit": "month"}}`),
+		},
+	)
+	defer srv.Close()
+	if _, status := runTestCommand(t, srv, "addon-upgrade", "-a", "myapp", "heroku-redis-round-4217", "premium-0"); status != 0 {
+		t.Fatalf("status = %d, want 0", status)
+	}
+	var patch *hktest.Request
+	for _, r := range srv.Requests() {
+		if r.Method == "PATCH" {
+			r := r
+			patch = &r
+		}
+	}
+	if patch == nil || !strings.Contains(string(patch.Body), `"plan":"heroku-redis:premium-0"`) {
+		t.Errorf("PATCH request = %+v, want plan heroku-redis:premium-0", patch)
+	}
+}
@@ -0,0 +1,84 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+var cmdAddonWait = &Command{
+	Run:      runAddonWait,
+	Usage:    "addon-wait [--timeout <duration>] <name>",
+	NeedsApp: true,
+	Category: "add-on",
+	Short:    "wait for an addon to be provisioned" + extra,
+	Long: `
+Addon-wait waits until an addon has finished provisioning. Many
+addons, such as Postgres databases on standard plans, are created
+in the background and can't be used for some minutes after
+addon-add returns. Addon-wait exits non-zero if the addon isn't
+provisioned before the timeout.
+
+Options:
+
+    --timeout <duration>  time to wait (default 20m)
+
+Examples:
+
+    $ hk addon-wait heroku-postgresql-round-4217
+    heroku-postgresql-round-4217 is provisioned.
+`,
+}
+
+var flagAddonWaitTimeout time.Duration
+
+// addonPollInterval is how often addon-wait checks an addon's state.
+var addonPollInterval = 5 * time.Second
+
+func init() {
+	cmdAddonWait.Flag.DurationVar(&flagAddonWaitTimeout, "timeout", 20*time.Minute, "time to wait")
+	cmdAddonAdd.Flag.DurationVar(&flagAddonWaitTimeout, "wait-timeout", 20*time.Minute, "time to wait with --wait")
+}
+
+func runAddonWait(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	if err := waitAddonProvisioned(appname, args[0], flagAddonWaitTimeout); err != nil {
+		printFatal(err.Error())
+	}
+	log.Printf("%s is provisioned.", args[0])
+}
+
+// addonState is the part of an addon that heroku-go doesn't expose:
+// whether it has finished provisioning.
+type addonState struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// waitAddonProvisioned polls the state of addon until it's provisioned,
+// showing a spinner on stderr while it waits.
+func waitAddonProvisioned(appname, addon string, timeout time.Duration) error {
+	s := newSpinner("Waiting for " + addon + " to be provisioned")
+	defer s.stop()
+	deadline := time.Now().Add(timeout)
+	for {
+		var a addonState
+		if err := client.Get(&a, "/apps/"+appname+"/addons/"+addon); err != nil {
+			return err
+		}
+		switch a.State {
+		case "", "provisioned":
+			return nil
+		case "deprovisioned":
+			return fmt.Errorf("%s was deprovisioned.", addon)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s is still %s after %s.", addon, a.State, timeout)
+		}
+		s.sleep(addonPollInterval)
+	}
+}
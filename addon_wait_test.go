@@ -0,0 +1,30 @@
+package hk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heroku/hk/hktest"
+)
+
+func TestAddonWait(t *testing.T) {
+	srv := hktest.NewServer(
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/addons/heroku-postgresql-round-4217", Body: []byte(`{"name": "heroku-postgresql-round-4217", "state": "provisioned"}`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/addons/heroku-redis-lively-1283", Body: []byte(`{"name": "heroku-redis-lively-1283", "state": "deprovisioned"}`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/addons/kafka-slippery-5721", Body: []byte(`{"name": "kafka-slippery-5721", "state": "provisioning"}`)},
+	)
+	defer srv.Close()
+	defer func() { flagAddonWaitTimeout = 20 * time.Minute }()
+	for _, tt := range []struct {
+		args   []string
+		status int
+	}{
+		{[]string{"addon-wait", "-a", "myapp", "heroku-postgresql-round-4217"}, 0},
+		{[]string{"addon-wait", "-a", "myapp", "heroku-redis-lively-1283"}, 1},
+		{[]string{"addon-wait", "-a", "myapp", "--timeout", "0", "kafka-slippery-5721"}, 1},
+	} {
+		if _, status := runTestCommand(t, srv, tt.args...); status != tt.status {
+			t.Errorf("hk %v: status = %d, want %d", tt.args, status, tt.status)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package addonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Defaults are an API's settings for the Client fields left unset.
+type Defaults struct {
+	URL       string
+	UserAgent string
+}
+
+// A Client is an add-on API client. Its zero value is a usable client that
+// uses the default settings of the API it calls.
+type Client struct {
+	// HTTP is the Client's internal http.Client, handling HTTP requests to
+	// the API. Defaults to http.DefaultClient.
+	HTTP *http.Client
+
+	// The URL of the API to communicate with. Defaults to the API's
+	// default URL.
+	URL string
+
+	// Username and Password are the HTTP basic auth credentials for API
+	// calls made by this Client: a Heroku account's email and API key.
+	Username string
+	Password string
+
+	// UserAgent to be provided in API requests. Set to the API's default
+	// user agent if not specified.
+	UserAgent string
+
+	// AdditionalHeaders are extra headers to add to each HTTP request sent
+	// by this Client.
+	AdditionalHeaders http.Header
+}
+
+// Do sends a request to path, below the API URL, and decodes the response
+// into v unless v is nil. If body is not nil, it's sent as JSON. def gives
+// the URL and user agent to use if c doesn't set them.
+func (c *Client) Do(def Defaults, method, path string, body, v interface{}) error {
+	var rbody io.Reader
+	if body != nil {
+		j, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rbody = bytes.NewReader(j)
+	}
+	apiURL := def.URL
+	if c.URL != "" {
+		apiURL = c.URL
+	}
+	req, err := http.NewRequest(method, strings.TrimRight(apiURL, "/")+path, rbody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	useragent := c.UserAgent
+	if useragent == "" {
+		useragent = def.UserAgent
+	}
+	req.Header.Set("User-Agent", useragent)
+	req.SetBasicAuth(c.Username, c.Password)
+	for k, v := range c.AdditionalHeaders {
+		req.Header[k] = v
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return responseError(res)
+	}
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// responseError returns the error message in a failed response, or its
+// status if it has none.
+func responseError(res *http.Response) error {
+	b, _ := ioutil.ReadAll(res.Body)
+	var e struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(b, &e) == nil && e.Message != "" {
+		return fmt.Errorf("%s", e.Message)
+	}
+	return fmt.Errorf("unexpected status code=%d message=%q", res.StatusCode, string(b))
+}
@@ -0,0 +1,51 @@
+package addonapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDo(t *testing.T) {
+	var got *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(404)
+			w.Write([]byte(`{"id": "not_found", "message": "Couldn't find that."}`))
+			return
+		}
+		w.Write([]byte(`{"name": "x"}`))
+	}))
+	defer ts.Close()
+
+	c := &Client{
+		Username:          "user@example.com",
+		Password:          "key",
+		AdditionalHeaders: http.Header{"X-Test": {"1"}},
+	}
+	def := Defaults{URL: ts.URL + "/", UserAgent: "test/1"}
+	var v struct{ Name string }
+	if err := c.Do(def, "POST", "/things", map[string]int{"n": 1}, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "x" {
+		t.Errorf("decoded %+v", v)
+	}
+	user, pass, _ := got.BasicAuth()
+	if got.Method != "POST" || got.URL.Path != "/things" || user != "user@example.com" || pass != "key" {
+		t.Errorf("request = %s %s as %s:%s", got.Method, got.URL.Path, user, pass)
+	}
+	if got.Header.Get("User-Agent") != "test/1" || got.Header.Get("X-Test") != "1" || got.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("headers = %v", got.Header)
+	}
+
+	c.UserAgent = "custom"
+	err := c.Do(def, "GET", "/missing", nil, nil)
+	if err == nil || err.Error() != "Couldn't find that." {
+		t.Errorf("error = %v, want the response's message", err)
+	}
+	if got.Header.Get("User-Agent") != "custom" {
+		t.Errorf("User-Agent = %q, want custom", got.Header.Get("User-Agent"))
+	}
+}
@@ -0,0 +1,15 @@
+// Package addonapi holds the HTTP client shared by hk's clients for add-on
+// APIs, such as the redis and scheduler packages. Those APIs all take JSON,
+// authenticate with a Heroku account's email and API key, and report
+// failures as a JSON message, so one Client sends requests for each of
+// them. A package for an API embeds Client in its own client type and
+// sends requests with the API's Defaults:
+//
+//	type Client struct {
+//		addonapi.Client
+//	}
+//
+//	func (c *Client) do(method, path string, body, v interface{}) error {
+//		return c.Do(defaults, method, path, body, v)
+//	}
+package addonapi
@@ -1,10 +1,9 @@
-package main
+package hk
 
 import (
 	"fmt"
 	"io"
 	"log"
-	"os"
 	"strings"
 	"text/tabwriter"
 
@@ -13,13 +12,17 @@ import (
 
 var cmdAddons = &Command{
 	Run:      runAddons,
-	Usage:    "addons [<service>:<plan>...]",
+	Usage:    "addons [-j] [<service>:<plan>...]",
 	NeedsApp: true,
 	Category: "add-on",
 	Short:    "list addons",
 	Long: `
 Lists addons.
 
+Options:
+
+    -j, --json  print addons as JSON
+
 Examples:
 
     $ hk addons
@@ -31,23 +34,34 @@ Examples:
 `,
 }
 
-func runAddons(cmd *Command, names []string) {
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+func init() {
+	registerList(cmdAddons, heroku.Addon{})
+}
+
+func runAddons(ctx *Context, names []string) {
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
 	defer w.Flush()
 
-	appname := mustApp()
-	addons, err := client.AddonList(appname, nil)
+	appname := ctx.MustApp()
+	addons, err := ctx.Client.AddonList(appname, nil)
 	if err != nil {
 		printFatal(err.Error())
 	}
 	for i, s := range names {
 		names[i] = strings.ToLower(s)
 	}
+	var matched []heroku.Addon
 	for _, a := range addons {
 		if len(names) == 0 || addonMatch(a, names) {
-			listAddon(w, a)
+			matched = append(matched, a)
 		}
 	}
+	if printList(ctx.Stdout, matched) {
+		return
+	}
+	for _, a := range matched {
+		listAddon(w, a)
+	}
 }
 
 func addonMatch(a heroku.Addon, names []string) bool {
@@ -79,12 +93,24 @@ func listAddon(w io.Writer, a heroku.Addon) {
 
 var cmdAddonAdd = &Command{
 	Run:      runAddonAdd,
-	Usage:    "addon-add <service>[:<plan>] [<config>=<value>...]",
+	Usage:    "addon-add [-q] [--no-wait] [--wait [--wait-timeout <duration>]] <service>[:<plan>] [<config>=<value>...]",
 	NeedsApp: true,
 	Category: "add-on",
 	Short:    "add an addon",
 	Long: `
-Adds an addon to an app.
+Adds an addon to an app. If adding the addon creates a release and
+the app has a release phase, its output is shown as it runs, and
+addon-add exits non-zero if the release fails. At a terminal,
+adding a Postgres database suggests how to check on it and connect.
+
+Options:
+
+    -q                         don't suggest next steps
+    --no-wait                  don't show release phase output or
+                               wait for the release to finish
+    --wait                     wait until the addon is provisioned
+                               (see 'hk help addon-wait')
+    --wait-timeout <duration>  time to wait with --wait (default 20m)
 
 Examples:
 
@@ -93,14 +119,24 @@ Examples:
 
     $ hk addon-add heroku-postgresql:standard-tengu
     Added heroku-postgresql:standard-tengu to myapp as heroku-postgresql-orange.
+
+    $ hk addon-add --wait heroku-postgresql:standard-0
+    Added heroku-postgresql:standard-0 to myapp as heroku-postgresql-round-4217.
+    heroku-postgresql-round-4217 is provisioned.
 `,
 }
 
-func runAddonAdd(cmd *Command, args []string) {
-	appname := mustApp()
+var flagAddonAddWait bool
+
+func init() {
+	cmdAddonAdd.Flag.BoolVar(&flagAddonAddWait, "wait", false, "wait until the addon is provisioned")
+}
+
+func runAddonAdd(ctx *Context, args []string) {
+	appname := ctx.MustApp()
 	if len(args) == 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	plan := args[0]
 	var opts heroku.AddonCreateOpts
@@ -108,7 +144,7 @@ func runAddonAdd(cmd *Command, args []string) {
 		config, err := parseAddonAddConfig(args[1:])
 		if err != nil {
 			log.Println(err)
-			os.Exit(2)
+			exit(2)
 		}
 		// if this is a postgres addon, resolve fork/follow/rollback args
 		provider, _ := splitProviderAndPlan(plan)
@@ -116,7 +152,7 @@ func runAddonAdd(cmd *Command, args []string) {
 			for k, _ := range *config {
 				if i := stringsIndex(hpgOptNames, k); i != -1 {
 					// contains an hpgOptNames key, we need to resolve these against envs
-					appEnv, err := client.ConfigVarInfo(appname)
+					appEnv, err := ctx.Client.ConfigVarInfo(appname)
 					must(err)
 					must(hpgAddonOptResolve(config, appEnv))
 					break
@@ -125,9 +161,25 @@ func runAddonAdd(cmd *Command, args []string) {
 		}
 		opts = heroku.AddonCreateOpts{Config: config}
 	}
-	addon, err := client.AddonCreate(appname, plan, &opts)
+	var before int
+	if !flagReleaseNoWait {
+		if rel, err := latestRelease(appname); err == nil {
+			before = rel.Version
+		}
+	}
+	addon, err := ctx.Client.AddonCreate(appname, plan, &opts)
 	must(err)
+	waitLatestRelease(ctx.Stdout, appname, before)
 	log.Printf("Added %s to %s as %s.", addon.Plan.Name, appname, addon.Name)
+	if flagAddonAddWait {
+		if err := waitAddonProvisioned(appname, addon.Name, flagAddonWaitTimeout); err != nil {
+			printFatal(err.Error())
+		}
+		log.Printf("%s is provisioned.", addon.Name)
+	}
+	if provider, _ := splitProviderAndPlan(addon.Plan.Name); provider == hpgAddonName() {
+		printTip("Run 'hk pg-wait' to wait until the database is available, and 'hk psql' to connect.")
+	}
 }
 
 func splitProviderAndPlan(providerAndPlan string) (provider string, plan string) {
@@ -178,20 +230,20 @@ Examples:
 `,
 }
 
-func runAddonRemove(cmd *Command, args []string) {
-	appname := mustApp()
+func runAddonRemove(ctx *Context, args []string) {
+	appname := ctx.MustApp()
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	name := args[0]
 	if strings.IndexRune(name, ':') != -1 {
 		// specified an addon with plan name, unsupported in v3
 		log.Println("Please specify an addon name, not a plan name.")
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	checkAddonError(client.AddonDelete(appname, name))
+	checkAddonError(ctx.Client.AddonDelete(appname, name))
 	log.Printf("Removed %s from %s.", name, appname)
 }
 
@@ -212,19 +264,87 @@ Examples:
 `,
 }
 
-func runAddonOpen(cmd *Command, args []string) {
-	appname := mustApp()
+func runAddonOpen(ctx *Context, args []string) {
+	appname := ctx.MustApp()
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	name := args[0]
 	// look up addon to make sure it exists and to get plan name
-	a, err := client.AddonInfo(appname, name)
+	a, err := ctx.Client.AddonInfo(appname, name)
 	checkAddonError(err)
 	must(openURL("https://addons-sso.heroku.com/apps/" + appname + "/addons/" + a.Plan.Name))
 }
 
+var cmdAddonDocs = &Command{
+	Run:      runAddonDocs,
+	Usage:    "addon-docs [--print-url] <service>",
+	Category: "add-on",
+	Short:    "open an addon service's documentation" + extra,
+	Long: `
+Opens the Dev Center documentation for an addon service in your
+default web browser. The service may be given with a plan, e.g.
+heroku-redis:premium-0, or as the name of an addon on the app given
+by -a or the git remote.
+
+Options:
+
+    --print-url  print the URL instead of opening it
+
+Examples:
+
+    $ hk addon-docs heroku-postgresql
+
+    $ hk addon-docs --print-url papertrail:choklad
+    https://devcenter.heroku.com/articles/papertrail
+`,
+}
+
+var flagAddonDocsPrint bool
+
+func init() {
+	cmdAddonDocs.Flag.BoolVar(&flagAddonDocsPrint, "print-url", false, "print the URL instead of opening it")
+	cmdAddonDocs.Flag.StringVar(&flagApp, "a", "", "app name")
+	cmdAddonDocs.Flag.StringVar(&flagRemote, "r", "", "git remote of app")
+}
+
+func runAddonDocs(ctx *Context, args []string) {
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	service, _ := splitProviderAndPlan(args[0])
+	s, err := ctx.Client.AddonServiceInfo(service)
+	if hkerr, ok := err.(heroku.Error); ok && hkerr.Id == "not_found" {
+		// not a service; try it as the name of one of the app's addons
+		if appname, aerr := ctx.App(); aerr == nil && appname != "" {
+			if a, aerr := ctx.Client.AddonInfo(appname, args[0]); aerr == nil {
+				service, _ = splitProviderAndPlan(a.Plan.Name)
+				s, err = ctx.Client.AddonServiceInfo(service)
+			}
+		}
+	}
+	if err != nil {
+		if hkerr, ok := err.(heroku.Error); ok && hkerr.Id == "not_found" {
+			printFatal("no addon service named %s.", service)
+		}
+		printFatal(err.Error())
+	}
+	u := addonDocsURL(s.Name)
+	if flagAddonDocsPrint {
+		fmt.Fprintln(ctx.Stdout, u)
+		return
+	}
+	must(openURL(u))
+}
+
+// addonDocsURL returns the Dev Center article for an addon service,
+// which is named after the service.
+func addonDocsURL(service string) string {
+	return "https://devcenter.heroku.com/articles/" + service
+}
+
 func checkAddonError(err error) {
 	if err != nil {
 		if hkerr, ok := err.(heroku.Error); ok && hkerr.Id == "not_found" {
@@ -232,6 +352,6 @@ func checkAddonError(err error) {
 		} else {
 			printFatal(err.Error())
 		}
-		os.Exit(2)
+		exit(2)
 	}
 }
@@ -1,8 +1,10 @@
-package main
+package hk
 
 import (
 	"reflect"
 	"testing"
+
+	"github.com/heroku/hk/hktest"
 )
 
 var testAddonConfigs = []struct {
@@ -33,3 +35,27 @@ func TestParseAddonAddConfig(t *testing.T) {
 		}
 	}
 }
+
+func TestAddonDocs(t *testing.T) {
+	srv := hktest.NewServer(
+		hktest.Fixture{Method: "GET", Path: "/addon-services/heroku-redis", Status: 404, Body: []byte(`{"id": "not_found", "message": "Couldn't find that add-on service."}`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/addons/heroku-redis", Status: 404, Body: []byte(`{"id": "not_found", "message": "Couldn't find that add-on."}`)},
+		hktest.Fixture{Method: "GET", Path: "/addon-services/papertrail", Body: []byte(`{"name": "papertrail"}`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/addons/papertrail-lively-1283", Body: []byte(`{"name": "papertrail-lively-1283", "plan": {"name": "papertrail:choklad"}}`)},
+		hktest.Fixture{Method: "GET", Path: "/addon-services/papertrail-lively-1283", Status: 404, Body: []byte(`{"id": "not_found", "message": "Couldn't find that add-on service."}`)},
+	)
+	defer srv.Close()
+	for _, args := range [][]string{
+		{"addon-docs", "--print-url", "papertrail:choklad"},
+		{"addon-docs", "--print-url", "-a", "myapp", "papertrail-lively-1283"},
+	} {
+		out, status := runTestCommand(t, srv, args...)
+		if want := "https://devcenter.heroku.com/articles/papertrail\n"; status != 0 || out != want {
+			t.Errorf("hk %v = %q, %d, want %q, 0", args, out, status, want)
+		}
+	}
+	if _, status := runTestCommand(t, srv, "addon-docs", "-a", "myapp", "heroku-redis"); status != 1 {
+		t.Errorf("hk addon-docs heroku-redis: status = %d, want 1", status)
+	}
+	flagAddonDocsPrint = false
+}
@@ -1,14 +1,24 @@
-package main
+package hk
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 )
 
 var cmdAPI = &Command{
 	Run:      runAPI,
-	Usage:    "api <method> <path>",
+	Usage:    "api [--list [<resource>] | [--validate] <method> <path>]",
 	Category: "hk",
 	Short:    "make a single API request" + extra,
 	Long: `
@@ -21,6 +31,20 @@ Method GET doesn't read or send a request body.
 Method name input will be upcased, so both 'hk api GET /apps' and
 'hk api get /apps' are valid commands.
 
+With --list, api prints the endpoints described by the API's JSON
+schema instead of making a request: their method, path, and title.
+Given a resource name, it lists only that resource's endpoints, along
+with the parameters each accepts. Required parameters are marked
+with a *. The schema is cached in $HOME/.hk for a day.
+
+Options:
+
+    --list        list the endpoints in the API schema
+    --validate    before sending the request, check that the schema
+                  has an endpoint for the method and path, and that
+                  a JSON request body has only parameters the
+                  endpoint accepts, and all that it requires
+
 As with any hk command, the behavior of hk api is controlled by
 various environment variables. See 'hk help environ' for details.
 
@@ -41,20 +65,247 @@ Examples:
     '
     $ printf 'type=web&qty=2' | hk api POST /apps/myapp/ps/scale
     2
+
+    $ hk api --list domain
+    POST    /apps/{app_identity}/domains                    Create  hostname*
+    DELETE  /apps/{app_identity}/domains/{domain_identity}  Delete
+    GET     /apps/{app_identity}/domains/{domain_identity}  Info
+    GET     /apps/{app_identity}/domains                    List
+
+    $ echo '{"hostnme": "www.example.com"}' | hk api --validate POST /apps/myapp/domains
+    error: unknown parameter hostnme for POST /apps/{app_identity}/domains
 `,
 }
 
-func runAPI(cmd *Command, args []string) {
+var (
+	flagAPIList     bool
+	flagAPIValidate bool
+)
+
+func init() {
+	cmdAPI.Flag.BoolVar(&flagAPIList, "list", false, "list endpoints in the API schema")
+	cmdAPI.Flag.BoolVar(&flagAPIValidate, "validate", false, "validate the request against the API schema")
+}
+
+func runAPI(ctx *Context, args []string) {
+	if flagAPIList {
+		if len(args) > 1 {
+			ctx.printUsage()
+			exit(2)
+		}
+		listAPISchema(ctx.Stdout, args)
+		return
+	}
 	if len(args) != 2 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	method := strings.ToUpper(args[0])
 	var body io.Reader
 	if method != "GET" {
 		body = os.Stdin
 	}
-	if err := client.APIReq(os.Stdout, method, args[1], body); err != nil {
+	if flagAPIValidate {
+		var b []byte
+		if body != nil {
+			var err error
+			b, err = ioutil.ReadAll(body)
+			must(err)
+			body = bytes.NewReader(b)
+		}
+		schema, err := loadAPISchema()
+		must(err)
+		if err := schema.validate(method, args[1], b); err != nil {
+			printFatal(err.Error())
+		}
+	}
+	if err := ctx.Client.APIReq(ctx.Stdout, method, args[1], body); err != nil {
 		printFatal(err.Error())
 	}
 }
+
+// how long a downloaded API schema is used before fetching it again
+const apiSchemaMaxAge = 24 * time.Hour
+
+// apiSchema is the part of the platform API's JSON schema that describes
+// its resources and their endpoints.
+type apiSchema struct {
+	Definitions map[string]struct {
+		Links []apiLink `json:"links"`
+	} `json:"definitions"`
+}
+
+type apiLink struct {
+	Method string `json:"method"`
+	Href   string `json:"href"`
+	Rel    string `json:"rel"`
+	Title  string `json:"title"`
+	Schema *struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	} `json:"schema"`
+}
+
+func apiSchemaPath() string {
+	return filepath.Join(hkHome(), "schema.json")
+}
+
+// loadAPISchema returns the API schema, from the cache in hkHome if it's
+// recent enough, or else from the API.
+func loadAPISchema() (*apiSchema, error) {
+	b, err := ioutil.ReadFile(apiSchemaPath())
+	if fi, serr := os.Stat(apiSchemaPath()); err != nil || serr != nil || time.Since(fi.ModTime()) > apiSchemaMaxAge {
+		var buf bytes.Buffer
+		if err := client.APIReq(&buf, "GET", "/schema", nil); err != nil {
+			return nil, err
+		}
+		b = buf.Bytes()
+		if err := os.MkdirAll(hkHome(), 0700); err == nil {
+			ioutil.WriteFile(apiSchemaPath(), b, 0600)
+		}
+	}
+	var schema apiSchema
+	if err := json.Unmarshal(b, &schema); err != nil {
+		return nil, fmt.Errorf("parsing API schema: %s", err)
+	}
+	return &schema, nil
+}
+
+func listAPISchema(out io.Writer, args []string) {
+	schema, err := loadAPISchema()
+	must(err)
+	var names []string
+	for name := range schema.Definitions {
+		if len(args) == 0 || name == args[0] {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		printFatal("no resource named %s in the API schema", args[0])
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(out, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, name := range names {
+		for _, l := range schema.Definitions[name].Links {
+			if len(args) == 0 {
+				listRec(w, l.Method, l.path(), l.Title)
+			} else {
+				listRec(w, l.Method, l.path(), l.Title, strings.Join(l.params(), " "))
+			}
+		}
+	}
+}
+
+// hrefVarRE matches a variable in a link's href template, e.g.
+// "{(%23%2Fdefinitions%2Fapp%2Fdefinitions%2Fidentity)}".
+var hrefVarRE = regexp.MustCompile(`\{\(([^)]*)\)\}`)
+
+// path returns the link's href with each variable written as
+// {<resource>_<property>}, e.g. /apps/{app_identity}.
+func (l apiLink) path() string {
+	return hrefVarRE.ReplaceAllStringFunc(l.Href, func(v string) string {
+		resource, property := hrefVar(v)
+		return "{" + resource + "_" + property + "}"
+	})
+}
+
+// vars returns the resource named by each variable in the link's href,
+// e.g. ["app", "domain"] for /apps/{app_identity}/domains/{domain_identity}.
+func (l apiLink) vars() []string {
+	var vars []string
+	for _, v := range hrefVarRE.FindAllString(l.Href, -1) {
+		resource, _ := hrefVar(v)
+		vars = append(vars, resource)
+	}
+	return vars
+}
+
+// expand returns the link's href with its variables replaced by vals, in
+// order.
+func (l apiLink) expand(vals []string) string {
+	i := 0
+	return hrefVarRE.ReplaceAllStringFunc(l.Href, func(v string) string {
+		i++
+		return url.QueryEscape(vals[i-1])
+	})
+}
+
+// hrefVar returns the resource and property referred to by an href
+// variable, e.g. "app" and "identity".
+func hrefVar(v string) (resource, property string) {
+	ref, err := url.QueryUnescape(hrefVarRE.FindStringSubmatch(v)[1])
+	if err != nil {
+		return v, ""
+	}
+	parts := strings.Split(strings.TrimPrefix(ref, "#/definitions/"), "/")
+	return parts[0], parts[len(parts)-1]
+}
+
+// matches reports whether the link is for method and path.
+func (l apiLink) matches(method, path string) bool {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	parts := hrefVarRE.Split(l.Href, -1)
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	re := "^" + strings.Join(parts, `[^/]+`) + "/?$"
+	return strings.ToUpper(l.Method) == method && regexp.MustCompile(re).MatchString(path)
+}
+
+// params returns the names of the link's parameters in order, with
+// required ones marked by a *.
+func (l apiLink) params() []string {
+	if l.Schema == nil {
+		return nil
+	}
+	var params []string
+	for name := range l.Schema.Properties {
+		if stringsIndex(l.Schema.Required, name) >= 0 {
+			name += "*"
+		}
+		params = append(params, name)
+	}
+	sort.Strings(params)
+	return params
+}
+
+// validate checks that the schema has an endpoint for method and path,
+// and that body, if it's a JSON object, holds only parameters the
+// endpoint accepts, including all those it requires.
+func (s *apiSchema) validate(method, path string, body []byte) error {
+	var link *apiLink
+	for _, d := range s.Definitions {
+		for i := range d.Links {
+			if d.Links[i].matches(method, path) {
+				link = &d.Links[i]
+			}
+		}
+	}
+	if link == nil {
+		return fmt.Errorf("no endpoint for %s %s in the API schema, see 'hk api --list'", method, path)
+	}
+	var params map[string]json.RawMessage
+	if len(bytes.TrimSpace(body)) == 0 || json.Unmarshal(body, &params) != nil {
+		params = nil
+	}
+	if link.Schema == nil {
+		return nil
+	}
+	for name := range params {
+		if _, ok := link.Schema.Properties[name]; !ok {
+			return fmt.Errorf("unknown parameter %s for %s %s", name, method, link.path())
+		}
+	}
+	if params != nil {
+		for _, name := range link.Schema.Required {
+			if _, ok := params[name]; !ok {
+				return fmt.Errorf("missing required parameter %s for %s %s", name, method, link.path())
+			}
+		}
+	}
+	return nil
+}
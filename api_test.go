@@ -0,0 +1,63 @@
+package hk
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testAPISchema = `{
+  "definitions": {
+    "domain": {
+      "links": [
+        {
+          "method": "POST",
+          "href": "/apps/{(%23%2Fdefinitions%2Fapp%2Fdefinitions%2Fidentity)}/domains",
+          "title": "Create",
+          "schema": {
+            "properties": {"hostname": {}, "sni_endpoint": {}},
+            "required": ["hostname"]
+          }
+        },
+        {
+          "method": "GET",
+          "href": "/apps/{(%23%2Fdefinitions%2Fapp%2Fdefinitions%2Fidentity)}/domains/{(%23%2Fdefinitions%2Fdomain%2Fdefinitions%2Fidentity)}",
+          "title": "Info"
+        }
+      ]
+    }
+  }
+}`
+
+func TestAPISchema(t *testing.T) {
+	var schema apiSchema
+	if err := json.Unmarshal([]byte(testAPISchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+	links := schema.Definitions["domain"].Links
+	if got, want := links[1].path(), "/apps/{app_identity}/domains/{domain_identity}"; got != want {
+		t.Errorf("path => %s, want %s", got, want)
+	}
+	if got := links[0].params(); len(got) != 2 || got[0] != "hostname*" || got[1] != "sni_endpoint" {
+		t.Errorf("params => %v, want [hostname* sni_endpoint]", got)
+	}
+
+	tests := []struct {
+		method, path, body string
+		ok                 bool
+	}{
+		{"GET", "/apps/myapp/domains/www.example.com", "", true},
+		{"GET", "/apps/myapp/domains/www.example.com?x=1", "", true},
+		{"GET", "/apps/myapp/domains", "", false},
+		{"POST", "/apps/myapp/domains", `{"hostname": "www.example.com"}`, true},
+		{"POST", "/apps/myapp/domains", `{"hostnme": "www.example.com"}`, false},
+		{"POST", "/apps/myapp/domains", `{"sni_endpoint": null}`, false},
+		{"POST", "/apps/myapp/domains", "", true},
+		{"DELETE", "/apps/myapp/domains/www.example.com", "", false},
+	}
+	for _, test := range tests {
+		err := schema.validate(test.method, test.path, []byte(test.body))
+		if (err == nil) != test.ok {
+			t.Errorf("validate(%s %s %s) => %v, want ok %t", test.method, test.path, test.body, err, test.ok)
+		}
+	}
+}
@@ -1,8 +1,7 @@
-package main
+package hk
 
 import (
 	"io"
-	"os"
 	"sort"
 	"strings"
 	"text/tabwriter"
@@ -12,31 +11,90 @@ import (
 
 var cmdApps = &Command{
 	Run:      runApps,
-	Usage:    "apps [<name>...]",
+	Usage:    "apps [-j | --tree [--separator <sep>]] [--owned] [--collaborated] [--org-role <role>] [<name>...]",
 	Category: "app",
 	Short:    "list apps",
 	Long: `
-Lists apps. Shows the app name, owner, and last release time (or
-time the app was created, if it's never been released).
+Lists apps. Shows the app name, owner, your relationship to the app,
+and last release time (or time the app was created, if it's never
+been released).
+
+The relationship is "owner" for apps you own, "collaborator" for
+apps you've been added to, or "org <role>" for apps you can access
+through your role in an organization.
+
+Options:
+
+    -j, --json         print apps as JSON
+    --owned            show apps you own
+    --collaborated     show apps you collaborate on
+    --org-role <role>  show apps you access through an organization
+                       role (e.g. admin or member)
+    --tree             group apps by the parts of their names
+    --separator <sep>  separator between the parts of app names, for
+                       --tree (default -)
+
+When more than one filter is given, apps matching any of them are
+shown.
+
+With --tree, apps named by a convention such as project-env-component
+are grouped by project, then env, with the number of apps in each
+group. A group of one app is shown as just the app.
 
 Examples:
 
     $ hk apps
-    myapp   user@test.com         Jan 2 12:34
-    myapp2  user@longdomainname…  Jan 2 12:34
+    myapp   user@test.com         owner         Jan 2 12:34
+    myapp2  user@longdomainname…  collaborator  Jan 2 12:34
+    myapp3  acme@herokumanager…   org admin     Jan 2 12:34
 
-    $ hk apps myapp
-    myapp  user@test.com  Jan 2 12:34
+    $ hk apps --org-role admin
+    myapp3  acme@herokumanager…   org admin     Jan 2 12:34
+
+    $ hk apps --tree
+    acme-* (5)
+      acme-production-* (3)
+        acme-production-api     acme@herokumanager…  org admin  Jan 2 12:34
+        acme-production-web     acme@herokumanager…  org admin  Jan 2 12:34
+        acme-production-worker  acme@herokumanager…  org admin  Jan 2 12:34
+      acme-staging-* (2)
+        acme-staging-api        acme@herokumanager…  org admin  Jan 2 12:34
+        acme-staging-web        acme@herokumanager…  org admin  Jan 2 12:34
+    blog                        user@test.com        owner      Jan 2 12:34
 `,
 }
 
-func runApps(cmd *Command, names []string) {
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+func init() {
+	registerList(cmdApps, heroku.App{})
+}
+
+var (
+	flagAppsOwned        bool
+	flagAppsCollaborated bool
+	flagAppsOrgRole      string
+	flagAppsTree         bool
+	flagAppsSeparator    string
+)
+
+func init() {
+	cmdApps.Flag.BoolVar(&flagAppsOwned, "owned", false, "show owned apps")
+	cmdApps.Flag.BoolVar(&flagAppsCollaborated, "collaborated", false, "show apps you collaborate on")
+	cmdApps.Flag.StringVar(&flagAppsOrgRole, "org-role", "", "show apps accessed through an organization role")
+	cmdApps.Flag.BoolVar(&flagAppsTree, "tree", false, "group apps by the parts of their names")
+	cmdApps.Flag.StringVar(&flagAppsSeparator, "separator", "-", "separator between parts of app names")
+}
+
+func runApps(ctx *Context, names []string) {
+	if flagAppsTree && (flagJSON || flagAppsSeparator == "") {
+		ctx.printUsage()
+		exit(2)
+	}
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
 	defer w.Flush()
 	var apps []heroku.App
 	if len(names) == 0 {
 		var err error
-		apps, err = client.AppList(&heroku.ListRange{Field: "name", Max: 1000})
+		apps, err = ctx.Client.AppList(&heroku.ListRange{Field: "name", Max: 1000})
 		must(err)
 	} else {
 		appch := make(chan *heroku.App, len(names))
@@ -46,7 +104,7 @@ func runApps(cmd *Command, names []string) {
 				appch <- nil
 			} else {
 				go func(appname string) {
-					if app, err := client.AppInfo(appname); err != nil {
+					if app, err := ctx.Client.AppInfo(appname); err != nil {
 						errch <- err
 					} else {
 						appch <- app
@@ -65,17 +123,97 @@ func runApps(cmd *Command, names []string) {
 			}
 		}
 	}
-	printAppList(w, apps)
+	rel, err := loadAppRelation()
+	must(err)
+	if flagAppsOwned || flagAppsCollaborated || flagAppsOrgRole != "" {
+		apps = filterAppsByRelation(apps, rel)
+	}
+	printAppList(w, apps, rel)
+}
+
+func filterAppsByRelation(apps []heroku.App, rel *appRelation) []heroku.App {
+	var kept []heroku.App
+	for _, a := range apps {
+		r := rel.of(a)
+		if (flagAppsOwned && r == "owner") ||
+			(flagAppsCollaborated && r == "collaborator") ||
+			(flagAppsOrgRole != "" && r == "org "+flagAppsOrgRole) {
+			kept = append(kept, a)
+		}
+	}
+	return kept
 }
 
-func printAppList(w io.Writer, apps []heroku.App) {
+func printAppList(w io.Writer, apps []heroku.App, rel *appRelation) {
 	sort.Sort(appsByName(apps))
+	if printList(w, apps) {
+		return
+	}
+	relations := make([]string, len(apps))
+	for i, a := range apps {
+		relations[i] = rel.of(a)
+	}
 	abbrevEmailApps(apps)
-	for _, a := range apps {
+	if flagAppsTree {
+		printAppTree(w, buildAppTree(apps, relations, flagAppsSeparator), flagAppsSeparator, 0)
+		return
+	}
+	for i, a := range apps {
 		if a.Name != "" {
-			listApp(w, a)
+			listApp(w, a, relations[i])
+		}
+	}
+}
+
+// An organization is a Heroku organization the user belongs to.
+type organization struct {
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+	Default bool   `json:"default"`
+}
+
+// appRelation describes how the current user can access apps.
+type appRelation struct {
+	email    string
+	orgRoles map[string]string
+}
+
+func loadAppRelation() (*appRelation, error) {
+	account, err := client.AccountInfo()
+	if err != nil {
+		return nil, err
+	}
+	orgs, err := listOrgs()
+	if err != nil {
+		return nil, err
+	}
+	rel := &appRelation{email: account.Email, orgRoles: make(map[string]string)}
+	for _, o := range orgs {
+		rel.orgRoles[o.Name] = o.Role
+	}
+	return rel, nil
+}
+
+// of returns "owner", "collaborator", or "org <role>" for app a.
+func (r *appRelation) of(a heroku.App) string {
+	if a.Owner.Email == r.email {
+		return "owner"
+	}
+	if org, ok := appOrg(&a); ok {
+		if role, ok := r.orgRoles[org]; ok {
+			return "org " + role
 		}
 	}
+	return "collaborator"
+}
+
+// appOrg returns the organization that owns app a, if any. Apps owned by
+// an organization have an owner address at herokumanager.com.
+func appOrg(a *heroku.App) (org string, ok bool) {
+	if !strings.HasSuffix(a.Owner.Email, "@herokumanager.com") {
+		return "", false
+	}
+	return strings.TrimSuffix(a.Owner.Email, "@herokumanager.com"), true
 }
 
 func abbrevEmailApps(apps []heroku.App) {
@@ -100,7 +238,7 @@ func abbrevEmailApps(apps []heroku.App) {
 	}
 }
 
-func listApp(w io.Writer, a heroku.App) {
+func listApp(w io.Writer, a heroku.App, relation string) {
 	t := a.CreatedAt
 	if a.ReleasedAt != nil {
 		t = *a.ReleasedAt
@@ -108,6 +246,7 @@ func listApp(w io.Writer, a heroku.App) {
 	listRec(w,
 		a.Name,
 		abbrev(a.Owner.Email, 20),
+		relation,
 		prettyTime{t},
 	)
 }
@@ -0,0 +1,30 @@
+package hk
+
+import (
+	"testing"
+
+	"github.com/bgentry/heroku-go"
+)
+
+func TestAppRelation(t *testing.T) {
+	rel := &appRelation{
+		email:    "me@example.com",
+		orgRoles: map[string]string{"acme": "admin"},
+	}
+	tests := []struct {
+		owner string
+		want  string
+	}{
+		{"me@example.com", "owner"},
+		{"you@example.com", "collaborator"},
+		{"acme@herokumanager.com", "org admin"},
+		{"other@herokumanager.com", "collaborator"},
+	}
+	for _, tt := range tests {
+		var a heroku.App
+		a.Owner.Email = tt.owner
+		if got := rel.of(a); got != tt.want {
+			t.Errorf("relation for owner %q = %q, want %q", tt.owner, got, tt.want)
+		}
+	}
+}
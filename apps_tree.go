@@ -0,0 +1,90 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/bgentry/heroku-go"
+)
+
+// An appTree groups apps by the parts of their names, such as
+// project-env-component. Each node is a name prefix, and may itself be an
+// app.
+type appTree struct {
+	name     string
+	app      *heroku.App
+	relation string
+	count    int // apps in the tree, including this node's
+	children map[string]*appTree
+}
+
+func newAppTree(name string) *appTree {
+	return &appTree{name: name, children: make(map[string]*appTree)}
+}
+
+// buildAppTree returns the tree of apps, with their names split by sep.
+// relations[i] is the relation of apps[i].
+func buildAppTree(apps []heroku.App, relations []string, sep string) *appTree {
+	root := newAppTree("")
+	for i := range apps {
+		if apps[i].Name == "" {
+			continue
+		}
+		parts := strings.Split(apps[i].Name, sep)
+		node := root
+		node.count++
+		for j := range parts {
+			prefix := strings.Join(parts[:j+1], sep)
+			child := node.children[prefix]
+			if child == nil {
+				child = newAppTree(prefix)
+				node.children[prefix] = child
+			}
+			child.count++
+			node = child
+		}
+		node.app, node.relation = &apps[i], relations[i]
+	}
+	return root
+}
+
+// printAppTree lists the children of t, indented by depth. Groups show
+// how many apps they hold, and a group of one app is shown as that app.
+// Groups with only one group in them are merged into it.
+func printAppTree(w io.Writer, t *appTree, sep string, depth int) {
+	var names []string
+	for name := range t.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	indent := strings.Repeat("  ", depth)
+	for _, name := range names {
+		c := t.children[name]
+		for c.app == nil && len(c.children) == 1 {
+			for _, only := range c.children {
+				c = only
+			}
+		}
+		if c.count == 1 {
+			for c.app == nil {
+				for _, only := range c.children {
+					c = only
+				}
+			}
+			a := *c.app
+			a.Name = indent + a.Name
+			listApp(w, a, c.relation)
+			continue
+		}
+		// empty cells keep the apps' columns aligned across groups
+		listRec(w, fmt.Sprintf("%s%s%s* (%d)", indent, c.name, sep, c.count), "", "", "")
+		if c.app != nil {
+			a := *c.app
+			a.Name = indent + "  " + a.Name
+			listApp(w, a, c.relation)
+		}
+		printAppTree(w, c, sep, depth+1)
+	}
+}
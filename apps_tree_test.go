@@ -0,0 +1,49 @@
+package hk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+func TestPrintAppTree(t *testing.T) {
+	names := []string{"acme-production-api", "acme-production-web", "acme-staging-api", "acme-staging-web", "blog", "blog-staging", "solo-production-web"}
+	created := time.Date(2014, 1, 2, 12, 34, 0, 0, time.Local)
+	var apps []heroku.App
+	var relations []string
+	for _, name := range names {
+		var a heroku.App
+		a.Name, a.Owner.Email, a.CreatedAt = name, "me", created
+		apps = append(apps, a)
+		relations = append(relations, "owner")
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 1, 2, 2, ' ', 0)
+	printAppTree(w, buildAppTree(apps, relations, "-"), "-", 0)
+	w.Flush()
+
+	when := prettyTime{created}.String()
+	want := strings.Replace(`acme-* (4)
+  acme-production-* (2)
+    acme-production-api  me  owner  WHEN
+    acme-production-web  me  owner  WHEN
+  acme-staging-* (2)
+    acme-staging-api     me  owner  WHEN
+    acme-staging-web     me  owner  WHEN
+blog-* (2)
+  blog                   me  owner  WHEN
+  blog-staging           me  owner  WHEN
+solo-production-web      me  owner  WHEN
+`, "WHEN", when, -1)
+	var got []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		got = append(got, strings.TrimRight(line, " "))
+	}
+	if g := strings.Join(got, "\n"); g != want {
+		t.Errorf("printAppTree =\n%s\nwant\n%s", g, want)
+	}
+}
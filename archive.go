@@ -0,0 +1,172 @@
+package hk
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdArchive = &Command{
+	Run:      runArchive,
+	Usage:    "archive [--output <file>] [--redact] [-n <lines>] <name>",
+	Category: "app",
+	Short:    "save a record of an app" + extra,
+	Long: `
+Archive saves a record of an app to a gzipped tarball, such as
+before destroying it: the app's info, all its releases, env vars,
+domains, and add-ons as JSON, and its recent log lines. 'hk destroy
+--archive' archives an app before destroying it.
+
+With --redact, env var values are left out, so the archive can be
+kept without holding secrets.
+
+Options:
+
+    --output <file>  file to save the archive to (default
+                     <name>-archive.tar.gz)
+    --redact         leave out env var values
+    -n <lines>       number of log lines to save (default 1500)
+
+Example:
+
+    $ hk archive --redact myapp
+    Archived myapp to myapp-archive.tar.gz.
+
+    $ tar -tzf myapp-archive.tar.gz
+    myapp/app.json
+    myapp/releases.json
+    myapp/config.json
+    myapp/domains.json
+    myapp/addons.json
+    myapp/log.txt
+`,
+}
+
+var (
+	flagArchiveOutput string
+	flagArchiveRedact bool
+	flagArchiveLines  int
+)
+
+func init() {
+	cmdArchive.Flag.StringVar(&flagArchiveOutput, "output", "", "file to save the archive to")
+	cmdArchive.Flag.BoolVar(&flagArchiveRedact, "redact", false, "leave out env var values")
+	cmdArchive.Flag.IntVar(&flagArchiveLines, "n", 1500, "number of log lines")
+}
+
+func runArchive(ctx *Context, args []string) {
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := args[0]
+	name := flagArchiveOutput
+	if name == "" {
+		name = appname + "-archive.tar.gz"
+	}
+	must(archiveApp(appname, name, flagArchiveRedact, flagArchiveLines))
+	log.Printf("Archived %s to %s.", appname, name)
+}
+
+// archiveApp saves a record of appname to a gzipped tarball called name.
+// If redact is set, env var values are left out.
+func archiveApp(appname, name string, redact bool, lines int) error {
+	app, err := client.AppInfo(appname)
+	if err != nil {
+		return err
+	}
+	var releases []heroku.Release
+	err = eachReleasePage(appname, releasePageSize, func(page []heroku.Release) bool {
+		releases = append(releases, page...)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	sort.Sort(hreleasesByVersion(releases))
+	if len(releases) > 0 && releases[0].Version != 1 {
+		printWarning("only releases from v%d on could be listed; older ones aren't archived.", releases[0].Version)
+	}
+	config, err := client.ConfigVarInfo(appname)
+	if err != nil {
+		return err
+	}
+	if redact {
+		for k := range config {
+			config[k] = "REDACTED"
+		}
+	}
+	domains, err := client.DomainList(appname, nil)
+	if err != nil {
+		return err
+	}
+	addons, err := client.AddonList(appname, nil)
+	if err != nil {
+		return err
+	}
+	body := openLog(appname, &heroku.LogSessionCreateOpts{Lines: &lines})
+	logs, err := ioutil.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	tw := tar.NewWriter(zw)
+	now := time.Now()
+	add := func(file string, b []byte) error {
+		hdr := &tar.Header{Name: appname + "/" + file, Mode: 0644, Size: int64(len(b)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(b)
+		return err
+	}
+	addJSON := func(file string, v interface{}) error {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		return add(file, append(b, '\n'))
+	}
+	err = addJSON("app.json", app)
+	if err == nil {
+		err = addJSON("releases.json", releases)
+	}
+	if err == nil {
+		err = addJSON("config.json", config)
+	}
+	if err == nil {
+		err = addJSON("domains.json", domains)
+	}
+	if err == nil {
+		err = addJSON("addons.json", addons)
+	}
+	if err == nil {
+		err = add("log.txt", logs)
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(name)
+	}
+	return err
+}
@@ -0,0 +1,71 @@
+package hk
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/heroku/hk/hktest"
+)
+
+func TestArchiveRedact(t *testing.T) {
+	srv := hktest.NewServer(
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp", Body: []byte(`{"name": "myapp"}`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/releases", Body: []byte(`[{"version": 1}]`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/config-vars", Body: []byte(`{"SECRET_KEY": "s3cret"}`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/domains", Body: []byte(`[]`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/addons", Body: []byte(`[]`)},
+		hktest.Fixture{Method: "GET", Path: "/logs", Body: []byte("2014-01-13T21:20:57+00:00 app[web.1]: hello\n")},
+	)
+	defer srv.Close()
+	srv.Add(hktest.Fixture{
+		Method: "POST", Path: "/apps/myapp/log-sessions", Status: 201,
+		Body: []byte(`{"logplex_url": "` + srv.URL + `/logs"}`),
+	})
+
+	dir, err := ioutil.TempDir("", "hk-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "myapp.tar.gz")
+	defer func() { flagArchiveOutput, flagArchiveRedact = "", false }()
+	if _, status := runTestCommand(t, srv, "archive", "--output", name, "--redact", "myapp"); status != 0 {
+		t.Fatalf("status = %d, want 0", status)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		b, _ := ioutil.ReadAll(tr)
+		files[hdr.Name] = string(b)
+	}
+	for _, want := range []string{"app.json", "releases.json", "config.json", "domains.json", "addons.json", "log.txt"} {
+		if _, ok := files["myapp/"+want]; !ok {
+			t.Errorf("archive has no myapp/%s", want)
+		}
+	}
+	if c := files["myapp/config.json"]; strings.Contains(c, "s3cret") || !strings.Contains(c, "SECRET_KEY") {
+		t.Errorf("config.json = %s, want SECRET_KEY redacted", c)
+	}
+	if l := files["myapp/log.txt"]; !strings.Contains(l, "hello") {
+		t.Errorf("log.txt = %q, want the log", l)
+	}
+}
@@ -0,0 +1,61 @@
+package hk
+
+import (
+	"log"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdAttach = &Command{
+	Run:      runAttach,
+	Usage:    "attach <dyno>",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "reattach to a running one-off dyno" + extra,
+	Long: `
+Attach reconnects the terminal to a one-off dyno that is still
+running, such as one whose hk run session was cut off by a dropped
+connection. If the dyno's attach URL is no longer valid, or it was
+started with --detached and has none, attach follows the dyno's log
+until it exits instead.
+
+Examples:
+
+    $ hk attach run.4821
+    Attached to run.4821 on myapp:
+    irb(main):002:0> ...
+
+    $ hk attach run.4321
+    No terminal for run.4321 on myapp, following its log:
+    2013-10-17T00:17:36.123456+00:00 app[run.4321]: processed 1200 jobs
+`,
+}
+
+func runAttach(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	dyno, err := ctx.Client.DynoInfo(appname, args[0])
+	must(err)
+	if dyno.State != "up" && dyno.State != "starting" {
+		printFatal("%s is %s.", dyno.Name, dyno.State)
+	}
+
+	if dyno.AttachURL != nil && *dyno.AttachURL != "" {
+		cn, br, err := dialDyno(*dyno.AttachURL)
+		if err == nil {
+			defer cn.Close()
+			log.Printf("Attached to %s on %s:", dyno.Name, appname)
+			pipeDyno(ctx.Stdout, cn, br)
+			return
+		}
+		printWarning("can't attach to %s: %s", dyno.Name, err)
+	}
+
+	log.Printf("No terminal for %s on %s, following its log:", dyno.Name, appname)
+	tail, lines := true, 100
+	opts := heroku.LogSessionCreateOpts{Dyno: &dyno.Name, Tail: &tail, Lines: &lines}
+	streamLog(ctx.Stdout, appname, &opts, logFilter{dyno: dyno.Name}, dynoExited(dyno.Name))
+}
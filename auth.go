@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"fmt"
@@ -20,32 +20,62 @@ var cmdCreds = &Command{
 	Long:     `Creds shows credentials that will be used for API calls.`,
 }
 
-func runCreds(cmd *Command, args []string) {
-	fmt.Println(getCreds(apiURL))
+func runCreds(ctx *Context, args []string) {
+	user, pass := getCreds(apiURL)
+	fmt.Fprintln(ctx.Stdout, user, pass)
 }
 
 var cmdLogin = &Command{
 	Run:      runLogin,
-	Usage:    "login <email>",
+	Usage:    "login [<email>]",
 	Category: "hk",
 	Short:    "log in to your Heroku account" + extra,
 	Long: `
-Log in with your Heroku credentials. Input is accepted by typing
-on the terminal. On unix machines, you can also pipe a password
-on standard input.
+Log in to your Heroku account.
 
-Example:
+Without an email address, login opens a web browser to authorize
+hk, and waits for the browser to be redirected back to hk. The
+access token it gets is refreshed automatically before it
+expires. This needs an OAuth client registered with Heroku, with
+the redirect URI http://127.0.0.1:5050/callback, configured in the
+config file (see 'hk help environ'):
+
+    oauth.client-id = <id>
+    oauth.client-secret = <secret>
+    oauth.redirect-addr = 127.0.0.1:5050  (optional)
+
+With an email address, login asks for your password (and a
+two-factor auth code, if your account needs one) instead. Input is
+accepted by typing on the terminal. On unix machines, you can also
+pipe a password on standard input.
+
+Examples:
+
+    $ hk login
+    Opening https://id.heroku.com/oauth/authorize?... in your browser...
+    Logged in as user@test.com.
 
     $ hk login user@test.com
     Enter password: 
-    Login successful.
+    Logged in.
 `,
 }
 
-func runLogin(cmd *Command, args []string) {
-	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+func runLogin(ctx *Context, args []string) {
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	if len(args) == 0 {
+		t, err := browserLogin()
+		if err != nil {
+			printFatal(err.Error())
+		}
+		if err := saveOAuthToken(t); err != nil {
+			printFatal("saving new token: " + err.Error())
+		}
+		fmt.Fprintf(ctx.Stdout, "Logged in as %s.\n", t.Login)
+		return
 	}
 	username := args[0]
 
@@ -55,28 +85,18 @@ func runLogin(cmd *Command, args []string) {
 		printFatal("reading password: " + err.Error())
 	}
 
-	hostname, token, err := attemptLogin(username, password, "")
-	if err != nil {
-		if herror, ok := err.(heroku.Error); ok && herror.Id == "two_factor" {
-			// 2FA requested, attempt 2FA login
-			var twoFactorCode string
-			fmt.Printf("Enter two-factor auth code: ")
-			_, err := fmt.Scanln(&twoFactorCode)
-			if err != nil {
-				printFatal("reading two-factor auth code: " + err.Error())
-			}
-			hostname, token, err = attemptLogin(username, password, twoFactorCode)
-			must(err)
-		} else {
-			must(err)
-		}
-	}
+	// a two-factor auth challenge is answered by the client's transport
+	hostname, token, err := attemptLogin(username, password)
+	must(err)
 
 	err = saveCreds(hostname, username, token)
 	if err != nil {
 		printFatal("saving new token: " + err.Error())
 	}
-	fmt.Println("Logged in.")
+	if err := removeOAuthToken(hostname); err != nil {
+		printWarning("removing old login token: %s", err)
+	}
+	fmt.Fprintln(ctx.Stdout, "Logged in.")
 }
 
 func readPassword(prompt string) (password string, err error) {
@@ -88,7 +108,7 @@ func readPassword(prompt string) (password string, err error) {
 	return speakeasy.Ask("Enter password: ")
 }
 
-func attemptLogin(username, password, twoFactorCode string) (hostname, token string, err error) {
+func attemptLogin(username, password string) (hostname, token string, err error) {
 	description := "hk login from " + time.Now().UTC().Format(time.RFC3339)
 	expires := 2592000 // 30 days
 	opts := heroku.OAuthAuthorizationCreateOpts{
@@ -102,10 +122,6 @@ func attemptLogin(username, password, twoFactorCode string) (hostname, token str
 	}
 	req.SetBasicAuth(username, password)
 
-	if twoFactorCode != "" {
-		req.Header.Set("Heroku-Two-Factor-Code", twoFactorCode)
-	}
-
 	var auth heroku.OAuthAuthorization
 	if err = client.DoReq(req, &auth); err != nil {
 		return
@@ -132,18 +148,22 @@ Example:
 `,
 }
 
-func runLogout(cmd *Command, args []string) {
+func runLogout(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	u, err := url.Parse(client.URL)
+	u, err := url.Parse(apiClient.URL)
 	if err != nil {
 		printFatal("couldn't parse client URL: " + err.Error())
 	}
-	err = removeCreds(strings.Split(u.Host, ":")[0])
+	host := strings.Split(u.Host, ":")[0]
+	err = removeCreds(host)
 	if err != nil {
 		printFatal("saving new netrc: " + err.Error())
 	}
-	fmt.Println("Logged out.")
+	if err := removeOAuthToken(host); err != nil {
+		printFatal("removing login token: " + err.Error())
+	}
+	fmt.Fprintln(ctx.Stdout, "Logged out.")
 }
@@ -0,0 +1,228 @@
+package hk
+
+import (
+	"bufio"
+	"log"
+	"math"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdAutoscale = &Command{
+	Run:      runAutoscale,
+	Usage:    "autoscale [--type <type>] [--metric rps|latency] [--target <n>] [--min <n>] [--max <n>] [--interval <duration>] [--dry-run]",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "scale dynos with router traffic" + extra,
+	Long: `
+Autoscale watches the router lines in the app's log and scales a
+process type between --min and --max dynos to keep a metric near
+its target. It runs in the foreground until interrupted; run it
+somewhere that stays up, such as a dyno of another app. API and
+log errors are printed, and the autoscaler carries on with the
+next interval.
+
+Metrics:
+
+    rps      requests per second per dyno; the process type is
+             scaled to the number of dynos that keeps each one at
+             or under the target (default 50)
+    latency  mean router service time in ms; the process type gets
+             one more dyno while latency is over the target, and
+             one fewer while it's under half the target (default
+             500)
+
+Options:
+
+    --type <type>          process type to scale (default web)
+    --metric <metric>      rps or latency (default rps)
+    --target <n>           target value of the metric
+    --min <n>              fewest dynos to run (default 1)
+    --max <n>              most dynos to run (default 10)
+    --interval <duration>  how often to decide (default 60s)
+    --dry-run              log decisions without scaling
+
+Example:
+
+    $ hk autoscale --type web --metric rps --min 2 --max 20
+    web: 212.4 req/s over 1m0s, 3 dynos; scaling to 5.
+    web: 180.1 req/s over 1m0s, 5 dynos; keeping 5.
+`,
+}
+
+var (
+	flagAutoscaleType     string
+	flagAutoscaleMetric   string
+	flagAutoscaleTarget   float64
+	flagAutoscaleMin      int
+	flagAutoscaleMax      int
+	flagAutoscaleInterval time.Duration
+	flagAutoscaleDryRun   bool
+)
+
+func init() {
+	cmdAutoscale.Flag.StringVar(&flagAutoscaleType, "type", "web", "process type to scale")
+	cmdAutoscale.Flag.StringVar(&flagAutoscaleMetric, "metric", "rps", "rps or latency")
+	cmdAutoscale.Flag.Float64Var(&flagAutoscaleTarget, "target", 0, "target value of the metric")
+	cmdAutoscale.Flag.IntVar(&flagAutoscaleMin, "min", 1, "fewest dynos")
+	cmdAutoscale.Flag.IntVar(&flagAutoscaleMax, "max", 10, "most dynos")
+	cmdAutoscale.Flag.DurationVar(&flagAutoscaleInterval, "interval", time.Minute, "how often to decide")
+	cmdAutoscale.Flag.BoolVar(&flagAutoscaleDryRun, "dry-run", false, "log decisions without scaling")
+}
+
+var autoscaleDefaultTargets = map[string]float64{
+	"rps":     50,
+	"latency": 500,
+}
+
+func runAutoscale(ctx *Context, args []string) {
+	defaultTarget, ok := autoscaleDefaultTargets[flagAutoscaleMetric]
+	if len(args) != 0 || !ok || flagAutoscaleMin < 0 || flagAutoscaleMax < flagAutoscaleMin || flagAutoscaleInterval <= 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	if flagAutoscaleTarget <= 0 {
+		flagAutoscaleTarget = defaultTarget
+	}
+	appname := ctx.MustApp()
+	pstype := flagAutoscaleType
+
+	var (
+		mu     sync.Mutex
+		sample routerSample
+	)
+	go func() {
+		// Log sessions end now and then, so keep opening new ones, backing
+		// off while they fail or end right away.
+		backoff := time.Second
+		for {
+			tail, source, router := true, "heroku", "router"
+			opts := heroku.LogSessionCreateOpts{Tail: &tail, Source: &source, Dyno: &router}
+			start := time.Now()
+			body, err := logSession(appname, &opts)
+			if err == nil {
+				s := bufio.NewScanner(body)
+				for s.Scan() {
+					mu.Lock()
+					sample.add(s.Text(), pstype)
+					mu.Unlock()
+				}
+				body.Close()
+			} else {
+				printError("reading router logs: %s", err)
+			}
+			if time.Since(start) > time.Minute {
+				backoff = time.Second
+				continue
+			}
+			time.Sleep(backoff)
+			if backoff *= 2; backoff > time.Minute {
+				backoff = time.Minute
+			}
+		}
+	}()
+
+	if flagAutoscaleDryRun {
+		log.Printf("Dry run: logging decisions for %s on %s without scaling.", pstype, appname)
+	}
+	for range time.Tick(flagAutoscaleInterval) {
+		mu.Lock()
+		cur := sample
+		sample = routerSample{}
+		mu.Unlock()
+
+		// API errors skip a tick rather than stopping the autoscaler
+		f, err := ctx.Client.FormationInfo(appname, pstype)
+		if err != nil {
+			printError(err.Error())
+			continue
+		}
+		want := autoscaleQuantity(flagAutoscaleMetric, cur, flagAutoscaleInterval,
+			f.Quantity, flagAutoscaleTarget, flagAutoscaleMin, flagAutoscaleMax)
+
+		desc := cur.describe(flagAutoscaleMetric, flagAutoscaleInterval)
+		if want == f.Quantity {
+			log.Printf("%s: %s, %d dynos; keeping %d.", pstype, desc, f.Quantity, want)
+			continue
+		}
+		log.Printf("%s: %s, %d dynos; scaling to %d.", pstype, desc, f.Quantity, want)
+		if !flagAutoscaleDryRun {
+			_, err := ctx.Client.FormationUpdate(appname, pstype, &heroku.FormationUpdateOpts{Quantity: &want})
+			if err != nil {
+				printError(err.Error())
+			}
+		}
+	}
+}
+
+var (
+	routerDynoRE    = regexp.MustCompile(`\bdyno=([\w-]+)\.\d+\b`)
+	routerServiceRE = regexp.MustCompile(`\bservice=(\d+)ms\b`)
+)
+
+// A routerSample totals the router log lines for one process type over an
+// interval.
+type routerSample struct {
+	requests  int
+	serviceMS int
+}
+
+// add counts line if it's a router line for a dyno of type pstype.
+func (s *routerSample) add(line, pstype string) {
+	m := routerDynoRE.FindStringSubmatch(line)
+	if m == nil || m[1] != pstype {
+		return
+	}
+	s.requests++
+	if m := routerServiceRE.FindStringSubmatch(line); m != nil {
+		ms, _ := strconv.Atoi(m[1])
+		s.serviceMS += ms
+	}
+}
+
+func (s routerSample) meanServiceMS() float64 {
+	if s.requests == 0 {
+		return 0
+	}
+	return float64(s.serviceMS) / float64(s.requests)
+}
+
+func (s routerSample) describe(metric string, interval time.Duration) string {
+	if metric == "latency" {
+		return strconv.FormatFloat(s.meanServiceMS(), 'f', 0, 64) + "ms mean service time over " +
+			strconv.Itoa(s.requests) + " requests"
+	}
+	rps := float64(s.requests) / interval.Seconds()
+	return strconv.FormatFloat(rps, 'f', 1, 64) + " req/s over " + interval.String()
+}
+
+// autoscaleQuantity returns the number of dynos to run for sample,
+// between min and max.
+func autoscaleQuantity(metric string, sample routerSample, interval time.Duration, current int, target float64, min, max int) int {
+	want := current
+	switch metric {
+	case "rps":
+		rps := float64(sample.requests) / interval.Seconds()
+		want = int(math.Ceil(rps / target))
+	case "latency":
+		if sample.requests > 0 {
+			switch mean := sample.meanServiceMS(); {
+			case mean > target:
+				want = current + 1
+			case mean < target/2:
+				want = current - 1
+			}
+		}
+	}
+	if want < min {
+		want = min
+	}
+	if want > max {
+		want = max
+	}
+	return want
+}
@@ -0,0 +1,53 @@
+package hk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRouterSample(t *testing.T) {
+	lines := []string{
+		`2013-10-17T00:17:35.079095+00:00 heroku[router]: at=info method=GET path=/ host=www.heroku.com fwd="1.2.3.4" dyno=web.1 connect=1ms service=6ms status=302 bytes=95`,
+		`2013-10-17T00:17:35.079095+00:00 heroku[router]: at=info method=GET path=/ host=www.heroku.com fwd="1.2.3.4" dyno=web.12 connect=1ms service=14ms status=200 bytes=95`,
+		`2013-10-17T00:17:35.079095+00:00 heroku[router]: at=info method=GET path=/ host=www.heroku.com fwd="1.2.3.4" dyno=webhooks.1 connect=1ms service=500ms status=200 bytes=95`,
+		`2013-10-17T00:17:35.079095+00:00 heroku[router]: at=error code=H12 desc="Request timeout" method=GET path=/ dyno=web.2 connect=1ms service=30000ms status=503`,
+		`2013-10-17T00:17:35.079095+00:00 heroku[web.1]: State changed from starting to up`,
+	}
+	var s routerSample
+	for _, line := range lines {
+		s.add(line, "web")
+	}
+	if s.requests != 3 || s.serviceMS != 30020 {
+		t.Errorf("sample = %+v, want 3 requests taking 30020ms", s)
+	}
+}
+
+func TestAutoscaleQuantity(t *testing.T) {
+	minute := time.Minute
+	tests := []struct {
+		metric   string
+		sample   routerSample
+		current  int
+		target   float64
+		min, max int
+		want     int
+	}{
+		// 6000 requests a minute is 100 req/s: 2 dynos at 50 each
+		{"rps", routerSample{requests: 6000}, 5, 50, 1, 10, 2},
+		{"rps", routerSample{requests: 6001}, 5, 50, 1, 10, 3},
+		{"rps", routerSample{}, 5, 50, 2, 10, 2},
+		{"rps", routerSample{requests: 600000}, 5, 50, 1, 10, 10},
+		{"latency", routerSample{requests: 10, serviceMS: 8000}, 3, 500, 1, 10, 4},
+		{"latency", routerSample{requests: 10, serviceMS: 1000}, 3, 500, 1, 10, 2},
+		{"latency", routerSample{requests: 10, serviceMS: 3000}, 3, 500, 1, 10, 3},
+		{"latency", routerSample{}, 3, 500, 1, 10, 3},
+		{"latency", routerSample{requests: 10, serviceMS: 8000}, 10, 500, 1, 10, 10},
+	}
+	for i, test := range tests {
+		got := autoscaleQuantity(test.metric, test.sample, minute, test.current, test.target, test.min, test.max)
+		if got != test.want {
+			t.Errorf("%d: autoscaleQuantity(%s, %+v, current=%d) = %d, want %d",
+				i, test.metric, test.sample, test.current, got, test.want)
+		}
+	}
+}
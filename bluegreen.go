@@ -0,0 +1,173 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdBlueGreenPromote = &Command{
+	Run:      runBlueGreenPromote,
+	Usage:    "bluegreen-promote [--vars <names>] [--gate <path>] [--gate-expect <status>] [--gate-timeout <duration>] [--gate-interval <duration>] [<blue-app>]",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "promote a green app's slug to its blue pair" + extra,
+	Long: `
+Bluegreen-promote releases the slug currently running on an app
+(the "green" app) to its paired "blue" app. It then copies the
+selected config vars from green to blue, runs a health check
+against blue, and prints instructions for moving green's custom
+domains over to blue. The slug is released before the vars are
+copied, so blue never runs its old slug with green's config.
+
+The blue app may be given as an argument, or configured for each
+green app in the config file (see 'hk help environ'):
+
+    bluegreen.myapp-green.blue = myapp-blue
+    bluegreen.myapp-green.vars = FEATURE_FLAGS,API_VERSION
+    bluegreen.myapp-green.gate = /healthz
+
+Options:
+
+    --vars <names>              comma-separated config vars to copy
+                                to blue
+    --gate <path>               health check path to poll on blue
+                                after release
+    --gate-expect <status>      expected HTTP status code (default 200)
+    --gate-timeout <duration>   give up on the health check after this
+                                long (default 2m)
+    --gate-interval <duration>  time between checks (default 5s)
+
+Example:
+
+    $ hk bluegreen-promote -a myapp-green --gate /healthz
+    Released myapp-green v42 to myapp-blue as v17.
+    Copied FEATURE_FLAGS to myapp-blue.
+    Health check passed: https://myapp-blue.herokuapp.com/healthz returned 200.
+    To switch traffic, move these domains from myapp-green to myapp-blue:
+      hk domain-remove -a myapp-green www.example.com
+      hk domain-add -a myapp-blue www.example.com
+    and point their DNS records at myapp-blue.herokuapp.com.
+`,
+}
+
+var (
+	flagBlueGreenVars         string
+	flagBlueGreenGate         string
+	flagBlueGreenGateExpect   int
+	flagBlueGreenGateTimeout  time.Duration
+	flagBlueGreenGateInterval time.Duration
+)
+
+func init() {
+	cmdBlueGreenPromote.Flag.StringVar(&flagBlueGreenVars, "vars", "", "config vars to copy")
+	cmdBlueGreenPromote.Flag.StringVar(&flagBlueGreenGate, "gate", "", "health check path")
+	cmdBlueGreenPromote.Flag.IntVar(&flagBlueGreenGateExpect, "gate-expect", 200, "expected HTTP status code")
+	cmdBlueGreenPromote.Flag.DurationVar(&flagBlueGreenGateTimeout, "gate-timeout", 2*time.Minute, "time to wait for the health check to pass")
+	cmdBlueGreenPromote.Flag.DurationVar(&flagBlueGreenGateInterval, "gate-interval", 5*time.Second, "time between checks")
+}
+
+func runBlueGreenPromote(ctx *Context, args []string) {
+	green := ctx.MustApp()
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	blue := configValue("bluegreen." + green + ".blue")
+	if len(args) == 1 {
+		blue = args[0]
+	}
+	if blue == "" {
+		printFatal("no blue app for %s. Specify one or set bluegreen.%s.blue in %s.", green, green, configPath())
+	}
+	vars := flagBlueGreenVars
+	if vars == "" {
+		vars = configValue("bluegreen." + green + ".vars")
+	}
+	gate := flagBlueGreenGate
+	if gate == "" {
+		gate = configValue("bluegreen." + green + ".gate")
+	}
+	mustNotBeDeployLocked(blue)
+
+	rel, err := latestRelease(green)
+	must(err)
+	if rel.Slug == nil {
+		printFatal("%s v%d has no slug to promote.", green, rel.Version)
+	}
+
+	// The API can't release a slug and config together, so the slug goes
+	// first: the vars copied are for green's code, not blue's old code.
+	desc := fmt.Sprintf("Promote %s v%d", green, rel.Version)
+	newrel, err := ctx.Client.ReleaseCreate(blue, rel.Slug.Id, &heroku.ReleaseCreateOpts{Description: &desc})
+	must(err)
+	log.Printf("Released %s v%d to %s as v%d.", green, rel.Version, blue, newrel.Version)
+
+	if vars != "" {
+		names := strings.Split(vars, ",")
+		must(copyConfigVars(green, blue, names))
+		log.Printf("Copied %s to %s.", strings.Join(names, ", "), blue)
+	}
+
+	if gate != "" {
+		u, err := gateURL(blue, gate)
+		must(err)
+		if err := healthGate(u, flagBlueGreenGateExpect, flagBlueGreenGateTimeout, flagBlueGreenGateInterval); err != nil {
+			printFatal(err.Error())
+		}
+		log.Printf("Health check passed: %s returned %d.", u, flagBlueGreenGateExpect)
+	}
+
+	domains, err := ctx.Client.DomainList(green, &heroku.ListRange{Field: "hostname", Max: 1000})
+	must(err)
+	blueApp, err := ctx.Client.AppInfo(blue)
+	must(err)
+	u, err := url.Parse(blueApp.WebURL)
+	must(err)
+	printDomainSwitch(ctx.Stdout, green, blue, u.Host, domains)
+}
+
+// copyConfigVars copies the named config vars from one app to another.
+// Names missing from the source app are unset on the destination.
+func copyConfigVars(from, to string, names []string) error {
+	config, err := client.ConfigVarInfo(from)
+	if err != nil {
+		return err
+	}
+	update := make(map[string]*string, len(names))
+	for _, name := range names {
+		if val, ok := config[name]; ok {
+			update[name] = &val
+		} else {
+			update[name] = nil
+		}
+	}
+	_, err = client.ConfigVarUpdate(to, update)
+	return err
+}
+
+// printDomainSwitch prints how to move the custom domains of app from to
+// app to, whose web URL is at host.
+func printDomainSwitch(w io.Writer, from, to, host string, domains []heroku.Domain) {
+	var custom []string
+	for _, d := range domains {
+		if !strings.HasSuffix(d.Hostname, ".herokuapp.com") {
+			custom = append(custom, d.Hostname)
+		}
+	}
+	if len(custom) == 0 {
+		fmt.Fprintf(w, "%s has no custom domains. Point your DNS records at %s.\n", from, host)
+		return
+	}
+	fmt.Fprintf(w, "To switch traffic, move these domains from %s to %s:\n", from, to)
+	for _, d := range custom {
+		fmt.Fprintf(w, "  hk domain-remove -a %s %s\n", from, d)
+		fmt.Fprintf(w, "  hk domain-add -a %s %s\n", to, d)
+	}
+	fmt.Fprintf(w, "and point their DNS records at %s.\n", host)
+}
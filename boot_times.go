@@ -0,0 +1,177 @@
+package hk
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdBootTimes = &Command{
+	Run:      runBootTimes,
+	Usage:    "boot-times [-n <lines>] [--slow <duration>]",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "show how long dynos take to boot" + extra,
+	Long: `
+Boot-times measures how long recent dyno starts took, from the
+"Starting process" log line to the dyno's state changing to up,
+and shows the number of boots and the median and slowest boot time
+for each process type.
+
+Web dynos that don't bind to their port within 60 seconds fail with
+an R10 boot timeout. Boots slower than --slow are listed, so slow
+boots can be fixed before they start failing.
+
+Options:
+
+    -n <lines>         number of log lines to look for boots in
+                       (default 1500)
+    --slow <duration>  list boots that took longer than this
+                       (default 45s)
+
+Example:
+
+    $ hk boot-times
+    type    boots  median  max
+    web     6      14s     52s
+    worker  2      8s      9s
+
+    Slow boots (over 45s; web dynos fail with R10 after 60s):
+    web.2  52s  2014-01-13T21:20:57.079095+00:00
+`,
+}
+
+var (
+	flagBootTimesLines int
+	flagBootTimesSlow  time.Duration
+)
+
+func init() {
+	cmdBootTimes.Flag.IntVar(&flagBootTimesLines, "n", 1500, "number of log lines")
+	cmdBootTimes.Flag.DurationVar(&flagBootTimesSlow, "slow", 45*time.Second, "slow boot threshold")
+}
+
+func runBootTimes(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	source := "heroku"
+	opts := heroku.LogSessionCreateOpts{Lines: &flagBootTimesLines, Source: &source}
+	body := openLog(appname, &opts)
+	defer body.Close()
+	boots, err := findBoots(bufio.NewScanner(body))
+	must(err)
+	if len(boots) == 0 {
+		log.Printf("No dyno boots in the last %d log lines.", flagBootTimesLines)
+		return
+	}
+
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	listRec(w, "type", "boots", "median", "max")
+	for _, s := range summarizeBoots(boots) {
+		listRec(w, s.Type, s.Count, s.Median, s.Max)
+	}
+	w.Flush()
+
+	var slow []dynoBoot
+	for _, b := range boots {
+		if b.Duration > flagBootTimesSlow {
+			slow = append(slow, b)
+		}
+	}
+	if len(slow) > 0 {
+		fmt.Fprintf(ctx.Stdout, "\nSlow boots (over %s; web dynos fail with R10 after 60s):\n", flagBootTimesSlow)
+		w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+		for _, b := range slow {
+			listRec(w, b.Dyno, b.Duration, b.Up.Format(time.RFC3339Nano))
+		}
+		w.Flush()
+	}
+}
+
+// A dynoBoot is a dyno start found in the log.
+type dynoBoot struct {
+	Dyno     string
+	Type     string
+	Up       time.Time
+	Duration time.Duration
+}
+
+// findBoots returns the boots in the log lines read by s: each "Starting
+// process" line for a dyno followed by its state changing to up.
+func findBoots(s *bufio.Scanner) ([]dynoBoot, error) {
+	starting := make(map[string]time.Time)
+	var boots []dynoBoot
+	for s.Scan() {
+		line := s.Text()
+		m := logLineRE.FindStringSubmatch(line)
+		if m == nil || m[1] != "heroku" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, strings.Fields(line)[0])
+		if err != nil {
+			continue
+		}
+		dyno := m[2]
+		msg := line[len(m[0]):]
+		switch {
+		case strings.Contains(msg, "Starting process"):
+			starting[dyno] = t
+		case strings.Contains(msg, "State changed from starting to up"):
+			start, ok := starting[dyno]
+			if !ok {
+				continue
+			}
+			delete(starting, dyno)
+			typ := dyno
+			if i := strings.Index(dyno, "."); i >= 0 {
+				typ = dyno[:i]
+			}
+			boots = append(boots, dynoBoot{Dyno: dyno, Type: typ, Up: t, Duration: t.Sub(start).Round(time.Second)})
+		}
+	}
+	return boots, s.Err()
+}
+
+// A bootSummary is the boot times of one process type.
+type bootSummary struct {
+	Type   string
+	Count  int
+	Median time.Duration
+	Max    time.Duration
+}
+
+// summarizeBoots returns the boot times of each process type in boots,
+// sorted by type.
+func summarizeBoots(boots []dynoBoot) []bootSummary {
+	byType := make(map[string][]time.Duration)
+	for _, b := range boots {
+		byType[b.Type] = append(byType[b.Type], b.Duration)
+	}
+	var types []string
+	for t := range byType {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	var sums []bootSummary
+	for _, t := range types {
+		ds := byType[t]
+		sort.Sort(durations(ds))
+		sums = append(sums, bootSummary{Type: t, Count: len(ds), Median: ds[len(ds)/2], Max: ds[len(ds)-1]})
+	}
+	return sums
+}
+
+type durations []time.Duration
+
+func (a durations) Len() int           { return len(a) }
+func (a durations) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a durations) Less(i, j int) bool { return a[i] < a[j] }
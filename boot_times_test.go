@@ -0,0 +1,43 @@
+package hk
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindBoots(t *testing.T) {
+	log := strings.Join([]string{
+		"2014-01-13T21:20:00.000000+00:00 heroku[web.1]: Starting process with command `bundle exec puma`",
+		"2014-01-13T21:20:01.000000+00:00 heroku[web.2]: Starting process with command `bundle exec puma`",
+		"2014-01-13T21:20:02.000000+00:00 heroku[worker.1]: Starting process with command `bundle exec sidekiq`",
+		"2014-01-13T21:20:10.400000+00:00 heroku[worker.1]: State changed from starting to up",
+		"2014-01-13T21:20:12.000000+00:00 heroku[web.1]: State changed from starting to up",
+		"2014-01-13T21:20:53.000000+00:00 heroku[web.2]: State changed from starting to up",
+		"2014-01-13T21:21:00.000000+00:00 heroku[web.3]: State changed from starting to up",
+		"2014-01-13T21:21:01.000000+00:00 app[web.1]: Starting process isn't from heroku",
+	}, "\n")
+	boots, err := findBoots(bufio.NewScanner(strings.NewReader(log)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, b := range boots {
+		got = append(got, b.Dyno+" "+b.Duration.String())
+	}
+	want := []string{"worker.1 8s", "web.1 12s", "web.2 52s"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findBoots => %v, want %v", got, want)
+	}
+
+	sums := summarizeBoots(boots)
+	wantSums := []bootSummary{
+		{"web", 2, 52 * time.Second, 52 * time.Second},
+		{"worker", 1, 8 * time.Second, 8 * time.Second},
+	}
+	if !reflect.DeepEqual(sums, wantSums) {
+		t.Errorf("summarizeBoots => %+v, want %+v", sums, wantSums)
+	}
+}
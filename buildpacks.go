@@ -0,0 +1,201 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+var cmdBuildpacks = &Command{
+	Run:      runBuildpacks,
+	Usage:    "buildpacks",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "list buildpacks" + extra,
+	Long: `
+Lists the app's buildpacks, in the order they run. The last one
+decides the process types of the app.
+
+Example:
+
+    $ hk buildpacks
+    1  https://github.com/heroku/heroku-buildpack-nodejs
+    2  https://github.com/heroku/heroku-buildpack-ruby
+`,
+}
+
+func runBuildpacks(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	urls, err := listBuildpacks(ctx.MustApp())
+	must(err)
+	for i, u := range urls {
+		fmt.Fprintf(ctx.Stdout, "%d  %s\n", i+1, u)
+	}
+}
+
+var cmdBuildpackAdd = &Command{
+	Run:      runBuildpackAdd,
+	Usage:    "buildpack-add [-i <index>] <url>",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "add a buildpack" + extra,
+	Long: `
+Adds a buildpack to the app. It's added last, unless -i gives the
+position to insert it at, counting from 1. The buildpack is used
+from the next build on.
+
+Options:
+
+    -i <index>  position to insert the buildpack at
+
+Examples:
+
+    $ hk buildpack-add https://github.com/heroku/heroku-buildpack-ruby
+    Added https://github.com/heroku/heroku-buildpack-ruby to myapp as buildpack 2.
+
+    $ hk buildpack-add -i 1 heroku/nodejs
+    Added heroku/nodejs to myapp as buildpack 1.
+`,
+}
+
+var flagBuildpackIndex int
+
+func init() {
+	cmdBuildpackAdd.Flag.IntVar(&flagBuildpackIndex, "i", 0, "position to insert at")
+}
+
+func runBuildpackAdd(ctx *Context, args []string) {
+	if len(args) != 1 || flagBuildpackIndex < 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	urls, err := listBuildpacks(appname)
+	must(err)
+	if stringsIndex(urls, args[0]) != -1 {
+		printFatal("%s already uses %s.", appname, args[0])
+	}
+	urls, n := insertBuildpack(urls, args[0], flagBuildpackIndex)
+	must(setBuildpacks(appname, urls))
+	log.Printf("Added %s to %s as buildpack %d.", args[0], appname, n)
+}
+
+var cmdBuildpackRemove = &Command{
+	Run:      runBuildpackRemove,
+	Usage:    "buildpack-remove (<url> | <index>)",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "remove a buildpack" + extra,
+	Long: `
+Removes a buildpack from the app, given by its URL or its position
+in 'hk buildpacks'.
+
+Examples:
+
+    $ hk buildpack-remove heroku/nodejs
+    Removed heroku/nodejs from myapp.
+
+    $ hk buildpack-remove 2
+    Removed https://github.com/heroku/heroku-buildpack-ruby from myapp.
+`,
+}
+
+func runBuildpackRemove(ctx *Context, args []string) {
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	urls, err := listBuildpacks(appname)
+	must(err)
+	i := stringsIndex(urls, args[0])
+	if n, err := strconv.Atoi(args[0]); err == nil && i == -1 {
+		if n < 1 || n > len(urls) {
+			printFatal("%s has %d buildpacks; there is no buildpack %d.", appname, len(urls), n)
+		}
+		i = n - 1
+	}
+	if i == -1 {
+		printFatal("%s doesn't use %s.", appname, args[0])
+	}
+	removed := urls[i]
+	urls = append(urls[:i:i], urls[i+1:]...)
+	must(setBuildpacks(appname, urls))
+	log.Printf("Removed %s from %s.", removed, appname)
+}
+
+var cmdBuildpackClear = &Command{
+	Run:      runBuildpackClear,
+	Usage:    "buildpack-clear",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "remove all buildpacks" + extra,
+	Long: `
+Removes all of the app's buildpacks, so the next build detects
+which to use.
+
+Example:
+
+    $ hk buildpack-clear
+    Cleared buildpacks of myapp.
+`,
+}
+
+func runBuildpackClear(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	must(setBuildpacks(appname, nil))
+	log.Printf("Cleared buildpacks of %s.", appname)
+}
+
+// listBuildpacks returns the URLs of an app's buildpacks, in order.
+func listBuildpacks(appname string) ([]string, error) {
+	var installations []struct {
+		Ordinal   int `json:"ordinal"`
+		Buildpack struct {
+			URL string `json:"url"`
+		} `json:"buildpack"`
+	}
+	if err := client.Get(&installations, "/apps/"+appname+"/buildpack-installations"); err != nil {
+		return nil, err
+	}
+	urls := make([]string, len(installations))
+	for i, b := range installations {
+		urls[i] = b.Buildpack.URL
+	}
+	return urls, nil
+}
+
+// insertBuildpack inserts url into urls at position index, counting from
+// 1, or appends it if index is 0 or past the end. It returns the new list
+// and the position url ended up at.
+func insertBuildpack(urls []string, url string, index int) ([]string, int) {
+	if index < 1 || index > len(urls) {
+		return append(urls, url), len(urls) + 1
+	}
+	i := index - 1
+	out := append(append(append([]string{}, urls[:i]...), url), urls[i:]...)
+	return out, index
+}
+
+// setBuildpacks replaces an app's buildpacks with urls, in order.
+func setBuildpacks(appname string, urls []string) error {
+	type update struct {
+		Buildpack string `json:"buildpack"`
+	}
+	var body struct {
+		Updates []update `json:"updates"`
+	}
+	body.Updates = []update{}
+	for _, u := range urls {
+		body.Updates = append(body.Updates, update{strings.TrimSpace(u)})
+	}
+	return client.Put(nil, "/apps/"+appname+"/buildpack-installations", body)
+}
@@ -0,0 +1,28 @@
+package hk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInsertBuildpack(t *testing.T) {
+	tests := []struct {
+		urls  []string
+		index int
+		want  []string
+		n     int
+	}{
+		{nil, 0, []string{"x"}, 1},
+		{[]string{"a", "b"}, 0, []string{"a", "b", "x"}, 3},
+		{[]string{"a", "b"}, 1, []string{"x", "a", "b"}, 1},
+		{[]string{"a", "b"}, 2, []string{"a", "x", "b"}, 2},
+		{[]string{"a", "b"}, 9, []string{"a", "b", "x"}, 3},
+	}
+	for _, test := range tests {
+		orig := append([]string(nil), test.urls...)
+		got, n := insertBuildpack(test.urls, "x", test.index)
+		if !reflect.DeepEqual(got, test.want) || n != test.n {
+			t.Errorf("insertBuildpack(%q, %d) = %q, %d, want %q, %d", orig, test.index, got, n, test.want, test.n)
+		}
+	}
+}
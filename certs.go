@@ -0,0 +1,522 @@
+package hk
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var cmdCerts = &Command{
+	Run:      runCerts,
+	Usage:    "certs [-j] [--check-expiry [--warn <duration>]]",
+	NeedsApp: true,
+	Category: "ssl",
+	Short:    "list SSL certificates" + extra,
+	Long: `
+Lists the app's SNI SSL endpoints. Shows the endpoint name, the
+domains its certificate covers, and when the certificate expires.
+
+With --check-expiry, certs also connects to each of the app's
+custom domains to see the certificate actually served, which
+covers certificates managed by ACM. It exits with status 1 if any
+certificate expires within the --warn window, so it can be run as a
+daily job. Domains that can't be reached are warned about but don't
+fail the check.
+
+Options:
+
+    -j, --json           print SSL endpoints as JSON
+    --check-expiry       check uploaded and served certificates
+    --warn <duration>    how soon an expiry fails the check, e.g.
+                         21d or 72h (default 21d)
+
+Examples:
+
+    $ hk certs
+    tokyo-1050  www.example.com, example.com  Mar  1 2016  in 212 days
+    osaka-7351  *.example.org                 Jul 10 2015  expired
+
+    $ hk certs --check-expiry --warn 30d
+    tokyo-1050       www.example.com, example.com  Mar  1 2016  in 212 days  ok
+    api.example.com  api.example.com               Jul 20 2015  in 19 days   EXPIRING
+`,
+}
+
+func init() {
+	registerList(cmdCerts, sniEndpoint{})
+}
+
+var (
+	flagCertsCheckExpiry bool
+	flagCertsWarn        string
+)
+
+func init() {
+	cmdCerts.Flag.BoolVar(&flagCertsCheckExpiry, "check-expiry", false, "check certificate expiry")
+	cmdCerts.Flag.StringVar(&flagCertsWarn, "warn", "21d", "expiry warning window")
+}
+
+func runCerts(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	if flagCertsCheckExpiry {
+		window, err := parseLongDuration(flagCertsWarn)
+		if err != nil {
+			printFatal("invalid --warn: %s", err)
+		}
+		checkCertExpiry(ctx.Stdout, ctx.MustApp(), window)
+		return
+	}
+	endpoints, err := listSNIEndpoints(ctx.MustApp())
+	must(err)
+	if printList(ctx.Stdout, endpoints) {
+		return
+	}
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	now := time.Now()
+	for _, e := range endpoints {
+		leaf, err := e.leaf()
+		if err != nil {
+			listRec(w, e.Name, "(unreadable certificate)", "", "")
+			continue
+		}
+		listRec(w,
+			e.Name,
+			strings.Join(certDomains(leaf), ", "),
+			leaf.NotAfter.Local().Format("Jan _2 2006"),
+			certExpiry(leaf, now),
+		)
+	}
+}
+
+// checkCertExpiry lists the app's uploaded certificates and those served
+// on its custom domains, and exits 1 if any expires within window.
+func checkCertExpiry(out io.Writer, appname string, window time.Duration) {
+	endpoints, err := listSNIEndpoints(appname)
+	must(err)
+	var domains []apiDomain
+	must(client.Get(&domains, "/apps/"+appname+"/domains"))
+
+	type checked struct {
+		source string
+		cert   *x509.Certificate
+	}
+	var certs []checked
+	for _, e := range endpoints {
+		leaf, err := e.leaf()
+		if err != nil {
+			printWarning("%s: %s", e.Name, err)
+			continue
+		}
+		certs = append(certs, checked{e.Name, leaf})
+	}
+	for _, d := range domains {
+		if d.Kind == "heroku" || strings.HasPrefix(d.Hostname, "*.") {
+			continue
+		}
+		c, err := probeCert(d.Hostname)
+		if err != nil {
+			printWarning("%s: %s", d.Hostname, err)
+			continue
+		}
+		certs = append(certs, checked{d.Hostname, c})
+	}
+
+	now := time.Now()
+	expiring := 0
+	w := tabwriter.NewWriter(out, 1, 2, 2, ' ', 0)
+	for _, c := range certs {
+		status := "ok"
+		if certExpiresWithin(c.cert, window, now) {
+			status = "EXPIRING"
+			expiring++
+		}
+		listRec(w,
+			c.source,
+			strings.Join(certDomains(c.cert), ", "),
+			c.cert.NotAfter.Local().Format("Jan _2 2006"),
+			certExpiry(c.cert, now),
+			status,
+		)
+	}
+	w.Flush()
+	if expiring > 0 {
+		exit(1)
+	}
+}
+
+// probeCert returns the certificate host serves over HTTPS. The
+// certificate isn't verified, so expired ones are returned too.
+func probeCert(host string) (*x509.Certificate, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", host+":443", &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate served")
+	}
+	return certs[0], nil
+}
+
+// certExpiresWithin reports whether c expires within window of now.
+func certExpiresWithin(c *x509.Certificate, window time.Duration, now time.Time) bool {
+	return c.NotAfter.Sub(now) <= window
+}
+
+var cmdCertInfo = &Command{
+	Run:      runCertInfo,
+	Usage:    "cert-info <name>",
+	NeedsApp: true,
+	Category: "ssl",
+	Short:    "show SSL certificate details" + extra,
+	Long: `
+Shows an SNI SSL endpoint's certificate: its domains, subject,
+issuer, validity dates, and the rest of its chain.
+
+Example:
+
+    $ hk cert-info tokyo-1050
+    Name:     tokyo-1050
+    CNAME:    tokyo-1050.herokussl.com
+    Domains:  www.example.com, example.com
+    Subject:  CN=www.example.com
+    Issuer:   CN=Example Intermediate CA,O=Example
+    Starts:   Mar  1 2015
+    Expires:  Mar  1 2016 (in 212 days)
+    Chain:    CN=Example Intermediate CA,O=Example
+              CN=Example Root CA,O=Example
+`,
+}
+
+func runCertInfo(ctx *Context, args []string) {
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	e, err := findSNIEndpoint(ctx.MustApp(), args[0])
+	must(err)
+	certs, err := parseCerts([]byte(e.CertificateChain))
+	if err != nil {
+		printFatal("%s: %s", e.Name, err)
+	}
+	leaf := certs[0]
+	fmt.Fprintf(ctx.Stdout, "Name:     %s\n", e.Name)
+	fmt.Fprintf(ctx.Stdout, "CNAME:    %s\n", e.CName)
+	fmt.Fprintf(ctx.Stdout, "Domains:  %s\n", strings.Join(certDomains(leaf), ", "))
+	fmt.Fprintf(ctx.Stdout, "Subject:  %s\n", leaf.Subject)
+	fmt.Fprintf(ctx.Stdout, "Issuer:   %s\n", leaf.Issuer)
+	fmt.Fprintf(ctx.Stdout, "Starts:   %s\n", leaf.NotBefore.Local().Format("Jan _2 2006"))
+	fmt.Fprintf(ctx.Stdout, "Expires:  %s (%s)\n", leaf.NotAfter.Local().Format("Jan _2 2006"), certExpiry(leaf, time.Now()))
+	for i, c := range certs[1:] {
+		label := "Chain:"
+		if i > 0 {
+			label = ""
+		}
+		fmt.Fprintf(ctx.Stdout, "%-9s %s\n", label, c.Subject)
+	}
+}
+
+var cmdCertAdd = &Command{
+	Run:      runCertAdd,
+	Usage:    "cert-add [--chain <file>] <crt> <key>",
+	NeedsApp: true,
+	Category: "ssl",
+	Short:    "add an SSL certificate" + extra,
+	Long: `
+Adds an SNI SSL endpoint for a certificate and its private key,
+both PEM encoded.
+
+The intermediate certificates can be in the certificate file, or in
+a separate file given with --chain. hk puts the chain in order,
+starting from the certificate for the app's domains, drops
+certificates that aren't part of it, and checks that the key
+matches.
+
+Options:
+
+    --chain <file>  PEM file of intermediate certificates
+
+Example:
+
+    $ hk cert-add --chain intermediate.pem server.crt server.key
+    Added tokyo-1050 to myapp for www.example.com, example.com.
+    Point your domains' DNS at tokyo-1050.herokussl.com.
+`,
+}
+
+var flagCertChain string
+
+func init() {
+	cmdCertAdd.Flag.StringVar(&flagCertChain, "chain", "", "intermediate certificates file")
+	cmdCertUpdate.Flag.StringVar(&flagCertChain, "chain", "", "intermediate certificates file")
+}
+
+func runCertAdd(ctx *Context, args []string) {
+	if len(args) != 2 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	body, leaf := mustReadCertFiles(args[0], args[1], flagCertChain)
+	var e sniEndpoint
+	must(ctx.Client.Post(&e, "/apps/"+appname+"/sni-endpoints", body))
+	log.Printf("Added %s to %s for %s.", e.Name, appname, strings.Join(certDomains(leaf), ", "))
+	if e.CName != "" {
+		log.Printf("Point your domains' DNS at %s.", e.CName)
+	}
+}
+
+var cmdCertUpdate = &Command{
+	Run:      runCertUpdate,
+	Usage:    "cert-update [--chain <file>] <name> <crt> <key>",
+	NeedsApp: true,
+	Category: "ssl",
+	Short:    "replace an SSL certificate" + extra,
+	Long: `
+Replaces the certificate and private key of an SNI SSL endpoint,
+e.g. to renew it. The files are checked as for cert-add.
+
+Options:
+
+    --chain <file>  PEM file of intermediate certificates
+
+Example:
+
+    $ hk cert-update tokyo-1050 server.crt server.key
+    Updated tokyo-1050 on myapp; it now expires Mar  1 2017.
+`,
+}
+
+func runCertUpdate(ctx *Context, args []string) {
+	if len(args) != 3 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	body, leaf := mustReadCertFiles(args[1], args[2], flagCertChain)
+	var e sniEndpoint
+	must(ctx.Client.Patch(&e, "/apps/"+appname+"/sni-endpoints/"+args[0], body))
+	log.Printf("Updated %s on %s; it now expires %s.", args[0], appname, leaf.NotAfter.Local().Format("Jan _2 2006"))
+}
+
+var cmdCertRemove = &Command{
+	Run:      runCertRemove,
+	Usage:    "cert-remove <name>",
+	NeedsApp: true,
+	Category: "ssl",
+	Short:    "remove an SSL certificate" + extra,
+	Long: `
+Removes an SNI SSL endpoint. Requests to the app's custom domains
+over HTTPS fail once it's gone.
+
+Example:
+
+    $ hk cert-remove tokyo-1050
+    Removed tokyo-1050 from myapp.
+`,
+}
+
+func runCertRemove(ctx *Context, args []string) {
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	must(ctx.Client.Delete("/apps/" + appname + "/sni-endpoints/" + args[0]))
+	log.Printf("Removed %s from %s.", args[0], appname)
+}
+
+// An sniEndpoint is an SNI SSL endpoint of an app.
+type sniEndpoint struct {
+	Id               string    `json:"id"`
+	Name             string    `json:"name"`
+	CName            string    `json:"cname"`
+	CertificateChain string    `json:"certificate_chain"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// leaf returns the first certificate of e's chain.
+func (e *sniEndpoint) leaf() (*x509.Certificate, error) {
+	certs, err := parseCerts([]byte(e.CertificateChain))
+	if err != nil {
+		return nil, err
+	}
+	return certs[0], nil
+}
+
+func listSNIEndpoints(appname string) ([]sniEndpoint, error) {
+	var endpoints []sniEndpoint
+	if err := client.Get(&endpoints, "/apps/"+appname+"/sni-endpoints"); err != nil {
+		return nil, err
+	}
+	sort.Sort(sniEndpointsByName(endpoints))
+	return endpoints, nil
+}
+
+func findSNIEndpoint(appname, name string) (*sniEndpoint, error) {
+	var e sniEndpoint
+	if err := client.Get(&e, "/apps/"+appname+"/sni-endpoints/"+name); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+type sniEndpointsByName []sniEndpoint
+
+func (a sniEndpointsByName) Len() int           { return len(a) }
+func (a sniEndpointsByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a sniEndpointsByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+// sniEndpointBody is the request body to create or update an endpoint.
+type sniEndpointBody struct {
+	CertificateChain string `json:"certificate_chain"`
+	PrivateKey       string `json:"private_key"`
+}
+
+// mustReadCertFiles reads a certificate, its private key, and optionally
+// a file of intermediate certificates, and returns the request body for
+// them along with the leaf certificate.
+func mustReadCertFiles(crtFile, keyFile, chainFile string) (*sniEndpointBody, *x509.Certificate) {
+	crt, err := ioutil.ReadFile(crtFile)
+	if err != nil {
+		printFatal(err.Error())
+	}
+	key, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		printFatal(err.Error())
+	}
+	if chainFile != "" {
+		chain, err := ioutil.ReadFile(chainFile)
+		if err != nil {
+			printFatal(err.Error())
+		}
+		crt = append(append(crt, '\n'), chain...)
+	}
+	chain, err := resolveCertChain(crt)
+	if err != nil {
+		printFatal("%s: %s", crtFile, err)
+	}
+	pemChain := encodeCerts(chain)
+	if _, err := tls.X509KeyPair(pemChain, key); err != nil {
+		printFatal("%s doesn't match %s: %s", keyFile, crtFile, err)
+	}
+	return &sniEndpointBody{string(pemChain), string(key)}, chain[0]
+}
+
+// parseCerts returns the certificates in PEM data, in order. Blocks other
+// than certificates are ignored.
+func parseCerts(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM certificates found")
+	}
+	return certs, nil
+}
+
+// resolveCertChain puts the certificates in PEM data in chain order: the
+// leaf, which issued no other certificate, then its issuer, and so on.
+// Certificates not in the leaf's chain are dropped. If more than one
+// certificate could be the leaf, the first one that isn't a CA is used.
+func resolveCertChain(data []byte) ([]*x509.Certificate, error) {
+	certs, err := parseCerts(data)
+	if err != nil {
+		return nil, err
+	}
+	issuedOther := func(c *x509.Certificate) bool {
+		for _, o := range certs {
+			if o != c && bytes.Equal(o.RawIssuer, c.RawSubject) && o.CheckSignatureFrom(c) == nil {
+				return true
+			}
+		}
+		return false
+	}
+	var leaf *x509.Certificate
+	for _, c := range certs {
+		if !issuedOther(c) && (leaf == nil || leaf.IsCA && !c.IsCA) {
+			leaf = c
+		}
+	}
+	if leaf == nil {
+		return nil, errors.New("certificates form a loop")
+	}
+	chain := []*x509.Certificate{leaf}
+	for c := leaf; !bytes.Equal(c.RawIssuer, c.RawSubject); {
+		var issuer *x509.Certificate
+		for _, o := range certs {
+			if bytes.Equal(c.RawIssuer, o.RawSubject) && c.CheckSignatureFrom(o) == nil {
+				issuer = o
+				break
+			}
+		}
+		if issuer == nil || len(chain) == len(certs) {
+			break // the rest of the chain is trusted by clients
+		}
+		chain = append(chain, issuer)
+		c = issuer
+	}
+	return chain, nil
+}
+
+func encodeCerts(certs []*x509.Certificate) []byte {
+	var buf bytes.Buffer
+	for _, c := range certs {
+		pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})
+	}
+	return buf.Bytes()
+}
+
+// certDomains returns the domains c is valid for.
+func certDomains(c *x509.Certificate) []string {
+	if len(c.DNSNames) > 0 {
+		return c.DNSNames
+	}
+	return []string{c.Subject.CommonName}
+}
+
+// certExpiry describes how long until c expires, as of now.
+func certExpiry(c *x509.Certificate, now time.Time) string {
+	d := c.NotAfter.Sub(now)
+	switch {
+	case d <= 0:
+		return "expired"
+	case d < 48*time.Hour:
+		return "in " + strings.TrimSpace(prettyDuration{d}.String())
+	}
+	return fmt.Sprintf("in %d days", int(d/(24*time.Hour)))
+}
@@ -0,0 +1,120 @@
+package hk
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// newTestCert makes a certificate for name, signed by parent (or self
+// signed if parent is nil).
+func newTestCert(t *testing.T, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(90 * 24 * time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	if !isCA {
+		tmpl.DNSNames = []string{name}
+	}
+	if parent == nil {
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, key
+}
+
+func TestResolveCertChain(t *testing.T) {
+	root, rootKey := newTestCert(t, "Test Root CA", true, nil, nil)
+	inter, interKey := newTestCert(t, "Test Intermediate CA", true, root, rootKey)
+	leaf, _ := newTestCert(t, "www.example.com", false, inter, interKey)
+	other, _ := newTestCert(t, "Unrelated CA", true, nil, nil)
+
+	// out of order, with a certificate from another chain
+	data := encodeCerts([]*x509.Certificate{root, other, leaf, inter})
+	chain, err := resolveCertChain(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range chain {
+		got = append(got, c.Subject.CommonName)
+	}
+	want := []string{"www.example.com", "Test Intermediate CA", "Test Root CA"}
+	if len(got) != len(want) {
+		t.Fatalf("chain = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("chain = %q, want %q", got, want)
+			break
+		}
+	}
+
+	// a lone leaf is its own chain
+	chain, err = resolveCertChain(encodeCerts([]*x509.Certificate{leaf}))
+	if err != nil || len(chain) != 1 || chain[0].Subject.CommonName != "www.example.com" {
+		t.Errorf("lone leaf chain = %v, %v", chain, err)
+	}
+
+	if _, err := resolveCertChain([]byte("not a certificate")); err == nil {
+		t.Error("expected error for data without certificates")
+	}
+}
+
+func TestCertExpiry(t *testing.T) {
+	now := time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		notAfter time.Time
+		want     string
+	}{
+		{now.Add(-time.Hour), "expired"},
+		{now.Add(10 * time.Hour), "in 10h"},
+		{now.Add(212*24*time.Hour + time.Hour), "in 212 days"},
+	}
+	for _, test := range tests {
+		c := &x509.Certificate{NotAfter: test.notAfter}
+		if got := certExpiry(c, now); got != test.want {
+			t.Errorf("certExpiry(%s) = %q, want %q", test.notAfter, got, test.want)
+		}
+	}
+}
+
+func TestCertExpiresWithin(t *testing.T) {
+	now := time.Date(2015, 7, 1, 0, 0, 0, 0, time.UTC)
+	window := 21 * 24 * time.Hour
+	tests := []struct {
+		notAfter time.Time
+		want     bool
+	}{
+		{now.Add(-time.Hour), true},
+		{now.Add(20 * 24 * time.Hour), true},
+		{now.Add(22 * 24 * time.Hour), false},
+	}
+	for _, test := range tests {
+		c := &x509.Certificate{NotAfter: test.notAfter}
+		if got := certExpiresWithin(c, window, now); got != test.want {
+			t.Errorf("certExpiresWithin(%s) = %v, want %v", test.notAfter, got, test.want)
+		}
+	}
+}
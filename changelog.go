@@ -0,0 +1,123 @@
+package hk
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdChangelog = &Command{
+	Run:      runChangelog,
+	Usage:    "changelog [--markdown] <version>..[<version>]",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "show commits deployed between releases" + extra,
+	Long: `
+Changelog finds the git commits deployed by two releases and lists
+the commits between them, using the git repo in the current
+directory. If the second version is omitted, the app's latest
+release is used.
+
+Options:
+
+    --markdown  print the changelog as markdown, e.g. for release notes
+
+Examples:
+
+    $ hk changelog v120..v125
+    0fda0ae  John Doe  Fix login redirect
+    3ae20c2  Bob Test  Add signup form
+
+    $ hk changelog --markdown v120..
+    ## myapp v120..v125
+
+    - Fix login redirect (0fda0ae, John Doe)
+    - Add signup form (3ae20c2, Bob Test)
+`,
+}
+
+var flagChangelogMarkdown bool
+
+func init() {
+	cmdChangelog.Flag.BoolVar(&flagChangelogMarkdown, "markdown", false, "print markdown")
+}
+
+func runChangelog(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 || !strings.Contains(args[0], "..") {
+		ctx.printUsage()
+		exit(2)
+	}
+	versions := strings.SplitN(args[0], "..", 2)
+	from, err := ctx.Client.ReleaseInfo(appname, strings.TrimPrefix(versions[0], "v"))
+	must(err)
+	var to *heroku.Release
+	if versions[1] == "" {
+		to, err = latestRelease(appname)
+	} else {
+		to, err = ctx.Client.ReleaseInfo(appname, strings.TrimPrefix(versions[1], "v"))
+	}
+	must(err)
+
+	fromCommit, err := releaseCommit(appname, from)
+	must(err)
+	toCommit, err := releaseCommit(appname, to)
+	must(err)
+
+	out, err := exec.Command("git", "log", "--format=%h%x09%an%x09%s", fromCommit+".."+toCommit).Output()
+	if err != nil {
+		printFatal("git log %s..%s failed. Are the commits in the local git repo?", fromCommit, toCommit)
+	}
+	entries := parseChangelog(out)
+
+	if flagChangelogMarkdown {
+		fmt.Fprintf(ctx.Stdout, "## %s v%d..v%d\n\n", appname, from.Version, to.Version)
+		for _, e := range entries {
+			fmt.Fprintf(ctx.Stdout, "- %s (%s, %s)\n", e.Subject, e.Commit, e.Author)
+		}
+		return
+	}
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, e := range entries {
+		listRec(w, e.Commit, e.Author, e.Subject)
+	}
+}
+
+// releaseCommit returns the git commit deployed by a release, taken from
+// its description for deploys and from its slug otherwise.
+func releaseCommit(appname string, rel *heroku.Release) (string, error) {
+	if isDeploy(rel.Description) {
+		return rel.Description[len("Deploy "):], nil
+	}
+	if rel.Slug != nil {
+		slug, err := client.SlugInfo(appname, rel.Slug.Id)
+		if err != nil {
+			return "", err
+		}
+		if slug.Commit != nil && *slug.Commit != "" {
+			return *slug.Commit, nil
+		}
+	}
+	return "", fmt.Errorf("can't find the git commit for %s v%d", appname, rel.Version)
+}
+
+type changelogEntry struct {
+	Commit  string
+	Author  string
+	Subject string
+}
+
+func parseChangelog(out []byte) []changelogEntry {
+	var entries []changelogEntry
+	for _, line := range strings.Split(string(out), "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) == 3 {
+			entries = append(entries, changelogEntry{parts[0], parts[1], parts[2]})
+		}
+	}
+	return entries
+}
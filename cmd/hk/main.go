@@ -0,0 +1,9 @@
+// Command hk is a fast Heroku client. See 'hk help' for its commands, and
+// package github.com/heroku/hk for running them from other programs.
+package main
+
+import "github.com/heroku/hk"
+
+func main() {
+	hk.Main()
+}
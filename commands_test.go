@@ -0,0 +1,72 @@
+package hk
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/heroku/hk/hktest"
+)
+
+// runTestCommand runs an hk command line against srv, and returns what it
+// wrote to stdout and its exit status.
+func runTestCommand(t *testing.T, srv *hktest.Server, args ...string) (string, int) {
+	os.Setenv("HEROKU_API_URL", srv.URL)
+	defer os.Setenv("HEROKU_API_URL", "")
+
+	var stdout, stderr bytes.Buffer
+	status := Run(args, Options{Stdout: &stdout, Stderr: &stderr, Username: "test", Password: "test"})
+	if status != 0 {
+		t.Logf("hk %v: stderr: %s", args, stderr.String())
+	}
+	return stdout.String(), status
+}
+
+func TestCommands(t *testing.T) {
+	fixtures, err := hktest.LoadFixtures("testdata/fixtures/myapp.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := hktest.NewServer(fixtures...)
+	defer srv.Close()
+
+	tests := []struct {
+		args   []string
+		status int
+		want   string
+	}{
+		{
+			[]string{"info", "-a", "myapp"}, 0, "" +
+				"Name:     myapp\n" +
+				"Owner:    owner@example.com\n" +
+				"Region:   us\n" +
+				"Stack:    cedar-14\n" +
+				"Git URL:  git@heroku.com:myapp.git\n" +
+				"Web URL:  https://myapp.herokuapp.com/\n",
+		},
+		{
+			[]string{"env", "-a", "myapp"}, 0, "" +
+				"DATABASE_URL=postgres://localhost/myapp\n" +
+				"RACK_ENV=production\n",
+		},
+		{[]string{"get", "-a", "myapp", "RACK_ENV"}, 0, "production\n"},
+		{[]string{"stack", "-a", "myapp"}, 0, "cedar-14 (next build: heroku-22)\n"},
+		{[]string{"get", "-a", "myapp", "MISSING"}, 1, ""},
+		{[]string{"info", "-a", "otherapp"}, 1, ""},
+	}
+	for _, test := range tests {
+		out, status := runTestCommand(t, srv, test.args...)
+		if status != test.status {
+			t.Errorf("hk %v: status = %d, want %d", test.args, status, test.status)
+		}
+		if out != test.want {
+			t.Errorf("hk %v: stdout = %q, want %q", test.args, out, test.want)
+		}
+	}
+	for _, req := range srv.Unmatched() {
+		if !strings.HasPrefix(req.Path, "/apps/otherapp") {
+			t.Errorf("unexpected unmatched request %s %s", req.Method, req.Path)
+		}
+	}
+}
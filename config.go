@@ -0,0 +1,80 @@
+package hk
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var hkConfig map[string]string
+
+// configPath returns the location of hk's config file. The file is optional
+// and holds settings for commands that need more than flags, one
+// "key = value" pair per line. Lines starting with # are comments.
+func configPath() string {
+	if s := os.Getenv("HKCONFIG"); s != "" {
+		return s
+	}
+	return filepath.Join(hkHome(), "config")
+}
+
+func loadConfig() {
+	if hkConfig == nil {
+		f, err := os.Open(configPath())
+		if err != nil {
+			if os.IsNotExist(err) {
+				hkConfig = make(map[string]string)
+				return
+			}
+			printFatal("loading config: " + err.Error())
+		}
+		defer f.Close()
+		if hkConfig, err = parseConfig(f); err != nil {
+			printFatal("loading config: " + err.Error())
+		}
+	}
+}
+
+func parseConfig(r io.Reader) (map[string]string, error) {
+	conf := make(map[string]string)
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		conf[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// configValue returns the value of key in the config file, or "" if unset.
+func configValue(key string) string {
+	loadConfig()
+	return hkConfig[key]
+}
+
+// commandHeaders returns the API request header fields configured for
+// the named command, from keys of the form "header.<command>.<field>".
+func commandHeaders(command string) http.Header {
+	loadConfig()
+	prefix := "header." + command + "."
+	h := http.Header{}
+	for k, v := range hkConfig {
+		if strings.HasPrefix(k, prefix) && len(k) > len(prefix) {
+			h.Set(k[len(prefix):], v)
+		}
+	}
+	return h
+}
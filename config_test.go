@@ -0,0 +1,59 @@
+package hk
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var parseConfigTests = []struct {
+	in  string
+	out map[string]string
+	err bool
+}{
+	{"", map[string]string{}, false},
+	{
+		"# comment\nbluegreen.myapp.blue = myapp-blue\n\n  key=value with spaces  \n",
+		map[string]string{"bluegreen.myapp.blue": "myapp-blue", "key": "value with spaces"},
+		false,
+	},
+	{"key = a=b", map[string]string{"key": "a=b"}, false},
+	{"key =", map[string]string{"key": ""}, false},
+	{"novalue", nil, true},
+	{"= value", nil, true},
+}
+
+func TestParseConfig(t *testing.T) {
+	for i, pt := range parseConfigTests {
+		conf, err := parseConfig(strings.NewReader(pt.in))
+		if (err != nil) != pt.err {
+			t.Errorf("%d. parseConfig(%q).err => %v, want error %t", i, pt.in, err, pt.err)
+			continue
+		}
+		if !pt.err && !reflect.DeepEqual(conf, pt.out) {
+			t.Errorf("%d. parseConfig(%q) => %v, want %v", i, pt.in, conf, pt.out)
+		}
+	}
+}
+
+func TestCommandHeaders(t *testing.T) {
+	defer func(c map[string]string) { hkConfig = c }(hkConfig)
+	hkConfig = map[string]string{
+		"header.certs.accept":     "application/vnd.heroku+json; version=3.sni_ssl_cert",
+		"header.certs.X-Variant":  "preview",
+		"header.cert-add.Variant": "other",
+		"header.certs.":           "ignored",
+		"bluegreen.myapp.blue":    "myapp-blue",
+	}
+	want := http.Header{
+		"Accept":    {"application/vnd.heroku+json; version=3.sni_ssl_cert"},
+		"X-Variant": {"preview"},
+	}
+	if got := commandHeaders("certs"); !reflect.DeepEqual(got, want) {
+		t.Errorf("commandHeaders(certs) => %v, want %v", got, want)
+	}
+	if got := commandHeaders("info"); len(got) != 0 {
+		t.Errorf("commandHeaders(info) => %v, want none", got)
+	}
+}
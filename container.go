@@ -0,0 +1,187 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/heroku/hk/hkclient"
+)
+
+// containerRegistry is the host of Heroku's Docker registry.
+var containerRegistry = "registry.heroku.com"
+
+var cmdContainerLogin = &Command{
+	Run:      runContainerLogin,
+	Usage:    "container-login",
+	Category: "release",
+	Short:    "log Docker in to the Heroku registry" + extra,
+	Long: `
+Container-login logs the local docker command in to Heroku's
+container registry, registry.heroku.com, with your API token, so
+that images can be pushed with 'hk container-push' or docker push.
+
+Example:
+
+    $ hk container-login
+    Logged in to registry.heroku.com.
+`,
+}
+
+func runContainerLogin(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	mustHaveDocker()
+	if apiClient.Password == "" {
+		printFatal("no credentials. Log in with `hk login` first.")
+	}
+	must(dockerLogin())
+	log.Printf("Logged in to %s.", containerRegistry)
+}
+
+// dockerLogin logs docker in to the registry with the API token.
+func dockerLogin() error {
+	c := exec.Command("docker", "login", "--username=_", "--password-stdin", containerRegistry)
+	c.Stdin = strings.NewReader(apiClient.Password)
+	if out, err := c.CombinedOutput(); err != nil {
+		return fmt.Errorf("docker login: %s%s", out, err)
+	}
+	return nil
+}
+
+var cmdContainerPush = &Command{
+	Run:      runContainerPush,
+	Usage:    "container-push [--image <image>] <type>...",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "push Docker images for process types" + extra,
+	Long: `
+Container-push builds a Docker image for each process type and
+pushes it to the app's repository in Heroku's registry, as
+registry.heroku.com/<app>/<type>. Each type is built from
+Dockerfile.<type> in the current directory if there is one, or
+else from Dockerfile. Pushed images run once released with 'hk
+container-release'.
+
+With --image, an image that's already built is tagged and pushed
+instead, for each type given.
+
+Docker is logged in to the registry first, as by 'hk
+container-login'.
+
+Options:
+
+    --image <image>  local image to push instead of building one
+
+Examples:
+
+    $ hk container-push web worker
+    ...
+    Pushed registry.heroku.com/myapp/web.
+    Pushed registry.heroku.com/myapp/worker.
+
+    $ hk container-push --image myapp:ci-1234 web
+    ...
+    Pushed registry.heroku.com/myapp/web.
+`,
+}
+
+var flagContainerImage string
+
+func init() {
+	cmdContainerPush.Flag.StringVar(&flagContainerImage, "image", "", "local image to push")
+}
+
+func runContainerPush(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) == 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	mustHaveDocker()
+	must(dockerLogin())
+	for _, typ := range args {
+		tag := containerTag(appname, typ)
+		if flagContainerImage != "" {
+			must(runDocker(ctx.Stdout, "tag", flagContainerImage, tag))
+		} else {
+			dockerfile := "Dockerfile." + typ
+			if _, err := os.Stat(dockerfile); err != nil {
+				dockerfile = "Dockerfile"
+			}
+			must(runDocker(ctx.Stdout, "build", "-f", dockerfile, "-t", tag, "."))
+		}
+		must(runDocker(ctx.Stdout, "push", tag))
+		log.Printf("Pushed %s.", tag)
+	}
+}
+
+var cmdContainerRelease = &Command{
+	Run:      runContainerRelease,
+	Usage:    "container-release <type>...",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "release pushed Docker images" + extra,
+	Long: `
+Container-release releases the images last pushed for the given
+process types with 'hk container-push', in one release. The images
+must be in the local Docker, as they are after being pushed from
+this machine.
+
+Example:
+
+    $ hk container-release web worker
+    Released web, worker to myapp v44.
+`,
+}
+
+func runContainerRelease(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) == 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	mustHaveDocker()
+	mustNotBeDeployLocked(appname)
+	var images []hkclient.FormationImage
+	for _, typ := range args {
+		tag := containerTag(appname, typ)
+		out, err := exec.Command("docker", "image", "inspect", "--format", "{{.Id}}", tag).Output()
+		id := strings.TrimSpace(string(out))
+		if err != nil || id == "" {
+			printFatal("No image %s. Push one with 'hk container-push %s'.", tag, typ)
+		}
+		images = append(images, hkclient.FormationImage{Type: typ, DockerImage: id})
+	}
+	must(ext().FormationImageUpdate(appname, images))
+	rel, err := latestRelease(appname)
+	must(err)
+	log.Printf("Released %s to %s v%d.", strings.Join(args, ", "), appname, rel.Version)
+}
+
+// containerTag returns the registry tag of the image for a process type.
+func containerTag(appname, typ string) string {
+	return containerRegistry + "/" + appname + "/" + typ
+}
+
+func runDocker(w io.Writer, args ...string) error {
+	c := exec.Command("docker", args...)
+	c.Stdout = w
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("docker %s: %s", args[0], err)
+	}
+	return nil
+}
+
+// mustHaveDocker exits unless the docker command is installed.
+func mustHaveDocker() {
+	if _, err := exec.LookPath("docker"); err != nil {
+		printFatal("Local docker command not found. For help installing it, see https://docs.docker.com/get-docker/")
+	}
+}
@@ -0,0 +1,76 @@
+package hk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/heroku/hk/hktest"
+)
+
+// fakeDocker puts a docker shell script first in PATH, and returns a func
+// to undo it.
+func fakeDocker(t *testing.T, script string) func() {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake docker is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "hk-docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "docker"), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+path)
+	return func() {
+		os.Setenv("PATH", path)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestContainerRelease(t *testing.T) {
+	// docker image inspect --format {{.Id}} <tag>
+	defer fakeDocker(t, `echo sha256:$(basename "$5")`)()
+	srv := hktest.NewServer(
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/config-vars", Body: []byte(`{}`)},
+		hktest.Fixture{Method: "PATCH", Path: "/apps/myapp/formation", Body: []byte(`[]`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/releases", Body: []byte(`[{"version": 44}]`)},
+	)
+	defer srv.Close()
+	if _, status := runTestCommand(t, srv, "container-release", "-a", "myapp", "web", "worker"); status != 0 {
+		t.Fatalf("status = %d, want 0", status)
+	}
+	if u := srv.Unmatched(); len(u) != 0 {
+		t.Errorf("unmatched requests: %v", u)
+	}
+	for _, req := range srv.Requests() {
+		if req.Method != "PATCH" {
+			continue
+		}
+		want := `{"updates":[{"type":"web","docker_image":"sha256:web"},{"type":"worker","docker_image":"sha256:worker"}]}`
+		if got := strings.TrimSpace(string(req.Body)); got != want {
+			t.Errorf("formation update = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestContainerReleaseDeployLocked(t *testing.T) {
+	defer fakeDocker(t, "")()
+	srv := hktest.NewServer(hktest.Fixture{
+		Method: "GET", Path: "/apps/myapp/config-vars",
+		Body: []byte(`{"HK_DEPLOY_LOCK": "incident #123"}`),
+	})
+	defer srv.Close()
+	if _, status := runTestCommand(t, srv, "container-release", "-a", "myapp", "web"); status == 0 {
+		t.Errorf("status = 0, want failure for a deploy locked app")
+	}
+	for _, req := range srv.Requests() {
+		if req.Method == "PATCH" {
+			t.Errorf("released a deploy locked app")
+		}
+	}
+}
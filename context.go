@@ -0,0 +1,67 @@
+package hk
+
+import (
+	"io"
+	"os"
+
+	"github.com/heroku/hk/postgresql"
+	"github.com/heroku/hk/redis"
+	"github.com/heroku/hk/scheduler"
+)
+
+// A Context carries what a command needs to run: the command itself, the
+// API clients, where to write output, and the selected app. Run functions
+// use it rather than the package-level clients, os.Stdout, and mustApp.
+// Helpers shared by several commands still use the package-level clients;
+// newContext fills in the Context from them, so both refer to the same
+// clients.
+type Context struct {
+	*Command
+
+	Client    API
+	PG        *postgresql.Client
+	Redis     *redis.Client
+	Scheduler *scheduler.Client
+	Stdout    io.Writer
+	Stderr    io.Writer
+
+	// AppName is the selected app, if already known. When it's empty, App
+	// resolves the app from flags, the environment, and git remotes.
+	AppName string
+}
+
+// newContext returns a Context for running cmd with the global clients and
+// the process's stdout and stderr.
+func newContext(cmd *Command) *Context {
+	return &Context{
+		Command:   cmd,
+		Client:    client,
+		PG:        pgclient,
+		Redis:     redisclient,
+		Scheduler: schedulerclient,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}
+}
+
+// printUsage prints the command's usage to ctx.Stderr.
+func (ctx *Context) printUsage() {
+	ctx.printUsageTo(ctx.Stderr)
+}
+
+// App returns the name of the selected app.
+func (ctx *Context) App() (string, error) {
+	if ctx.AppName != "" {
+		return ctx.AppName, nil
+	}
+	return app()
+}
+
+// MustApp is like App, but exits on error.
+func (ctx *Context) MustApp() string {
+	name, err := ctx.App()
+	if err != nil {
+		printFatal(err.Error())
+	}
+	return name
+}
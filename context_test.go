@@ -0,0 +1,41 @@
+package hk
+
+import (
+	"bytes"
+	"testing"
+)
+
+// fakeConfig is an API that serves one app's config vars.
+type fakeConfig struct {
+	API
+	app    string
+	config map[string]string
+}
+
+func (f *fakeConfig) ConfigVarInfo(appIdentity string) (map[string]string, error) {
+	if appIdentity != f.app {
+		return nil, nil
+	}
+	return f.config, nil
+}
+
+func TestRunEnvWithContext(t *testing.T) {
+	var out bytes.Buffer
+	ctx := &Context{
+		Command: cmdEnv,
+		Client:  &fakeConfig{app: "myapp", config: map[string]string{"B": "2", "A": "1"}},
+		Stdout:  &out,
+		AppName: "myapp",
+	}
+	runEnv(ctx, nil)
+	if got, want := out.String(), "A=1\nB=2\n"; got != want {
+		t.Errorf("env output = %q, want %q", got, want)
+	}
+
+	out.Reset()
+	ctx.Command = cmdGet
+	runGet(ctx, []string{"B"})
+	if got, want := out.String(), "2\n"; got != want {
+		t.Errorf("get output = %q, want %q", got, want)
+	}
+}
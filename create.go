@@ -1,19 +1,33 @@
-package main
+package hk
 
 import (
 	"log"
 	"os/exec"
+	"strings"
 
 	"github.com/bgentry/heroku-go"
 )
 
 var cmdCreate = &Command{
 	Run:      runCreate,
-	Usage:    "create [-r <region>] [<name>]",
+	Usage:    "create [-q] [-r <region>] [--org <org>] [--addons <plans>] [--buildpack <urls>] [--env-file <file>] [--remote <name>] [<name>]",
 	Category: "app",
 	Short:    "create an app",
 	Long: `
-Create creates a new heroku app.
+Create creates a new heroku app, and adds a git remote for it to
+the current repo. At a terminal, it then suggests how to deploy.
+
+Options:
+
+    -q                  don't suggest next steps
+    -r <region>         region to create the app in
+    --org <org>         organization to create the app in
+    --addons <plans>    comma-separated add-on plans to add
+    --buildpack <urls>  comma-separated buildpack URLs to use, in
+                        order
+    --env-file <file>   .env file of env vars to set (see 'hk help
+                        env-push')
+    --remote <name>     name of the git remote to add (default heroku)
 
 Examples:
 
@@ -22,16 +36,50 @@ Examples:
 
     $ hk create -r eu myapp
     Created myapp.
+
+    $ hk create --org acme acme-api
+    Created acme-api in acme.
+
+    $ hk create --addons heroku-postgresql,papertrail --env-file .env myapp
+    Created myapp.
+    Added heroku-postgresql:hobby-dev to myapp as heroku-postgresql-round-4217.
+    Added papertrail:choklad to myapp as papertrail-lively-1283.
+    Set 3 env vars on myapp.
 `,
 }
 
-var flagRegion string
+var (
+	flagRegion          string
+	flagCreateOrg       string
+	flagCreateAddons    string
+	flagCreateBuildpack string
+	flagCreateEnvFile   string
+	flagCreateRemote    string
+)
 
 func init() {
 	cmdCreate.Flag.StringVar(&flagRegion, "r", "", "region name")
+	cmdCreate.Flag.StringVar(&flagCreateOrg, "org", "", "organization name")
+	cmdCreate.Flag.StringVar(&flagCreateAddons, "addons", "", "add-on plans to add")
+	cmdCreate.Flag.StringVar(&flagCreateBuildpack, "buildpack", "", "buildpack URLs")
+	cmdCreate.Flag.StringVar(&flagCreateEnvFile, "env-file", "", "env file")
+	cmdCreate.Flag.StringVar(&flagCreateRemote, "remote", "heroku", "git remote name")
 }
 
-func runCreate(cmd *Command, args []string) {
+func runCreate(ctx *Context, args []string) {
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	// read the env file first so a bad file doesn't leave a half-made app
+	var env map[string]string
+	if flagCreateEnvFile != "" {
+		var err error
+		if env, err = readDotenv(flagCreateEnvFile); err != nil {
+			printFatal(err.Error())
+		}
+	}
+
 	var opts heroku.AppCreateOpts
 	if flagRegion != "" {
 		opts.Region = &flagRegion
@@ -39,8 +87,42 @@ func runCreate(cmd *Command, args []string) {
 	if len(args) > 0 {
 		opts.Name = &args[0]
 	}
-	app, err := client.AppCreate(&opts)
+	var app *heroku.App
+	var err error
+	if flagCreateOrg != "" {
+		app, err = createOrgApp(flagCreateOrg, &opts)
+	} else {
+		app, err = ctx.Client.AppCreate(&opts)
+	}
 	must(err)
-	exec.Command("git", "remote", "add", "heroku", app.GitURL).Run()
-	log.Printf("Created %s.", app.Name)
+	exec.Command("git", "remote", "add", flagCreateRemote, app.GitURL).Run()
+	if flagCreateOrg != "" {
+		log.Printf("Created %s in %s.", app.Name, flagCreateOrg)
+	} else {
+		log.Printf("Created %s.", app.Name)
+	}
+
+	if flagCreateBuildpack != "" {
+		urls := strings.Split(flagCreateBuildpack, ",")
+		must(setBuildpacks(app.Name, urls))
+		log.Printf("Set buildpacks on %s to %s.", app.Name, strings.Join(urls, ", "))
+	}
+	if len(env) > 0 {
+		config := make(map[string]*string, len(env))
+		for k, v := range env {
+			v := v
+			config[k] = &v
+		}
+		_, err := ctx.Client.ConfigVarUpdate(app.Name, config)
+		must(err)
+		log.Printf("Set %d env vars on %s.", len(env), app.Name)
+	}
+	if flagCreateAddons != "" {
+		for _, plan := range strings.Split(flagCreateAddons, ",") {
+			addon, err := ctx.Client.AddonCreate(app.Name, strings.TrimSpace(plan), nil)
+			must(err)
+			log.Printf("Added %s to %s as %s.", addon.Plan.Name, app.Name, addon.Name)
+		}
+	}
+	printTip("Deploy with 'hk push' or 'git push %s master'.", flagCreateRemote)
 }
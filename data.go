@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"time"
@@ -9,8 +9,8 @@ import (
 type Release struct {
 	heroku.Release
 
-	Commit string // deduced from Description, if possible
-	Who    string // who created the release
+	Commit string `json:"commit,omitempty"` // deduced from Description, if possible
+	Who    string `json:"-"`                // who created the release
 }
 
 type LogSession struct {
@@ -0,0 +1,312 @@
+package hk
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/heroku/hk/hkclient"
+)
+
+var cmdDeploy = &Command{
+	Run:      runDeploy,
+	Usage:    "deploy [--version <version>] [<dir>]",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "build and release a directory",
+	Long: `
+Deploy builds a directory (the current directory by default) on
+Heroku and releases the result, without needing a git push. The
+directory is uploaded as a tarball and the build output is shown
+as it runs.
+
+Files ignored by git (when the directory is in a git repo) and
+files matching patterns in .slugignore are left out of the upload.
+
+Options:
+
+    --version <version>  version label for the build (default: the
+                         current git commit, if any)
+
+Example:
+
+    $ hk deploy
+    Uploading 1.2 MB... done.
+    -----> Ruby app detected
+    ...
+    Deployed myapp v43.
+`,
+}
+
+var flagDeployVersion string
+
+func init() {
+	cmdDeploy.Flag.StringVar(&flagDeployVersion, "version", "", "version label for the build")
+}
+
+func runDeploy(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	dir := "."
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	mustNotBeDeployLocked(appname)
+
+	version := flagDeployVersion
+	if version == "" {
+		version = dirGitCommit(dir)
+	}
+
+	b, err := buildDir(ctx.Stdout, appname, dir, version)
+	must(err)
+	if b.Release == nil {
+		log.Printf("Deployed %s.", appname)
+		return
+	}
+	rel, err := ctx.Client.ReleaseInfo(appname, b.Release.Id)
+	must(err)
+	log.Printf("Deployed %s v%d.", appname, rel.Version)
+}
+
+// buildDir uploads dir as a tarball and builds it on appname, showing the
+// build output as it runs. It returns the build once it has succeeded.
+func buildDir(w io.Writer, appname, dir, version string) (*hkclient.Build, error) {
+	files, err := deployFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	tarball, err := ioutil.TempFile("", "hk-deploy")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tarball.Name())
+	defer tarball.Close()
+	if err := writeTarball(tarball, dir, "", files); err != nil {
+		return nil, err
+	}
+
+	src, err := ext().SourceCreate(appname)
+	if err != nil {
+		return nil, err
+	}
+	size, err := tarball.Seek(0, 2)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Uploading %s... ", prettySize(size))
+	if _, err := tarball.Seek(0, 0); err != nil {
+		return nil, err
+	}
+	if err := uploadSource(src.SourceBlob.PutURL, tarball, size); err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(os.Stderr, "done.")
+
+	b, err := ext().BuildCreate(appname, src.SourceBlob.GetURL, version)
+	if err != nil {
+		return nil, err
+	}
+	if b.OutputStreamURL != "" {
+		res, err := http.Get(b.OutputStreamURL)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(w, res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for b.Status == "pending" {
+		time.Sleep(2 * time.Second)
+		if b, err = ext().BuildInfo(appname, b.Id); err != nil {
+			return nil, err
+		}
+	}
+	if b.Status != "succeeded" {
+		return nil, fmt.Errorf("Build %s.", b.Status)
+	}
+	return b, nil
+}
+
+func uploadSource(u string, r io.Reader, size int64) error {
+	req, err := http.NewRequest("PUT", u, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return fmt.Errorf("upload failed: %s", res.Status)
+	}
+	return nil
+}
+
+// deployFiles lists the files in dir to upload, relative to dir. In a git
+// repo, files ignored by git are left out. Files matching .slugignore are
+// always left out.
+func deployFiles(dir string) ([]string, error) {
+	var files []string
+	c := exec.Command("git", "ls-files", "-z", "--cached", "--others", "--exclude-standard")
+	c.Dir = dir
+	if out, err := c.Output(); err == nil {
+		for _, f := range strings.Split(string(out), "\x00") {
+			if f == "" {
+				continue
+			}
+			f = filepath.FromSlash(f)
+			// tracked files deleted without git rm are still listed
+			if _, err := os.Lstat(filepath.Join(dir, f)); os.IsNotExist(err) {
+				continue
+			}
+			files = append(files, f)
+		}
+	} else {
+		err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if fi.IsDir() && fi.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if !fi.IsDir() {
+				rel, err := filepath.Rel(dir, path)
+				if err != nil {
+					return err
+				}
+				files = append(files, rel)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	patterns, err := readSlugignore(filepath.Join(dir, ".slugignore"))
+	if err != nil {
+		return nil, err
+	}
+	var kept []string
+	for _, f := range files {
+		if !slugignored(patterns, filepath.ToSlash(f)) {
+			kept = append(kept, f)
+		}
+	}
+	return kept, nil
+}
+
+func readSlugignore(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var patterns []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, s.Err()
+}
+
+// slugignored reports whether the slash-separated path p matches any of the
+// .slugignore patterns. A pattern matches a file's full path, its base name,
+// or any directory containing it.
+func slugignored(patterns []string, p string) bool {
+	for _, pat := range patterns {
+		pat = strings.Trim(pat, "/")
+		if ok, _ := filepath.Match(pat, p); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pat, filepath.Base(p)); ok {
+			return true
+		}
+		for d := filepath.Dir(p); d != "."; d = filepath.Dir(d) {
+			if ok, _ := filepath.Match(pat, d); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeTarball writes files, relative to dir, to w as a gzipped tarball,
+// with prefix added to the name of each.
+func writeTarball(w io.Writer, dir, prefix string, files []string) error {
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		fi, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+		link := ""
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		} else if !fi.Mode().IsRegular() {
+			continue
+		}
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = prefix + filepath.ToSlash(name)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if link == "" {
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(tw, f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func prettySize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,99 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// deployLockVar is the config var used to record a deploy lock on an app.
+const deployLockVar = "HK_DEPLOY_LOCK"
+
+var cmdDeployLock = &Command{
+	Run:      runDeployLock,
+	Usage:    "deploy-lock <reason>",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "prevent deploys to an app" + extra,
+	Long: `
+Deploy-lock marks an app as locked for deploys, for example during
+an incident. Commands that deploy code to an app refuse to run
+while it is locked. The lock and its reason are shown by 'hk info'.
+
+Locking an app restarts all of its dynos. The lock is stored in
+the ` + deployLockVar + ` config var, and like any config change, setting
+it creates a new release.
+
+Example:
+
+    $ hk deploy-lock "incident #123"
+    Locked deploys to myapp. Its dynos are restarting.
+`,
+}
+
+func runDeployLock(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) == 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	user, _ := getCreds(apiURL)
+	val := fmt.Sprintf("%s (by %s at %s)",
+		strings.Join(args, " "),
+		user,
+		time.Now().UTC().Format(time.RFC3339),
+	)
+	_, err := ctx.Client.ConfigVarUpdate(appname, map[string]*string{deployLockVar: &val})
+	must(err)
+	log.Printf("Locked deploys to %s. Its dynos are restarting.", appname)
+}
+
+var cmdDeployUnlock = &Command{
+	Run:      runDeployUnlock,
+	Usage:    "deploy-unlock",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "allow deploys to a locked app" + extra,
+	Long: `
+Deploy-unlock removes a lock set by 'hk deploy-lock'.
+
+Like locking, unlocking an app creates a new release and restarts
+all of its dynos, since the lock is a config var.
+
+Example:
+
+    $ hk deploy-unlock
+    Unlocked deploys to myapp. Its dynos are restarting.
+`,
+}
+
+func runDeployUnlock(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	_, err := ctx.Client.ConfigVarUpdate(appname, map[string]*string{deployLockVar: nil})
+	must(err)
+	log.Printf("Unlocked deploys to %s. Its dynos are restarting.", appname)
+}
+
+// deployLock returns the deploy lock reason for an app, or "" if the app
+// isn't locked.
+func deployLock(appname string) (string, error) {
+	config, err := client.ConfigVarInfo(appname)
+	if err != nil {
+		return "", err
+	}
+	return config[deployLockVar], nil
+}
+
+// mustNotBeDeployLocked exits with an error if the app is deploy locked.
+func mustNotBeDeployLocked(appname string) {
+	reason, err := deployLock(appname)
+	must(err)
+	if reason != "" {
+		printFatal("deploys to %s are locked: %s. Run 'hk deploy-unlock' to unlock.", appname, reason)
+	}
+}
@@ -0,0 +1,192 @@
+package hk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdDeployRecord = &Command{
+	Run:      runDeployRecord,
+	Usage:    "deploy-record [--provider <provider>] [<version>]",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "post a deploy marker to a monitoring service" + extra,
+	Long: `
+Deploy-record posts a deployment marker for a release (the latest
+release by default) to a monitoring service, so dashboards show a
+line for each deploy. The marker includes the app name, release
+version, the user who made the release, and its git commit.
+
+Providers are datadog, newrelic, and generic-webhook. Each is
+configured in the config file (see 'hk help environ'):
+
+    deployrecord.provider = datadog
+    deployrecord.datadog.api-key = <key>
+    deployrecord.newrelic.api-key = <key>
+    deployrecord.newrelic.app-name = <name>  (default: the app name)
+    deployrecord.generic-webhook.url = https://example.com/deploys
+
+The generic webhook receives a JSON POST with the fields app,
+version, user, commit, and description.
+
+Options:
+
+    --provider <provider>  service to post to (default: the
+                           deployrecord.provider config setting)
+
+Examples:
+
+    $ hk deploy-record
+    Recorded myapp v42 with datadog.
+
+    $ hk deploy-record --provider generic-webhook v41
+    Recorded myapp v41 with generic-webhook.
+`,
+}
+
+var flagDeployRecordProvider string
+
+func init() {
+	cmdDeployRecord.Flag.StringVar(&flagDeployRecordProvider, "provider", "", "monitoring service to post to")
+}
+
+func runDeployRecord(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	provider := flagDeployRecordProvider
+	if provider == "" {
+		provider = configValue("deployrecord.provider")
+	}
+	if provider == "" {
+		printFatal("no provider. Use --provider or set deployrecord.provider in %s.", configPath())
+	}
+
+	var rel *heroku.Release
+	var err error
+	if len(args) == 1 {
+		ver := strings.TrimPrefix(args[0], "v")
+		if _, err := strconv.Atoi(ver); err != nil {
+			printFatal("invalid version %q", args[0])
+		}
+		rel, err = ctx.Client.ReleaseInfo(appname, ver)
+	} else {
+		rel, err = latestRelease(appname)
+	}
+	must(err)
+
+	m := deployMarker{
+		App:         appname,
+		Version:     rel.Version,
+		User:        rel.User.Email,
+		Description: rel.Description,
+	}
+	if commit, err := releaseCommit(appname, rel); err == nil {
+		m.Commit = commit
+	}
+	req, err := deployMarkerRequest(provider, m, configValue)
+	if err != nil {
+		printFatal(err.Error())
+	}
+	res, err := http.DefaultClient.Do(req)
+	must(err)
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		printFatal("%s responded with %s", provider, res.Status)
+	}
+	log.Printf("Recorded %s v%d with %s.", appname, rel.Version, provider)
+}
+
+// A deployMarker describes a release to be recorded by a monitoring service.
+type deployMarker struct {
+	App         string `json:"app"`
+	Version     int    `json:"version"`
+	User        string `json:"user"`
+	Commit      string `json:"commit"`
+	Description string `json:"description"`
+}
+
+func (m deployMarker) title() string {
+	return fmt.Sprintf("Deployed %s v%d", m.App, m.Version)
+}
+
+// deployMarkerRequest builds the request that records m with provider. Its
+// settings are looked up with conf.
+func deployMarkerRequest(provider string, m deployMarker, conf func(key string) string) (*http.Request, error) {
+	setting := func(name string) (string, error) {
+		key := "deployrecord." + provider + "." + name
+		if v := conf(key); v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("%s is not set in %s", key, configPath())
+	}
+
+	switch provider {
+	case "datadog":
+		key, err := setting("api-key")
+		if err != nil {
+			return nil, err
+		}
+		text := fmt.Sprintf("%s by %s", m.Description, m.User)
+		if m.Commit != "" {
+			text += " (" + m.Commit + ")"
+		}
+		return newJSONRequest("https://api.datadoghq.com/api/v1/events?api_key="+url.QueryEscape(key), map[string]interface{}{
+			"title": m.title(),
+			"text":  text,
+			"tags":  []string{"app:" + m.App, "deploy"},
+		})
+	case "newrelic":
+		key, err := setting("api-key")
+		if err != nil {
+			return nil, err
+		}
+		name := conf("deployrecord.newrelic.app-name")
+		if name == "" {
+			name = m.App
+		}
+		form := url.Values{
+			"deployment[app_name]":    {name},
+			"deployment[revision]":    {m.Commit},
+			"deployment[user]":        {m.User},
+			"deployment[description]": {fmt.Sprintf("v%d %s", m.Version, m.Description)},
+		}
+		req, err := http.NewRequest("POST", "https://api.newrelic.com/deployments.xml", strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Api-Key", key)
+		return req, nil
+	case "generic-webhook":
+		u, err := setting("url")
+		if err != nil {
+			return nil, err
+		}
+		return newJSONRequest(u, m)
+	}
+	return nil, fmt.Errorf("unknown provider %q, expected datadog, newrelic, or generic-webhook", provider)
+}
+
+func newJSONRequest(u string, v interface{}) (*http.Request, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", u, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
@@ -0,0 +1,45 @@
+package hk
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDeployMarkerRequest(t *testing.T) {
+	conf := map[string]string{
+		"deployrecord.generic-webhook.url": "https://example.com/deploys",
+		"deployrecord.newrelic.api-key":    "secret",
+	}
+	confFn := func(key string) string { return conf[key] }
+	m := deployMarker{App: "myapp", Version: 42, User: "jane@example.com", Commit: "a1b2c3d", Description: "Deploy a1b2c3d"}
+
+	req, err := deployMarkerRequest("generic-webhook", m, confFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.URL.String() != "https://example.com/deploys" {
+		t.Errorf("webhook URL = %q", req.URL)
+	}
+	var got deployMarker
+	if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != m {
+		t.Errorf("webhook body = %+v, want %+v", got, m)
+	}
+
+	req, err = deployMarkerRequest("newrelic", m, confFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Header.Get("X-Api-Key") != "secret" {
+		t.Errorf("newrelic api key = %q", req.Header.Get("X-Api-Key"))
+	}
+
+	if _, err := deployMarkerRequest("datadog", m, confFn); err == nil {
+		t.Errorf("expected error for missing datadog api key")
+	}
+	if _, err := deployMarkerRequest("pagerduty", m, confFn); err == nil {
+		t.Errorf("expected error for unknown provider")
+	}
+}
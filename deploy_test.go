@@ -0,0 +1,72 @@
+package hk
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestSlugignored(t *testing.T) {
+	patterns := []string{"*.psd", "/spec", "log/", "tmp/cache"}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"design.psd", true},
+		{"assets/logo.psd", true},
+		{"spec/app_spec.rb", true},
+		{"log/development.log", true},
+		{"tmp/cache/a/b", true},
+		{"tmp/pids/web.pid", false},
+		{"app/models/user.rb", false},
+		{"logo.png", false},
+	}
+	for _, tt := range tests {
+		if got := slugignored(patterns, tt.path); got != tt.want {
+			t.Errorf("slugignored(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestDeployFilesInGitRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir, err := ioutil.TempDir("", "hk-deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	git := func(args ...string) {
+		c := exec.Command("git", append([]string{"-c", "user.name=hk", "-c", "user.email=hk@example.com"}, args...)...)
+		c.Dir = dir
+		if out, err := c.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s%s", args[0], out, err)
+		}
+	}
+	for _, name := range []string{"Procfile", "gone.txt"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	// deleted without git rm, so still tracked
+	if err := os.Remove(filepath.Join(dir, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := deployFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != "Procfile" {
+		t.Errorf("deployFiles => %v, want [Procfile]", files)
+	}
+	if commit := dirGitCommit(dir); len(commit) != 40 {
+		t.Errorf("dirGitCommit => %q, want the commit in %s", commit, dir)
+	}
+}
@@ -1,40 +1,130 @@
-package main
+package hk
 
 import (
 	"log"
 	"os"
 	"os/exec"
+	"sort"
+	"strings"
 )
 
 var cmdDestroy = &Command{
 	Run:      runDestroy,
-	Usage:    "destroy <name>",
+	Usage:    "destroy [--archive <file>] <name>",
 	Category: "app",
 	Short:    "destroy an app",
 	Long: `
 Destroy destroys a heroku app.
 
-There is no going back, so be sure you mean it.
+There is no going back, so be sure you mean it. If your account
+uses two-factor auth, destroy asks for a code.
 
-Example:
+Destroy also cleans up local state for the app: git remotes
+pointing at it are removed (along with the heroku.remote git config
+setting, if it names one of them), and scheduled maintenance for it
+is canceled. It warns about config file entries and environment
+variables that still refer to the app.
+
+With --archive, destroy first saves a record of the app to file, as
+'hk archive --redact' does, and doesn't destroy the app if that
+fails.
+
+Options:
+
+    --archive <file>  save a record of the app to file first
+
+Examples:
 
     $ hk destroy myapp
     Destroyed myapp.
+    Removed git remote heroku.
+
+    $ hk destroy --archive myapp-archive.tar.gz myapp
+    Archived myapp to myapp-archive.tar.gz.
+    Destroyed myapp.
 `,
 }
 
-func runDestroy(cmd *Command, args []string) {
+var flagDestroyArchive string
+
+func init() {
+	cmdDestroy.Flag.StringVar(&flagDestroyArchive, "archive", "", "file to archive the app to")
+}
+
+func runDestroy(ctx *Context, args []string) {
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	appname := args[0]
-	must(client.AppDelete(appname))
+	if flagDestroyArchive != "" {
+		if err := archiveApp(appname, flagDestroyArchive, true, 1500); err != nil {
+			printFatal("archiving %s: %s. It wasn't destroyed.", appname, err)
+		}
+		log.Printf("Archived %s to %s.", appname, flagDestroyArchive)
+	}
+	must(ctx.Client.AppDelete(appname))
 	log.Printf("Destroyed %s.", appname)
+	cleanupDestroyedApp(appname)
+}
+
+// cleanupDestroyedApp removes the local state for a destroyed app: its git
+// remotes and scheduled maintenance. It warns about config and environment
+// variables that still refer to it.
+func cleanupDestroyedApp(appname string) {
 	remotes, _ := gitRemotes()
 	for remote, remoteApp := range remotes {
 		if appname == remoteApp {
-			exec.Command("git", "remote", "rm", remote).Run()
+			if exec.Command("git", "remote", "rm", remote).Run() == nil {
+				log.Printf("Removed git remote %s.", remote)
+			}
+			if remoteFromGitConfig() == remote {
+				exec.Command("git", "config", "--unset", "heroku.remote").Run()
+			}
+			if os.Getenv("HEROKU_REMOTE") == remote {
+				printWarning("HEROKU_REMOTE refers to removed git remote %s.", remote)
+			}
+		}
+	}
+	if os.Getenv("HKAPP") == appname {
+		printWarning("HKAPP refers to destroyed app %s.", appname)
+	}
+	cleanupMaintenanceSchedule(appname)
+	loadConfig()
+	if keys := configRefs(hkConfig, appname); len(keys) > 0 {
+		printWarning("%s still refers to %s: %s", configPath(), appname, strings.Join(keys, ", "))
+	}
+}
+
+// cleanupMaintenanceSchedule cancels scheduled maintenance for appname.
+func cleanupMaintenanceSchedule(appname string) {
+	sched, err := loadMaintenanceSchedule()
+	if err != nil {
+		printWarning("couldn't read maintenance schedule: %s", err)
+		return
+	}
+	var kept []maintenanceChange
+	for _, c := range sched {
+		if c.App != appname {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) != len(sched) {
+		if err := saveMaintenanceSchedule(kept); err != nil {
+			printWarning("couldn't update maintenance schedule: %s", err)
+		}
+	}
+}
+
+// configRefs returns the sorted config keys that name appname, either as a
+// dot-separated part of the key or as the whole value.
+func configRefs(conf map[string]string, appname string) []string {
+	var keys []string
+	for k, v := range conf {
+		if v == appname || stringsIndex(strings.Split(k, "."), appname) >= 0 {
+			keys = append(keys, k)
 		}
 	}
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,20 @@
+package hk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfigRefs(t *testing.T) {
+	conf := map[string]string{
+		"bluegreen.myapp.blue":       "myapp-blue",
+		"bluegreen.myapp.gate":       "/healthz",
+		"bluegreen.other.blue":       "myapp",
+		"bluegreen.myapp-green.blue": "other",
+		"deployrecord.provider":      "datadog",
+	}
+	want := []string{"bluegreen.myapp.blue", "bluegreen.myapp.gate", "bluegreen.other.blue"}
+	if got := configRefs(conf, "myapp"); !reflect.DeepEqual(got, want) {
+		t.Errorf("configRefs => %v, want %v", got, want)
+	}
+}
@@ -1,6 +1,6 @@
 // +build !release
 
-package main
+package hk
 
 const (
 	Version = "dev"
@@ -1,9 +1,9 @@
-package main
+package hk
 
 import (
 	"fmt"
 	"log"
-	"os"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/bgentry/heroku-go"
@@ -11,36 +11,81 @@ import (
 
 var cmdDomains = &Command{
 	Run:      runDomains,
-	Usage:    "domains",
+	Usage:    "domains [-j] [--zonefile [--ttl <seconds>]]",
 	NeedsApp: true,
 	Category: "domain",
 	Short:    "list domains",
 	Long: `
 Lists domains.
 
+Options:
+
+    -j, --json       print domains as JSON
+    --zonefile       print BIND zone file records pointing the app's
+                     custom domains at their DNS targets
+    --ttl <seconds>  TTL of zone file records (default 3600)
+
+Apex domains (like example.com) can't be CNAMEs, so --zonefile
+gives them ALIAS records, which many DNS providers support under
+that name or as ANAME. Domains with two labels are taken to be
+apex domains.
+
 Examples:
 
     $ hk domains
     test.herokuapp.com
     www.test.com
+
+    $ hk domains --zonefile
+    ; custom domains of test
+    test.com.      3600  IN  ALIAS  test.com.herokudns.com.
+    www.test.com.  3600  IN  CNAME  www.test.com.herokudns.com.
 `,
 }
 
-func runDomains(cmd *Command, args []string) {
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+func init() {
+	registerList(cmdDomains, heroku.Domain{})
+}
+
+var (
+	flagZonefile bool
+	flagZoneTTL  int
+)
+
+func init() {
+	cmdDomains.Flag.BoolVar(&flagZonefile, "zonefile", false, "print zone file records")
+	cmdDomains.Flag.IntVar(&flagZoneTTL, "ttl", 3600, "zone file record TTL")
+}
+
+func runDomains(ctx *Context, args []string) {
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
 	defer w.Flush()
 
-	appname := mustApp()
+	appname := ctx.MustApp()
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
+	}
+	if flagZonefile {
+		var domains []apiDomain
+		must(ctx.Client.Get(&domains, "/apps/"+appname+"/domains"))
+		fmt.Fprintf(w, "; custom domains of %s\n", appname)
+		for _, d := range domains {
+			if d.Kind != "heroku" {
+				listRec(w, zoneRecord(d, appname, flagZoneTTL)...)
+			}
+		}
+		return
 	}
-	domains, err := client.DomainList(appname, &heroku.ListRange{
+	domains, err := ctx.Client.DomainList(appname, &heroku.ListRange{
 		Field: "hostname",
 		Max:   1000,
 	})
 	must(err)
 
+	if printList(ctx.Stdout, domains) {
+		return
+	}
 	for _, d := range domains {
 		fmt.Fprintln(w, d.Hostname)
 	}
@@ -54,14 +99,14 @@ var cmdDomainAdd = &Command{
 	Short:    "add a domain",
 }
 
-func runDomainAdd(cmd *Command, args []string) {
-	appname := mustApp()
+func runDomainAdd(ctx *Context, args []string) {
+	appname := ctx.MustApp()
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	domain := args[0]
-	_, err := client.DomainCreate(appname, domain)
+	_, err := ctx.Client.DomainCreate(appname, domain)
 	must(err)
 	log.Printf("Added %s to %s.", domain, appname)
 }
@@ -74,13 +119,38 @@ var cmdDomainRemove = &Command{
 	Short:    "remove a domain",
 }
 
-func runDomainRemove(cmd *Command, args []string) {
-	appname := mustApp()
+func runDomainRemove(ctx *Context, args []string) {
+	appname := ctx.MustApp()
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	domain := args[0]
-	must(client.DomainDelete(appname, domain))
+	must(ctx.Client.DomainDelete(appname, domain))
 	log.Printf("Removed %s from %s.", domain, appname)
 }
+
+// An apiDomain is a domain as the API returns it, with the fields the
+// heroku.Domain type lacks.
+type apiDomain struct {
+	Hostname        string `json:"hostname"`
+	Kind            string `json:"kind"`
+	CName           string `json:"cname"`
+	ACMStatus       string `json:"acm_status"`
+	ACMStatusReason string `json:"acm_status_reason"`
+}
+
+// zoneRecord returns the fields of a zone file record pointing d at its
+// DNS target. Domains without a DNS target of their own point at the
+// app's herokuapp.com domain.
+func zoneRecord(d apiDomain, appname string, ttl int) []interface{} {
+	target := d.CName
+	if target == "" {
+		target = appname + ".herokuapp.com"
+	}
+	typ := "CNAME"
+	if strings.Count(strings.TrimSuffix(d.Hostname, "."), ".") < 2 {
+		typ = "ALIAS"
+	}
+	return []interface{}{ensureSuffix(d.Hostname, "."), ttl, "IN", typ, ensureSuffix(target, ".")}
+}
@@ -0,0 +1,22 @@
+package hk
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestZoneRecord(t *testing.T) {
+	tests := []struct {
+		domain apiDomain
+		want   string
+	}{
+		{apiDomain{Hostname: "www.test.com", CName: "www.test.com.herokudns.com"}, "[www.test.com. 300 IN CNAME www.test.com.herokudns.com.]"},
+		{apiDomain{Hostname: "test.com", CName: "test.com.herokudns.com"}, "[test.com. 300 IN ALIAS test.com.herokudns.com.]"},
+		{apiDomain{Hostname: "www.test.com"}, "[www.test.com. 300 IN CNAME myapp.herokuapp.com.]"},
+	}
+	for _, test := range tests {
+		if got := fmt.Sprint(zoneRecord(test.domain, "myapp", 300)); got != test.want {
+			t.Errorf("zoneRecord(%+v) = %s, want %s", test.domain, got, test.want)
+		}
+	}
+}
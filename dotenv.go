@@ -0,0 +1,369 @@
+package hk
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var cmdEnvPull = &Command{
+	Run:      runEnvPull,
+	Usage:    "env-pull [-f <file>] [--only <patterns>] [--merge | --overwrite] [--dry-run]",
+	NeedsApp: true,
+	Category: "config",
+	Short:    "write env vars to a .env file" + extra,
+	Long: `
+Env-pull downloads an app's env vars into a local .env file, one
+NAME=value pair per line. If the file already has vars, one of
+--merge or --overwrite is required.
+
+Options:
+
+    -f <file>          file to write (default .env)
+    --only <patterns>  only pull vars matching one of these
+                       comma-separated patterns, where * matches any
+                       characters (e.g. 'FEATURE_*'); other vars in
+                       the file are left alone
+    --merge            keep vars that are only in the file, and
+                       update the rest from the app
+    --overwrite        replace the file with the app's vars
+    --dry-run          show which vars would change without writing
+
+Examples:
+
+    $ hk env-pull
+    Wrote 5 env vars from myapp to .env.
+
+    $ hk env-pull --only 'FEATURE_*' --overwrite -f features.env
+    Wrote 3 env vars from myapp to features.env.
+
+    $ hk env-pull --merge --dry-run
+    + REDIS_URL
+    ~ DATABASE_URL
+`,
+}
+
+var cmdEnvPush = &Command{
+	Run:      runEnvPush,
+	Usage:    "env-push [-f <file> | --from <app>] [--only <patterns>] [--merge | --overwrite] [--dry-run] [--yes]",
+	NeedsApp: true,
+	Category: "config",
+	Short:    "set env vars from a .env file" + extra,
+	Long: `
+Env-push sets an app's env vars from a local .env file, or from
+another app with --from, in a single update. If the app already has
+env vars, one of --merge or --overwrite is required.
+
+When copying from another app, env-push shows the changes and asks
+for confirmation before making them, unless --yes is given.
+
+Options:
+
+    -f <file>          file to read (default .env)
+    --from <app>       copy vars from this app instead of a file
+    --only <patterns>  only push vars matching one of these
+                       comma-separated patterns, where * matches any
+                       characters (e.g. 'FEATURE_*'); other vars on
+                       the app are left alone, even with --overwrite
+    --merge            set the vars in the file, leaving others alone
+    --overwrite        also unset vars that aren't in the file
+    --dry-run          show which vars would change without setting
+                       them
+    -y, --yes          don't ask for confirmation
+
+Examples:
+
+    $ hk env-push --merge
+    Set env vars and restarted myapp.
+
+    $ hk env-push -a myapp-production --from myapp-staging --only 'FEATURE_*' --overwrite
+    + FEATURE_NEW_CHECKOUT
+    - FEATURE_OLD_SEARCH
+    Apply 2 changes to myapp-production? [y/N] y
+    Set env vars and restarted myapp-production.
+
+    $ hk env-push --overwrite --dry-run
+    - PAPERTRAIL_API_TOKEN
+    ~ WEB_CONCURRENCY
+`,
+}
+
+var (
+	flagEnvFile      string
+	flagEnvFrom      string
+	flagEnvOnly      string
+	flagEnvMerge     bool
+	flagEnvOverwrite bool
+	flagEnvDryRun    bool
+	flagEnvYes       bool
+)
+
+func init() {
+	for _, cmd := range []*Command{cmdEnvPull, cmdEnvPush} {
+		cmd.Flag.StringVar(&flagEnvFile, "f", ".env", "env file")
+		cmd.Flag.StringVar(&flagEnvOnly, "only", "", "var name patterns")
+		cmd.Flag.BoolVar(&flagEnvMerge, "merge", false, "merge with existing vars")
+		cmd.Flag.BoolVar(&flagEnvOverwrite, "overwrite", false, "replace existing vars")
+		cmd.Flag.BoolVar(&flagEnvDryRun, "dry-run", false, "show changes without making them")
+	}
+	cmdEnvPush.Flag.StringVar(&flagEnvFrom, "from", "", "app to copy vars from")
+	cmdEnvPush.Flag.BoolVar(&flagEnvYes, "y", false, "don't ask for confirmation")
+	cmdEnvPush.Flag.BoolVar(&flagEnvYes, "yes", false, "don't ask for confirmation")
+}
+
+func runEnvPull(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 0 || (flagEnvMerge && flagEnvOverwrite) {
+		ctx.printUsage()
+		exit(2)
+	}
+	local, err := readDotenv(flagEnvFile)
+	if err != nil && !os.IsNotExist(err) {
+		printFatal(err.Error())
+	}
+	remote, err := ctx.Client.ConfigVarInfo(appname)
+	must(err)
+	patterns := envPatterns(flagEnvOnly)
+	remote, _ = splitEnv(remote, patterns)
+	local, kept := splitEnv(local, patterns)
+	mustChooseEnvMode(flagEnvFile, local)
+
+	result := remote
+	if flagEnvMerge {
+		result = mergeEnv(local, remote)
+	}
+	if flagEnvDryRun {
+		printEnvDiff(ctx.Stdout, local, result)
+		return
+	}
+	result = mergeEnv(kept, result)
+
+	var buf bytes.Buffer
+	must(writeDotenv(&buf, result))
+	must(ioutil.WriteFile(flagEnvFile, buf.Bytes(), 0600))
+	log.Printf("Wrote %d env vars from %s to %s.", len(result), appname, flagEnvFile)
+}
+
+func runEnvPush(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 0 || (flagEnvMerge && flagEnvOverwrite) {
+		ctx.printUsage()
+		exit(2)
+	}
+	var local map[string]string
+	var err error
+	if flagEnvFrom != "" {
+		local, err = ctx.Client.ConfigVarInfo(flagEnvFrom)
+		must(err)
+	} else if local, err = readDotenv(flagEnvFile); err != nil {
+		printFatal(err.Error())
+	}
+	remote, err := ctx.Client.ConfigVarInfo(appname)
+	must(err)
+	patterns := envPatterns(flagEnvOnly)
+	local, _ = splitEnv(local, patterns)
+	remote, _ = splitEnv(remote, patterns)
+	mustChooseEnvMode(appname, remote)
+
+	result := local
+	if flagEnvMerge {
+		result = mergeEnv(remote, local)
+	}
+	if flagEnvDryRun {
+		printEnvDiff(ctx.Stdout, remote, result)
+		return
+	}
+
+	config := make(map[string]*string)
+	for _, c := range envDiff(remote, result) {
+		if c.Op == '-' {
+			config[c.Name] = nil
+		} else {
+			val := result[c.Name]
+			config[c.Name] = &val
+		}
+	}
+	if len(config) == 0 {
+		log.Printf("No env vars changed on %s.", appname)
+		return
+	}
+	if flagEnvFrom != "" && !flagEnvYes {
+		printEnvDiff(ctx.Stdout, remote, result)
+		mustConfirm(fmt.Sprintf("Apply %d changes to %s?", len(config), appname))
+	}
+	_, err = ctx.Client.ConfigVarUpdate(appname, config)
+	must(err)
+	log.Printf("Set env vars and restarted %s.", appname)
+}
+
+// mustChooseEnvMode exits unless --merge or --overwrite was given, or the
+// destination has no vars to lose.
+func mustChooseEnvMode(dest string, existing map[string]string) {
+	if len(existing) > 0 && !flagEnvMerge && !flagEnvOverwrite {
+		printFatal("%s already has env vars. Use --merge or --overwrite.", dest)
+	}
+}
+
+func printEnvDiff(w io.Writer, old, new map[string]string) {
+	changes := envDiff(old, new)
+	if len(changes) == 0 {
+		log.Println("No env vars would change.")
+	}
+	for _, c := range changes {
+		fmt.Fprintf(w, "%c %s\n", c.Op, c.Name)
+	}
+}
+
+// envPatterns splits a comma-separated list of var name patterns.
+func envPatterns(s string) []string {
+	var patterns []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// splitEnv divides env into the vars whose names match one of patterns
+// and the rest. With no patterns, every var matches.
+func splitEnv(env map[string]string, patterns []string) (in, out map[string]string) {
+	if len(patterns) == 0 {
+		return env, nil
+	}
+	in, out = make(map[string]string), make(map[string]string)
+	for k, v := range env {
+		matched := false
+		for _, p := range patterns {
+			if globMatch(p, k) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			in[k] = v
+		} else {
+			out[k] = v
+		}
+	}
+	return in, out
+}
+
+// mergeEnv returns the vars in base, updated with the vars in overlay.
+func mergeEnv(base, overlay map[string]string) map[string]string {
+	m := make(map[string]string, len(base)+len(overlay))
+	for k, v := range base {
+		m[k] = v
+	}
+	for k, v := range overlay {
+		m[k] = v
+	}
+	return m
+}
+
+// An envChange is a var added (+), removed (-), or changed (~) between two
+// sets of env vars.
+type envChange struct {
+	Op   byte
+	Name string
+}
+
+// envDiff returns the changes that turn old into new, sorted by name.
+func envDiff(old, new map[string]string) []envChange {
+	var changes []envChange
+	for k, v := range new {
+		if ov, ok := old[k]; !ok {
+			changes = append(changes, envChange{'+', k})
+		} else if ov != v {
+			changes = append(changes, envChange{'~', k})
+		}
+	}
+	for k := range old {
+		if _, ok := new[k]; !ok {
+			changes = append(changes, envChange{'-', k})
+		}
+	}
+	sort.Sort(envChangesByName(changes))
+	return changes
+}
+
+type envChangesByName []envChange
+
+func (a envChangesByName) Len() int           { return len(a) }
+func (a envChangesByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a envChangesByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+func readDotenv(name string) (map[string]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	env, err := parseDotenv(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return env, nil
+}
+
+// parseDotenv reads NAME=value lines. Blank lines, # comments, and a leading
+// "export " are ignored. Values may be single quoted (taken literally) or
+// double quoted (with Go escape sequences such as \n).
+func parseDotenv(r io.Reader) (map[string]string, error) {
+	env := make(map[string]string)
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.Index(line, "=")
+		if i < 1 {
+			return nil, fmt.Errorf("line %d: expected NAME=value", n)
+		}
+		name, val := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		switch {
+		case len(val) >= 2 && val[0] == '\'' && val[len(val)-1] == '\'':
+			val = val[1 : len(val)-1]
+		case len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"':
+			uq, err := strconv.Unquote(val)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad quoted value for %s", n, name)
+			}
+			val = uq
+		}
+		env[name] = val
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// writeDotenv writes env sorted by name, quoting values that wouldn't
+// otherwise read back unchanged.
+func writeDotenv(w io.Writer, env map[string]string) error {
+	var names []string
+	for k := range env {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		val := env[k]
+		if val != strings.TrimSpace(val) || strings.ContainsAny(val, "\"'#\\\n\r\t") {
+			val = strconv.Quote(val)
+		}
+		if _, err := fmt.Fprintf(w, "%s=%s\n", k, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package hk
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	in := `
+# comment
+PLAIN=value
+export EXPORTED=1
+SINGLE='a "b" c'
+DOUBLE="line1\nline2"
+EMPTY=
+`
+	want := map[string]string{
+		"PLAIN":    "value",
+		"EXPORTED": "1",
+		"SINGLE":   `a "b" c`,
+		"DOUBLE":   "line1\nline2",
+		"EMPTY":    "",
+	}
+	got, err := parseDotenv(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDotenv => %v, want %v", got, want)
+	}
+
+	if _, err := parseDotenv(strings.NewReader("NOEQUALS\n")); err == nil {
+		t.Errorf("expected error for line without =")
+	}
+}
+
+func TestWriteDotenvRoundTrip(t *testing.T) {
+	env := map[string]string{
+		"A": "plain",
+		"B": " padded ",
+		"C": "multi\nline",
+		"D": `has "quotes" and #hash`,
+	}
+	var buf bytes.Buffer
+	if err := writeDotenv(&buf, env); err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseDotenv(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, env) {
+		t.Errorf("round trip => %v, want %v", got, env)
+	}
+}
+
+func TestEnvDiff(t *testing.T) {
+	old := map[string]string{"A": "1", "B": "2", "C": "3"}
+	new := map[string]string{"A": "1", "B": "20", "D": "4"}
+	want := []envChange{{'~', "B"}, {'-', "C"}, {'+', "D"}}
+	if got := envDiff(old, new); !reflect.DeepEqual(got, want) {
+		t.Errorf("envDiff => %v, want %v", got, want)
+	}
+}
+
+func TestSplitEnv(t *testing.T) {
+	env := map[string]string{
+		"FEATURE_A":    "1",
+		"FEATURE_B":    "0",
+		"DATABASE_URL": "postgres://",
+		"BETA_SEARCH":  "on",
+	}
+	in, out := splitEnv(env, envPatterns("FEATURE_*, BETA_*"))
+	wantIn := map[string]string{"FEATURE_A": "1", "FEATURE_B": "0", "BETA_SEARCH": "on"}
+	wantOut := map[string]string{"DATABASE_URL": "postgres://"}
+	if !reflect.DeepEqual(in, wantIn) || !reflect.DeepEqual(out, wantOut) {
+		t.Errorf("splitEnv = %v, %v, want %v, %v", in, out, wantIn, wantOut)
+	}
+
+	in, out = splitEnv(env, nil)
+	if !reflect.DeepEqual(in, env) || len(out) != 0 {
+		t.Errorf("splitEnv without patterns = %v, %v", in, out)
+	}
+}
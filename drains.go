@@ -1,9 +1,8 @@
-package main
+package hk
 
 import (
 	"fmt"
 	"log"
-	"os"
 	"text/tabwriter"
 
 	"github.com/bgentry/heroku-go"
@@ -11,7 +10,7 @@ import (
 
 var cmdDrains = &Command{
 	Run:      runDrains,
-	Usage:    "drains",
+	Usage:    "drains [-j]",
 	NeedsApp: true,
 	Category: "app",
 	Short:    "list log drains" + extra,
@@ -19,6 +18,10 @@ var cmdDrains = &Command{
 Lists log drains on an app. Shows the drain's ID, as well as its
 Add-on name (if it's from an Add-on) or its URL.
 
+Options:
+
+    -j, --json  print drains as JSON
+
 Example:
 
     $ hk drains
@@ -28,26 +31,33 @@ Example:
 `,
 }
 
-func runDrains(cmd *Command, args []string) {
+func init() {
+	registerList(cmdDrains, heroku.LogDrain{})
+}
+
+func runDrains(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	appname := mustApp()
+	appname := ctx.MustApp()
 
 	// fetch app's addons concurrently in case we need to resolve addon names
 	addonsch := make(chan []heroku.Addon, 1)
 	errch := make(chan error, 1)
 	go func(appname string) {
-		if addons, err := client.AddonList(appname, nil); err != nil {
+		if addons, err := ctx.Client.AddonList(appname, nil); err != nil {
 			errch <- err
 		} else {
 			addonsch <- addons
 		}
 	}(appname)
 
-	drains, err := client.LogDrainList(appname, nil)
+	drains, err := ctx.Client.LogDrainList(appname, nil)
 	must(err)
+	if printList(ctx.Stdout, drains) {
+		return
+	}
 
 	hasAddonDrains := false
 	merged := make([]*mergedLogDrain, len(drains))
@@ -68,7 +78,7 @@ func runDrains(cmd *Command, args []string) {
 		}
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
 	defer w.Flush()
 
 	for _, m := range merged {
@@ -129,19 +139,19 @@ Example:
 `,
 }
 
-func runDrainInfo(cmd *Command, args []string) {
+func runDrainInfo(ctx *Context, args []string) {
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	appname := mustApp()
+	appname := ctx.MustApp()
 	drainIdOrURL := args[0]
-	drain, err := client.LogDrainInfo(appname, drainIdOrURL)
+	drain, err := ctx.Client.LogDrainInfo(appname, drainIdOrURL)
 	must(err)
 
 	addonName := "none"
 	if drain.Addon != nil {
-		addon, err := client.AddonInfo(appname, drain.Addon.Id)
+		addon, err := ctx.Client.AddonInfo(appname, drain.Addon.Id)
 		if err != nil {
 			addonName = "unknown"
 		} else {
@@ -149,10 +159,10 @@ func runDrainInfo(cmd *Command, args []string) {
 		}
 	}
 
-	fmt.Printf("Id:     %s\n", drain.Id)
-	fmt.Printf("Token:  %s\n", drain.Token)
-	fmt.Printf("Addon:  %s\n", addonName)
-	fmt.Printf("URL:    %s\n", drain.URL)
+	fmt.Fprintf(ctx.Stdout, "Id:     %s\n", drain.Id)
+	fmt.Fprintf(ctx.Stdout, "Token:  %s\n", drain.Token)
+	fmt.Fprintf(ctx.Stdout, "Addon:  %s\n", addonName)
+	fmt.Fprintf(ctx.Stdout, "URL:    %s\n", drain.URL)
 }
 
 var cmdDrainAdd = &Command{
@@ -171,16 +181,16 @@ Example:
 `,
 }
 
-func runDrainAdd(cmd *Command, args []string) {
+func runDrainAdd(ctx *Context, args []string) {
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 
 	url := args[0]
-	_, err := client.LogDrainCreate(mustApp(), url)
+	_, err := ctx.Client.LogDrainCreate(ctx.MustApp(), url)
 	must(err)
-	log.Printf("Added log drain to %s.", mustApp())
+	log.Printf("Added log drain to %s.", ctx.MustApp())
 }
 
 var cmdDrainRemove = &Command{
@@ -202,13 +212,13 @@ Example:
 `,
 }
 
-func runDrainRemove(cmd *Command, args []string) {
+func runDrainRemove(ctx *Context, args []string) {
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 
 	drainId := args[0]
-	must(client.LogDrainDelete(mustApp(), drainId))
-	log.Printf("Removed log drain from %s.", mustApp())
+	must(ctx.Client.LogDrainDelete(ctx.MustApp(), drainId))
+	log.Printf("Removed log drain from %s.", ctx.MustApp())
 }
@@ -0,0 +1,144 @@
+package hk
+
+import (
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdDrainsSync = &Command{
+	Run:      runDrainsSync,
+	Usage:    "drains-sync --url <url> --match <pattern> [--dry-run]",
+	Category: "app",
+	Short:    "add a log drain to many apps" + extra,
+	Long: `
+Drains-sync makes sure a log drain exists on every app whose name
+matches a shell pattern, adding it where it's missing. It also
+reports any other URL drains on those apps, so that drains left
+over from an old logging setup can be found and removed with 'hk
+drain-remove'. Add-on drains aren't reported. Apps are updated in
+parallel.
+
+Drains-sync exits with status 1 if the drain couldn't be added to
+any app.
+
+Options:
+
+    --url <url>        the drain URL every matching app should have
+    --match <pattern>  update apps with names matching the pattern
+    --dry-run          show what would change without adding drains
+
+Examples:
+
+    $ hk drains-sync --url syslog://logs.example.com:514 --match 'myapp-prod-*'
+    myapp-prod-api     added
+    myapp-prod-web     ok
+    myapp-prod-worker  ok     extra: syslog://old.example.com:514
+`,
+}
+
+var (
+	flagDrainsSyncURL    string
+	flagDrainsSyncMatch  string
+	flagDrainsSyncDryRun bool
+)
+
+func init() {
+	cmdDrainsSync.Flag.StringVar(&flagDrainsSyncURL, "url", "", "drain URL")
+	cmdDrainsSync.Flag.StringVar(&flagDrainsSyncMatch, "match", "", "app name pattern")
+	cmdDrainsSync.Flag.BoolVar(&flagDrainsSyncDryRun, "dry-run", false, "show what would change")
+}
+
+// drainsSyncParallelism is how many apps drains-sync updates at once.
+const drainsSyncParallelism = 8
+
+// A drainSyncResult is what drains-sync did to one app.
+type drainSyncResult struct {
+	Status string // "ok", "added", "missing" (with --dry-run), or "error"
+	Extras []string
+	Err    error
+}
+
+func runDrainsSync(ctx *Context, args []string) {
+	if len(args) != 0 || flagDrainsSyncURL == "" || flagDrainsSyncMatch == "" {
+		ctx.printUsage()
+		exit(2)
+	}
+	appnames, err := matchingAppNames(flagDrainsSyncMatch)
+	if err != nil {
+		printFatal(err.Error())
+	}
+	if len(appnames) == 0 {
+		printFatal("no apps match %s", flagDrainsSyncMatch)
+	}
+
+	results := make([]drainSyncResult, len(appnames))
+	sem := make(chan bool, drainsSyncParallelism)
+	var wg sync.WaitGroup
+	for i, appname := range appnames {
+		wg.Add(1)
+		go func(i int, appname string) {
+			defer wg.Done()
+			sem <- true
+			defer func() { <-sem }()
+			results[i] = syncDrain(appname, flagDrainsSyncURL, flagDrainsSyncDryRun)
+		}(i, appname)
+	}
+	wg.Wait()
+
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	failed := false
+	for i, r := range results {
+		if r.Err != nil {
+			failed = true
+			listRec(w, appnames[i], r.Status, r.Err)
+			continue
+		}
+		var extras string
+		if len(r.Extras) > 0 {
+			extras = "extra: " + strings.Join(r.Extras, ", ")
+		}
+		listRec(w, appnames[i], r.Status, extras)
+	}
+	w.Flush()
+	if failed {
+		exit(1)
+	}
+}
+
+// syncDrain adds a drain for url to an app unless it already has one.
+func syncDrain(appname, url string, dryRun bool) drainSyncResult {
+	drains, err := client.LogDrainList(appname, nil)
+	if err != nil {
+		return drainSyncResult{Status: "error", Err: err}
+	}
+	present, extras := drainSyncPlan(drains, url)
+	r := drainSyncResult{Status: "ok", Extras: extras}
+	switch {
+	case present:
+	case dryRun:
+		r.Status = "missing"
+	default:
+		if _, err := client.LogDrainCreate(appname, url); err != nil {
+			return drainSyncResult{Status: "error", Extras: extras, Err: err}
+		}
+		r.Status = "added"
+	}
+	return r
+}
+
+// drainSyncPlan reports whether drains include one for url, and returns
+// the URLs of the other drains that don't belong to add-ons.
+func drainSyncPlan(drains []heroku.LogDrain, url string) (present bool, extras []string) {
+	for _, d := range drains {
+		switch {
+		case d.URL == url:
+			present = true
+		case d.Addon == nil:
+			extras = append(extras, d.URL)
+		}
+	}
+	return present, extras
+}
@@ -0,0 +1,36 @@
+package hk
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bgentry/heroku-go"
+)
+
+func TestDrainSyncPlan(t *testing.T) {
+	addonDrain := heroku.LogDrain{URL: "https://addon.example.com/drain"}
+	addonDrain.Addon = &struct {
+		Id string `json:"id"`
+	}{"01234567-89ab-cdef-0123-456789abcdef"}
+	drains := []heroku.LogDrain{
+		{URL: "syslog://old.example.com:514"},
+		addonDrain,
+		{URL: "syslog://logs.example.com:514"},
+	}
+
+	present, extras := drainSyncPlan(drains, "syslog://logs.example.com:514")
+	if !present {
+		t.Errorf("present = false, want true")
+	}
+	if want := []string{"syslog://old.example.com:514"}; !reflect.DeepEqual(extras, want) {
+		t.Errorf("extras = %v, want %v", extras, want)
+	}
+
+	present, extras = drainSyncPlan(drains, "syslog://new.example.com:514")
+	if present {
+		t.Errorf("present = true, want false")
+	}
+	if len(extras) != 2 {
+		t.Errorf("extras = %v, want 2 URL drains", extras)
+	}
+}
@@ -1,7 +1,8 @@
-package main
+package hk
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"sort"
@@ -11,17 +12,37 @@ import (
 	"time"
 
 	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/term"
+	"github.com/mgutz/ansi"
 )
 
 var cmdDynos = &Command{
 	Run:      runDynos,
-	Usage:    "dynos [<name>...]",
+	Usage:    "dynos [-j | --watch [--interval <duration>]] [--state <state>] [<name>...]",
 	NeedsApp: true,
 	Category: "dyno",
 	Short:    "list dynos",
 	Long: `
 Lists dynos. Shows the name, size, state, age, and command.
 
+Dynos are fetched a page at a time, so all of the dynos of apps
+with very large formations are listed, and each process type's
+dynos are printed as soon as they've all been fetched.
+
+Options:
+
+    -j, --json       print dynos as JSON, including the dyno id,
+                     release, creation time, and whether an attach
+                     URL is available
+    --state <state>  only show dynos in the given state, or any of
+                     a comma-separated list of states (e.g. up,
+                     crashed, starting)
+    --watch          redraw the list every interval until interrupted,
+                     highlighting dynos whose state changed since the
+                     last refresh
+    --interval <duration>
+                     time between refreshes with --watch (default 3s)
+
 Examples:
 
     $ hk dynos
@@ -32,46 +53,184 @@ Examples:
     $ hk dynos web
     web.1     1X  up  15h  "blog /app /tmp/dst"
     web.2     1X  up   8h  "blog /app /tmp/dst"
+
+    $ hk dynos --state crashed,starting web worker
+    web.7     1X  crashed   2m  "blog /app /tmp/dst"
+    worker.3  1X  starting  5s  "bin/worker"
+
+    $ hk dynos --watch --interval 5s web
+    Every 5s: dynos on myapp, 18:31:02
+
+    web.1  1X  up        15h  "blog /app /tmp/dst"
+    web.2  1X  starting   2s  "blog /app /tmp/dst"
+
+    $ hk dynos --json web.1
+    [
+      {
+        "id": "01234567-89ab-cdef-0123-456789abcdef",
+        "name": "web.1",
+        ...
+      }
+    ]
 `,
 }
 
-func runDynos(cmd *Command, names []string) {
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
-	defer w.Flush()
+func init() {
+	registerList(cmdDynos, dynoJSON{})
+}
+
+var (
+	flagDynosState    string
+	flagDynosWatch    bool
+	flagDynosInterval time.Duration
+)
+
+func init() {
+	cmdDynos.Flag.StringVar(&flagDynosState, "state", "", "only show dynos in these states")
+	cmdDynos.Flag.BoolVar(&flagDynosWatch, "watch", false, "redraw the list until interrupted")
+	cmdDynos.Flag.DurationVar(&flagDynosInterval, "interval", 3*time.Second, "time between refreshes")
+}
+
+func runDynos(ctx *Context, names []string) {
+	appname := ctx.MustApp()
+	var states []string
+	if flagDynosState != "" {
+		states = strings.Split(flagDynosState, ",")
+	}
+	filter := dynoFilter{names, states}
 
-	if len(names) > 1 {
-		cmd.printUsage()
-		os.Exit(2)
+	if flagDynosWatch {
+		if flagJSON || flagDynosInterval <= 0 {
+			ctx.printUsage()
+			exit(2)
+		}
+		watchDynos(ctx.Stdout, appname, filter, flagDynosInterval)
+		return
+	}
+	if listPrinter.Active() {
+		dynos, err := listDynos(appname, filter)
+		must(err)
+		printList(ctx.Stdout, dynosJSON(dynos))
+		return
 	}
-	listDynos(w, names)
+
+	// each process type is printed as soon as it's fetched
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	must(eachDynoType(appname, filter, func(dynos []heroku.Dyno) {
+		for i := range dynos {
+			listDyno(w, &dynos[i])
+		}
+		w.Flush()
+	}))
 }
 
-func listDynos(w io.Writer, names []string) {
-	appname := mustApp()
-	dynos, err := client.DynoList(appname, nil)
+// findDynos returns the app's dynos sorted by name. If names is non-empty,
+// only dynos matching one of the given names or process types are returned.
+func findDynos(appname string, names []string) []heroku.Dyno {
+	dynos, err := listDynos(appname, dynoFilter{names: names})
 	must(err)
-	sort.Sort(DynosByName(dynos))
+	return dynos
+}
 
-	if len(names) == 0 {
-		for _, d := range dynos {
-			listDyno(w, &d)
+// the number of dynos fetched per API request
+const dynoPageSize = 200
+
+// listDynos returns the app's dynos matching filter, sorted by process
+// type in the API's order and then by DynosByName.
+func listDynos(appname string, filter dynoFilter) ([]heroku.Dyno, error) {
+	var all []heroku.Dyno
+	err := eachDynoType(appname, filter, func(dynos []heroku.Dyno) {
+		all = append(all, dynos...)
+	})
+	return all, err
+}
+
+// eachDynoType fetches the app's dynos a page at a time, and calls fn with
+// those matching filter a process type at a time, sorted by DynosByName.
+// Pages come in order of name, so each type's dynos are together, and fn
+// is called as soon as the dynos of the next type start arriving rather
+// than after every page is fetched.
+func eachDynoType(appname string, filter dynoFilter, fn func([]heroku.Dyno)) error {
+	var typ []heroku.Dyno
+	flush := func() {
+		if len(typ) > 0 {
+			sort.Sort(DynosByName(typ))
+			fn(typ)
+			typ = nil
 		}
-		return
 	}
+	lr := &heroku.ListRange{Field: "name", Max: dynoPageSize}
+	for {
+		dynos, err := client.DynoList(appname, lr)
+		if err != nil {
+			return err
+		}
+		n := len(dynos)
+		if lr.FirstId != "" && n > 0 && dynos[0].Name == lr.FirstId {
+			// ranges are inclusive, so skip the last dyno of the prior page
+			dynos = dynos[1:]
+		}
+		for _, d := range filter.apply(dynos) {
+			if len(typ) > 0 && typ[0].Type != d.Type {
+				flush()
+			}
+			typ = append(typ, d)
+		}
+		if n < dynoPageSize || len(dynos) == 0 {
+			break
+		}
+		lr.FirstId = lastDynoName(dynos)
+	}
+	flush()
+	return nil
+}
 
-	for _, name := range names {
-		for _, d := range dynos {
-			if !strings.Contains(name, ".") {
-				if strings.HasPrefix(d.Name, name+".") {
-					listDyno(w, &d)
-				}
-			} else {
-				if d.Name == name {
-					listDyno(w, &d)
-				}
+// lastDynoName returns the name that sorts last in the API's name order,
+// which is lexical rather than DynosByName.
+func lastDynoName(dynos []heroku.Dyno) string {
+	last := ""
+	for _, d := range dynos {
+		if d.Name > last {
+			last = d.Name
+		}
+	}
+	return last
+}
+
+// dynoFilter matches dynos by name or process type and by state. Empty
+// fields match all dynos.
+type dynoFilter struct {
+	names  []string
+	states []string
+}
+
+func (f dynoFilter) match(d *heroku.Dyno) bool {
+	if len(f.states) > 0 && stringsIndex(f.states, d.State) == -1 {
+		return false
+	}
+	if len(f.names) == 0 {
+		return true
+	}
+	for _, name := range f.names {
+		if !strings.Contains(name, ".") {
+			if strings.HasPrefix(d.Name, name+".") {
+				return true
 			}
+		} else if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (f dynoFilter) apply(dynos []heroku.Dyno) []heroku.Dyno {
+	var matched []heroku.Dyno
+	for i := range dynos {
+		if f.match(&dynos[i]) {
+			matched = append(matched, dynos[i])
 		}
 	}
+	return matched
 }
 
 func listDyno(w io.Writer, d *heroku.Dyno) {
@@ -84,6 +243,113 @@ func listDyno(w io.Writer, d *heroku.Dyno) {
 	)
 }
 
+// watchDynos redraws the dynos matching filter every interval, forever.
+// Errors fetching the dynos are printed, and the next refresh tries again.
+func watchDynos(w io.Writer, appname string, filter dynoFilter, interval time.Duration) {
+	f, ok := w.(*os.File)
+	clear := ok && term.IsTerminal(f) && !accessibleOutput
+	var last map[string]string
+	for {
+		dynos, err := listDynos(appname, filter)
+		if err != nil {
+			printError(err.Error())
+			time.Sleep(interval)
+			continue
+		}
+		if clear {
+			term.ClearScreen(f)
+		} else if last != nil {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Every %s: dynos on %s, %s\n\n", interval, appname, time.Now().Format("15:04:05"))
+		printWatchedDynos(w, dynos, last)
+		last = make(map[string]string, len(dynos))
+		for _, d := range dynos {
+			last[d.Name] = d.State
+		}
+		time.Sleep(interval)
+	}
+}
+
+// dynoStateColors are the colors of dyno states that changed since the
+// last refresh of dynos --watch.
+var dynoStateColors = map[string]string{
+	"up":       "green",
+	"starting": "yellow",
+	"crashed":  "red",
+}
+
+// printWatchedDynos prints dynos like listDyno, highlighting the state of
+// those whose state differs from the one in last. If last is nil, nothing
+// is highlighted. Columns are aligned by hand, since tabwriter would
+// count the color codes.
+func printWatchedDynos(w io.Writer, dynos []heroku.Dyno, last map[string]string) {
+	rows := make([][]string, len(dynos))
+	changed := make([]bool, len(dynos))
+	var widths [4]int
+	for i := range dynos {
+		d := &dynos[i]
+		prev, seen := last[d.Name]
+		changed[i] = last != nil && (!seen || prev != d.State)
+		state := d.State
+		if changed[i] && accessibleOutput {
+			state += " (changed)"
+		}
+		rows[i] = []string{d.Name, d.Size, state, prettyDuration{dynoAge(d)}.String(), maybeQuote(d.Command)}
+		for j := range widths {
+			if len(rows[i][j]) > widths[j] {
+				widths[j] = len(rows[i][j])
+			}
+		}
+	}
+	for i, row := range rows {
+		for j := range widths {
+			pad := strings.Repeat(" ", widths[j]-len(row[j]))
+			if color, ok := dynoStateColors[row[j]]; ok && j == 2 && changed[i] {
+				fmt.Fprint(w, ansi.Color(row[j], color+"+b"), pad, "  ")
+			} else {
+				fmt.Fprint(w, row[j], pad, "  ")
+			}
+		}
+		fmt.Fprintln(w, row[4])
+	}
+}
+
+type dynoJSON struct {
+	Id         string    `json:"id"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Size       string    `json:"size"`
+	State      string    `json:"state"`
+	Command    string    `json:"command"`
+	Attachable bool      `json:"attachable"`
+	Release    string    `json:"release_id"`
+	Version    int       `json:"release_version"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// dynosJSON returns the JSON form of dynos.
+func dynosJSON(dynos []heroku.Dyno) []dynoJSON {
+	out := make([]dynoJSON, len(dynos))
+	for i, d := range dynos {
+		out[i] = dynoJSON{
+			Id:         d.Id,
+			Name:       d.Name,
+			Type:       d.Type,
+			Size:       d.Size,
+			State:      d.State,
+			Command:    d.Command,
+			Attachable: d.AttachURL != nil && *d.AttachURL != "",
+			Release:    d.Release.Id,
+			Version:    d.Release.Version,
+			CreatedAt:  d.CreatedAt,
+			UpdatedAt:  d.UpdatedAt,
+		}
+	}
+	return out
+}
+
 // quotes s as a json string if it contains any weird chars
 // currently weird is anything other than [alnum]_-
 func maybeQuote(s string) string {
@@ -0,0 +1,142 @@
+package hk
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var dynoFilterTests = []struct {
+	filter dynoFilter
+	want   []string
+}{
+	{dynoFilter{}, []string{"run.1", "web.1", "web.2", "worker.1"}},
+	{dynoFilter{names: []string{"web"}}, []string{"web.1", "web.2"}},
+	{dynoFilter{names: []string{"web.2", "run"}}, []string{"run.1", "web.2"}},
+	{dynoFilter{states: []string{"crashed"}}, []string{"web.2"}},
+	{dynoFilter{states: []string{"up", "starting"}}, []string{"run.1", "web.1", "worker.1"}},
+	{dynoFilter{names: []string{"web"}, states: []string{"up"}}, []string{"web.1"}},
+	{dynoFilter{names: []string{"we"}}, nil},
+}
+
+func TestDynoFilter(t *testing.T) {
+	dynos := []heroku.Dyno{
+		{Name: "run.1", State: "up"},
+		{Name: "web.1", State: "up"},
+		{Name: "web.2", State: "crashed"},
+		{Name: "worker.1", State: "starting"},
+	}
+	for i, ft := range dynoFilterTests {
+		var got []string
+		for _, d := range ft.filter.apply(dynos) {
+			got = append(got, d.Name)
+		}
+		if len(got) != len(ft.want) {
+			t.Errorf("%d. got %v, want %v", i, got, ft.want)
+			continue
+		}
+		for j := range got {
+			if got[j] != ft.want[j] {
+				t.Errorf("%d. got %v, want %v", i, got, ft.want)
+				break
+			}
+		}
+	}
+}
+
+// fakeDynoList is an API whose DynoList pages through names in the
+// API's lexical name order.
+type fakeDynoList struct {
+	API
+	names []string
+	calls int
+}
+
+func (f *fakeDynoList) DynoList(appIdentity string, lr *heroku.ListRange) ([]heroku.Dyno, error) {
+	f.calls++
+	sort.Strings(f.names)
+	var dynos []heroku.Dyno
+	for _, name := range f.names {
+		if name >= lr.FirstId && len(dynos) < lr.Max {
+			typ := strings.Split(name, ".")[0]
+			dynos = append(dynos, heroku.Dyno{Name: name, Type: typ})
+		}
+	}
+	return dynos, nil
+}
+
+func TestListDynosSortsAcrossPages(t *testing.T) {
+	defer func(c API) { client = c }(client)
+	fake := &fakeDynoList{}
+	for i := 1; i <= dynoPageSize+50; i++ {
+		fake.names = append(fake.names, "web."+strconv.Itoa(i))
+	}
+	client = fake
+	dynos, err := listDynos("myapp", dynoFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dynos) != len(fake.names) {
+		t.Fatalf("got %d dynos, want %d", len(dynos), len(fake.names))
+	}
+	for i, d := range dynos {
+		if want := "web." + strconv.Itoa(i+1); d.Name != want {
+			t.Fatalf("%d. got %s, want %s", i, d.Name, want)
+		}
+	}
+}
+
+func TestPrintWatchedDynos(t *testing.T) {
+	defer func(a bool) { accessibleOutput = a }(accessibleOutput)
+	accessibleOutput = true
+	now := time.Now()
+	dynos := []heroku.Dyno{
+		{Name: "web.1", Size: "1X", State: "up", Command: "bin/web", UpdatedAt: now},
+		{Name: "web.2", Size: "1X", State: "crashed", Command: "bin/web", UpdatedAt: now},
+	}
+	var buf bytes.Buffer
+	printWatchedDynos(&buf, dynos, map[string]string{"web.1": "up", "web.2": "up"})
+	want := "" +
+		"web.1  1X  up                  0s  \"bin/web\"\n" +
+		"web.2  1X  crashed (changed)   0s  \"bin/web\"\n"
+	if buf.String() != want {
+		t.Errorf("printWatchedDynos =>\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestEachDynoTypeStreams(t *testing.T) {
+	defer func(c API) { client = c }(client)
+	fake := &fakeDynoList{}
+	for i := 1; i <= dynoPageSize+50; i++ {
+		fake.names = append(fake.names, "web."+strconv.Itoa(i))
+	}
+	for i := 1; i <= dynoPageSize+50; i++ {
+		fake.names = append(fake.names, "worker."+strconv.Itoa(i))
+	}
+	client = fake
+
+	var types []string
+	var calls []int
+	err := eachDynoType("myapp", dynoFilter{}, func(dynos []heroku.Dyno) {
+		types = append(types, dynos[0].Type)
+		calls = append(calls, fake.calls)
+		if last := dynos[len(dynos)-1].Name; last != dynos[0].Type+"."+strconv.Itoa(dynoPageSize+50) {
+			t.Errorf("%s dynos end with %s, want them sorted", dynos[0].Type, last)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(types, " ") != "web worker" {
+		t.Fatalf("types => %v, want [web worker]", types)
+	}
+	// web is done once the second page, which starts worker, is fetched
+	if calls[0] != 2 || calls[1] != 3 {
+		t.Errorf("types passed after %v pages, want [2 3]", calls)
+	}
+}
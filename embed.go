@@ -0,0 +1,88 @@
+// Package hk is the hk command line client for Heroku. Main runs the hk
+// command. Run runs hk commands from other programs, such as chat bots or
+// deploy tools, with their output captured, and list commands' results
+// passed back as values:
+//
+//	var out bytes.Buffer
+//	var releases []*hk.Release
+//	status := hk.Run([]string{"releases", "-a", "myapp"}, hk.Options{
+//		Stdout:   &out,
+//		Password: token,
+//		Result:   func(v interface{}) { releases = v.([]*hk.Release) },
+//	})
+package hk
+
+import (
+	"io"
+	"log"
+	"os"
+)
+
+// exit ends hk with the given status. It is os.Exit, except during
+// Run, where it ends only the embedded run.
+var exit = os.Exit
+
+// An exitCode is the panic value exit uses to unwind an embedded run.
+type exitCode int
+
+// Options configure a Run of an hk command.
+type Options struct {
+	// Stdout receives the command's output, and Stderr its usage, log
+	// messages, and errors. Progress shown while waiting, such as
+	// spinners, still goes to os.Stderr.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Username and Password, if set, are used instead of .netrc and
+	// the current profile.
+	Username string
+	Password string
+
+	// Client, if set, is used for Heroku API calls instead of the
+	// client hk sets up, for example to run commands against a fake.
+	Client API
+
+	// Result, if set, is given the slice that a list command, such as
+	// apps or releases, would otherwise print. Its element type is the
+	// one the command registered with the output package; see
+	// output.Type.
+	Result func(v interface{})
+}
+
+// Run runs an hk command line (without the leading "hk") in this
+// process, and returns its exit status. Flags are reset before each run,
+// but runs must not overlap, since commands share flag and client globals.
+// Plugins can't be run this way.
+func Run(args []string, opts Options) (status int) {
+	if opts.Stdout == nil {
+		opts.Stdout = os.Stdout
+	}
+	if opts.Stderr == nil {
+		opts.Stderr = os.Stderr
+	}
+	log.SetOutput(opts.Stderr)
+	exit = func(code int) { panic(exitCode(code)) }
+	defer func() {
+		log.SetOutput(os.Stderr)
+		exit = os.Exit
+		if r := recover(); r != nil {
+			code, ok := r.(exitCode)
+			if !ok {
+				panic(r)
+			}
+			status = int(code)
+		}
+	}()
+
+	if len(args) < 1 {
+		printUsageTo(opts.Stderr)
+		return 2
+	}
+	initNetwork()
+	setup(opts)
+	if !dispatch(args, opts) {
+		printError("unknown command: %s", args[0])
+		return 2
+	}
+	return 0
+}
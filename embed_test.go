@@ -0,0 +1,105 @@
+package hk
+
+import (
+	"bytes"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bgentry/heroku-go"
+)
+
+func TestRunEmbedded(t *testing.T) {
+	var gotUser, gotPass string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotPass, _ = r.BasicAuth()
+		if r.URL.Path != "/apps/myapp/config-vars" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"FOO": "bar"}`))
+	}))
+	defer ts.Close()
+	os.Setenv("HEROKU_API_URL", ts.URL)
+	defer os.Setenv("HEROKU_API_URL", "")
+
+	var stdout, stderr bytes.Buffer
+	opts := Options{Stdout: &stdout, Stderr: &stderr, Username: "bot", Password: "token"}
+	if status := Run([]string{"get", "-a", "myapp", "FOO"}, opts); status != 0 {
+		t.Fatalf("status = %d, stderr = %q", status, stderr.String())
+	}
+	if stdout.String() != "bar\n" {
+		t.Errorf("stdout = %q, want %q", stdout.String(), "bar\n")
+	}
+	if gotUser != "bot" || gotPass != "token" {
+		t.Errorf("credentials = %q:%q, want bot:token", gotUser, gotPass)
+	}
+
+	// a missing var exits with status 1 without ending the process
+	stdout.Reset()
+	if status := Run([]string{"get", "-a", "myapp", "MISSING"}, opts); status != 1 {
+		t.Errorf("status = %d, want 1", status)
+	}
+	if !strings.Contains(stderr.String(), "No such key") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+
+	if status := Run([]string{"no-such-command"}, opts); status != 2 {
+		t.Errorf("status for unknown command = %d, want 2", status)
+	}
+}
+
+// fakeKeys is an API whose KeyList returns keys.
+type fakeKeys struct {
+	API
+	keys []heroku.Key
+}
+
+func (f fakeKeys) KeyList(lr *heroku.ListRange) ([]heroku.Key, error) {
+	return f.keys, nil
+}
+
+func TestRunResult(t *testing.T) {
+	keys := []heroku.Key{{Email: "a@example.com"}, {Email: "b@example.com"}}
+	var stdout, stderr bytes.Buffer
+	var got interface{}
+	opts := Options{
+		Stdout:   &stdout,
+		Stderr:   &stderr,
+		Password: "token",
+		Client:   fakeKeys{keys: keys},
+		Result:   func(v interface{}) { got = v },
+	}
+	if status := Run([]string{"keys"}, opts); status != 0 {
+		t.Fatalf("status = %d, stderr = %q", status, stderr.String())
+	}
+	list, ok := got.([]heroku.Key)
+	if !ok || len(list) != 2 || list[1].Email != "b@example.com" {
+		t.Errorf("result = %#v, want the fake's keys", got)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("stdout = %q, want nothing printed", stdout.String())
+	}
+}
+
+func TestResetFlags(t *testing.T) {
+	var n int
+	var watch bool
+	cmd := &Command{Usage: "test"}
+	cmd.Flag.IntVar(&n, "n", 10, "count")
+	cmd.Flag.BoolVar(&watch, "watch", false, "watch")
+	if err := cmd.Flag.Parse([]string{"-n", "3", "--watch"}); err != nil {
+		t.Fatal(err)
+	}
+	flagApp = "myapp"
+	resetFlags(cmd)
+	if n != 10 || watch || flagApp != "" {
+		t.Errorf("after resetFlags, n = %d, watch = %v, flagApp = %q, want 10, false, \"\"", n, watch, flagApp)
+	}
+	cmd.Flag.Visit(func(f *flag.Flag) {
+		t.Errorf("after resetFlags, -%s is still set", f.Name)
+	})
+}
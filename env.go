@@ -1,9 +1,8 @@
-package main
+package hk
 
 import (
 	"fmt"
 	"log"
-	"os"
 	"sort"
 	"strings"
 )
@@ -17,12 +16,12 @@ var cmdEnv = &Command{
 	Long:     `Show all env vars.`,
 }
 
-func runEnv(cmd *Command, args []string) {
+func runEnv(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	config, err := client.ConfigVarInfo(mustApp())
+	config, err := ctx.Client.ConfigVarInfo(ctx.MustApp())
 	must(err)
 	var configKeys []string
 	for k := range config {
@@ -30,7 +29,7 @@ func runEnv(cmd *Command, args []string) {
 	}
 	sort.Strings(configKeys)
 	for _, k := range configKeys {
-		fmt.Printf("%s=%s\n", k, config[k])
+		fmt.Fprintf(ctx.Stdout, "%s=%s\n", k, config[k])
 	}
 }
 
@@ -50,41 +49,61 @@ Example:
 `,
 }
 
-func runGet(cmd *Command, args []string) {
+func runGet(ctx *Context, args []string) {
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	config, err := client.ConfigVarInfo(mustApp())
+	config, err := ctx.Client.ConfigVarInfo(ctx.MustApp())
 	must(err)
 	value, found := config[args[0]]
 	if !found {
 		printFatal("No such key as '%s'", args[0])
 	}
-	fmt.Println(value)
+	fmt.Fprintln(ctx.Stdout, value)
 }
 
 var cmdSet = &Command{
 	Run:      runSet,
-	Usage:    "set <name>=<value>...",
+	Usage:    "set [--no-wait] [--preboot-aware [--timeout <duration>]] <name>=<value>...",
 	NeedsApp: true,
 	Category: "config",
 	Short:    "set env var",
 	Long: `
 Set the value of an env var.
 
-Example:
+If the app has a release phase, its output is shown as it runs, and
+set exits non-zero if the release fails.
+
+With --preboot-aware, set also waits until every web dyno runs the
+new release and is up, so the change is live when set returns. When
+the app has preboot enabled, this includes waiting for the old dynos
+to be replaced after the new ones are up.
+
+Options:
+
+    --no-wait             don't show release phase output or wait for
+                          the release to finish
+    --preboot-aware       wait until web dynos serve the new release
+    --timeout <duration>  time to wait for web dynos to serve the new
+                          release (default 10m)
+
+Examples:
 
     $ hk set BUILDPACK_URL=http://github.com/kr/heroku-buildpack-inline.git
     Set env vars and restarted myapp.
+
+    $ hk set --preboot-aware RACK_ENV=production
+    Waiting for web dynos to serve v124...
+    Set env vars on myapp, web dynos are serving v124.
 `,
 }
 
-func runSet(cmd *Command, args []string) {
-	appname := mustApp()
+func runSet(ctx *Context, args []string) {
+	appname := ctx.MustApp()
 	if len(args) == 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	config := make(map[string]*string)
 	for _, arg := range args {
@@ -95,20 +114,42 @@ func runSet(cmd *Command, args []string) {
 		val := arg[i+1:]
 		config[arg[:i]] = &val
 	}
-	_, err := client.ConfigVarUpdate(appname, config)
+	if flagSetPrebootAware && flagReleaseNoWait {
+		printFatal("--preboot-aware and --no-wait can't be used together")
+	}
+	_, err := ctx.Client.ConfigVarUpdate(appname, config)
 	must(err)
+	waitLatestRelease(ctx.Stdout, appname, 0)
+	if flagSetPrebootAware {
+		preboot, err := prebootEnabled(appname)
+		must(err)
+		if !preboot {
+			printWarning("preboot isn't enabled on %s, so web dynos restart without overlap.", appname)
+		}
+		version, err := waitWebDynosLive(appname, flagSetTimeout)
+		if err != nil {
+			printFatal("%s.", err)
+		}
+		log.Printf("Set env vars on %s, web dynos are serving v%d.", appname, version)
+		return
+	}
 	log.Printf("Set env vars and restarted " + appname + ".")
 }
 
 var cmdUnset = &Command{
 	Run:      runUnset,
-	Usage:    "unset <name>...",
+	Usage:    "unset [--no-wait] <name>...",
 	NeedsApp: true,
 	Category: "config",
 	Short:    "unset env var",
 	Long: `
 Unset an env var.
 
+Options:
+
+    --no-wait  don't show release phase output or wait for the
+               release to finish
+
 Example:
 
     $ hk unset BUILDPACK_URL
@@ -116,17 +157,18 @@ Example:
 `,
 }
 
-func runUnset(cmd *Command, args []string) {
-	appname := mustApp()
+func runUnset(ctx *Context, args []string) {
+	appname := ctx.MustApp()
 	if len(args) == 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	config := make(map[string]*string)
 	for _, key := range args {
 		config[key] = nil
 	}
-	_, err := client.ConfigVarUpdate(appname, config)
+	_, err := ctx.Client.ConfigVarUpdate(appname, config)
 	must(err)
+	waitLatestRelease(ctx.Stdout, appname, 0)
 	log.Printf("Unset env vars and restarted %s.", appname)
 }
@@ -0,0 +1,183 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/heroku/hk/term"
+)
+
+var cmdEnvTemplate = &Command{
+	Run:      runEnvTemplate,
+	Usage:    "env-template render [--parent <app>] [--dry-run] [-y] <file>",
+	NeedsApp: true,
+	Category: "config",
+	Short:    "set env vars from a template" + extra,
+	Long: `
+Env-template render sets an app's env vars from a template, so that
+review apps and other per-branch apps get config derived from the
+app they were made from. The template is a .env file whose values
+may contain placeholders:
+
+    {{app_name}}     the name of the app being set up
+    {{app_url}}      its web URL, e.g. https://myapp-pr-42.herokuapp.com/
+    {{parent_name}}  the name of the app given by --parent
+    {{parent.NAME}}  the value of env var NAME on the parent app
+
+Vars in the template are set on the app; other vars are left alone.
+Env-template shows the changes and, at a terminal, asks for
+confirmation before making them unless --yes is given. A template
+that uses an unknown placeholder, or a parent var that isn't set, is
+an error, and nothing is changed.
+
+Options:
+
+    --parent <app>  app to read {{parent.NAME}} vars from
+    --dry-run       show the rendered vars without setting them
+    -y, --yes       don't ask for confirmation
+
+Examples:
+
+    $ cat review.env
+    APP_HOST={{app_name}}.herokuapp.com
+    ASSET_URL={{app_url}}assets
+    S3_BUCKET={{parent.S3_BUCKET}}
+    SENTRY_ENVIRONMENT=review-{{app_name}}
+
+    $ hk env-template render -a myapp-pr-42 --parent myapp-staging review.env
+    + APP_HOST
+    + ASSET_URL
+    + S3_BUCKET
+    + SENTRY_ENVIRONMENT
+    Apply 4 changes to myapp-pr-42? [y/N] y
+    Set env vars and restarted myapp-pr-42.
+
+    $ hk env-template render -a myapp-pr-42 --parent myapp-staging --dry-run review.env
+    APP_HOST=myapp-pr-42.herokuapp.com
+    ASSET_URL=https://myapp-pr-42.herokuapp.com/assets
+    S3_BUCKET=myapp-staging-assets
+    SENTRY_ENVIRONMENT=review-myapp-pr-42
+`,
+}
+
+var (
+	flagEnvTemplateParent string
+	flagEnvTemplateDryRun bool
+	flagEnvTemplateYes    bool
+)
+
+func init() {
+	cmdEnvTemplate.Flag.StringVar(&flagEnvTemplateParent, "parent", "", "app to read parent vars from")
+	cmdEnvTemplate.Flag.BoolVar(&flagEnvTemplateDryRun, "dry-run", false, "show the rendered vars without setting them")
+	cmdEnvTemplate.Flag.BoolVar(&flagEnvTemplateYes, "y", false, "don't ask for confirmation")
+	cmdEnvTemplate.Flag.BoolVar(&flagEnvTemplateYes, "yes", false, "don't ask for confirmation")
+}
+
+func runEnvTemplate(ctx *Context, args []string) {
+	if len(args) != 2 || args[0] != "render" {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	tmpl, err := readDotenv(args[1])
+	if err != nil {
+		printFatal(err.Error())
+	}
+	app, err := ctx.Client.AppInfo(appname)
+	must(err)
+	data := envTemplateData{AppName: app.Name, AppURL: app.WebURL}
+	if flagEnvTemplateParent != "" {
+		data.ParentName = flagEnvTemplateParent
+		data.Parent, err = ctx.Client.ConfigVarInfo(flagEnvTemplateParent)
+		must(err)
+	}
+	env, err := renderEnvTemplate(tmpl, data)
+	if err != nil {
+		printFatal("%s: %s", args[1], err)
+	}
+	if flagEnvTemplateDryRun {
+		must(writeDotenv(ctx.Stdout, env))
+		return
+	}
+
+	remote, err := ctx.Client.ConfigVarInfo(appname)
+	must(err)
+	config := make(map[string]*string)
+	for _, c := range envDiff(remote, mergeEnv(remote, env)) {
+		val := env[c.Name]
+		config[c.Name] = &val
+	}
+	if len(config) == 0 {
+		log.Printf("No env vars changed on %s.", appname)
+		return
+	}
+	printEnvDiff(ctx.Stdout, remote, mergeEnv(remote, env))
+	if !flagEnvTemplateYes && term.IsTerminal(os.Stdin) {
+		mustConfirm(fmt.Sprintf("Apply %d changes to %s?", len(config), appname))
+	}
+	_, err = ctx.Client.ConfigVarUpdate(appname, config)
+	must(err)
+	log.Printf("Set env vars and restarted %s.", appname)
+}
+
+// envTemplateData holds the values an env template's placeholders can
+// refer to.
+type envTemplateData struct {
+	AppName    string
+	AppURL     string
+	ParentName string
+	Parent     map[string]string // nil if there is no parent app
+}
+
+var envPlaceholderRE = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.]+)\s*\}\}`)
+
+// renderEnvTemplate replaces the placeholders in the values of tmpl. It
+// returns an error naming every placeholder it couldn't fill.
+func renderEnvTemplate(tmpl map[string]string, data envTemplateData) (map[string]string, error) {
+	env := make(map[string]string, len(tmpl))
+	var bad []string
+	for name, val := range tmpl {
+		env[name] = envPlaceholderRE.ReplaceAllStringFunc(val, func(m string) string {
+			key := envPlaceholderRE.FindStringSubmatch(m)[1]
+			v, err := data.lookup(key)
+			if err != nil {
+				bad = append(bad, fmt.Sprintf("%s: %s", name, err))
+			}
+			return v
+		})
+	}
+	if len(bad) > 0 {
+		sort.Strings(bad)
+		return nil, fmt.Errorf("%s", strings.Join(bad, "; "))
+	}
+	return env, nil
+}
+
+func (d envTemplateData) lookup(key string) (string, error) {
+	switch key {
+	case "app_name":
+		return d.AppName, nil
+	case "app_url":
+		return d.AppURL, nil
+	case "parent_name":
+		if d.ParentName == "" {
+			return "", fmt.Errorf("{{parent_name}} needs --parent")
+		}
+		return d.ParentName, nil
+	}
+	if strings.HasPrefix(key, "parent.") {
+		if d.Parent == nil {
+			return "", fmt.Errorf("{{%s}} needs --parent", key)
+		}
+		v, ok := d.Parent[strings.TrimPrefix(key, "parent.")]
+		if !ok {
+			return "", fmt.Errorf("%s has no %s", d.ParentName, strings.TrimPrefix(key, "parent."))
+		}
+		return v, nil
+	}
+	return "", fmt.Errorf("unknown placeholder {{%s}}", key)
+}
@@ -0,0 +1,55 @@
+package hk
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRenderEnvTemplate(t *testing.T) {
+	data := envTemplateData{
+		AppName:    "myapp-pr-42",
+		AppURL:     "https://myapp-pr-42.herokuapp.com/",
+		ParentName: "myapp-staging",
+		Parent:     map[string]string{"S3_BUCKET": "myapp-staging-assets"},
+	}
+	tmpl := map[string]string{
+		"APP_HOST":  "{{app_name}}.herokuapp.com",
+		"ASSET_URL": "{{ app_url }}assets",
+		"S3_BUCKET": "{{parent.S3_BUCKET}}",
+		"FROM":      "{{parent_name}}",
+		"PLAIN":     "value",
+	}
+	want := map[string]string{
+		"APP_HOST":  "myapp-pr-42.herokuapp.com",
+		"ASSET_URL": "https://myapp-pr-42.herokuapp.com/assets",
+		"S3_BUCKET": "myapp-staging-assets",
+		"FROM":      "myapp-staging",
+		"PLAIN":     "value",
+	}
+	got, err := renderEnvTemplate(tmpl, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("renderEnvTemplate => %v, want %v", got, want)
+	}
+}
+
+func TestRenderEnvTemplateErrors(t *testing.T) {
+	tests := []struct {
+		val  string
+		data envTemplateData
+		want string
+	}{
+		{"{{nope}}", envTemplateData{}, "unknown placeholder {{nope}}"},
+		{"{{parent.X}}", envTemplateData{}, "{{parent.X}} needs --parent"},
+		{"{{parent.X}}", envTemplateData{ParentName: "p", Parent: map[string]string{}}, "p has no X"},
+	}
+	for _, test := range tests {
+		_, err := renderEnvTemplate(map[string]string{"A": test.val}, test.data)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("renderEnvTemplate(%q) error = %v, want %q", test.val, err, test.want)
+		}
+	}
+}
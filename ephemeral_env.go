@@ -0,0 +1,231 @@
+package hk
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/term"
+)
+
+var cmdEnvCreate = &Command{
+	Run:      runEnvCreate,
+	Usage:    "env-create [--branch <branch>] [--skip-addons]",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "create an app for the current git branch" + extra,
+	Long: `
+Env-create makes an ephemeral copy of an app for a feature branch,
+like a review app: a new app named after the app and the branch,
+with the app's stack, region, and buildpacks, new add-ons on the
+same plans, and a copy of the app's env vars. It then deploys the
+current directory to the new app, as 'hk deploy' does.
+
+Env vars set by the app's add-ons aren't copied, since the new
+add-ons set their own. The new app's HK_ENV_PARENT env var names the
+app it was made from, which env-destroy checks before destroying it.
+
+If the branch's app already exists, env-create deploys the current
+directory to it again.
+
+Options:
+
+    --branch <branch>  branch to name the new app after (default the
+                       current git branch)
+    --skip-addons      don't add any add-ons
+
+Examples:
+
+    $ hk env-create -a myapp-staging
+    Created myapp-staging-signup-form from myapp-staging.
+    Added heroku-postgresql:hobby-dev to myapp-staging-signup-form.
+    Copied 14 env vars to myapp-staging-signup-form.
+    ...
+    Deployed myapp-staging-signup-form v5.
+
+    $ hk env-create -a myapp-staging
+    ...
+    Deployed myapp-staging-signup-form v6.
+`,
+}
+
+var cmdEnvDestroy = &Command{
+	Run:      runEnvDestroy,
+	Usage:    "env-destroy [--branch <branch>] [-y]",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "destroy the app for the current git branch" + extra,
+	Long: `
+Env-destroy destroys the app that env-create made for a branch,
+along with its add-ons and its git remotes. It only destroys apps
+that env-create made from the app given. At a terminal, it asks for
+confirmation unless --yes is given.
+
+Options:
+
+    --branch <branch>  branch whose app to destroy (default the
+                       current git branch)
+    -y, --yes          don't ask for confirmation
+
+Example:
+
+    $ hk env-destroy -a myapp-staging
+    Destroy myapp-staging-signup-form? [y/N] y
+    Destroyed myapp-staging-signup-form.
+`,
+}
+
+var (
+	flagEnvBranch     string
+	flagEnvSkipAddons bool
+	flagEnvDestroyYes bool
+)
+
+func init() {
+	cmdEnvCreate.Flag.StringVar(&flagEnvBranch, "branch", "", "git branch")
+	cmdEnvCreate.Flag.BoolVar(&flagEnvSkipAddons, "skip-addons", false, "don't add any add-ons")
+	cmdEnvDestroy.Flag.StringVar(&flagEnvBranch, "branch", "", "git branch")
+	cmdEnvDestroy.Flag.BoolVar(&flagEnvDestroyYes, "y", false, "don't ask for confirmation")
+	cmdEnvDestroy.Flag.BoolVar(&flagEnvDestroyYes, "yes", false, "don't ask for confirmation")
+}
+
+// envParentVar is the env var that names the app an ephemeral app was
+// made from.
+const envParentVar = "HK_ENV_PARENT"
+
+func runEnvCreate(ctx *Context, args []string) {
+	parent := ctx.MustApp()
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	name := envAppName(parent, mustEnvBranch())
+
+	if _, err := ctx.Client.AppInfo(name); err == nil {
+		mustBeEnvOf(name, parent)
+	} else {
+		app, err := ctx.Client.AppInfo(parent)
+		must(err)
+		_, err = ctx.Client.AppCreate(&heroku.AppCreateOpts{Name: &name, Region: &app.Region.Name, Stack: &app.Stack.Name})
+		must(err)
+		log.Printf("Created %s from %s.", name, parent)
+		if err := copyEnvApp(parent, name); err != nil {
+			printFatal("%s %s is only partly set up; destroy it with 'hk env-destroy -a %s'.", err, name, parent)
+		}
+	}
+
+	mustNotBeDeployLocked(name)
+	b, err := buildDir(ctx.Stdout, name, ".", dirGitCommit("."))
+	must(err)
+	if b.Release == nil {
+		log.Printf("Deployed %s.", name)
+		return
+	}
+	rel, err := ctx.Client.ReleaseInfo(name, b.Release.Id)
+	must(err)
+	log.Printf("Deployed %s v%d.", name, rel.Version)
+}
+
+// copyEnvApp gives env, a new ephemeral app, the buildpacks, add-on
+// plans, and env vars of parent, and records parent in env's config.
+func copyEnvApp(parent, env string) error {
+	urls, err := listBuildpacks(parent)
+	if err != nil {
+		return err
+	}
+	if len(urls) > 0 {
+		if err := setBuildpacks(env, urls); err != nil {
+			return err
+		}
+	}
+	if !flagEnvSkipAddons {
+		addons, err := client.AddonList(parent, nil)
+		if err != nil {
+			return err
+		}
+		for _, a := range addons {
+			if _, err := client.AddonCreate(env, a.Plan.Name, nil); err != nil {
+				printWarning("couldn't add %s to %s: %s", a.Plan.Name, env, err)
+				continue
+			}
+			log.Printf("Added %s to %s.", a.Plan.Name, env)
+		}
+	}
+	n, err := copyAppConfig(parent, env)
+	if err != nil {
+		return err
+	}
+	log.Printf("Copied %d env vars to %s.", n, env)
+	_, err = client.ConfigVarUpdate(env, map[string]*string{envParentVar: &parent})
+	return err
+}
+
+func runEnvDestroy(ctx *Context, args []string) {
+	parent := ctx.MustApp()
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	name := envAppName(parent, mustEnvBranch())
+	mustBeEnvOf(name, parent)
+	if !flagEnvDestroyYes && term.IsTerminal(os.Stdin) {
+		mustConfirm(fmt.Sprintf("Destroy %s?", name))
+	}
+	must(ctx.Client.AppDelete(name))
+	log.Printf("Destroyed %s.", name)
+	cleanupDestroyedApp(name)
+}
+
+// mustBeEnvOf exits unless app is an ephemeral app made from parent.
+func mustBeEnvOf(app, parent string) {
+	config, err := client.ConfigVarInfo(app)
+	must(err)
+	if config[envParentVar] != parent {
+		printFatal("%s wasn't made from %s by env-create.", app, parent)
+	}
+}
+
+// mustEnvBranch returns the branch given by --branch, or else the current
+// git branch.
+func mustEnvBranch() string {
+	if flagEnvBranch != "" {
+		return flagEnvBranch
+	}
+	out, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	branch := strings.TrimSpace(string(out))
+	if err != nil || branch == "" || branch == "HEAD" {
+		printFatal("Not on a git branch. Give one with --branch.")
+	}
+	return branch
+}
+
+// maximum length of an app name
+const maxAppNameLen = 30
+
+var appNameUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// envAppName returns the name of the ephemeral app for branch of parent:
+// the parent's name and the branch, lowercased, with runs of anything
+// but letters and digits made dashes. If that's too long for an app name,
+// the branch is shortened and a hash of it added, so that long branches
+// with the same start get different apps.
+func envAppName(parent, branch string) string {
+	slug := strings.Trim(appNameUnsafe.ReplaceAllString(strings.ToLower(branch), "-"), "-")
+	name := parent + "-" + slug
+	if len(name) <= maxAppNameLen {
+		return name
+	}
+	sum := sha1.Sum([]byte(branch))
+	hash := hex.EncodeToString(sum[:])[:6]
+	n := maxAppNameLen - len(parent) - len(hash) - 2
+	if n < 1 {
+		return strings.TrimRight(parent[:maxAppNameLen-len(hash)-1], "-") + "-" + hash
+	}
+	return parent + "-" + strings.TrimRight(slug[:n], "-") + "-" + hash
+}
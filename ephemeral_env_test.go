@@ -0,0 +1,23 @@
+package hk
+
+import "testing"
+
+func TestEnvAppName(t *testing.T) {
+	tests := []struct {
+		parent, branch, want string
+	}{
+		{"myapp", "signup-form", "myapp-signup-form"},
+		{"myapp", "feature/Signup_Form", "myapp-feature-signup-form"},
+		{"myapp-staging", "bob/add-the-new-signup-form", "myapp-staging-bob-add-t-28f8b3"},
+		{"myapp-staging-eu-west-primary", "x", "myapp-staging-eu-west-p-11f6ad"},
+	}
+	for _, test := range tests {
+		got := envAppName(test.parent, test.branch)
+		if got != test.want {
+			t.Errorf("envAppName(%q, %q) = %q, want %q", test.parent, test.branch, got, test.want)
+		}
+		if len(got) > maxAppNameLen {
+			t.Errorf("envAppName(%q, %q) = %q, longer than %d", test.parent, test.branch, got, maxAppNameLen)
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package hk
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdErrors = &Command{
+	Run:      runErrors,
+	Usage:    "errors [-n <lines>] [--watch [--threshold <code>=<n>/<period>,...] [--exec <command>]]",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "summarize platform errors in the log" + extra,
+	Long: `
+Errors counts the Heroku error codes, such as H12 (request timeout)
+or R14 (memory quota exceeded), in an app's recent log lines, and
+shows how often each occurred and when it was last seen.
+
+With --watch, errors instead streams the log and prints each error
+as it happens. Each --threshold is a code, a count, and a period, e.g.
+H12=5/min for five request timeouts within a minute; the period is
+sec, min, hour, or a duration such as 30s. When a threshold is
+reached, errors prints an alert and, with --exec, runs the command
+with sh. The command gets HK_APP, HK_ERROR_CODE, and HK_ERROR_COUNT
+in its environment. A threshold doesn't alert again until the count
+within its period starts over.
+
+Options:
+
+    -n <lines>                 number of log lines to count errors in
+                               (default 1500)
+    --watch                    stream the log and print errors as they
+                               happen
+    --threshold <thresholds>   comma-separated thresholds to alert on
+    --exec <command>           command to run on each alert
+
+Examples:
+
+    $ hk errors
+    code  count  last seen                         description
+    H12   14     2014-01-13T21:20:57.079095+00:00  Request timeout
+    R14   3      2014-01-13T21:18:02.288521+00:00  Memory quota exceeded
+
+    $ hk errors --watch --threshold H12=5/min --exec ./page-me.sh
+    2014-01-13T21:20:51.066089+00:00  H12  web.2  Request timeout
+    ...
+    2014-01-13T21:20:57.079095+00:00  H12  web.1  Request timeout
+    Alert: 5 H12 errors within 1m0s. Running ./page-me.sh.
+`,
+}
+
+var (
+	flagErrorsLines     int
+	flagErrorsWatch     bool
+	flagErrorsThreshold string
+	flagErrorsExec      string
+)
+
+func init() {
+	cmdErrors.Flag.IntVar(&flagErrorsLines, "n", 1500, "number of log lines")
+	cmdErrors.Flag.BoolVar(&flagErrorsWatch, "watch", false, "stream the log")
+	cmdErrors.Flag.StringVar(&flagErrorsThreshold, "threshold", "", "thresholds to alert on")
+	cmdErrors.Flag.StringVar(&flagErrorsExec, "exec", "", "command to run on each alert")
+}
+
+func runErrors(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 0 || (!flagErrorsWatch && (flagErrorsThreshold != "" || flagErrorsExec != "")) {
+		ctx.printUsage()
+		exit(2)
+	}
+	if !flagErrorsWatch {
+		opts := heroku.LogSessionCreateOpts{Lines: &flagErrorsLines}
+		body := openLog(appname, &opts)
+		defer body.Close()
+		counts, err := countLogErrors(bufio.NewScanner(body))
+		must(err)
+		printErrorCounts(ctx.Stdout, counts)
+		return
+	}
+
+	var thresholds []errorThreshold
+	if flagErrorsThreshold != "" {
+		for _, s := range strings.Split(flagErrorsThreshold, ",") {
+			t, err := parseErrorThreshold(strings.TrimSpace(s))
+			if err != nil {
+				printFatal(err.Error())
+			}
+			thresholds = append(thresholds, t)
+		}
+	}
+	if flagErrorsExec != "" && len(thresholds) == 0 {
+		printFatal("--exec needs at least one --threshold")
+	}
+	watch := newErrorWatch(thresholds)
+	for {
+		// log sessions end now and then, so keep opening new ones
+		tail, lines := true, 0
+		body := openLog(appname, &heroku.LogSessionCreateOpts{Tail: &tail, Lines: &lines})
+		s := bufio.NewScanner(body)
+		for s.Scan() {
+			e, ok := parseLogError(s.Text())
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(ctx.Stdout, "%s  %s  %s  %s\n", e.Time, e.Code, e.Dyno, e.Desc)
+			for _, t := range watch.add(e.Code, time.Now()) {
+				alertErrors(ctx.Stdout, appname, t)
+			}
+		}
+		body.Close()
+	}
+}
+
+// alertErrors reports that threshold t was reached, and runs the --exec
+// command in the background if there is one.
+func alertErrors(w io.Writer, appname string, t errorThreshold) {
+	if flagErrorsExec == "" {
+		log.Printf("Alert: %d %s errors within %s.", t.Count, t.Code, t.Period)
+		return
+	}
+	log.Printf("Alert: %d %s errors within %s. Running %s.", t.Count, t.Code, t.Period, flagErrorsExec)
+	cmd := exec.Command("sh", "-c", flagErrorsExec)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(),
+		"HK_APP="+appname,
+		"HK_ERROR_CODE="+t.Code,
+		"HK_ERROR_COUNT="+strconv.Itoa(t.Count),
+	)
+	go func() {
+		if err := cmd.Run(); err != nil {
+			printWarning("%s: %s", flagErrorsExec, err)
+		}
+	}()
+}
+
+var (
+	// e.g. "heroku[router]: at=error code=H12 desc="Request timeout" ... dyno=web.2"
+	routerErrorRE = regexp.MustCompile(`\bcode=([A-Z]\d+) desc="([^"]*)"`)
+	// e.g. "heroku[web.1]: Error R14 (Memory quota exceeded)"
+	dynoErrorRE = regexp.MustCompile(`\bError ([A-Z]\d+) \(([^)]*)\)`)
+	// e.g. "dyno=web.2"
+	routerErrorDynoRE = regexp.MustCompile(`\bdyno=([\w.-]+)`)
+)
+
+// A logError is a Heroku error code seen in a log line.
+type logError struct {
+	Time string
+	Code string
+	Dyno string
+	Desc string
+}
+
+// parseLogError returns the error in a log line from Heroku, if it has
+// one.
+func parseLogError(line string) (logError, bool) {
+	m := logLineRE.FindStringSubmatch(line)
+	if m == nil || m[1] != "heroku" {
+		return logError{}, false
+	}
+	e := logError{Time: strings.Fields(line)[0], Dyno: m[2]}
+	if em := routerErrorRE.FindStringSubmatch(line); em != nil {
+		e.Code, e.Desc = em[1], em[2]
+		if dm := routerErrorDynoRE.FindStringSubmatch(line); dm != nil {
+			e.Dyno = dm[1]
+		}
+		return e, true
+	}
+	if em := dynoErrorRE.FindStringSubmatch(line); em != nil {
+		e.Code, e.Desc = em[1], em[2]
+		return e, true
+	}
+	return logError{}, false
+}
+
+// An errorCount is how often an error code was seen, and when last.
+type errorCount struct {
+	Code     string
+	Desc     string
+	Count    int
+	LastSeen string
+}
+
+// countLogErrors counts the errors in the log lines read by s, most
+// frequent first.
+func countLogErrors(s *bufio.Scanner) ([]errorCount, error) {
+	byCode := make(map[string]*errorCount)
+	for s.Scan() {
+		e, ok := parseLogError(s.Text())
+		if !ok {
+			continue
+		}
+		c := byCode[e.Code]
+		if c == nil {
+			c = &errorCount{Code: e.Code, Desc: e.Desc}
+			byCode[e.Code] = c
+		}
+		c.Count++
+		c.LastSeen = e.Time
+	}
+	var counts []errorCount
+	for _, c := range byCode {
+		counts = append(counts, *c)
+	}
+	sort.Sort(errorCountsByCount(counts))
+	return counts, s.Err()
+}
+
+type errorCountsByCount []errorCount
+
+func (a errorCountsByCount) Len() int      { return len(a) }
+func (a errorCountsByCount) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a errorCountsByCount) Less(i, j int) bool {
+	if a[i].Count != a[j].Count {
+		return a[i].Count > a[j].Count
+	}
+	return a[i].Code < a[j].Code
+}
+
+func printErrorCounts(out io.Writer, counts []errorCount) {
+	if len(counts) == 0 {
+		log.Println("No errors in the log.")
+		return
+	}
+	w := tabwriter.NewWriter(out, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	listRec(w, "code", "count", "last seen", "description")
+	for _, c := range counts {
+		listRec(w, c.Code, c.Count, c.LastSeen, c.Desc)
+	}
+}
+
+// An errorThreshold alerts when Count errors with Code are seen within
+// Period.
+type errorThreshold struct {
+	Code   string
+	Count  int
+	Period time.Duration
+}
+
+var errorPeriods = map[string]time.Duration{
+	"sec":  time.Second,
+	"min":  time.Minute,
+	"hour": time.Hour,
+}
+
+// parseErrorThreshold parses a threshold such as H12=5/min or R14=1/30s.
+func parseErrorThreshold(s string) (errorThreshold, error) {
+	bad := fmt.Errorf("bad threshold %q, want e.g. H12=5/min", s)
+	i, j := strings.Index(s, "="), strings.Index(s, "/")
+	if i < 1 || j < i {
+		return errorThreshold{}, bad
+	}
+	n, err := strconv.Atoi(s[i+1 : j])
+	if err != nil || n < 1 {
+		return errorThreshold{}, bad
+	}
+	period, ok := errorPeriods[s[j+1:]]
+	if !ok {
+		if period, err = time.ParseDuration(s[j+1:]); err != nil || period <= 0 {
+			return errorThreshold{}, bad
+		}
+	}
+	return errorThreshold{Code: strings.ToUpper(s[:i]), Count: n, Period: period}, nil
+}
+
+// An errorWatch counts errors as they're seen, to find when thresholds
+// are reached.
+type errorWatch struct {
+	thresholds []errorThreshold
+	seen       [][]time.Time // times seen within the period, per threshold
+}
+
+func newErrorWatch(thresholds []errorThreshold) *errorWatch {
+	return &errorWatch{thresholds: thresholds, seen: make([][]time.Time, len(thresholds))}
+}
+
+// add records an error with code seen at now, and returns the thresholds
+// it makes reached. A reached threshold starts counting over.
+func (w *errorWatch) add(code string, now time.Time) []errorThreshold {
+	var reached []errorThreshold
+	for i, t := range w.thresholds {
+		if t.Code != code {
+			continue
+		}
+		times := w.seen[i]
+		for len(times) > 0 && now.Sub(times[0]) >= t.Period {
+			times = times[1:]
+		}
+		times = append(times, now)
+		if len(times) >= t.Count {
+			reached = append(reached, t)
+			times = nil
+		}
+		w.seen[i] = times
+	}
+	return reached
+}
@@ -0,0 +1,80 @@
+package hk
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCountLogErrors(t *testing.T) {
+	log := strings.Join([]string{
+		`2014-01-13T21:18:02.000000+00:00 heroku[web.1]: Error R14 (Memory quota exceeded)`,
+		`2014-01-13T21:20:51.000000+00:00 heroku[router]: at=error code=H12 desc="Request timeout" method=GET path=/ dyno=web.2 connect=1ms service=30000ms status=503`,
+		`2014-01-13T21:20:52.000000+00:00 app[web.2]: code=H12 desc="Request timeout" from the app isn't counted`,
+		`2014-01-13T21:20:53.000000+00:00 heroku[router]: at=info method=GET path=/ dyno=web.1 connect=1ms service=6ms status=200`,
+		`2014-01-13T21:20:57.000000+00:00 heroku[router]: at=error code=H12 desc="Request timeout" method=GET path=/ dyno=web.1 connect=1ms service=30000ms status=503`,
+	}, "\n")
+	counts, err := countLogErrors(bufio.NewScanner(strings.NewReader(log)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []errorCount{
+		{"H12", "Request timeout", 2, "2014-01-13T21:20:57.000000+00:00"},
+		{"R14", "Memory quota exceeded", 1, "2014-01-13T21:18:02.000000+00:00"},
+	}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("countLogErrors => %+v, want %+v", counts, want)
+	}
+
+	e, ok := parseLogError(strings.Split(log, "\n")[1])
+	if !ok || e.Dyno != "web.2" {
+		t.Errorf("parseLogError => %+v, %v, want dyno web.2", e, ok)
+	}
+}
+
+func TestParseErrorThreshold(t *testing.T) {
+	tests := []struct {
+		s    string
+		want errorThreshold
+		ok   bool
+	}{
+		{"H12=5/min", errorThreshold{"H12", 5, time.Minute}, true},
+		{"r14=1/30s", errorThreshold{"R14", 1, 30 * time.Second}, true},
+		{"H10=2/hour", errorThreshold{"H10", 2, time.Hour}, true},
+		{"H12=0/min", errorThreshold{}, false},
+		{"H12=5", errorThreshold{}, false},
+		{"=5/min", errorThreshold{}, false},
+		{"H12=5/fortnight", errorThreshold{}, false},
+	}
+	for _, test := range tests {
+		got, err := parseErrorThreshold(test.s)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("parseErrorThreshold(%q) => %+v, %v", test.s, got, err)
+		}
+	}
+}
+
+func TestErrorWatch(t *testing.T) {
+	w := newErrorWatch([]errorThreshold{{"H12", 3, time.Minute}, {"R14", 1, time.Minute}})
+	start := time.Date(2014, 1, 13, 21, 0, 0, 0, time.UTC)
+	steps := []struct {
+		code    string
+		at      time.Duration
+		reached int
+	}{
+		{"H12", 0, 0},
+		{"H12", 30 * time.Second, 0},
+		{"H12", 70 * time.Second, 0}, // the first has aged out
+		{"H10", 75 * time.Second, 0},
+		{"H12", 80 * time.Second, 1},
+		{"H12", 85 * time.Second, 0}, // counting starts over
+		{"R14", 90 * time.Second, 1},
+	}
+	for i, s := range steps {
+		if got := w.add(s.code, start.Add(s.at)); len(got) != s.reached {
+			t.Errorf("%d: add(%s) reached %v, want %d thresholds", i, s.code, got, s.reached)
+		}
+	}
+}
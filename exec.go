@@ -0,0 +1,160 @@
+package hk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/heroku/hk/addonapi"
+	"github.com/heroku/hk/term"
+)
+
+var cmdExec = &Command{
+	Run:      runExec,
+	Usage:    "exec <dyno> [<command> [<argument>...]]",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "run a command in a running dyno" + extra,
+	Long: `
+Exec opens a shell in a running dyno, or runs a command in it, through
+Heroku Exec. Unlike hk run, it doesn't start a new dyno: the command
+sees the dyno's own processes, files, and memory, which makes it the
+way to debug a dyno in trouble.
+
+Heroku Exec must be enabled for the app, and dynos only accept exec
+sessions once they've restarted after enabling it:
+
+    $ hk feature-enable runtime-heroku-exec
+    $ hk restart
+
+Exec connects with the local ssh command, using a key that it makes
+with ssh-keygen for the session and deletes afterwards. Both come with
+OpenSSH.
+
+Examples:
+
+    $ hk exec web.1
+    ~ $ ps aux
+    ...
+
+    $ hk exec worker.2 cat /proc/meminfo
+    MemTotal:       62914560 kB
+    ...
+`,
+}
+
+// execTunnelPort is the port that Heroku Exec's SSH tunnels listen on.
+const execTunnelPort = "80"
+
+// An execTunnel is an SSH endpoint that reaches one dyno.
+type execTunnel struct {
+	TunnelHost string `json:"tunnel_host"`
+	ClientUser string `json:"client_user"`
+}
+
+func runExec(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) < 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	for _, name := range []string{"ssh", "ssh-keygen"} {
+		if _, err := exec.LookPath(name); err != nil {
+			printFatal("Local %s command not found. hk exec needs OpenSSH; for help installing it, see https://www.openssh.com/", name)
+		}
+	}
+	dyno, err := ctx.Client.DynoInfo(appname, args[0])
+	must(err)
+	if dyno.State != "up" {
+		printFatal("%s is %s.", dyno.Name, dyno.State)
+	}
+	config, err := ctx.Client.ConfigVarInfo(appname)
+	must(err)
+	execURL := config["HEROKU_EXEC_URL"]
+	if execURL == "" {
+		printFatal("Heroku Exec isn't enabled for %s. Run 'hk feature-enable runtime-heroku-exec -a %s', then restart %s.", appname, appname, dyno.Name)
+	}
+
+	dir, err := ioutil.TempDir("", "hk-exec")
+	must(err)
+	status, err := execDyno(dir, execURL, appname, dyno.Name, args[1:])
+	os.RemoveAll(dir)
+	must(err)
+	exit(status)
+}
+
+// execDyno runs command, or a shell if it's empty, in dyno, and returns
+// its exit status. It makes the session's key in dir.
+func execDyno(dir, execURL, appname, dyno string, command []string) (int, error) {
+	key := filepath.Join(dir, "id_ed25519")
+	keygen := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-C", "hk exec", "-f", key)
+	if out, err := keygen.CombinedOutput(); err != nil {
+		return 0, fmt.Errorf("ssh-keygen: %s%s", out, err)
+	}
+	pub, err := ioutil.ReadFile(key + ".pub")
+	if err != nil {
+		return 0, err
+	}
+	t, err := requestExecTunnel(execURL, appname, dyno, strings.TrimSpace(string(pub)))
+	if err != nil {
+		return 0, err
+	}
+
+	host, port := tunnelAddr(t.TunnelHost, execTunnelPort)
+	tty := len(command) == 0 || term.IsTerminal(os.Stdin)
+	// Interrupts are for the command, not for hk.
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+	c := exec.Command("ssh", execSSHArgs(key, t.ClientUser, host, port, tty, command)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err = c.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode(), nil
+	}
+	return 0, err
+}
+
+// requestExecTunnel asks the Heroku Exec service at execURL to let the
+// holder of pubkey, an authorized_keys line, into dyno.
+func requestExecTunnel(execURL, appname, dyno, pubkey string) (*execTunnel, error) {
+	c := addonapi.Client{
+		HTTP:      apiClient.HTTP,
+		Username:  apiClient.Username,
+		Password:  apiClient.Password,
+		UserAgent: userAgent,
+	}
+	var t execTunnel
+	path := "/api/v2/" + url.PathEscape(appname) + "/" + url.PathEscape(dyno)
+	err := c.Do(addonapi.Defaults{URL: execURL}, "PUT", path, map[string]string{"ssh_key": pubkey}, &t)
+	if err != nil {
+		return nil, err
+	}
+	if t.TunnelHost == "" || t.ClientUser == "" {
+		return nil, fmt.Errorf("Heroku Exec gave no tunnel for %s", dyno)
+	}
+	return &t, nil
+}
+
+// execSSHArgs returns the ssh arguments that run command as user at host
+// and port, authenticating with the private key file key. The dyno's host
+// key is new for each session, so it isn't checked or remembered.
+func execSSHArgs(key, user, host, port string, tty bool, command []string) []string {
+	args := []string{
+		"-i", key,
+		"-p", port,
+		"-o", "IdentitiesOnly=yes",
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=" + os.DevNull,
+		"-o", "LogLevel=ERROR",
+	}
+	if tty {
+		args = append(args, "-t")
+	}
+	args = append(args, user+"@"+host)
+	return append(args, command...)
+}
@@ -0,0 +1,63 @@
+package hk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/bgentry/heroku-go"
+)
+
+func TestRequestExecTunnel(t *testing.T) {
+	var gotPath, gotPass, gotKey string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.Method + " " + r.URL.Path
+		_, gotPass, _ = r.BasicAuth()
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		gotKey = body["ssh_key"]
+		w.Write([]byte(`{"tunnel_host": "tunnel.example.com", "client_user": "u123"}`))
+	}))
+	defer ts.Close()
+	defer func(c *heroku.Client) { apiClient = c }(apiClient)
+	apiClient = &heroku.Client{Password: "token"}
+
+	tun, err := requestExecTunnel(ts.URL+"/", "myapp", "web.1", "ssh-ed25519 AAAA hk exec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "PUT /api/v2/myapp/web.1" || gotPass != "token" || gotKey != "ssh-ed25519 AAAA hk exec" {
+		t.Errorf("request = %q with password %q and key %q", gotPath, gotPass, gotKey)
+	}
+	if tun.TunnelHost != "tunnel.example.com" || tun.ClientUser != "u123" {
+		t.Errorf("tunnel = %+v", tun)
+	}
+}
+
+func TestExecSSHArgs(t *testing.T) {
+	opts := []string{
+		"-i", "/tmp/key",
+		"-p", "80",
+		"-o", "IdentitiesOnly=yes",
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=" + os.DevNull,
+		"-o", "LogLevel=ERROR",
+	}
+	tests := []struct {
+		tty     bool
+		command []string
+		want    []string
+	}{
+		{true, nil, append(append([]string{}, opts...), "-t", "u@host")},
+		{false, []string{"cat", "/proc/meminfo"}, append(append([]string{}, opts...), "u@host", "cat", "/proc/meminfo")},
+	}
+	for i, test := range tests {
+		got := execSSHArgs("/tmp/key", "u", "host", "80", test.tty, test.command)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d. execSSHArgs = %q, want %q", i, got, test.want)
+		}
+	}
+}
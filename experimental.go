@@ -0,0 +1,71 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+var helpExperimental = &Command{
+	Usage:    "experimental",
+	Category: "hk",
+	Short:    "try commands that aren't stable yet",
+	Long: `
+Some new hk commands are experimental: their flags and output may
+change between releases, or they may be removed. They aren't listed
+in 'hk help' and won't run until they're turned on in the config
+file (see HKCONFIG in 'hk help environ').
+
+To turn on one experimental command, set its feature flag:
+
+    experimental.<feature> = true
+
+To turn on all of them, set:
+
+    experimental = true
+
+A feature flag set to false turns its command off even when
+experimental = true.
+`,
+}
+
+// experimentEnabled reports whether the named experimental feature is
+// turned on in the config file.
+func experimentEnabled(feature string) bool {
+	loadConfig()
+	if v, ok := hkConfig["experimental."+feature]; ok {
+		return v == "true"
+	}
+	return hkConfig["experimental"] == "true"
+}
+
+// Enabled reports whether c can be run: it isn't experimental, or its
+// feature flag is turned on.
+func (c *Command) Enabled() bool {
+	return c.Experimental == "" || experimentEnabled(c.Experimental)
+}
+
+// printExperiments lists the experimental commands and whether each is
+// turned on.
+func printExperiments(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 1, 2, 2, ' ', 0)
+	defer tw.Flush()
+	n := 0
+	for _, c := range commands {
+		if c.Experimental == "" {
+			continue
+		}
+		if n == 0 {
+			fmt.Fprint(tw, "\nExperimental commands:\n\n")
+		}
+		n++
+		state := "off"
+		if c.Enabled() {
+			state = "on"
+		}
+		listRec(tw, "    "+c.Name(), "experimental."+c.Experimental, state)
+	}
+	if n == 0 {
+		fmt.Fprintln(tw, "\nThis version of hk has no experimental commands.")
+	}
+}
@@ -0,0 +1,58 @@
+package hk
+
+import (
+	"testing"
+
+	"github.com/heroku/hk/hktest"
+)
+
+func TestExperimentEnabled(t *testing.T) {
+	defer func(c map[string]string) { hkConfig = c }(hkConfig)
+	tests := []struct {
+		config map[string]string
+		want   bool
+	}{
+		{map[string]string{}, false},
+		{map[string]string{"experimental": "true"}, true},
+		{map[string]string{"experimental.widgets": "true"}, true},
+		{map[string]string{"experimental.gadgets": "true"}, false},
+		{map[string]string{"experimental": "true", "experimental.widgets": "false"}, false},
+	}
+	for i, test := range tests {
+		hkConfig = test.config
+		if got := experimentEnabled("widgets"); got != test.want {
+			t.Errorf("%d. experimentEnabled(widgets) with %v => %t, want %t", i, test.config, got, test.want)
+		}
+	}
+}
+
+func TestExperimentalCommandGated(t *testing.T) {
+	defer func(c map[string]string) { hkConfig = c }(hkConfig)
+	defer func(c []*Command) { commands = c }(commands)
+	ran := false
+	cmd := &Command{
+		Run:          func(ctx *Context, args []string) { ran = true },
+		Usage:        "widgets",
+		Short:        "make widgets" + extra,
+		Experimental: "widgets",
+	}
+	commands = append(commands, cmd)
+	srv := hktest.NewServer()
+	defer srv.Close()
+
+	hkConfig = map[string]string{}
+	if cmd.ListAsExtra() {
+		t.Errorf("disabled experimental command is listed")
+	}
+	if _, status := runTestCommand(t, srv, "widgets"); status == 0 || ran {
+		t.Errorf("disabled experimental command ran, status %d", status)
+	}
+
+	hkConfig = map[string]string{"experimental.widgets": "true"}
+	if !cmd.ListAsExtra() {
+		t.Errorf("enabled experimental command isn't listed")
+	}
+	if _, status := runTestCommand(t, srv, "widgets"); status != 0 || !ran {
+		t.Errorf("enabled experimental command didn't run, status %d", status)
+	}
+}
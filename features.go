@@ -1,10 +1,10 @@
-package main
+package hk
 
 import (
 	"fmt"
 	"io"
 	"log"
-	"os"
+	"path"
 	"text/tabwriter"
 
 	"github.com/bgentry/heroku-go"
@@ -12,37 +12,89 @@ import (
 
 var cmdFeatures = &Command{
 	Run:      runFeatures,
-	Usage:    "features",
+	Usage:    "features [-j] [--enabled | --available]",
 	NeedsApp: true,
 	Category: "app",
 	Short:    "list app features" + extra,
 	Long: `
-Features lists Heroku Labs features for an app.
+Features lists Heroku Labs features for an app. Shows whether each
+feature is enabled (+), its name, state, description, and
+documentation URL.
+
+Options:
+
+    --enabled    only list enabled features
+    --available  only list features that aren't enabled
+    -j, --json   print features as JSON
 
 Example:
 
     $ hk features
-    +  preboot
-       user-env-compile
-    +  websockets
+    +  preboot           public  Provide seamless web dyno deploys  https://devcenter.heroku.com/articles/preboot
+       user-env-compile  public  Add user config vars to ...        https://devcenter.heroku.com/articles/labs-user-env-compile
+    +  websockets        beta    Enable WebSockets support          https://devcenter.heroku.com/articles/heroku-labs-websockets
 `,
 }
 
-func runFeatures(cmd *Command, args []string) {
-	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+func init() {
+	registerList(cmdFeatures, labsFeature{})
+}
+
+var (
+	flagFeaturesEnabled   bool
+	flagFeaturesAvailable bool
+)
+
+func init() {
+	for _, cmd := range []*Command{cmdFeatures, cmdAccountFeatures} {
+		cmd.Flag.BoolVar(&flagFeaturesEnabled, "enabled", false, "only list enabled features")
+		cmd.Flag.BoolVar(&flagFeaturesAvailable, "available", false, "only list features that aren't enabled")
 	}
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
-	defer w.Flush()
+}
 
-	features, err := client.AppFeatureList(mustApp(), &heroku.ListRange{Field: "name"})
+func runFeatures(ctx *Context, args []string) {
+	if len(args) != 0 || flagFeaturesEnabled && flagFeaturesAvailable {
+		ctx.printUsage()
+		exit(2)
+	}
+	features, err := ctx.Client.AppFeatureList(ctx.MustApp(), &heroku.ListRange{Field: "name"})
 	must(err)
 
-	listFeatures(w, features)
+	lf := make([]labsFeature, len(features))
+	for i, f := range features {
+		lf[i] = labsFeature{f.Name, f.State, f.Enabled, f.Description, f.DocURL}
+	}
+	printFeatures(ctx.Stdout, lf)
+}
+
+// labsFeature is the common form of app and account features.
+type labsFeature struct {
+	Name        string `json:"name"`
+	State       string `json:"state"`
+	Enabled     bool   `json:"enabled"`
+	Description string `json:"description"`
+	DocURL      string `json:"doc_url"`
+}
+
+// printFeatures prints features to out, applying the --enabled,
+// --available, and --json flags.
+func printFeatures(out io.Writer, features []labsFeature) {
+	var shown []labsFeature
+	for _, f := range features {
+		if flagFeaturesEnabled && !f.Enabled || flagFeaturesAvailable && f.Enabled {
+			continue
+		}
+		shown = append(shown, f)
+	}
+	if printList(out, shown) {
+		return
+	}
+	w := tabwriter.NewWriter(out, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	listFeatures(w, shown)
 }
 
-func listFeatures(w io.Writer, features []heroku.AppFeature) {
+func listFeatures(w io.Writer, features []labsFeature) {
 	for _, f := range features {
 		enabled := " "
 		if f.Enabled {
@@ -51,6 +103,9 @@ func listFeatures(w io.Writer, features []heroku.AppFeature) {
 		listRec(w,
 			enabled,
 			f.Name,
+			f.State,
+			abbrev(f.Description, 50),
+			f.DocURL,
 		)
 	}
 }
@@ -71,73 +126,143 @@ Example:
 `,
 }
 
-func runFeatureInfo(cmd *Command, args []string) {
+func runFeatureInfo(ctx *Context, args []string) {
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	appname := mustApp()
+	appname := ctx.MustApp()
 	featureName := args[0]
-	feature, err := client.AppFeatureInfo(appname, featureName)
+	feature, err := ctx.Client.AppFeatureInfo(appname, featureName)
 	must(err)
-	fmt.Printf("Name:         %s\n", feature.Name)
-	fmt.Printf("Docs:         %s\n", feature.DocURL)
-	fmt.Printf("Enabled:      %t\n", feature.Enabled)
-	fmt.Printf("Description:  %s\n", feature.Description)
+	fmt.Fprintf(ctx.Stdout, "Name:         %s\n", feature.Name)
+	fmt.Fprintf(ctx.Stdout, "Docs:         %s\n", feature.DocURL)
+	fmt.Fprintf(ctx.Stdout, "Enabled:      %t\n", feature.Enabled)
+	fmt.Fprintf(ctx.Stdout, "Description:  %s\n", feature.Description)
 }
 
 var cmdFeatureEnable = &Command{
-	Run:      runFeatureEnable,
-	Usage:    "feature-enable <feature>",
-	NeedsApp: true,
-	Category: "app",
-	Short:    "enable an app feature" + extra,
+	Run:         runFeatureEnable,
+	Usage:       "feature-enable [--all-matching <pattern>] <feature>",
+	NeedsApp:    true,
+	AppOptional: true,
+	Category:    "app",
+	Short:       "enable an app feature" + extra,
 	Long: `
-Enables a Heroku Labs feature on an app.
+Enables a Heroku Labs feature on an app, or on every app whose name
+matches a pattern.
 
-Example:
+Options:
+
+    --all-matching <pattern>  enable the feature on all apps with
+                              names matching the shell pattern
+
+Examples:
 
     $ hk feature-enable preboot
     Enabled preboot on myapp.
-`,
-}
 
-func runFeatureEnable(cmd *Command, args []string) {
-	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
-	}
-	appname := mustApp()
-	featureName := args[0]
-	feature, err := client.AppFeatureUpdate(appname, featureName, true)
-	must(err)
-	log.Printf("Enabled %s on %s.", feature.Name, appname)
+    $ hk feature-enable --all-matching 'myapp-*' preboot
+    Enabled preboot on myapp-production.
+    Enabled preboot on myapp-staging.
+`,
 }
 
 var cmdFeatureDisable = &Command{
-	Run:      runFeatureDisable,
-	Usage:    "feature-disable <feature>",
-	NeedsApp: true,
-	Category: "app",
-	Short:    "disable an app feature" + extra,
+	Run:         runFeatureDisable,
+	Usage:       "feature-disable [--all-matching <pattern>] <feature>",
+	NeedsApp:    true,
+	AppOptional: true,
+	Category:    "app",
+	Short:       "disable an app feature" + extra,
 	Long: `
-Disables a Heroku Labs feature on an app.
+Disables a Heroku Labs feature on an app, or on every app whose name
+matches a pattern.
 
-Example:
+Options:
+
+    --all-matching <pattern>  disable the feature on all apps with
+                              names matching the shell pattern
+
+Examples:
 
     $ hk feature-disable websockets
     Disabled websockets on myapp.
+
+    $ hk feature-disable --all-matching 'myapp-*' websockets
+    Disabled websockets on myapp-production.
+    Disabled websockets on myapp-staging.
 `,
 }
 
-func runFeatureDisable(cmd *Command, args []string) {
-	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+var flagFeatureAllMatching string
+
+func init() {
+	for _, cmd := range []*Command{cmdFeatureEnable, cmdFeatureDisable} {
+		cmd.Flag.StringVar(&flagFeatureAllMatching, "all-matching", "", "app name pattern")
+	}
+}
+
+func runFeatureEnable(ctx *Context, args []string) {
+	updateFeature(ctx, args, true)
+}
+
+func runFeatureDisable(ctx *Context, args []string) {
+	updateFeature(ctx, args, false)
+}
+
+func updateFeature(ctx *Context, args []string, enabled bool) {
+	if len(args) != 1 || (flagApp != "" || flagRemote != "") && flagFeatureAllMatching != "" {
+		ctx.printUsage()
+		exit(2)
 	}
-	appname := mustApp()
 	featureName := args[0]
-	feature, err := client.AppFeatureUpdate(appname, featureName, false)
-	must(err)
-	log.Printf("Disabled %s on %s.", feature.Name, appname)
+	verb := "Disabled"
+	if enabled {
+		verb = "Enabled"
+	}
+
+	var appnames []string
+	if flagFeatureAllMatching == "" {
+		appname := ctx.MustApp()
+		if appname == "" {
+			printError("no app specified")
+			ctx.printUsage()
+			exit(2)
+		}
+		appnames = []string{appname}
+	} else {
+		var err error
+		appnames, err = matchingAppNames(flagFeatureAllMatching)
+		if err != nil {
+			printFatal(err.Error())
+		}
+		if len(appnames) == 0 {
+			printFatal("no apps match %s", flagFeatureAllMatching)
+		}
+	}
+	for _, appname := range appnames {
+		feature, err := ctx.Client.AppFeatureUpdate(appname, featureName, enabled)
+		must(err)
+		log.Printf("%s %s on %s.", verb, feature.Name, appname)
+	}
+}
+
+// matchingAppNames returns the sorted names of all apps matching the shell
+// pattern.
+func matchingAppNames(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	apps, err := client.AppList(&heroku.ListRange{Field: "name", Max: 1000})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, a := range apps {
+		if ok, _ := path.Match(pattern, a.Name); ok {
+			names = append(names, a.Name)
+		}
+	}
+	return names, nil
 }
@@ -0,0 +1,155 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdFork = &Command{
+	Run:      runFork,
+	Usage:    "fork [--region <region>] [--skip-addons] <newname>",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "copy an app to a new app" + extra,
+	Long: `
+Fork creates a new app that's a copy of an app: on the same stack,
+in the same region unless --region is given, with the same
+buildpacks and env vars, new add-ons on the same plans, and a first
+release of the slug the app is running now.
+
+Env vars set by add-ons aren't copied, since the new add-ons set
+their own. The add-ons start empty: copy a database's data with 'hk
+pg-copy'. Unless --skip-addons is given, an add-on that can't be
+added is reported, and the fork continues without it.
+
+The new app runs one web dyno; scale it with 'hk scale'.
+
+Options:
+
+    --region <region>  region to create the new app in
+    --skip-addons      don't add any add-ons
+
+Example:
+
+    $ hk fork -a myapp myapp-staging
+    Created myapp-staging.
+    Added heroku-postgresql:standard-0 to myapp-staging.
+    Added heroku-redis:premium-0 to myapp-staging.
+    Copied 14 env vars to myapp-staging.
+    Released myapp v42's slug to myapp-staging as v5.
+`,
+}
+
+var (
+	flagForkRegion     string
+	flagForkSkipAddons bool
+)
+
+func init() {
+	cmdFork.Flag.StringVar(&flagForkRegion, "region", "", "region name")
+	cmdFork.Flag.BoolVar(&flagForkSkipAddons, "skip-addons", false, "don't add any add-ons")
+}
+
+func runFork(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	newname := args[0]
+	app, err := ctx.Client.AppInfo(appname)
+	must(err)
+	rel, err := latestRelease(appname)
+	must(err)
+	if rel.Slug == nil {
+		printFatal("%s has no slug to copy. Deploy it first.", appname)
+	}
+	urls, err := listBuildpacks(appname)
+	must(err)
+
+	region := app.Region.Name
+	if flagForkRegion != "" {
+		region = flagForkRegion
+	}
+	fork, err := ctx.Client.AppCreate(&heroku.AppCreateOpts{Name: &newname, Region: &region, Stack: &app.Stack.Name})
+	must(err)
+	log.Printf("Created %s.", fork.Name)
+	if err := forkApp(appname, fork.Name, urls, rel); err != nil {
+		printFatal("%s %s is only partly set up; destroy it with 'hk destroy %s'.", err, fork.Name, fork.Name)
+	}
+}
+
+// forkApp copies the buildpacks, add-ons, env vars, and the slug of
+// release rel from appname to fork.
+func forkApp(appname, fork string, buildpacks []string, rel *heroku.Release) error {
+	if len(buildpacks) > 0 {
+		if err := setBuildpacks(fork, buildpacks); err != nil {
+			return err
+		}
+	}
+	if !flagForkSkipAddons {
+		addons, err := client.AddonList(appname, nil)
+		if err != nil {
+			return err
+		}
+		for _, a := range addons {
+			if _, err := client.AddonCreate(fork, a.Plan.Name, nil); err != nil {
+				printWarning("couldn't add %s to %s: %s", a.Plan.Name, fork, err)
+				continue
+			}
+			log.Printf("Added %s to %s.", a.Plan.Name, fork)
+		}
+	}
+	n, err := copyAppConfig(appname, fork)
+	if err != nil {
+		return err
+	}
+	log.Printf("Copied %d env vars to %s.", n, fork)
+
+	desc := fmt.Sprintf("Fork of %s v%d", appname, rel.Version)
+	newrel, err := client.ReleaseCreate(fork, rel.Slug.Id, &heroku.ReleaseCreateOpts{Description: &desc})
+	if err != nil {
+		return err
+	}
+	log.Printf("Released %s v%d's slug to %s as v%d.", appname, rel.Version, fork, newrel.Version)
+	return nil
+}
+
+// hkConfigVars are the config vars hk sets to keep track of an app. They
+// describe the app itself, so they aren't copied to other apps.
+var hkConfigVars = []string{deployLockVar, envParentVar}
+
+// copyAppConfig copies the env vars of app from to app to, except those
+// set by from's add-ons and hkConfigVars, and returns how many it copied.
+func copyAppConfig(from, to string) (int, error) {
+	config, err := client.ConfigVarInfo(from)
+	if err != nil {
+		return 0, err
+	}
+	addons, err := client.AddonList(from, nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, a := range addons {
+		for _, name := range a.ConfigVars {
+			delete(config, name)
+		}
+	}
+	for _, name := range hkConfigVars {
+		delete(config, name)
+	}
+	if len(config) == 0 {
+		return 0, nil
+	}
+	update := make(map[string]*string, len(config))
+	for k, v := range config {
+		v := v
+		update[k] = &v
+	}
+	if _, err := client.ConfigVarUpdate(to, update); err != nil {
+		return 0, err
+	}
+	return len(update), nil
+}
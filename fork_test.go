@@ -0,0 +1,41 @@
+package hk
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/heroku/hk/hktest"
+)
+
+func TestFork(t *testing.T) {
+	srv := hktest.NewServer(
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp", Body: json.RawMessage(`{"name": "myapp", "region": {"name": "eu"}, "stack": {"name": "heroku-22"}}`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/releases", Body: json.RawMessage(`[{"id": "rel-42", "version": 42, "slug": {"id": "slug-42"}}]`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/buildpack-installations", Body: json.RawMessage(`[]`)},
+		hktest.Fixture{Method: "POST", Path: "/apps", Body: json.RawMessage(`{"name": "myapp-staging"}`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/addons", Body: json.RawMessage(`[{"name": "heroku-postgresql-crimson", "plan": {"name": "heroku-postgresql:standard-0"}, "config_vars": ["DATABASE_URL"]}]`)},
+		hktest.Fixture{Method: "POST", Path: "/apps/myapp-staging/addons", Body: json.RawMessage(`{"name": "heroku-postgresql-copper"}`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/config-vars", Body: json.RawMessage(`{"DATABASE_URL": "postgres://a", "HK_DEPLOY_LOCK": "ci: migrating", "RACK_ENV": "staging"}`)},
+		hktest.Fixture{Method: "PATCH", Path: "/apps/myapp-staging/config-vars", Body: json.RawMessage(`{}`)},
+		hktest.Fixture{Method: "POST", Path: "/apps/myapp-staging/releases", Body: json.RawMessage(`{"version": 5}`)},
+	)
+	defer srv.Close()
+	if _, status := runTestCommand(t, srv, "fork", "-a", "myapp", "myapp-staging"); status != 0 {
+		t.Fatalf("status = %d, unmatched requests %v", status, srv.Unmatched())
+	}
+	bodies := make(map[string]string)
+	for _, r := range srv.Requests() {
+		bodies[r.Method+" "+r.Path] = string(r.Body)
+	}
+	for req, want := range map[string]string{
+		"POST /apps":                            `"region":"eu"`,
+		"POST /apps/myapp-staging/addons":       `"plan":"heroku-postgresql:standard-0"`,
+		"PATCH /apps/myapp-staging/config-vars": `{"RACK_ENV":"staging"}`,
+		"POST /apps/myapp-staging/releases":     `"slug":"slug-42"`,
+	} {
+		if !strings.Contains(bodies[req], want) {
+			t.Errorf("%s body = %s, want it to contain %s", req, bodies[req], want)
+		}
+	}
+}
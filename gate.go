@@ -0,0 +1,107 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var cmdGate = &Command{
+	Run:      runGate,
+	Usage:    "gate [--url <path or url>] [--expect <status>] [--timeout <duration>] [--interval <duration>]",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "wait for an app to pass a health check" + extra,
+	Long: `
+Gate polls an app's health check URL until it responds with the
+expected HTTP status. It exits nonzero if the check hasn't passed
+before the timeout. Gate is meant to be chained between commands
+in deploy scripts, e.g. after a rollback or restart.
+
+Options:
+
+    --url <path or url>     URL to check. A path is relative to the
+                            app's web URL (default /)
+    --expect <status>       expected HTTP status code (default 200)
+    --timeout <duration>    give up after this long (default 2m)
+    --interval <duration>   time between checks (default 5s)
+
+Examples:
+
+    $ hk gate --url /healthz
+    Health check passed: https://myapp.herokuapp.com/healthz returned 200.
+
+    $ hk rollback v4 && hk gate --url /healthz --timeout 5m
+`,
+}
+
+var (
+	flagGateURL      string
+	flagGateExpect   int
+	flagGateTimeout  time.Duration
+	flagGateInterval time.Duration
+)
+
+func init() {
+	cmdGate.Flag.StringVar(&flagGateURL, "url", "/", "health check path or URL")
+	cmdGate.Flag.IntVar(&flagGateExpect, "expect", 200, "expected HTTP status code")
+	cmdGate.Flag.DurationVar(&flagGateTimeout, "timeout", 2*time.Minute, "time to wait for the check to pass")
+	cmdGate.Flag.DurationVar(&flagGateInterval, "interval", 5*time.Second, "time between checks")
+}
+
+func runGate(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	u, err := gateURL(ctx.MustApp(), flagGateURL)
+	must(err)
+	if err := healthGate(u, flagGateExpect, flagGateTimeout, flagGateInterval); err != nil {
+		printFatal(err.Error())
+	}
+	log.Printf("Health check passed: %s returned %d.", u, flagGateExpect)
+}
+
+// gateURL resolves a health check path against the app's web URL. Full URLs
+// are returned unchanged.
+func gateURL(appname, path string) (string, error) {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path, nil
+	}
+	app, err := client.AppInfo(appname)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(app.WebURL, "/") + ensurePrefix(path, "/"), nil
+}
+
+// healthGate polls u every interval until it responds with status expect,
+// returning an error if that hasn't happened within timeout. Requests that
+// hang are given up on at the timeout too.
+func healthGate(u string, expect int, timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	last := "no response"
+	for {
+		remaining := deadline.Sub(time.Now())
+		if remaining <= 0 {
+			break
+		}
+		res, err := (&http.Client{Timeout: remaining}).Get(u)
+		if err != nil {
+			last = err.Error()
+		} else {
+			res.Body.Close()
+			if res.StatusCode == expect {
+				return nil
+			}
+			last = res.Status
+		}
+		if time.Now().Add(interval).After(deadline) {
+			break
+		}
+		time.Sleep(interval)
+	}
+	return fmt.Errorf("health check failed after %s: %s (last result: %s)", timeout, u, last)
+}
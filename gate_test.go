@@ -0,0 +1,54 @@
+package hk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+func TestHealthGateTimesOutHungRequest(t *testing.T) {
+	done := make(chan bool)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	start := time.Now()
+	if err := healthGate(srv.URL, 200, 200*time.Millisecond, 50*time.Millisecond); err == nil {
+		t.Errorf("healthGate of hung server => nil, want error")
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("healthGate of hung server took %s, want about 200ms", d)
+	}
+}
+
+type fakeAppInfo struct {
+	API
+	app heroku.App
+}
+
+func (f *fakeAppInfo) AppInfo(appIdentity string) (*heroku.App, error) {
+	return &f.app, nil
+}
+
+func TestGateURL(t *testing.T) {
+	defer func(c API) { client = c }(client)
+	fake := &fakeAppInfo{}
+	fake.app.WebURL = "https://myapp-1234.example.com/"
+	client = fake
+	tests := []struct{ path, want string }{
+		{"/", "https://myapp-1234.example.com/"},
+		{"healthz", "https://myapp-1234.example.com/healthz"},
+		{"http://other.example.com/up", "http://other.example.com/up"},
+	}
+	for i, test := range tests {
+		got, err := gateURL("myapp", test.path)
+		if err != nil || got != test.want {
+			t.Errorf("%d. gateURL(%q) => %q, %v, want %q", i, test.path, got, err, test.want)
+		}
+	}
+}
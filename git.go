@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"bufio"
@@ -56,6 +56,28 @@ func gitDescribe(rels []*Release) error {
 	return err
 }
 
+// dirGitCommit returns the full SHA of the commit checked out in the git
+// repo containing dir, or "" if dir isn't in one.
+func dirGitCommit(dir string) string {
+	c := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD^{commit}")
+	c.Dir = dir
+	out, err := c.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// resolveGitCommit returns the full SHA of ref in the local git repo, or ref
+// itself if it can't be resolved (e.g. outside of a git repo).
+func resolveGitCommit(ref string) string {
+	out, err := exec.Command("git", "rev-parse", "--verify", "--quiet", ref+"^{commit}").Output()
+	if err != nil {
+		return ref
+	}
+	return strings.TrimSpace(string(out))
+}
+
 func isDeploy(s string) bool {
 	return len(s) == len("Deploy 0000000") && strings.HasPrefix(s, "Deploy ")
 }
@@ -120,7 +142,7 @@ func remoteFromGitConfig() string {
 	return strings.TrimSpace(string(b))
 }
 
-var errMultipleHerokuRemotes = errors.New("multiple apps in git remotes")
+var errMultipleHerokuRemotes = errors.New("multiple apps in git remotes; use -a <app> or -r <remote>, or set HEROKU_REMOTE")
 
 func appFromGitRemote(remote string) (string, error) {
 	if remote != "" {
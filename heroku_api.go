@@ -0,0 +1,121 @@
+package hk
+
+import (
+	"net/http"
+
+	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/hkclient"
+)
+
+// API is the part of the Heroku API client that hk commands use. The
+// global client is a *heroku.Client configured by initClients, but tests
+// replace it with fakes, and programs running hk's commands with Run can
+// give their own in Options.Client. A fake can embed API and override
+// only the methods it needs.
+type API interface {
+	rawService
+	accountService
+	appsService
+	addonsService
+	configService
+	dynosService
+	logsService
+	releasesService
+}
+
+// ext returns a client for the endpoints heroku-go has no methods for.
+// It sends its requests through client, so fakes of client see them too.
+func ext() hkclient.Client {
+	return hkclient.Client{API: client}
+}
+
+// rawService makes API requests for endpoints the client has no methods for.
+type rawService interface {
+	Get(v interface{}, path string) error
+	Post(v interface{}, path string, body interface{}) error
+	Put(v interface{}, path string, body interface{}) error
+	Patch(v interface{}, path string, body interface{}) error
+	Delete(path string) error
+	APIReq(v interface{}, meth, path string, body interface{}) error
+	NewRequest(method, path string, body interface{}) (*http.Request, error)
+	DoReq(req *http.Request, v interface{}) error
+}
+
+type accountService interface {
+	AccountInfo() (*heroku.Account, error)
+	AccountFeatureInfo(accountFeatureIdentity string) (*heroku.AccountFeature, error)
+	AccountFeatureList(lr *heroku.ListRange) ([]heroku.AccountFeature, error)
+	AccountFeatureUpdate(accountFeatureIdentity string, enabled bool) (*heroku.AccountFeature, error)
+	KeyCreate(publicKey string) (*heroku.Key, error)
+	KeyDelete(keyIdentity string) error
+	KeyList(lr *heroku.ListRange) ([]heroku.Key, error)
+}
+
+type appsService interface {
+	AppCreate(options *heroku.AppCreateOpts) (*heroku.App, error)
+	AppDelete(appIdentity string) error
+	AppInfo(appIdentity string) (*heroku.App, error)
+	AppList(lr *heroku.ListRange) ([]heroku.App, error)
+	AppUpdate(appIdentity string, options *heroku.AppUpdateOpts) (*heroku.App, error)
+	AppFeatureInfo(appIdentity string, appFeatureIdentity string) (*heroku.AppFeature, error)
+	AppFeatureList(appIdentity string, lr *heroku.ListRange) ([]heroku.AppFeature, error)
+	AppFeatureUpdate(appIdentity string, appFeatureIdentity string, enabled bool) (*heroku.AppFeature, error)
+	AppTransferCreate(app string, recipient string) (*heroku.AppTransfer, error)
+	AppTransferDelete(appTransferIdentity string) error
+	AppTransferList(lr *heroku.ListRange) ([]heroku.AppTransfer, error)
+	AppTransferUpdate(appTransferIdentity string, state string) (*heroku.AppTransfer, error)
+	CollaboratorCreate(appIdentity string, user string, options *heroku.CollaboratorCreateOpts) (*heroku.Collaborator, error)
+	CollaboratorDelete(appIdentity string, collaboratorIdentity string) error
+	CollaboratorList(appIdentity string, lr *heroku.ListRange) ([]heroku.Collaborator, error)
+	DomainCreate(appIdentity string, hostname string) (*heroku.Domain, error)
+	DomainDelete(appIdentity string, domainIdentity string) error
+	DomainList(appIdentity string, lr *heroku.ListRange) ([]heroku.Domain, error)
+	RegionList(lr *heroku.ListRange) ([]heroku.Region, error)
+	StackList(lr *heroku.ListRange) ([]heroku.Stack, error)
+}
+
+type addonsService interface {
+	AddonCreate(appIdentity string, plan string, options *heroku.AddonCreateOpts) (*heroku.Addon, error)
+	AddonDelete(appIdentity string, addonIdentity string) error
+	AddonInfo(appIdentity string, addonIdentity string) (*heroku.Addon, error)
+	AddonList(appIdentity string, lr *heroku.ListRange) ([]heroku.Addon, error)
+	AddonServiceInfo(addonServiceIdentity string) (*heroku.AddonService, error)
+	AddonUpdate(appIdentity string, addonIdentity string, plan string) (*heroku.Addon, error)
+	PlanInfo(addonServiceIdentity string, planIdentity string) (*heroku.Plan, error)
+	PlanList(addonServiceIdentity string, lr *heroku.ListRange) ([]heroku.Plan, error)
+}
+
+type configService interface {
+	ConfigVarInfo(appIdentity string) (map[string]string, error)
+	ConfigVarUpdate(appIdentity string, options map[string]*string) (map[string]string, error)
+}
+
+type dynosService interface {
+	DynoCreate(appIdentity string, command string, options *heroku.DynoCreateOpts) (*heroku.Dyno, error)
+	DynoInfo(appIdentity string, dynoIdentity string) (*heroku.Dyno, error)
+	DynoList(appIdentity string, lr *heroku.ListRange) ([]heroku.Dyno, error)
+	DynoRestart(appIdentity string, dynoIdentity string) error
+	DynoRestartAll(appIdentity string) error
+	FormationBatchUpdate(appIdentity string, updates []heroku.FormationBatchUpdateOpts) ([]heroku.Formation, error)
+	FormationInfo(appIdentity string, formationIdentity string) (*heroku.Formation, error)
+	FormationList(appIdentity string, lr *heroku.ListRange) ([]heroku.Formation, error)
+	FormationUpdate(appIdentity string, formationIdentity string, options *heroku.FormationUpdateOpts) (*heroku.Formation, error)
+}
+
+type logsService interface {
+	LogDrainCreate(appIdentity string, url string) (*heroku.LogDrain, error)
+	LogDrainDelete(appIdentity string, logDrainIdentity string) error
+	LogDrainInfo(appIdentity string, logDrainIdentity string) (*heroku.LogDrain, error)
+	LogDrainList(appIdentity string, lr *heroku.ListRange) ([]heroku.LogDrain, error)
+	LogSessionCreate(appIdentity string, options *heroku.LogSessionCreateOpts) (*heroku.LogSession, error)
+}
+
+type releasesService interface {
+	ReleaseCreate(appIdentity string, slug string, options *heroku.ReleaseCreateOpts) (*heroku.Release, error)
+	ReleaseInfo(appIdentity string, releaseIdentity string) (*heroku.Release, error)
+	ReleaseList(appIdentity string, lr *heroku.ListRange) ([]heroku.Release, error)
+	ReleaseRollback(appIdentity string, release string) (*heroku.Release, error)
+	SlugInfo(appIdentity string, slugIdentity string) (*heroku.Slug, error)
+}
+
+var _ API = (*heroku.Client)(nil)
@@ -0,0 +1,46 @@
+package hk
+
+import (
+	"testing"
+
+	"github.com/bgentry/heroku-go"
+)
+
+// fakeReleases is an API that serves a fixed list of releases. Calling
+// any other method panics.
+type fakeReleases struct {
+	API
+	releases []heroku.Release
+}
+
+func (f *fakeReleases) ReleaseList(appIdentity string, lr *heroku.ListRange) ([]heroku.Release, error) {
+	rels := f.releases
+	if lr != nil && lr.Descending {
+		rels = nil
+		for i := len(f.releases) - 1; i >= 0; i-- {
+			rels = append(rels, f.releases[i])
+		}
+	}
+	if lr != nil && lr.Max > 0 && lr.Max < len(rels) {
+		rels = rels[:lr.Max]
+	}
+	return rels, nil
+}
+
+func TestLatestReleaseWithFakeClient(t *testing.T) {
+	defer func(c API) { client = c }(client)
+	client = &fakeReleases{releases: []heroku.Release{{Version: 1}, {Version: 2}, {Version: 3}}}
+
+	rel, err := latestRelease("myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel.Version != 3 {
+		t.Errorf("latestRelease version = %d, want 3", rel.Version)
+	}
+
+	client = &fakeReleases{}
+	if _, err := latestRelease("myapp"); err == nil {
+		t.Errorf("expected error for app with no releases")
+	}
+}
@@ -0,0 +1,71 @@
+package hk
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// recordHistory is set by main, so that commands run by programs that
+// embed hk aren't recorded in the user's history.
+var recordHistory bool
+
+// A historyEntry is one command run, as recorded in the history log. Only
+// the command and app are kept, never the command's other arguments.
+type historyEntry struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	App     string    `json:"app,omitempty"`
+}
+
+func historyPath() string {
+	return filepath.Join(hkHome(), "history")
+}
+
+// appendHistory adds a run of command on app to the history log, unless
+// the config file sets history = false.
+func appendHistory(command, app string) error {
+	if configValue("history") == "false" {
+		return nil
+	}
+	b, err := json.Marshal(historyEntry{time.Now().UTC(), command, app})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hkHome(), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(historyPath(), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readHistory returns the entries of the history log, oldest first.
+// Lines that can't be read, e.g. one cut short by a crash, are skipped.
+func readHistory() ([]historyEntry, error) {
+	f, err := os.Open(historyPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []historyEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e historyEntry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil && e.Command != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,81 @@
+package hkclient
+
+import "time"
+
+// An AppSetup creates an app from a source tarball with an app.json,
+// provisioning its add-ons, setting its config, building it, and running
+// its postdeploy script, all in one request.
+type AppSetup struct {
+	// unique identifier of app setup
+	Id string `json:"id"`
+
+	// status of app setup, one of pending, succeeded, or failed
+	Status string `json:"status"`
+
+	// reason the app setup failed, if it did
+	FailureMessage string `json:"failure_message"`
+
+	// errors found in the app.json
+	ManifestErrors []string `json:"manifest_errors"`
+
+	// the app being set up
+	App struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+
+	// the app's first build, or nil if it hasn't started
+	Build *struct {
+		Id              string `json:"id"`
+		Status          string `json:"status"`
+		OutputStreamURL string `json:"output_stream_url"`
+	} `json:"build"`
+
+	// result of the postdeploy script, or nil if it hasn't run
+	Postdeploy *struct {
+		Output   string `json:"output"`
+		ExitCode int    `json:"exit_code"`
+	} `json:"postdeploy"`
+
+	// the app's success_url from app.json, resolved against its web URL
+	ResolvedSuccessURL string `json:"resolved_success_url"`
+
+	// when app setup was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// when app setup was updated
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AppSetupCreateOpts are the settings of a new app setup.
+type AppSetupCreateOpts struct {
+	// the app to create
+	App struct {
+		Name         *string `json:"name,omitempty"`
+		Region       *string `json:"region,omitempty"`
+		Organization *string `json:"organization,omitempty"`
+	} `json:"app"`
+
+	// the source tarball, which must include an app.json
+	SourceBlob struct {
+		URL     string  `json:"url"`
+		Version *string `json:"version,omitempty"`
+	} `json:"source_blob"`
+
+	// values that take the place of those in app.json
+	Overrides struct {
+		Env map[string]string `json:"env,omitempty"`
+	} `json:"overrides"`
+}
+
+// AppSetupCreate starts setting up an app.
+func (c Client) AppSetupCreate(opts AppSetupCreateOpts) (*AppSetup, error) {
+	var s AppSetup
+	return &s, c.APIReq(&s, "POST", "/app-setups", opts)
+}
+
+// AppSetupInfo returns an app setup.
+func (c Client) AppSetupInfo(appSetupIdentity string) (*AppSetup, error) {
+	var s AppSetup
+	return &s, c.APIReq(&s, "GET", "/app-setups/"+appSetupIdentity, nil)
+}
@@ -0,0 +1,76 @@
+package hkclient
+
+import (
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+// A Build turns source code into a slug, and releases it.
+type Build struct {
+	// unique identifier of build
+	Id string `json:"id"`
+
+	// status of build, one of pending, succeeded, or failed
+	Status string `json:"status"`
+
+	// URL to stream the build's output from while it runs
+	OutputStreamURL string `json:"output_stream_url"`
+
+	// the source the build was made from
+	SourceBlob struct {
+		URL     string `json:"url"`
+		Version string `json:"version"`
+	} `json:"source_blob"`
+
+	// the release the build created, or nil if it hasn't created one
+	Release *struct {
+		Id string `json:"id"`
+	} `json:"release"`
+
+	// when build was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// when build was updated
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BuildCreate starts a build of the source at sourceURL. version labels
+// the build, e.g. with a git commit, and may be empty.
+func (c Client) BuildCreate(appIdentity, sourceURL, version string) (*Build, error) {
+	body := map[string]interface{}{
+		"source_blob": map[string]string{"url": sourceURL, "version": version},
+	}
+	var b Build
+	return &b, c.APIReq(&b, "POST", "/apps/"+appIdentity+"/builds", body)
+}
+
+// BuildInfo returns a build.
+func (c Client) BuildInfo(appIdentity, buildIdentity string) (*Build, error) {
+	var b Build
+	return &b, c.APIReq(&b, "GET", "/apps/"+appIdentity+"/builds/"+buildIdentity, nil)
+}
+
+// BuildList lists an app's builds. lr is an optional ListRange that sets
+// the Range options for the paginated list of results.
+func (c Client) BuildList(appIdentity string, lr *heroku.ListRange) ([]Build, error) {
+	var builds []Build
+	return builds, c.list(&builds, "/apps/"+appIdentity+"/builds", lr)
+}
+
+// A Source is a place to upload source code to for a build.
+type Source struct {
+	SourceBlob struct {
+		// URL to build from
+		GetURL string `json:"get_url"`
+
+		// URL to upload the source tarball to with a PUT
+		PutURL string `json:"put_url"`
+	} `json:"source_blob"`
+}
+
+// SourceCreate creates a place to upload source code to.
+func (c Client) SourceCreate(appIdentity string) (*Source, error) {
+	var s Source
+	return &s, c.APIReq(&s, "POST", "/apps/"+appIdentity+"/sources", nil)
+}
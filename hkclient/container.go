@@ -0,0 +1,21 @@
+package hkclient
+
+// A FormationImage sets the Docker image a process type runs.
+type FormationImage struct {
+	// process type, e.g. web
+	Type string `json:"type"`
+
+	// id of an image pushed to the app's registry, e.g. sha256:0123...
+	DockerImage string `json:"docker_image"`
+}
+
+// FormationImageUpdate releases Docker images pushed to the app's
+// container registry, one per process type.
+func (c Client) FormationImageUpdate(appIdentity string, images []FormationImage) error {
+	req, err := c.NewRequest("PATCH", "/apps/"+appIdentity+"/formation", map[string]interface{}{"updates": images})
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.heroku+json; version=3.docker-releases")
+	return c.DoReq(req, nil)
+}
@@ -0,0 +1,14 @@
+// Package hkclient calls the Heroku API endpoints that heroku-go doesn't
+// cover yet, such as builds, pipelines, spaces, and webhooks, so hk
+// commands needn't wait on new heroku-go releases.
+//
+// A Client sends its requests through any API, such as a *heroku.Client,
+// so it shares that client's credentials, headers, and debug output. Its
+// types and methods are named and shaped like heroku-go's:
+//
+//	c := hkclient.Client{API: herokuClient}
+//	b, err := c.BuildInfo("myapp", buildId)
+//
+// When heroku-go gains an endpoint, callers can move to it and the
+// endpoint can be dropped from here.
+package hkclient
@@ -0,0 +1,57 @@
+package hkclient
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/bgentry/heroku-go"
+)
+
+// An API sends requests to the Heroku API. *heroku.Client is an API.
+type API interface {
+	APIReq(v interface{}, meth, path string, body interface{}) error
+	NewRequest(method, path string, body interface{}) (*http.Request, error)
+	DoReq(req *http.Request, v interface{}) error
+}
+
+// A Client calls the endpoints heroku-go has no methods for, through API.
+type Client struct {
+	API
+}
+
+// list gets the list at path into v, limited by lr if it's not nil.
+func (c Client) list(v interface{}, path string, lr *heroku.ListRange) error {
+	req, err := c.NewRequest("GET", path, nil)
+	if err != nil {
+		return err
+	}
+	if lr != nil {
+		setRange(req, lr)
+	}
+	return c.DoReq(req, v)
+}
+
+// setRange sets the Range header for lr. It's heroku.ListRange.SetHeader,
+// without the stray comma SetHeader adds when both Max and Descending are
+// set.
+func setRange(req *http.Request, lr *heroku.ListRange) {
+	hdr := lr.FirstId + ".." + lr.LastId
+	if lr.Field != "" {
+		hdr = lr.Field + " " + hdr
+	}
+	var opts []string
+	if lr.Descending {
+		opts = append(opts, "order=desc")
+	}
+	if lr.Max != 0 {
+		opts = append(opts, fmt.Sprintf("max=%d", lr.Max))
+	}
+	for i, o := range opts {
+		if i == 0 {
+			hdr += "; " + o
+		} else {
+			hdr += ", " + o
+		}
+	}
+	req.Header.Set("Range", hdr)
+}
@@ -0,0 +1,114 @@
+package hkclient
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/hktest"
+)
+
+func TestSetRange(t *testing.T) {
+	tests := []struct {
+		lr   heroku.ListRange
+		want string
+	}{
+		{heroku.ListRange{Field: "created_at"}, "created_at .."},
+		{heroku.ListRange{Field: "created_at", Max: 1, Descending: true}, "created_at ..; order=desc, max=1"},
+		{heroku.ListRange{Field: "id", FirstId: "a", Max: 10}, "id a..; max=10"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "/", nil)
+		setRange(req, &tt.lr)
+		if got := req.Header.Get("Range"); got != tt.want {
+			t.Errorf("setRange(%+v) = %q, want %q", tt.lr, got, tt.want)
+		}
+	}
+}
+
+func TestBuildList(t *testing.T) {
+	srv := hktest.NewServer(hktest.Fixture{
+		Method: "GET", Path: "/apps/myapp/builds",
+		Body: []byte(`[{"id": "b1", "status": "succeeded", "source_blob": {"version": "3ae20c2"}, "release": {"id": "r1"}}]`),
+	})
+	defer srv.Close()
+	c := Client{&heroku.Client{URL: srv.URL}}
+	builds, err := c.BuildList("myapp", &heroku.ListRange{Field: "created_at", Max: 1, Descending: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builds) != 1 || builds[0].Id != "b1" || builds[0].SourceBlob.Version != "3ae20c2" || builds[0].Release.Id != "r1" {
+		t.Errorf("BuildList = %+v", builds)
+	}
+}
+
+func TestPipelinePromotionCreate(t *testing.T) {
+	srv := hktest.NewServer(hktest.Fixture{
+		Method: "POST", Path: "/pipeline-promotions", Status: 201,
+		Body: []byte(`{"id": "p1", "status": "pending"}`),
+	})
+	defer srv.Close()
+	c := Client{&heroku.Client{URL: srv.URL}}
+	p, err := c.PipelinePromotionCreate("pipe", "src", []string{"t1", "t2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Id != "p1" || p.Status != "pending" {
+		t.Errorf("PipelinePromotionCreate = %+v", p)
+	}
+	want := `{"pipeline":{"id":"pipe"},"source":{"app":{"id":"src"}},"targets":[{"app":{"id":"t1"}},{"app":{"id":"t2"}}]}`
+	if reqs := srv.Requests(); len(reqs) != 1 || string(reqs[0].Body) != want {
+		t.Errorf("requests = %v, want one with body %s", reqs, want)
+	}
+}
+
+func TestAppWebhookDeliveryList(t *testing.T) {
+	srv := hktest.NewServer(hktest.Fixture{
+		Method: "GET", Path: "/apps/myapp/webhook-deliveries",
+		Body: []byte(`[{"id": "d1", "status": "failed", "num_attempts": 3, "last_attempt": {"code": 503, "status": "failed"}, "event": {"id": "e1", "include": "api:release"}}]`),
+	})
+	defer srv.Close()
+	c := Client{&heroku.Client{URL: srv.URL}}
+	ds, err := c.AppWebhookDeliveryList("myapp", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds) != 1 || ds[0].NumAttempts != 3 || ds[0].LastAttempt == nil || *ds[0].LastAttempt.Code != 503 || ds[0].Event.Include != "api:release" {
+		t.Errorf("AppWebhookDeliveryList = %+v", ds)
+	}
+}
+
+func TestSlugInfo(t *testing.T) {
+	srv := hktest.NewServer(hktest.Fixture{
+		Method: "GET", Path: "/apps/myapp/slugs/s1",
+		Body: []byte(`{"id": "s1", "commit": "62b3059", "size": 50540544, "checksum": "SHA256:4c2a7e", "stack": {"name": "heroku-18"}, "process_types": {"web": "bin/web"}}`),
+	})
+	defer srv.Close()
+	c := Client{&heroku.Client{URL: srv.URL}}
+	s, err := c.SlugInfo("myapp", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Id != "s1" || s.Size == nil || *s.Size != 50540544 || s.Stack.Name != "heroku-18" || s.ProcessTypes["web"] != "bin/web" {
+		t.Errorf("SlugInfo = %+v", s)
+	}
+}
+
+func TestFormationImageUpdate(t *testing.T) {
+	srv := hktest.NewServer(hktest.Fixture{Method: "PATCH", Path: "/apps/myapp/formation", Body: []byte(`[]`)})
+	defer srv.Close()
+	c := Client{&heroku.Client{URL: srv.URL}}
+	if err := c.FormationImageUpdate("myapp", []FormationImage{{Type: "web", DockerImage: "sha256:abc"}}); err != nil {
+		t.Fatal(err)
+	}
+	reqs := srv.Requests()
+	if len(reqs) != 1 {
+		t.Fatalf("requests = %v, want 1", reqs)
+	}
+	if got, want := string(reqs[0].Body), `{"updates":[{"type":"web","docker_image":"sha256:abc"}]}`; got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+	if got := reqs[0].Header.Get("Accept"); got != "application/vnd.heroku+json; version=3.docker-releases" {
+		t.Errorf("Accept = %q", got)
+	}
+}
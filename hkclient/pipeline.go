@@ -0,0 +1,151 @@
+package hkclient
+
+import (
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+// A Pipeline groups apps into stages, such as staging and production,
+// that a slug is promoted through.
+type Pipeline struct {
+	// unique identifier of pipeline
+	Id string `json:"id"`
+
+	// name of pipeline
+	Name string `json:"name"`
+
+	// when pipeline was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// when pipeline was updated
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PipelineCreate creates a pipeline.
+func (c Client) PipelineCreate(name string) (*Pipeline, error) {
+	var p Pipeline
+	return &p, c.APIReq(&p, "POST", "/pipelines", map[string]string{"name": name})
+}
+
+// PipelineInfo returns a pipeline.
+func (c Client) PipelineInfo(pipelineIdentity string) (*Pipeline, error) {
+	var p Pipeline
+	return &p, c.APIReq(&p, "GET", "/pipelines/"+pipelineIdentity, nil)
+}
+
+// PipelineList lists pipelines. lr is an optional ListRange that sets the
+// Range options for the paginated list of results.
+func (c Client) PipelineList(lr *heroku.ListRange) ([]Pipeline, error) {
+	var pipelines []Pipeline
+	return pipelines, c.list(&pipelines, "/pipelines", lr)
+}
+
+// A PipelineCoupling puts an app in a stage of a pipeline.
+type PipelineCoupling struct {
+	// unique identifier of pipeline coupling
+	Id string `json:"id"`
+
+	// stage of the app in the pipeline
+	Stage string `json:"stage"`
+
+	// the coupled app
+	App struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+
+	// the pipeline the app is coupled to
+	Pipeline struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"pipeline"`
+}
+
+// PipelineCouplingCreate adds an app to a stage of a pipeline.
+func (c Client) PipelineCouplingCreate(appIdentity, pipelineId, stage string) (*PipelineCoupling, error) {
+	body := map[string]string{"app": appIdentity, "pipeline": pipelineId, "stage": stage}
+	var pc PipelineCoupling
+	return &pc, c.APIReq(&pc, "POST", "/pipeline-couplings", body)
+}
+
+// PipelineCouplingDelete removes an app from its pipeline.
+func (c Client) PipelineCouplingDelete(pipelineCouplingIdentity string) error {
+	return c.APIReq(nil, "DELETE", "/pipeline-couplings/"+pipelineCouplingIdentity, nil)
+}
+
+// PipelineCouplingInfoByApp returns the coupling of an app to its
+// pipeline.
+func (c Client) PipelineCouplingInfoByApp(appIdentity string) (*PipelineCoupling, error) {
+	var pc PipelineCoupling
+	return &pc, c.APIReq(&pc, "GET", "/apps/"+appIdentity+"/pipeline-couplings", nil)
+}
+
+// PipelineCouplingListByPipeline lists the couplings of a pipeline's apps.
+func (c Client) PipelineCouplingListByPipeline(pipelineIdentity string) ([]PipelineCoupling, error) {
+	var couplings []PipelineCoupling
+	return couplings, c.APIReq(&couplings, "GET", "/pipelines/"+pipelineIdentity+"/pipeline-couplings", nil)
+}
+
+// A PipelinePromotion copies the slug of one app in a pipeline to others.
+type PipelinePromotion struct {
+	// unique identifier of promotion
+	Id string `json:"id"`
+
+	// status of promotion, one of pending or completed
+	Status string `json:"status"`
+}
+
+// PipelinePromotionCreate promotes the slug of the source app to the
+// target apps, all of which must be in the pipeline.
+func (c Client) PipelinePromotionCreate(pipelineId, sourceAppId string, targetAppIds []string) (*PipelinePromotion, error) {
+	type appRef struct {
+		App struct {
+			Id string `json:"id"`
+		} `json:"app"`
+	}
+	var body struct {
+		Pipeline struct {
+			Id string `json:"id"`
+		} `json:"pipeline"`
+		Source  appRef   `json:"source"`
+		Targets []appRef `json:"targets"`
+	}
+	body.Pipeline.Id = pipelineId
+	body.Source.App.Id = sourceAppId
+	for _, id := range targetAppIds {
+		var ref appRef
+		ref.App.Id = id
+		body.Targets = append(body.Targets, ref)
+	}
+	var p PipelinePromotion
+	return &p, c.APIReq(&p, "POST", "/pipeline-promotions", body)
+}
+
+// PipelinePromotionInfo returns a promotion.
+func (c Client) PipelinePromotionInfo(pipelinePromotionIdentity string) (*PipelinePromotion, error) {
+	var p PipelinePromotion
+	return &p, c.APIReq(&p, "GET", "/pipeline-promotions/"+pipelinePromotionIdentity, nil)
+}
+
+// A PipelinePromotionTarget is the result of a promotion for one target
+// app.
+type PipelinePromotionTarget struct {
+	// the target app
+	App struct {
+		Id string `json:"id"`
+	} `json:"app"`
+
+	// status of promotion to the app, one of pending, succeeded, or failed
+	Status string `json:"status"`
+
+	// why the promotion to the app failed, if it did
+	ErrorMessage *string `json:"error_message"`
+}
+
+// PipelinePromotionTargetList lists the results of a promotion for each
+// of its target apps.
+func (c Client) PipelinePromotionTargetList(pipelinePromotionIdentity string) ([]PipelinePromotionTarget, error) {
+	var targets []PipelinePromotionTarget
+	return targets, c.APIReq(&targets, "GET", "/pipeline-promotions/"+pipelinePromotionIdentity+"/promotion-targets", nil)
+}
@@ -0,0 +1,71 @@
+package hkclient
+
+import "time"
+
+// A Slug is a snapshot of an app's code, ready to run. It has the fields
+// of heroku.Slug that heroku-go leaves out, such as its size.
+type Slug struct {
+	// unique identifier of slug
+	Id string `json:"id"`
+
+	// where to download the slug from
+	Blob struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	} `json:"blob"`
+
+	// description from buildpack of slug
+	BuildpackProvidedDescription *string `json:"buildpack_provided_description"`
+
+	// hash of the slug, e.g. SHA256:0123..., or nil
+	Checksum *string `json:"checksum"`
+
+	// identification of the code in version control, e.g. a git commit
+	Commit *string `json:"commit"`
+
+	// process type names and their commands
+	ProcessTypes map[string]string `json:"process_types"`
+
+	// size of the slug in bytes, or nil
+	Size *int64 `json:"size"`
+
+	// the stack the slug runs on
+	Stack struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"stack"`
+
+	// when slug was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// when slug was updated
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SlugInfo returns a slug.
+func (c Client) SlugInfo(appIdentity, slugIdentity string) (*Slug, error) {
+	var s Slug
+	return &s, c.APIReq(&s, "GET", "/apps/"+appIdentity+"/slugs/"+slugIdentity, nil)
+}
+
+// SlugCreateOpts are the settings of a new slug.
+type SlugCreateOpts struct {
+	// process type names and their commands
+	ProcessTypes map[string]string `json:"process_types"`
+
+	// hash of the slug, e.g. SHA256:0123...
+	Checksum *string `json:"checksum,omitempty"`
+
+	// identification of the code in version control, e.g. a git commit
+	Commit *string `json:"commit,omitempty"`
+
+	// description of the slug, in place of a buildpack's
+	BuildpackProvidedDescription *string `json:"buildpack_provided_description,omitempty"`
+}
+
+// SlugCreate creates a slug. Its tarball is then uploaded with a PUT to
+// the returned slug's Blob.URL.
+func (c Client) SlugCreate(appIdentity string, opts SlugCreateOpts) (*Slug, error) {
+	var s Slug
+	return &s, c.APIReq(&s, "POST", "/apps/"+appIdentity+"/slugs", opts)
+}
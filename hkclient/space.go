@@ -0,0 +1,54 @@
+package hkclient
+
+import (
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+// A Space is an isolated network for a team's apps.
+type Space struct {
+	// unique identifier of space
+	Id string `json:"id"`
+
+	// unique name of space
+	Name string `json:"name"`
+
+	// availability of space, one of allocating, allocated, or deleting
+	State string `json:"state"`
+
+	// whether the space has shield settings for compliance
+	Shield bool `json:"shield"`
+
+	// the region the space is in
+	Region struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"region"`
+
+	// the team that owns the space
+	Team struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"team"`
+
+	// when space was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// when space was updated
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SpaceInfo returns a space.
+func (c Client) SpaceInfo(spaceIdentity string) (*Space, error) {
+	var s Space
+	return &s, c.APIReq(&s, "GET", "/spaces/"+spaceIdentity, nil)
+}
+
+// SpaceList lists the spaces the user can see. lr is an optional
+// ListRange that sets the Range options for the paginated list of
+// results.
+func (c Client) SpaceList(lr *heroku.ListRange) ([]Space, error) {
+	var spaces []Space
+	return spaces, c.list(&spaces, "/spaces", lr)
+}
@@ -0,0 +1,163 @@
+package hkclient
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+// An AppWebhook sends notifications of events on an app to a URL.
+type AppWebhook struct {
+	// unique identifier of webhook
+	Id string `json:"id"`
+
+	// URL notifications are sent to
+	URL string `json:"url"`
+
+	// the entities the webhook is notified about, e.g. api:release
+	Include []string `json:"include"`
+
+	// notify or sync; sync webhooks are retried until they succeed
+	Level string `json:"level"`
+
+	// the app the webhook belongs to
+	App struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"app"`
+
+	// when webhook was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// when webhook was updated
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AppWebhookCreateOpts are the settings of a new webhook.
+type AppWebhookCreateOpts struct {
+	// URL to send notifications to
+	URL string `json:"url"`
+
+	// the entities to notify about, e.g. api:release
+	Include []string `json:"include"`
+
+	// notify or sync
+	Level string `json:"level"`
+
+	// secret used to sign notifications, if any
+	Secret *string `json:"secret,omitempty"`
+
+	// value of the Authorization header sent with notifications, if any
+	Authorization *string `json:"authorization,omitempty"`
+}
+
+// AppWebhookCreate adds a webhook to an app.
+func (c Client) AppWebhookCreate(appIdentity string, opts AppWebhookCreateOpts) (*AppWebhook, error) {
+	var w AppWebhook
+	return &w, c.APIReq(&w, "POST", "/apps/"+appIdentity+"/webhooks", opts)
+}
+
+// AppWebhookDelete removes a webhook from an app.
+func (c Client) AppWebhookDelete(appIdentity, webhookIdentity string) error {
+	return c.APIReq(nil, "DELETE", "/apps/"+appIdentity+"/webhooks/"+webhookIdentity, nil)
+}
+
+// AppWebhookInfo returns a webhook of an app.
+func (c Client) AppWebhookInfo(appIdentity, webhookIdentity string) (*AppWebhook, error) {
+	var w AppWebhook
+	return &w, c.APIReq(&w, "GET", "/apps/"+appIdentity+"/webhooks/"+webhookIdentity, nil)
+}
+
+// AppWebhookList lists an app's webhooks. lr is an optional ListRange
+// that sets the Range options for the paginated list of results.
+func (c Client) AppWebhookList(appIdentity string, lr *heroku.ListRange) ([]AppWebhook, error) {
+	var hooks []AppWebhook
+	return hooks, c.list(&hooks, "/apps/"+appIdentity+"/webhooks", lr)
+}
+
+// An AppWebhookDelivery is an attempt, or series of attempts, to notify a
+// webhook of an event.
+type AppWebhookDelivery struct {
+	// unique identifier of delivery
+	Id string `json:"id"`
+
+	// pending, scheduled, retrying, failed, or succeeded
+	Status string `json:"status"`
+
+	// number of times the delivery has been attempted
+	NumAttempts int `json:"num_attempts"`
+
+	// when the delivery will next be attempted, if it will be
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+
+	// the latest attempt, or nil if there hasn't been one
+	LastAttempt *struct {
+		Id string `json:"id"`
+
+		// HTTP status code the webhook's URL responded with, if it did
+		Code *int `json:"code"`
+
+		// why the attempt failed, e.g. timeout, if it did
+		ErrorClass *string `json:"error_class"`
+
+		// scheduled, succeeded, or failed
+		Status string `json:"status"`
+
+		CreatedAt time.Time `json:"created_at"`
+	} `json:"last_attempt"`
+
+	// the event delivered
+	Event struct {
+		Id      string `json:"id"`
+		Include string `json:"include"`
+	} `json:"event"`
+
+	// the webhook delivered to
+	Webhook struct {
+		Id    string `json:"id"`
+		Level string `json:"level"`
+	} `json:"webhook"`
+
+	// when delivery was created
+	CreatedAt time.Time `json:"created_at"`
+
+	// when delivery was updated
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AppWebhookDeliveryInfo returns a delivery of an app's webhook.
+func (c Client) AppWebhookDeliveryInfo(appIdentity, deliveryIdentity string) (*AppWebhookDelivery, error) {
+	var d AppWebhookDelivery
+	return &d, c.APIReq(&d, "GET", "/apps/"+appIdentity+"/webhook-deliveries/"+deliveryIdentity, nil)
+}
+
+// AppWebhookDeliveryList lists the deliveries of an app's webhooks. lr is
+// an optional ListRange that sets the Range options for the paginated
+// list of results.
+func (c Client) AppWebhookDeliveryList(appIdentity string, lr *heroku.ListRange) ([]AppWebhookDelivery, error) {
+	var deliveries []AppWebhookDelivery
+	return deliveries, c.list(&deliveries, "/apps/"+appIdentity+"/webhook-deliveries", lr)
+}
+
+// An AppWebhookEvent is something that happened to an app that webhooks
+// are notified of.
+type AppWebhookEvent struct {
+	// unique identifier of event
+	Id string `json:"id"`
+
+	// the entity the event is about, e.g. api:release
+	Include string `json:"include"`
+
+	// the notification sent to webhooks, as JSON
+	Payload json.RawMessage `json:"payload"`
+
+	// when event was created
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AppWebhookEventInfo returns an event of an app's webhooks.
+func (c Client) AppWebhookEventInfo(appIdentity, eventIdentity string) (*AppWebhookEvent, error) {
+	var e AppWebhookEvent
+	return &e, c.APIReq(&e, "GET", "/apps/"+appIdentity+"/webhook-events/"+eventIdentity, nil)
+}
@@ -220,7 +220,7 @@ func (b *Build) buildAndUpload() (shasum []byte, err error) {
 const relverGo = `
 // +build release
 
-package main
+package hk
 const (
 	Version = %q
 )
@@ -244,7 +244,7 @@ func (b *Build) build() (err error) {
 	if err != nil {
 		return fmt.Errorf("writing relver.go: %s", err)
 	}
-	cmd := exec.Command("godep", "go", "build", "-tags", "release", "-o", b.filename())
+	cmd := exec.Command("godep", "go", "build", "-tags", "release", "-o", b.filename(), "./cmd/hk")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	env := []string{"GOOS=" + b.OS, "GOARCH=" + b.Arch, "CGO_ENABLED=0"}
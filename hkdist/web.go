@@ -39,6 +39,7 @@ func web(args []string) {
 	r.HandleFunc("/{cmd}/current/{plat}.json", http.HandlerFunc(curInfo)).Methods("GET", "HEAD")
 	r.HandleFunc("/{cmd}/{ver}/{plat}.json", http.HandlerFunc(getHash)).Methods("GET", "HEAD")
 	r.HandleFunc("/release.json", http.HandlerFunc(listReleases)).Methods("GET", "HEAD")
+	r.HandleFunc("/telemetry", http.HandlerFunc(telemetry)).Methods("POST")
 	r.Path("/{cmd}/current/{plat}.json").Methods("PUT").Handler(authenticate{herokaiOnly{http.HandlerFunc(setCur)}})
 	r.Path("/{cmd}/{ver}/{plat}.json").Methods("PUT").Handler(authenticate{herokaiOnly{http.HandlerFunc(putVer)}})
 	r.PathPrefix("/").Methods("GET", "HEAD").Handler(http.FileServer(http.Dir("hkdist/public")))
@@ -250,6 +251,27 @@ func putVer(w http.ResponseWriter, r *http.Request) {
 	io.WriteString(w, "created\n")
 }
 
+// telemetry logs a usage report from an hk whose user turned on
+// telemetry. A report holds only command counts, version, and platform.
+func telemetry(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var report struct {
+		Version  string         `json:"version"`
+		Platform string         `json:"platform"`
+		Commands map[string]int `json:"commands"`
+	}
+	if !readReqJSON(w, r, 10000, &report) {
+		return
+	}
+	b, err := json.Marshal(report)
+	if err != nil {
+		http.Error(w, "internal error", 500)
+		return
+	}
+	log.Printf(`{"func":"telemetry", "report":%s}`, b)
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func readReqJSON(w http.ResponseWriter, r *http.Request, n int64, v interface{}) bool {
 	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, n)).Decode(v)
 	if err != nil {
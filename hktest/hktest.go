@@ -0,0 +1,130 @@
+// Package hktest serves recorded Heroku API responses for testing hk
+// commands and plugins.
+//
+// A Server answers requests from a list of Fixtures, and records every
+// request it sees. Point hk (or a plugin) at it with HEROKU_API_URL:
+//
+//	fixtures, err := hktest.LoadFixtures("testdata/info.json")
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	srv := hktest.NewServer(fixtures...)
+//	defer srv.Close()
+//	os.Setenv("HEROKU_API_URL", srv.URL)
+//
+// Requests that match no fixture get a 404 and are listed by Unmatched, so
+// tests can tell a missing recording from an API error.
+package hktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// A Fixture is one recorded API response.
+type Fixture struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Status int             `json:"status,omitempty"` // 200 if zero
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// A Request is a request the Server received.
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// A Server is an httptest.Server that replays Fixtures.
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	fixtures  []Fixture
+	requests  []Request
+	unmatched []Request
+}
+
+// LoadFixtures reads a JSON array of Fixtures from the file at path.
+func LoadFixtures(path string) ([]Fixture, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []Fixture
+	if err := json.Unmarshal(b, &fixtures); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return fixtures, nil
+}
+
+// NewServer starts a Server replaying fixtures. The first fixture matching
+// a request's method and path answers it. The caller should Close the
+// server when done.
+func NewServer(fixtures ...Fixture) *Server {
+	s := &Server{fixtures: fixtures}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Add appends fixtures to those the server replays.
+func (s *Server) Add(fixtures ...Fixture) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fixtures = append(s.fixtures, fixtures...)
+}
+
+// Requests returns every request received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Unmatched returns the requests no fixture matched.
+func (s *Server) Unmatched() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.unmatched...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	req := Request{Method: r.Method, Path: r.URL.Path, Header: r.Header, Body: body}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	f, ok := s.match(req)
+	if !ok {
+		s.unmatched = append(s.unmatched, req)
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"id":"not_found","message":"hktest: no fixture for %s %s"}`, req.Method, req.Path)
+		return
+	}
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	w.Write(f.Body)
+}
+
+func (s *Server) match(req Request) (Fixture, bool) {
+	for _, f := range s.fixtures {
+		if f.Method == req.Method && f.Path == req.Path {
+			return f, true
+		}
+	}
+	return Fixture{}, false
+}
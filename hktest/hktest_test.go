@@ -0,0 +1,69 @@
+package hktest
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	srv := NewServer(Fixture{Method: "GET", Path: "/apps/myapp", Body: []byte(`{"name":"myapp"}`)})
+	defer srv.Close()
+	srv.Add(Fixture{Method: "DELETE", Path: "/apps/myapp", Status: 403, Body: []byte(`{"id":"forbidden"}`)})
+
+	tests := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{"GET", "/apps/myapp", 200, `{"name":"myapp"}`},
+		{"DELETE", "/apps/myapp", 403, `{"id":"forbidden"}`},
+		{"GET", "/apps/other", 404, "no fixture for GET /apps/other"},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, srv.URL+test.path, nil)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != test.status || !strings.Contains(string(b), test.body) {
+			t.Errorf("%s %s = %d %s, want %d %s", test.method, test.path, res.StatusCode, b, test.status, test.body)
+		}
+	}
+	if n := len(srv.Requests()); n != 3 {
+		t.Errorf("len(Requests()) = %d, want 3", n)
+	}
+	if u := srv.Unmatched(); len(u) != 1 || u[0].Path != "/apps/other" {
+		t.Errorf("Unmatched() = %v", u)
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hktest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fixtures.json")
+	data := `[{"method": "GET", "path": "/account", "body": {"email": "a@example.com"}}]`
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fixtures, err := LoadFixtures(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) != 1 || fixtures[0].Path != "/account" || string(fixtures[0].Body) != `{"email": "a@example.com"}` {
+		t.Errorf("fixtures = %+v", fixtures)
+	}
+
+	ioutil.WriteFile(path, []byte("not json"), 0644)
+	if _, err := LoadFixtures(path); err == nil {
+		t.Error("expected error for malformed fixtures")
+	}
+}
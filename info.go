@@ -1,8 +1,7 @@
-package main
+package hk
 
 import (
 	"fmt"
-	"os"
 )
 
 var cmdInfo = &Command{
@@ -14,17 +13,35 @@ var cmdInfo = &Command{
 	Long:     `Info shows general information about the current app.`,
 }
 
-func runInfo(cmd *Command, args []string) {
+func runInfo(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	app, err := client.AppInfo(mustApp())
+	appname := ctx.MustApp()
+	lockch := make(chan string, 1)
+	errch := make(chan error, 1)
+	go func() {
+		if reason, err := deployLock(appname); err != nil {
+			errch <- err
+		} else {
+			lockch <- reason
+		}
+	}()
+	app, err := ctx.Client.AppInfo(appname)
 	must(err)
-	fmt.Printf("Name:     %s\n", app.Name)
-	fmt.Printf("Owner:    %s\n", app.Owner.Email)
-	fmt.Printf("Region:   %s\n", app.Region.Name)
-	fmt.Printf("Stack:    %s\n", app.Stack.Name)
-	fmt.Printf("Git URL:  %s\n", app.GitURL)
-	fmt.Printf("Web URL:  %s\n", app.WebURL)
+	fmt.Fprintf(ctx.Stdout, "Name:     %s\n", app.Name)
+	fmt.Fprintf(ctx.Stdout, "Owner:    %s\n", app.Owner.Email)
+	fmt.Fprintf(ctx.Stdout, "Region:   %s\n", app.Region.Name)
+	fmt.Fprintf(ctx.Stdout, "Stack:    %s\n", app.Stack.Name)
+	fmt.Fprintf(ctx.Stdout, "Git URL:  %s\n", app.GitURL)
+	fmt.Fprintf(ctx.Stdout, "Web URL:  %s\n", app.WebURL)
+	select {
+	case err := <-errch:
+		printFatal(err.Error())
+	case reason := <-lockch:
+		if reason != "" {
+			fmt.Fprintf(ctx.Stdout, "Locked:   %s\n", reason)
+		}
+	}
 }
@@ -0,0 +1,116 @@
+package hk
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var cmdIPs = &Command{
+	Run:      runIPs,
+	Usage:    "ips",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "show outbound IP addresses" + extra,
+	Long: `
+Ips shows the addresses the app's outbound traffic comes from, for
+adding to allowlists on other services.
+
+Apps in a private space send traffic from the space's stable
+outbound IPs, which are listed. Apps in the common runtime have no
+fixed outbound IPs; ips lists the proxies of static IP add-ons
+(such as Fixie, QuotaGuard Static, and Proximo) found in the app's
+env vars, which traffic can be routed through instead.
+
+Examples:
+
+    $ hk ips -a private-app
+    Space:  acme-space
+    52.0.0.1
+    52.0.0.2
+
+    $ hk ips -a myapp
+    myapp is in the common runtime and has no fixed outbound IPs.
+    Route traffic through these static IP proxies instead:
+    FIXIE_URL  velodrome.usefixie.com:80
+`,
+}
+
+func runIPs(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	var app struct {
+		Space *struct {
+			Name string `json:"name"`
+		} `json:"space"`
+	}
+	must(ctx.Client.Get(&app, "/apps/"+appname))
+	if app.Space != nil {
+		var nat struct {
+			Sources []string `json:"sources"`
+			State   string   `json:"state"`
+		}
+		must(ctx.Client.Get(&nat, "/spaces/"+app.Space.Name+"/nat"))
+		fmt.Fprintf(ctx.Stdout, "Space:  %s\n", app.Space.Name)
+		if nat.State != "" && nat.State != "enabled" {
+			printWarning("outbound IPs of %s are %s.", app.Space.Name, nat.State)
+		}
+		for _, ip := range nat.Sources {
+			fmt.Fprintln(ctx.Stdout, ip)
+		}
+		return
+	}
+
+	config, err := ctx.Client.ConfigVarInfo(appname)
+	must(err)
+	proxies := staticIPProxies(config)
+	fmt.Fprintf(ctx.Stdout, "%s is in the common runtime and has no fixed outbound IPs.\n", appname)
+	if len(proxies) == 0 {
+		fmt.Fprintln(ctx.Stdout, "Add a static IP add-on, such as fixie or quotaguardstatic, to get some.")
+		return
+	}
+	fmt.Fprintln(ctx.Stdout, "Route traffic through these static IP proxies instead:")
+	var names []string
+	for name := range proxies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(ctx.Stdout, "%s  %s\n", name, proxies[name])
+	}
+}
+
+// staticIPVars are env vars set by static IP add-ons.
+var staticIPVars = []string{
+	"FIXIE_URL",
+	"FIXIE_SOCKS_HOST",
+	"PROXIMO_URL",
+	"QUOTAGUARDSTATIC_URL",
+	"QUOTAGUARDSHIELD_URL",
+	"STATICA_URL",
+}
+
+// staticIPProxies returns the proxy host of each static IP add-on env
+// var in config, without credentials.
+func staticIPProxies(config map[string]string) map[string]string {
+	proxies := make(map[string]string)
+	for _, name := range staticIPVars {
+		v, ok := config[name]
+		if !ok || v == "" {
+			continue
+		}
+		if !strings.Contains(v, "://") {
+			v = "socks5://" + v
+		}
+		u, err := url.Parse(v)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		proxies[name] = u.Host
+	}
+	return proxies
+}
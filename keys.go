@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"bytes"
@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"syscall"
 	"text/tabwriter"
+
+	"github.com/bgentry/heroku-go"
 )
 
 var (
@@ -18,12 +20,16 @@ var (
 
 var cmdKeys = &Command{
 	Run:      runKeys,
-	Usage:    "keys",
+	Usage:    "keys [-j]",
 	Category: "account",
 	Short:    "list ssh public keys" + extra,
 	Long: `
 Keys lists SSH public keys associated with your Heroku account.
 
+Options:
+
+    -j, --json  print keys as JSON
+
 Examples:
 
     $ hk keys
@@ -31,16 +37,23 @@ Examples:
 `,
 }
 
-func runKeys(cmd *Command, args []string) {
+func init() {
+	registerList(cmdKeys, heroku.Key{})
+}
+
+func runKeys(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 
-	keys, err := client.KeyList(nil)
+	keys, err := ctx.Client.KeyList(nil)
 	must(err)
+	if printList(ctx.Stdout, keys) {
+		return
+	}
 
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
 	defer w.Flush()
 
 	for i := range keys {
@@ -67,10 +80,10 @@ It tries these sources for keys, in order:
 `,
 }
 
-func runKeyAdd(cmd *Command, args []string) {
+func runKeyAdd(ctx *Context, args []string) {
 	if len(args) > 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	if len(args) == 1 {
 		sshPubKeyPath = args[0]
@@ -83,7 +96,7 @@ func runKeyAdd(cmd *Command, args []string) {
 		printFatal(err.Error())
 	}
 
-	key, err := client.KeyCreate(string(keys))
+	key, err := ctx.Client.KeyCreate(string(keys))
 	must(err)
 	log.Printf("Key %s for %s added.", abbrev(key.Fingerprint, 15), key.Email)
 }
@@ -151,14 +164,14 @@ Examples:
 `,
 }
 
-func runKeyRemove(cmd *Command, args []string) {
+func runKeyRemove(ctx *Context, args []string) {
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	fingerprint := args[0]
 
-	err := client.KeyDelete(fingerprint)
+	err := ctx.Client.KeyDelete(fingerprint)
 	must(err)
 	log.Printf("Key %s removed.", abbrev(fingerprint, 18))
 }
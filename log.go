@@ -1,26 +1,29 @@
-package main
+package hk
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
 	"regexp"
+	"strings"
 
 	"github.com/bgentry/heroku-go"
 	"github.com/mgutz/ansi"
 )
 
 var (
-	lines  int
-	source string
-	dyno   string
+	lines     int
+	tailLines int
+	source    string
+	dyno      string
+	ps        string
 )
 
 var cmdLog = &Command{
 	Run:      runLog,
-	Usage:    "log [-n <lines>] [-s <source>] [-d <dyno>]",
+	Usage:    "log [-n <lines> | --lines <lines>] [-s <source>] [-d <dyno>] [-p <type>]",
 	NeedsApp: true,
 	Category: "app",
 	Short:    "stream app log lines",
@@ -29,9 +32,15 @@ Log prints the streaming application log.
 
 Options:
 
-    -n <N>       print at most N log lines
-    -s <source>  filter log source
-    -d <dyno>    filter dyno or process type
+    -n <N>                 print at most N log lines, then exit
+    --lines <N>            print the last N log lines before streaming
+                           (default 10)
+    -s, --source <source>  filter log source (e.g. app or heroku)
+    -d, --dyno <dyno>      filter dyno or process type
+    -p, --ps <type>        filter process type
+
+Filters are applied by the log server where possible, and also to
+each line received.
 
 Examples:
 
@@ -57,19 +66,29 @@ Examples:
 
 func init() {
 	cmdLog.Flag.IntVar(&lines, "n", -1, "max number of log lines to request")
-	cmdLog.Flag.StringVar(&source, "s", "", "only display logs from the given source")
-	cmdLog.Flag.StringVar(&dyno, "d", "", "only display logs from the given dyno or process type")
+	cmdLog.Flag.IntVar(&tailLines, "lines", 10, "number of log lines to print before streaming")
+	for _, name := range []string{"s", "source"} {
+		cmdLog.Flag.StringVar(&source, name, "", "only display logs from the given source")
+	}
+	for _, name := range []string{"d", "dyno"} {
+		cmdLog.Flag.StringVar(&dyno, name, "", "only display logs from the given dyno or process type")
+	}
+	for _, name := range []string{"p", "ps"} {
+		cmdLog.Flag.StringVar(&ps, name, "", "only display logs from the given process type")
+	}
 }
 
-func runLog(cmd *Command, args []string) {
+func runLog(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 
 	opts := heroku.LogSessionCreateOpts{}
 	if dyno != "" {
 		opts.Dyno = &dyno
+	} else if ps != "" {
+		opts.Dyno = &ps
 	}
 	if source != "" {
 		opts.Source = &source
@@ -79,39 +98,103 @@ func runLog(cmd *Command, args []string) {
 		opts.Lines = &lines
 	} else {
 		tailopt := true
-		lineopt := 10
 		opts.Tail = &tailopt
-		opts.Lines = &lineopt
+		opts.Lines = &tailLines
+	}
+	filter := logFilter{source: source, dyno: dyno, ps: ps}
+
+	streamLog(ctx.Stdout, ctx.MustApp(), &opts, filter, nil)
+}
+
+// streamLog prints the lines of a new log session that match filter. If
+// until is not nil, streaming stops after the first line for which it
+// returns true.
+func streamLog(w io.Writer, appname string, opts *heroku.LogSessionCreateOpts, filter logFilter, until func(line string) bool) {
+	body := openLog(appname, opts)
+	defer body.Close()
+
+	// colors are disabled globally in main() depending on term.IsTerminal()
+	writer := newColorizer(w)
+
+	scanner := bufio.NewScanner(body)
+	scanner.Split(bufio.ScanLines)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !filter.match(line) {
+			continue
+		}
+		_, err := writer.Writeln(line)
+		must(err)
+		if until != nil && until(line) {
+			return
+		}
 	}
+}
 
-	session, err := client.LogSessionCreate(mustApp(), &opts)
+// openLog creates a log session and returns the body of its log stream.
+func openLog(appname string, opts *heroku.LogSessionCreateOpts) io.ReadCloser {
+	body, err := logSession(appname, opts)
 	if err != nil {
 		printFatal(err.Error())
 	}
+	return body
+}
+
+// logSession is like openLog, but returns errors rather than exiting.
+func logSession(appname string, opts *heroku.LogSessionCreateOpts) (io.ReadCloser, error) {
+	session, err := client.LogSessionCreate(appname, opts)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := http.Get(session.LogplexURL)
 	if err != nil {
-		printFatal(err.Error())
+		return nil, err
 	}
 	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
 		if resp.StatusCode/100 == 4 {
-			printFatal("Unauthorized")
-		} else {
-			printFatal("Unexpected error: " + resp.Status)
+			return nil, errors.New("Unauthorized")
 		}
+		return nil, errors.New("Unexpected error: " + resp.Status)
 	}
+	return resp.Body, nil
+}
 
-	// colors are disabled globally in main() depending on term.IsTerminal()
-	writer := newColorizer(os.Stdout)
+// logLineRE matches the source and dyno of a log line, e.g.
+// "2013-10-17T00:17:35.066089+00:00 app[web.1]: ...".
+var logLineRE = regexp.MustCompile(`^\S+ ([\w-]+)\[([\w.-]+)\]:`)
 
-	scanner := bufio.NewScanner(resp.Body)
-	scanner.Split(bufio.ScanLines)
+// A logFilter selects log lines by source, dyno, and process type. Empty
+// fields match everything. The dyno field matches either a dyno name
+// (web.1) or a process type (web).
+type logFilter struct {
+	source, dyno, ps string
+}
 
-	for scanner.Scan() {
-		_, err = writer.Writeln(scanner.Text())
-		must(err)
+func (f logFilter) match(line string) bool {
+	if f.source == "" && f.dyno == "" && f.ps == "" {
+		return true
 	}
-
-	resp.Body.Close()
+	m := logLineRE.FindStringSubmatch(line)
+	if m == nil {
+		return true // not a log line we understand; don't hide it
+	}
+	lineSource, lineDyno := m[1], m[2]
+	lineType := lineDyno
+	if i := strings.Index(lineDyno, "."); i >= 0 {
+		lineType = lineDyno[:i]
+	}
+	if f.source != "" && f.source != lineSource {
+		return false
+	}
+	if f.dyno != "" && f.dyno != lineDyno && f.dyno != lineType {
+		return false
+	}
+	if f.ps != "" && f.ps != lineType {
+		return false
+	}
+	return true
 }
 
 type colorizer struct {
@@ -0,0 +1,35 @@
+package hk
+
+import "testing"
+
+func TestLogFilter(t *testing.T) {
+	const (
+		appWeb1  = "2013-10-17T00:17:35.066089+00:00 app[web.1]: Completed 302 Found in 0ms"
+		appWork  = "2013-10-17T00:17:35.066089+00:00 app[worker.2]: Job done"
+		router   = "2013-10-17T00:17:35.079095+00:00 heroku[router]: at=info method=GET path=/"
+		herokuW1 = "2013-10-17T00:17:33.918946+00:00 heroku[web.1]: State changed from starting to up"
+		garbage  = "not a log line"
+	)
+	tests := []struct {
+		filter logFilter
+		line   string
+		want   bool
+	}{
+		{logFilter{}, appWeb1, true},
+		{logFilter{source: "app"}, appWeb1, true},
+		{logFilter{source: "app"}, router, false},
+		{logFilter{dyno: "web.1"}, appWeb1, true},
+		{logFilter{dyno: "web.1"}, appWork, false},
+		{logFilter{dyno: "web"}, herokuW1, true},
+		{logFilter{ps: "worker"}, appWork, true},
+		{logFilter{ps: "worker"}, appWeb1, false},
+		{logFilter{ps: "web", source: "heroku"}, herokuW1, true},
+		{logFilter{ps: "web", source: "heroku"}, appWeb1, false},
+		{logFilter{source: "app"}, garbage, true},
+	}
+	for i, tt := range tests {
+		if got := tt.filter.match(tt.line); got != tt.want {
+			t.Errorf("%d. %+v.match(%q) = %v, want %v", i, tt.filter, tt.line, got, tt.want)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"bufio"
@@ -13,7 +13,11 @@ import (
 	"strings"
 
 	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/addonapi"
+	"github.com/heroku/hk/output"
 	"github.com/heroku/hk/postgresql"
+	"github.com/heroku/hk/redis"
+	"github.com/heroku/hk/scheduler"
 	"github.com/heroku/hk/term"
 	"github.com/mgutz/ansi"
 )
@@ -25,14 +29,23 @@ var (
 
 type Command struct {
 	// args does not include the command name
-	Run      func(cmd *Command, args []string)
+	Run      func(ctx *Context, args []string)
 	Flag     flag.FlagSet
 	NeedsApp bool
 
+	// AppOptional, with NeedsApp, lets the command run when no app is
+	// given or found, for commands that can act on other apps instead.
+	AppOptional bool
+
 	Usage    string // first word is the command name
 	Category string // i.e. "App", "Account", etc.
 	Short    string // `hk help` output
 	Long     string // `hk help cmd` output
+
+	// Experimental names the feature flag that must be turned on before
+	// the command can be run, for commands that aren't stable yet. See
+	// 'hk help experimental'.
+	Experimental string
 }
 
 func (c *Command) printUsage() {
@@ -69,11 +82,11 @@ func (c *Command) Runnable() bool {
 const extra = " (extra)"
 
 func (c *Command) List() bool {
-	return c.Short != "" && !strings.HasSuffix(c.Short, extra)
+	return c.Short != "" && !strings.HasSuffix(c.Short, extra) && c.Enabled()
 }
 
 func (c *Command) ListAsExtra() bool {
-	return c.Short != "" && strings.HasSuffix(c.Short, extra)
+	return c.Short != "" && strings.HasSuffix(c.Short, extra) && c.Enabled()
 }
 
 func (c *Command) ShortExtra() string {
@@ -85,6 +98,7 @@ var commands = []*Command{
 	cmdCreate,
 	cmdApps,
 	cmdDynos,
+	cmdDeploy,
 	cmdReleases,
 	cmdReleaseInfo,
 	cmdRollback,
@@ -93,6 +107,7 @@ var commands = []*Command{
 	cmdAddonRemove,
 	cmdScale,
 	cmdRestart,
+	cmdStop,
 	cmdSet,
 	cmdUnset,
 	cmdEnv,
@@ -109,6 +124,7 @@ var commands = []*Command{
 
 	helpEnviron,
 	helpPlugins,
+	helpExperimental,
 	helpMore,
 	helpAbout,
 
@@ -116,69 +132,182 @@ var commands = []*Command{
 	cmdAccess,
 	cmdAccessAdd,
 	cmdAccessRemove,
+	cmdAccessUpdate,
+	cmdAccounts,
+	cmdAccountAdd,
+	cmdAccountDefaults,
 	cmdAccountFeatures,
 	cmdAccountFeatureInfo,
 	cmdAccountFeatureEnable,
 	cmdAccountFeatureDisable,
+	cmdACMEnable,
+	cmdACMDisable,
+	cmdACMStatus,
+	cmdAddonAttach,
+	cmdAddonAttachments,
+	cmdAddonDetach,
+	cmdAddonDocs,
+	cmdAddonDowngrade,
 	cmdAddonOpen,
+	cmdAddonPlans,
+	cmdAddonUpgrade,
+	cmdAddonWait,
 	cmdAPI,
+	cmdArchive,
+	cmdAttach,
+	cmdAutoscale,
+	cmdBlueGreenPromote,
+	cmdBootTimes,
+	cmdBuildpacks,
+	cmdBuildpackAdd,
+	cmdBuildpackRemove,
+	cmdBuildpackClear,
+	cmdCerts,
+	cmdCertInfo,
+	cmdCertAdd,
+	cmdCertUpdate,
+	cmdCertRemove,
+	cmdChangelog,
+	cmdContainerLogin,
+	cmdContainerPush,
+	cmdContainerRelease,
 	cmdCreds,
+	cmdDeployLock,
+	cmdDeployRecord,
+	cmdDeployUnlock,
 	cmdDrains,
 	cmdDrainInfo,
 	cmdDrainAdd,
 	cmdDrainRemove,
+	cmdDrainsSync,
+	cmdEnvCreate,
+	cmdEnvDestroy,
+	cmdEnvPull,
+	cmdEnvPush,
+	cmdEnvTemplate,
+	cmdErrors,
+	cmdExec,
 	cmdFeatures,
 	cmdFeatureInfo,
 	cmdFeatureEnable,
 	cmdFeatureDisable,
+	cmdFork,
+	cmdGate,
 	cmdGet,
+	cmdIPs,
 	cmdKeys,
 	cmdKeyAdd,
 	cmdKeyRemove,
+	cmdKill,
 	cmdLogin,
 	cmdLogout,
 	cmdMaintenance,
 	cmdMaintenanceEnable,
 	cmdMaintenanceDisable,
+	cmdMaintenanceScheduler,
+	cmdMetricRun,
+	cmdMetrics,
 	cmdOpen,
+	cmdOrgs,
+	cmdOrgApps,
+	cmdPgBackups,
+	cmdPgBackupCapture,
+	cmdPgBackupDownload,
+	cmdPgBackupRestore,
+	cmdPgConnectionString,
+	cmdPgCopy,
+	cmdPgCredentials,
+	cmdPgCredentialsRotate,
+	cmdPgDiagnose,
 	cmdPgInfo,
+	cmdPgKill,
+	cmdPgKillAll,
+	cmdPgPs,
+	cmdPgPull,
+	cmdPgPush,
+	cmdPgWait,
+	cmdPipelines,
+	cmdPipelineInfo,
+	cmdPipelineCreate,
+	cmdPipelineAdd,
+	cmdPipelineRemove,
+	cmdPipelinePromote,
 	cmdPsql,
+	cmdPush,
+	cmdRedisCli,
+	cmdRedisInfo,
+	cmdRedisMaintenance,
+	cmdRedisMaxmemoryPolicy,
+	cmdRedisTimeout,
 	cmdRegions,
+	cmdReleaseDiff,
+	cmdReleaseOpen,
+	cmdResource,
+	cmdScaleHistory,
+	cmdSchedule,
+	cmdScheduleAdd,
+	cmdScheduleUpdate,
+	cmdScheduleRemove,
+	cmdSetup,
+	cmdSlugs,
+	cmdSlugInfo,
+	cmdSlugDownload,
+	cmdSlugPush,
+	cmdStack,
+	cmdStacks,
+	cmdStackMigrate,
+	cmdStackSet,
+	cmdStats,
 	cmdStatus,
+	cmdSwitch,
 	cmdTransfer,
 	cmdTransfers,
 	cmdTransferAccept,
 	cmdTransferDecline,
 	cmdTransferCancel,
 	cmdURL,
+	cmdWebhooks,
+	cmdWebhookAdd,
+	cmdWebhookRemove,
+	cmdWebhookDeliveries,
+	cmdWebhookRedeliver,
 	cmdWhichApp,
+	cmdWorkerCheck,
 
 	// unlisted
 	cmdUpdate,
 }
 
 var (
-	flagApp   string
-	client    *heroku.Client
-	pgclient  *postgresql.Client
-	hkAgent   = "hk/" + Version + " (" + runtime.GOOS + "; " + runtime.GOARCH + ")"
-	userAgent = hkAgent + " " + heroku.DefaultUserAgent
+	flagApp         string
+	flagRemote      string
+	client          API
+	apiClient       *heroku.Client // the real client behind client
+	pgclient        *postgresql.Client
+	redisclient     *redis.Client
+	schedulerclient *scheduler.Client
+	hkAgent         = "hk/" + Version + " (" + runtime.GOOS + "; " + runtime.GOARCH + ")"
+	userAgent       = hkAgent + " " + heroku.DefaultUserAgent
 )
 
-func main() {
+// Main runs the hk command line in os.Args, and exits when it fails. It is
+// the whole of the hk command, which only calls it.
+func Main() {
 	log.SetFlags(0)
 
 	// make sure command is specified, disallow global args
 	args := os.Args[1:]
 	if len(args) < 1 || strings.IndexRune(args[0], '-') == 0 {
 		printUsageTo(os.Stderr)
-		os.Exit(2)
+		exit(2)
 	}
 
+	initNetwork()
+
 	// Run the update command as early as possible to avoid the possibility of
 	// installations being stranded without updates due to errors in other code
 	if args[0] == cmdUpdate.Name() {
-		cmdUpdate.Run(cmdUpdate, args)
+		cmdUpdate.Run(newContext(cmdUpdate), args)
 		return
 	} else if updater != nil {
 		defer updater.backgroundRun() // doesn't run if os.Exit is called
@@ -187,26 +316,74 @@ func main() {
 	if !term.IsTerminal(os.Stdout) {
 		ansi.DisableColors(true)
 	}
+	setup(Options{})
+	maybeSendTelemetry()
+
+	recordHistory = true
+	pagerAllowed = true
+	if dispatch(args, Options{Stdout: os.Stdout, Stderr: os.Stderr}) {
+		return
+	}
+
+	path := findPlugin(args[0])
+	if path == "" {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[0])
+		if g := suggest(args[0]); len(g) > 0 {
+			fmt.Fprintf(os.Stderr, "Possible alternatives: %v\n", strings.Join(g, " "))
+		}
+		fmt.Fprintf(os.Stderr, "Run 'hk help' for usage.\n")
+		exit(2)
+	}
+	err := execPlugin(path, args)
+	printFatal("exec error: %s", err)
+}
 
+// setup configures the API clients for a run of hk. Without credentials in
+// opts, it logs in from .netrc or the current profile, refreshing an
+// expired OAuth token. Main and Run both set up through it, after
+// initNetwork.
+func setup(opts Options) {
+	initAccessibility()
 	initClients()
+	if opts.Username != "" || opts.Password != "" {
+		setClientCreds(opts.Username, opts.Password)
+	} else {
+		refreshOAuthToken()
+	}
+	if opts.Client != nil {
+		client = opts.Client
+	}
+}
 
+// dispatch runs the command named by args[0], writing its output to
+// opts.Stdout and opts.Stderr. It reports whether there is such a command.
+func dispatch(args []string, opts Options) bool {
+	stdout, stderr := opts.Stdout, opts.Stderr
 	for _, cmd := range commands {
 		if cmd.Name() == args[0] && cmd.Run != nil {
 			cmd.Flag.Usage = func() {
 				cmd.printUsage()
 			}
-			if cmd.NeedsApp {
+			if cmd.NeedsApp && cmd.Flag.Lookup("a") == nil {
 				cmd.Flag.StringVar(&flagApp, "a", "", "app name")
+				cmd.Flag.StringVar(&flagRemote, "r", "", "git remote of app")
 			}
+			if !cmd.Enabled() {
+				printFatal("%s is experimental. To try it, set experimental.%s = true in %s. See 'hk help experimental'.", cmd.Name(), cmd.Experimental, configPath())
+			}
+			resetFlags(cmd)
+			applyFlagDefaults(cmd)
 			if err := cmd.Flag.Parse(args[1:]); err != nil {
-				os.Exit(2)
+				exit(2)
 			}
+			listPrinter = &output.Printer{Command: cmd.Name(), JSON: flagJSON, Result: opts.Result}
 			if flagApp != "" {
 				if gitRemoteApp, err := appFromGitRemote(flagApp); err == nil {
 					flagApp = gitRemoteApp
 				}
 			}
-			if cmd.NeedsApp {
+			var appname string
+			if cmd.NeedsApp && !cmd.AppOptional {
 				a, err := app()
 				switch {
 				case err == errMultipleHerokuRemotes, err == nil && a == "":
@@ -216,27 +393,45 @@ func main() {
 					}
 					printError(msg)
 					cmd.printUsage()
-					os.Exit(2)
+					exit(2)
 				case err != nil:
 					printFatal(err.Error())
 				}
+				appname = a
+			}
+			if recordHistory {
+				a := appname
+				if cmd.NeedsApp && a == "" {
+					a, _ = app()
+				}
+				appendHistory(cmd.Name(), a)
 			}
-			cmd.Run(cmd, cmd.Flag.Args())
-			return
+			setCommandHeaders(cmd.Name())
+			if pagerAllowed && stdout == os.Stdout && isPaged(cmd) {
+				stop := startPager()
+				defer stop()
+				stdout = os.Stdout
+			}
+			ctx := newContext(cmd)
+			ctx.Stdout, ctx.Stderr, ctx.AppName = stdout, stderr, appname
+			cmd.Run(ctx, cmd.Flag.Args())
+			return true
 		}
 	}
+	return false
+}
 
-	path := findPlugin(args[0])
-	if path == "" {
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", args[0])
-		if g := suggest(args[0]); len(g) > 0 {
-			fmt.Fprintf(os.Stderr, "Possible alternatives: %v\n", strings.Join(g, " "))
-		}
-		fmt.Fprintf(os.Stderr, "Run 'hk help' for usage.\n")
-		os.Exit(2)
-	}
-	err := execPlugin(path, args)
-	printFatal("exec error: %s", err)
+// resetFlags restores cmd's flags and the app flags to their defaults, so
+// that flags given to an earlier run in the same process don't carry over.
+func resetFlags(cmd *Command) {
+	flagApp, flagRemote = "", ""
+	fs := flag.NewFlagSet(cmd.Name(), flag.ContinueOnError)
+	cmd.Flag.VisitAll(func(f *flag.Flag) {
+		f.Value.Set(f.DefValue)
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	fs.Usage = cmd.Flag.Usage
+	cmd.Flag = *fs
 }
 
 func initClients() {
@@ -246,28 +441,33 @@ func initClients() {
 		apiURL = s
 		disableSSLVerify = true
 	}
-	user, pass := getCreds(apiURL)
+	user, pass := accountCreds(apiURL)
 	debug := os.Getenv("HKDEBUG") != ""
-	client = &heroku.Client{
+	apiClient = &heroku.Client{
 		URL:       apiURL,
-		Username:  user,
-		Password:  pass,
 		UserAgent: userAgent,
 		Debug:     debug,
 	}
 	pgclient = &postgresql.Client{
-		Username:  user,
-		Password:  pass,
 		UserAgent: userAgent,
 		Debug:     debug,
 	}
+	transport := http.DefaultTransport
 	if disableSSLVerify || os.Getenv("HEROKU_SSL_VERIFY") == "disable" {
-		client.HTTP = &http.Client{Transport: http.DefaultTransport}
-		client.HTTP.Transport.(*http.Transport).TLSClientConfig = &tls.Config{
-			InsecureSkipVerify: true,
-		}
-		pgclient.HTTP = client.HTTP
+		// copy the default transport, so other HTTP clients still verify
+		insecure := http.DefaultTransport.(*http.Transport).Clone()
+		insecure.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		transport = insecure
 	}
+	apiClient.HTTP = &http.Client{Transport: &twoFactorTransport{
+		base:   transport,
+		prompt: promptTwoFactorCode,
+	}}
+	pgclient.HTTP = apiClient.HTTP
+	redisclient = &redis.Client{Client: addonapi.Client{
+		HTTP:      apiClient.HTTP,
+		UserAgent: userAgent,
+	}}
 	if s := os.Getenv("HEROKU_POSTGRESQL_HOST"); s != "" {
 		pgclient.StarterURL = "https://" + s + ".herokuapp.com" + postgresql.DefaultAPIPath
 		pgclient.URL = "https://" + s + ".herokuapp.com" + postgresql.DefaultAPIPath
@@ -275,11 +475,11 @@ func initClients() {
 	if s := os.Getenv("SHOGUN"); s != "" {
 		pgclient.URL = "https://shogun-" + s + ".herokuapp.com" + postgresql.DefaultAPIPath
 	}
-	client.AdditionalHeaders = http.Header{}
+	apiClient.AdditionalHeaders = http.Header{}
 	pgclient.AdditionalHeaders = http.Header{}
 	for _, h := range strings.Split(os.Getenv("HKHEADER"), "\n") {
 		if i := strings.Index(h, ":"); i >= 0 {
-			client.AdditionalHeaders.Set(
+			apiClient.AdditionalHeaders.Set(
 				strings.TrimSpace(h[:i]),
 				strings.TrimSpace(h[i+1:]),
 			)
@@ -289,6 +489,31 @@ func initClients() {
 			)
 		}
 	}
+	redisclient.AdditionalHeaders = pgclient.AdditionalHeaders
+	schedulerclient = &scheduler.Client{Client: addonapi.Client{
+		HTTP:              apiClient.HTTP,
+		UserAgent:         userAgent,
+		AdditionalHeaders: pgclient.AdditionalHeaders,
+	}}
+	setClientCreds(user, pass)
+	client = apiClient
+}
+
+// setClientCreds sets the credentials every API client uses.
+func setClientCreds(user, pass string) {
+	apiClient.Username, apiClient.Password = user, pass
+	pgclient.Username, pgclient.Password = user, pass
+	redisclient.Username, redisclient.Password = user, pass
+	schedulerclient.Username, schedulerclient.Password = user, pass
+}
+
+// setCommandHeaders adds the header fields configured for command to
+// every API request, overriding those from HKHEADER.
+func setCommandHeaders(command string) {
+	for k, v := range commandHeaders(command) {
+		apiClient.AdditionalHeaders[k] = v
+		pgclient.AdditionalHeaders[k] = v
+	}
 }
 
 func app() (string, error) {
@@ -296,10 +521,18 @@ func app() (string, error) {
 		return flagApp, nil
 	}
 
+	if flagRemote != "" {
+		return appFromGitRemote(flagRemote)
+	}
+
 	if app := os.Getenv("HKAPP"); app != "" {
 		return app, nil
 	}
 
+	if remote := os.Getenv("HEROKU_REMOTE"); remote != "" {
+		return appFromGitRemote(remote)
+	}
+
 	return appFromGitRemote(remoteFromGitConfig())
 }
 
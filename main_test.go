@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"net/http"
@@ -8,18 +8,24 @@ import (
 	"github.com/heroku/hk/postgresql"
 )
 
+// httpTransport returns the *http.Transport under c's two-factor auth
+// transport.
+func httpTransport(c *http.Client) *http.Transport {
+	return c.Transport.(*twoFactorTransport).base.(*http.Transport)
+}
+
 func TestSSLEnabled(t *testing.T) {
 	initClients()
 
-	if client.HTTP == nil {
+	if apiClient.HTTP == nil {
 		// No http.Client means the client defaults to SSL enabled
 		return
 	}
-	if client.HTTP.Transport == nil {
+	if apiClient.HTTP.Transport == nil {
 		// No transport means the client defaults to SSL enabled
 		return
 	}
-	conf := client.HTTP.Transport.(*http.Transport).TLSClientConfig
+	conf := httpTransport(apiClient.HTTP).TLSClientConfig
 	if conf == nil {
 		// No TLSClientConfig means the client defaults to SSL enabled
 		return
@@ -36,7 +42,7 @@ func TestSSLEnabled(t *testing.T) {
 		// No transport means the pgclient defaults to SSL enabled
 		return
 	}
-	conf = pgclient.HTTP.Transport.(*http.Transport).TLSClientConfig
+	conf = httpTransport(pgclient.HTTP).TLSClientConfig
 	if conf == nil {
 		// No TLSClientConfig means the pgclient defaults to SSL enabled
 		return
@@ -46,6 +52,7 @@ func TestSSLEnabled(t *testing.T) {
 	}
 
 	client = nil
+	apiClient = nil
 	pgclient = nil
 }
 
@@ -53,15 +60,15 @@ func TestSSLDisable(t *testing.T) {
 	os.Setenv("HEROKU_SSL_VERIFY", "disable")
 	initClients()
 
-	if client.HTTP == nil {
-		t.Fatalf("client.HTTP not set, expected http.Client")
+	if apiClient.HTTP == nil {
+		t.Fatalf("apiClient.HTTP not set, expected http.Client")
 	}
-	if client.HTTP.Transport == nil {
-		t.Fatalf("client.HTTP.Transport not set")
+	if apiClient.HTTP.Transport == nil {
+		t.Fatalf("apiClient.HTTP.Transport not set")
 	}
-	conf := client.HTTP.Transport.(*http.Transport).TLSClientConfig
+	conf := httpTransport(apiClient.HTTP).TLSClientConfig
 	if conf == nil {
-		t.Fatalf("client.HTTP.Transport's TLSClientConfig is nil")
+		t.Fatalf("apiClient.HTTP.Transport's TLSClientConfig is nil")
 	}
 	if !conf.InsecureSkipVerify {
 		t.Errorf("expected InsecureSkipVerify == true")
@@ -73,7 +80,7 @@ func TestSSLDisable(t *testing.T) {
 	if pgclient.HTTP.Transport == nil {
 		t.Fatalf("pgclient.HTTP.Transport not set")
 	}
-	conf = pgclient.HTTP.Transport.(*http.Transport).TLSClientConfig
+	conf = httpTransport(pgclient.HTTP).TLSClientConfig
 	if conf == nil {
 		t.Fatalf("pgclient.HTTP.Transport's TLSClientConfig is nil")
 	}
@@ -83,6 +90,7 @@ func TestSSLDisable(t *testing.T) {
 
 	os.Setenv("HEROKU_SSL_VERIFY", "")
 	client = nil
+	apiClient = nil
 	pgclient = nil
 }
 
@@ -91,8 +99,8 @@ func TestHerokuAPIURL(t *testing.T) {
 	os.Setenv("HEROKU_API_URL", newURL)
 	initClients()
 
-	if client.URL != newURL {
-		t.Errorf("expected client.URL to be %q, got %q", newURL, client.URL)
+	if apiClient.URL != newURL {
+		t.Errorf("expected apiClient.URL to be %q, got %q", newURL, apiClient.URL)
 	}
 
 	// cleanup
@@ -106,10 +114,10 @@ func TestHerokuPostgresqlHost(t *testing.T) {
 	initClients()
 
 	if pgclient.URL != newURL {
-		t.Errorf("expected client.URL to be %q, got %q", newURL, pgclient.URL)
+		t.Errorf("expected pgclient.URL to be %q, got %q", newURL, pgclient.URL)
 	}
 	if pgclient.StarterURL != newURL {
-		t.Errorf("expected client.StarterURL to be %q, got %q", newURL, pgclient.StarterURL)
+		t.Errorf("expected pgclient.StarterURL to be %q, got %q", newURL, pgclient.StarterURL)
 	}
 
 	// cleanup
@@ -123,11 +131,11 @@ func TestShogun(t *testing.T) {
 	initClients()
 
 	if pgclient.URL != newURL {
-		t.Errorf("expected client.URL to be %q, got %q", newURL, pgclient.URL)
+		t.Errorf("expected pgclient.URL to be %q, got %q", newURL, pgclient.URL)
 	}
 	// starter URL should be unchanged
 	if pgclient.StarterURL != "" {
-		t.Errorf("expected client.StarterURL to be empty, got %q", pgclient.StarterURL)
+		t.Errorf("expected pgclient.StarterURL to be empty, got %q", pgclient.StarterURL)
 	}
 
 	// cleanup
@@ -144,11 +152,11 @@ func TestShogunAndHerokuPostgresqlHost(t *testing.T) {
 	initClients()
 
 	if pgclient.URL != newShogunURL {
-		t.Errorf("expected client.URL to be %q, got %q", newShogunURL, pgclient.URL)
+		t.Errorf("expected pgclient.URL to be %q, got %q", newShogunURL, pgclient.URL)
 	}
 	// starter URL should be unchanged
 	if pgclient.StarterURL != newHostURL {
-		t.Errorf("expected client.StarterURL to be %q, got %q", newHostURL, pgclient.StarterURL)
+		t.Errorf("expected pgclient.StarterURL to be %q, got %q", newHostURL, pgclient.StarterURL)
 	}
 
 	// TODO
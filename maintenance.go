@@ -1,9 +1,14 @@
-package main
+package hk
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/bgentry/heroku-go"
 )
@@ -24,45 +29,138 @@ Example:
 `,
 }
 
-func runMaintenance(cmd *Command, args []string) {
+func runMaintenance(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	app, err := client.AppInfo(mustApp())
+	app, err := ctx.Client.AppInfo(ctx.MustApp())
 	must(err)
 	state := "disabled"
 	if app.Maintenance {
 		state = "enabled"
 	}
-	fmt.Println(state)
+	fmt.Fprintln(ctx.Stdout, state)
 }
 
 var cmdMaintenanceEnable = &Command{
 	Run:      runMaintenanceEnable,
-	Usage:    "maintenance-enable",
+	Usage:    "maintenance-enable [--at <time>] [--duration <duration>]",
 	NeedsApp: true,
 	Category: "app",
 	Short:    "enable maintenance mode" + extra,
 	Long: `
 Enables maintenance mode on an app.
 
-Example:
+With --at, maintenance mode is scheduled instead of enabled right
+away. With --duration, maintenance mode is disabled again after the
+given time. Scheduled changes are saved locally and carried out by
+'hk maintenance-scheduler', which must be running at the scheduled
+times.
+
+Options:
+
+    --at <time>            when to enable maintenance mode, as HH:MM
+                           in local time (the next time it occurs) or
+                           an RFC 3339 timestamp
+    --duration <duration>  how long to keep maintenance mode enabled
+                           (e.g. 30m)
+
+Examples:
 
     $ hk maintenance-enable
     Enabled maintenance mode on myapp.
+
+    $ hk maintenance-enable --at 02:00 --duration 30m
+    Scheduled maintenance mode on myapp from Jan 14 02:00 to Jan 14 02:30.
 `,
 }
 
-func runMaintenanceEnable(cmd *Command, args []string) {
-	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+var (
+	flagMaintenanceAt       string
+	flagMaintenanceDuration time.Duration
+)
+
+func init() {
+	cmdMaintenanceEnable.Flag.StringVar(&flagMaintenanceAt, "at", "", "when to enable maintenance mode")
+	cmdMaintenanceEnable.Flag.DurationVar(&flagMaintenanceDuration, "duration", 0, "how long to keep maintenance mode enabled")
+}
+
+func runMaintenanceEnable(ctx *Context, args []string) {
+	if len(args) != 0 || flagMaintenanceDuration < 0 {
+		ctx.printUsage()
+		exit(2)
 	}
-	newmode := true
-	app, err := client.AppUpdate(mustApp(), &heroku.AppUpdateOpts{Maintenance: &newmode})
-	must(err)
-	log.Printf("Enabled maintenance mode on %s.", app.Name)
+	appname := ctx.MustApp()
+	if flagMaintenanceAt == "" && flagMaintenanceDuration == 0 {
+		setMaintenance(appname, true)
+		return
+	}
+
+	start := time.Now()
+	if flagMaintenanceAt != "" {
+		var err error
+		if start, err = parseMaintenanceTime(flagMaintenanceAt, time.Now()); err != nil {
+			printFatal(err.Error())
+		}
+	}
+	var sched []maintenanceChange
+	if flagMaintenanceAt == "" {
+		setMaintenance(appname, true)
+	} else {
+		sched = append(sched, maintenanceChange{appname, true, start})
+	}
+	end := start.Add(flagMaintenanceDuration)
+	if flagMaintenanceDuration > 0 {
+		sched = append(sched, maintenanceChange{appname, false, end})
+	}
+	must(scheduleMaintenance(sched...))
+
+	switch {
+	case flagMaintenanceAt == "":
+		log.Printf("Scheduled maintenance mode off on %s at %s.", appname, prettyTime{end})
+	case flagMaintenanceDuration == 0:
+		log.Printf("Scheduled maintenance mode on %s at %s.", appname, prettyTime{start})
+	default:
+		log.Printf("Scheduled maintenance mode on %s from %s to %s.", appname, prettyTime{start}, prettyTime{end})
+	}
+	log.Println("Run 'hk maintenance-scheduler' to carry out scheduled changes.")
+}
+
+func setMaintenance(appname string, enabled bool) {
+	must(updateMaintenance(appname, enabled))
+}
+
+// updateMaintenance turns the app's maintenance mode on or off, and logs
+// that it did.
+func updateMaintenance(appname string, enabled bool) error {
+	app, err := client.AppUpdate(appname, &heroku.AppUpdateOpts{Maintenance: &enabled})
+	if err != nil {
+		return err
+	}
+	if enabled {
+		log.Printf("Enabled maintenance mode on %s.", app.Name)
+	} else {
+		log.Printf("Disabled maintenance mode on %s.", app.Name)
+	}
+	return nil
+}
+
+// parseMaintenanceTime parses s as either an RFC 3339 timestamp or a local
+// HH:MM time, which refers to the next time after now that it occurs.
+func parseMaintenanceTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	hm, err := time.Parse("15:04", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, expected HH:MM or an RFC 3339 timestamp", s)
+	}
+	t := time.Date(now.Year(), now.Month(), now.Day(), hm.Hour(), hm.Minute(), 0, 0, now.Location())
+	if !t.After(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
 }
 
 var cmdMaintenanceDisable = &Command{
@@ -81,13 +179,214 @@ Example:
 `,
 }
 
-func runMaintenanceDisable(cmd *Command, args []string) {
+func runMaintenanceDisable(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	newmode := false
-	app, err := client.AppUpdate(mustApp(), &heroku.AppUpdateOpts{Maintenance: &newmode})
-	must(err)
-	log.Printf("Disabled maintenance mode on %s.", app.Name)
+	setMaintenance(ctx.MustApp(), false)
+}
+
+var cmdMaintenanceScheduler = &Command{
+	Run:      runMaintenanceScheduler,
+	Usage:    "maintenance-scheduler",
+	Category: "app",
+	Short:    "carry out scheduled maintenance mode changes" + extra,
+	Long: `
+Maintenance-scheduler runs in the foreground, enabling and disabling
+maintenance mode on apps at the times scheduled with
+'hk maintenance-enable --at'. Changes that are already due are
+carried out right away, in the order they were scheduled for; when
+several changes to an app are due at once, only the latest is
+carried out. A change that fails is tried again every 30 seconds,
+for up to 15 minutes after it was due. The schedule is kept in
+$HOME/.hk/maintenance.json.
+
+Example:
+
+    $ hk maintenance-scheduler
+    Waiting for 2 scheduled changes.
+    Enabled maintenance mode on myapp.
+    Disabled maintenance mode on myapp.
+`,
+}
+
+func runMaintenanceScheduler(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	// The schedule is reloaded each time, to pick up changes scheduled
+	// since. Errors are printed rather than stopping the scheduler, and
+	// changes that failed are tried again next time.
+	for first := true; ; first = false {
+		sched, err := loadMaintenanceSchedule()
+		if err != nil {
+			printError(err.Error())
+			time.Sleep(30 * time.Second)
+			continue
+		}
+		if first {
+			log.Printf("Waiting for %d scheduled changes.", len(sched))
+		}
+		if done := applyDueMaintenance(sched, time.Now(), updateMaintenance); len(done) > 0 {
+			if err := unscheduleMaintenance(done); err != nil {
+				printError(err.Error())
+			}
+		}
+		time.Sleep(30 * time.Second)
+	}
+}
+
+// how long after a scheduled change is due it's tried before giving up
+const maintenanceRetryTime = 15 * time.Minute
+
+// applyDueMaintenance carries out the changes in sched that are due at now
+// with update, in time order, and returns those that are finished with:
+// those carried out, those superseded by a later due change to the same
+// app, and those that have failed for longer than maintenanceRetryTime.
+func applyDueMaintenance(sched []maintenanceChange, now time.Time, update func(appname string, enable bool) error) []maintenanceChange {
+	var done []maintenanceChange
+	latest := make(map[string]maintenanceChange)
+	for _, c := range sched {
+		if c.At.After(now) {
+			continue
+		}
+		if l, ok := latest[c.App]; ok {
+			if c.At.Before(l.At) {
+				done = append(done, c)
+				continue
+			}
+			done = append(done, l)
+		}
+		latest[c.App] = c
+	}
+	var due []maintenanceChange
+	for _, c := range latest {
+		due = append(due, c)
+	}
+	sort.Sort(maintenanceChangesByTime(due))
+	for _, c := range due {
+		err := update(c.App, c.Enable)
+		switch {
+		case err == nil:
+			done = append(done, c)
+		case now.Sub(c.At) > maintenanceRetryTime:
+			printError("giving up on maintenance mode change to %s scheduled for %s: %s", c.App, prettyTime{c.At}, err)
+			done = append(done, c)
+		default:
+			printError(err.Error())
+		}
+	}
+	return done
+}
+
+// A maintenanceChange is a scheduled change to an app's maintenance mode.
+type maintenanceChange struct {
+	App    string
+	Enable bool
+	At     time.Time
+}
+
+type maintenanceChangesByTime []maintenanceChange
+
+func (a maintenanceChangesByTime) Len() int           { return len(a) }
+func (a maintenanceChangesByTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a maintenanceChangesByTime) Less(i, j int) bool { return a[i].At.Before(a[j].At) }
+
+func maintenanceSchedulePath() string {
+	return filepath.Join(hkHome(), "maintenance.json")
+}
+
+func loadMaintenanceSchedule() ([]maintenanceChange, error) {
+	var sched []maintenanceChange
+	b, err := ioutil.ReadFile(maintenanceSchedulePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sched, json.Unmarshal(b, &sched)
+}
+
+func saveMaintenanceSchedule(sched []maintenanceChange) error {
+	b, err := json.MarshalIndent(sched, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hkHome(), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(maintenanceSchedulePath(), b, 0600)
+}
+
+// updateMaintenanceSchedule rewrites the schedule with fn while holding a
+// lock file beside it, so that changes scheduled while the scheduler
+// rewrites it aren't lost.
+func updateMaintenanceSchedule(fn func([]maintenanceChange) []maintenanceChange) error {
+	if err := os.MkdirAll(hkHome(), 0700); err != nil {
+		return err
+	}
+	lock := maintenanceSchedulePath() + ".lock"
+	for start := time.Now(); ; {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			break
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+		if fi, err := os.Stat(lock); err == nil && time.Since(fi.ModTime()) > time.Minute {
+			// left behind by an hk that died holding it
+			os.Remove(lock)
+			continue
+		}
+		if time.Since(start) > 10*time.Second {
+			return fmt.Errorf("maintenance schedule is locked; remove %s if no hk is using it", lock)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	defer os.Remove(lock)
+
+	sched, err := loadMaintenanceSchedule()
+	if err != nil {
+		return err
+	}
+	return saveMaintenanceSchedule(fn(sched))
+}
+
+func scheduleMaintenance(changes ...maintenanceChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	return updateMaintenanceSchedule(func(sched []maintenanceChange) []maintenanceChange {
+		return append(sched, changes...)
+	})
+}
+
+// unscheduleMaintenance removes the given changes from the schedule,
+// leaving any others that were scheduled since it was loaded.
+func unscheduleMaintenance(changes []maintenanceChange) error {
+	return updateMaintenanceSchedule(func(sched []maintenanceChange) []maintenanceChange {
+		var kept []maintenanceChange
+		for _, c := range sched {
+			if i := indexMaintenanceChange(changes, c); i >= 0 {
+				changes = append(changes[:i:i], changes[i+1:]...)
+			} else {
+				kept = append(kept, c)
+			}
+		}
+		return kept
+	})
+}
+
+func indexMaintenanceChange(changes []maintenanceChange, c maintenanceChange) int {
+	for i, d := range changes {
+		if d.App == c.App && d.Enable == c.Enable && d.At.Equal(c.At) {
+			return i
+		}
+	}
+	return -1
 }
@@ -0,0 +1,72 @@
+package hk
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMaintenanceTime(t *testing.T) {
+	now := time.Date(2014, 1, 13, 18, 30, 0, 0, time.Local)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"20:00", time.Date(2014, 1, 13, 20, 0, 0, 0, time.Local)},
+		{"02:00", time.Date(2014, 1, 14, 2, 0, 0, 0, time.Local)},
+		{"18:30", time.Date(2014, 1, 14, 18, 30, 0, 0, time.Local)},
+		{"2014-02-01T02:00:00Z", time.Date(2014, 2, 1, 2, 0, 0, 0, time.UTC)},
+	}
+	for i, tt := range tests {
+		got, err := parseMaintenanceTime(tt.in, now)
+		if err != nil {
+			t.Errorf("%d. parseMaintenanceTime(%q) error: %s", i, tt.in, err)
+		} else if !got.Equal(tt.want) {
+			t.Errorf("%d. parseMaintenanceTime(%q) => %s, want %s", i, tt.in, got, tt.want)
+		}
+	}
+	if _, err := parseMaintenanceTime("2am", now); err == nil {
+		t.Errorf("expected error for invalid time")
+	}
+}
+
+func TestApplyDueMaintenance(t *testing.T) {
+	now := time.Date(2014, 1, 14, 2, 40, 0, 0, time.UTC)
+	at := func(hm string) time.Time {
+		t, _ := time.Parse("15:04", hm)
+		return time.Date(2014, 1, 14, t.Hour(), t.Minute(), 0, 0, time.UTC)
+	}
+	sched := []maintenanceChange{
+		{"myapp", false, at("02:30")},
+		{"myapp", true, at("02:00")},  // superseded by the disable
+		{"other", true, at("02:35")},  // fails, but is retried
+		{"broken", true, at("02:20")}, // fails for too long
+		{"later", true, at("03:00")},  // not due
+	}
+	var applied []string
+	update := func(appname string, enable bool) error {
+		applied = append(applied, fmt.Sprintf("%s=%t", appname, enable))
+		if appname == "other" || appname == "broken" {
+			return errors.New("service unavailable")
+		}
+		return nil
+	}
+	done := applyDueMaintenance(sched, now, update)
+
+	want := []string{"broken=true", "myapp=false", "other=true"}
+	if strings.Join(applied, " ") != strings.Join(want, " ") {
+		t.Errorf("applied %v, want %v", applied, want)
+	}
+	var got []string
+	for _, c := range done {
+		got = append(got, fmt.Sprintf("%s=%t", c.App, c.Enable))
+	}
+	sort.Strings(got)
+	want = []string{"broken=true", "myapp=false", "myapp=true"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("done %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdMetricRun = &Command{
+	Run:      runMetricRun,
+	Usage:    "metric-run [-s <size>] [--above <n>] [--below <n>] <command> [<argument>...]",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "run a process that prints a number" + extra,
+	Long: `
+Metric-run runs a one-off process that prints a single number, such
+as the depth of a job queue, and prints that number. The last
+non-blank line of the process's output is taken as the number, so
+warnings printed before it are ignored.
+
+With --above or --below, metric-run exits with status 1 when the
+number is past the threshold, making it a building block for
+autoscaling and monitoring scripts. If the process doesn't print
+a number, metric-run exits with status 3.
+
+Options:
+
+    -s <size>    set the size for the dyno (e.g. 2X)
+    --above <n>  exit 1 if the number is greater than n
+    --below <n>  exit 1 if the number is less than n
+
+Examples:
+
+    $ hk metric-run rake queue:depth
+    412
+
+    $ hk metric-run --above 1000 rake queue:depth || hk scale worker=10
+    412
+`,
+}
+
+var (
+	flagMetricSize  string
+	flagMetricAbove float64
+	flagMetricBelow float64
+)
+
+func init() {
+	cmdMetricRun.Flag.StringVar(&flagMetricSize, "s", "", "dyno size")
+	cmdMetricRun.Flag.Float64Var(&flagMetricAbove, "above", math.NaN(), "upper threshold")
+	cmdMetricRun.Flag.Float64Var(&flagMetricBelow, "below", math.NaN(), "lower threshold")
+}
+
+func runMetricRun(ctx *Context, args []string) {
+	if len(args) == 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+
+	attach := true
+	opts := heroku.DynoCreateOpts{Attach: &attach}
+	if flagMetricSize != "" {
+		if !strings.HasSuffix(flagMetricSize, "X") {
+			ctx.printUsage()
+			exit(2)
+		}
+		opts.Size = &flagMetricSize
+	}
+	dyno, err := ctx.Client.DynoCreate(appname, strings.Join(args, " "), &opts)
+	must(err)
+	cn, br, err := dialDyno(*dyno.AttachURL)
+	if err != nil {
+		printFatal(err.Error())
+	}
+	defer cn.Close()
+	out, err := ioutil.ReadAll(br)
+	if err != nil && err != io.EOF {
+		printFatal(err.Error())
+	}
+
+	n, err := parseMetric(string(out))
+	if err != nil {
+		printError("%s: %s", dyno.Name, err)
+		exit(3)
+	}
+	fmt.Fprintln(ctx.Stdout, strconv.FormatFloat(n, 'f', -1, 64))
+	if metricOutOfRange(n, flagMetricAbove, flagMetricBelow) {
+		exit(1)
+	}
+}
+
+// parseMetric returns the number on the last non-blank line of out.
+func parseMetric(out string) (float64, error) {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	last := strings.TrimSpace(lines[len(lines)-1])
+	if last == "" {
+		return 0, fmt.Errorf("no output")
+	}
+	n, err := strconv.ParseFloat(last, 64)
+	if err != nil {
+		return 0, fmt.Errorf("expected a number, got %q", abbrev(last, 40))
+	}
+	return n, nil
+}
+
+// metricOutOfRange reports whether n is greater than above or less than
+// below. NaN thresholds are unset.
+func metricOutOfRange(n, above, below float64) bool {
+	return (!math.IsNaN(above) && n > above) || (!math.IsNaN(below) && n < below)
+}
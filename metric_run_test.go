@@ -0,0 +1,46 @@
+package hk
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseMetric(t *testing.T) {
+	tests := []struct {
+		out  string
+		want float64
+		ok   bool
+	}{
+		{"412\r\n", 412, true},
+		{"warning: deprecated gem\r\n0.75\r\n\r\n", 0.75, true},
+		{"  -3  ", -3, true},
+		{"", 0, false},
+		{"queue depth is 12\n", 0, false},
+	}
+	for _, test := range tests {
+		got, err := parseMetric(test.out)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("parseMetric(%q) = %v, %v, want %v (ok=%v)", test.out, got, err, test.want, test.ok)
+		}
+	}
+}
+
+func TestMetricOutOfRange(t *testing.T) {
+	unset := math.NaN()
+	tests := []struct {
+		n, above, below float64
+		want            bool
+	}{
+		{5, unset, unset, false},
+		{5, 10, unset, false},
+		{11, 10, unset, true},
+		{10, 10, unset, false},
+		{1, unset, 2, true},
+		{5, 10, 2, false},
+	}
+	for _, test := range tests {
+		if got := metricOutOfRange(test.n, test.above, test.below); got != test.want {
+			t.Errorf("metricOutOfRange(%v, %v, %v) = %v, want %v", test.n, test.above, test.below, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package hk
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdMetrics = &Command{
+	Run:      runMetrics,
+	Usage:    "metrics [-n <lines>] [<type>]",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "show dyno load and memory" + extra,
+	Long: `
+Metrics shows the load and memory use of an app's dynos, or of the
+dynos of one process type, from the samples that Heroku logs every
+20 seconds or so. For each dyno, it shows the latest 1-minute load
+average and memory use, the memory quota, and sparklines of how
+each changed over the samples found.
+
+Samples are only logged for apps with the log-runtime-metrics
+feature; turn it on with 'hk feature-enable log-runtime-metrics'.
+Metrics reads the log rather than the metrics service behind the
+Dashboard's Metrics tab, since that service isn't part of the
+public Platform API and has no documented endpoints or auth.
+
+Options:
+
+    -n <lines>  number of log lines to look for samples in
+                (default 1500)
+
+Example:
+
+    $ hk metrics web
+    dyno   load  load trend  memory  quota   %    memory trend
+    web.1  0.42  ▁▂▂▃▅▃▂▂▁▁  312 MB  512 MB  61%  ▅▅▅▆▆▆▆▇▇▇
+    web.2  0.18  ▁▁▂▁▁▂▁▁▁▁  288 MB  512 MB  56%  ▅▅▅▅▅▅▆▆▆▆
+`,
+}
+
+var flagMetricsLines int
+
+func init() {
+	cmdMetrics.Flag.IntVar(&flagMetricsLines, "n", 1500, "number of log lines")
+}
+
+func runMetrics(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	source := "heroku"
+	opts := heroku.LogSessionCreateOpts{Lines: &flagMetricsLines, Source: &source}
+	if len(args) == 1 {
+		opts.Dyno = &args[0]
+	}
+	body := openLog(appname, &opts)
+	defer body.Close()
+	metrics, err := readDynoMetrics(bufio.NewScanner(body))
+	must(err)
+	if len(metrics) == 0 {
+		log.Printf("No dyno metrics in the last %d log lines. Turn on log-runtime-metrics with 'hk feature-enable log-runtime-metrics'.", flagMetricsLines)
+		return
+	}
+
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	if accessibleOutput {
+		listRec(w, "dyno", "load", "max load", "memory", "max memory", "quota", "%")
+	} else {
+		listRec(w, "dyno", "load", "load trend", "memory", "quota", "%", "memory trend")
+	}
+	for _, m := range metrics {
+		load, mem := lastSample(m.Load), lastSample(m.Memory)
+		pct := ""
+		if m.Quota > 0 && len(m.Memory) > 0 {
+			pct = fmt.Sprintf("%.0f%%", mem/m.Quota*100)
+		}
+		if accessibleOutput {
+			listRec(w, m.Dyno, formatLoad(m.Load, load), formatLoad(m.Load, maxSample(m.Load)),
+				formatMB(mem), formatMB(maxSample(m.Memory)), formatMB(m.Quota), pct)
+			continue
+		}
+		memMax := m.Quota
+		if memMax == 0 {
+			memMax = maxSample(m.Memory)
+		}
+		listRec(w, m.Dyno, formatLoad(m.Load, load), sparkline(m.Load, maxSample(m.Load)),
+			formatMB(mem), formatMB(m.Quota), pct, sparkline(m.Memory, memMax))
+	}
+}
+
+// dynoMetrics are the load and memory samples of a dyno, oldest first.
+// They come from log-runtime-metrics lines only: the Dashboard's metrics
+// service has no public API to read them from instead.
+type dynoMetrics struct {
+	Dyno   string
+	Load   []float64 // 1-minute load averages
+	Memory []float64 // total memory use, in MB
+	Quota  float64   // memory quota in MB, or 0 if unknown
+}
+
+// e.g. "sample#memory_total=21.00MB"
+var metricSampleRE = regexp.MustCompile(`\bsample#([\w.]+)=([\d.]+)`)
+
+// readDynoMetrics returns the samples of each dyno in log-runtime-metrics
+// lines read by s, sorted by dyno.
+func readDynoMetrics(s *bufio.Scanner) ([]dynoMetrics, error) {
+	byDyno := make(map[string]*dynoMetrics)
+	for s.Scan() {
+		line := s.Text()
+		m := logLineRE.FindStringSubmatch(line)
+		if m == nil || m[1] != "heroku" || !strings.Contains(line, "sample#") {
+			continue
+		}
+		d := byDyno[m[2]]
+		if d == nil {
+			d = &dynoMetrics{Dyno: m[2]}
+			byDyno[m[2]] = d
+		}
+		for _, sm := range metricSampleRE.FindAllStringSubmatch(line, -1) {
+			v, err := strconv.ParseFloat(sm[2], 64)
+			if err != nil {
+				continue
+			}
+			switch sm[1] {
+			case "load_avg_1m":
+				d.Load = append(d.Load, v)
+			case "memory_total":
+				d.Memory = append(d.Memory, v)
+			case "memory_quota":
+				d.Quota = v
+			}
+		}
+	}
+	var dynos []string
+	for dyno, d := range byDyno {
+		if len(d.Load) > 0 || len(d.Memory) > 0 {
+			dynos = append(dynos, dyno)
+		}
+	}
+	sort.Strings(dynos)
+	var metrics []dynoMetrics
+	for _, dyno := range dynos {
+		metrics = append(metrics, *byDyno[dyno])
+	}
+	return metrics, s.Err()
+}
+
+// the most samples drawn in a sparkline
+const sparklineWidth = 20
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws the last sparklineWidth samples as bars, scaled so that
+// max is a full bar.
+func sparkline(samples []float64, max float64) string {
+	if len(samples) > sparklineWidth {
+		samples = samples[len(samples)-sparklineWidth:]
+	}
+	bars := make([]rune, len(samples))
+	for i, v := range samples {
+		n := 0
+		if max > 0 {
+			n = int(v / max * float64(len(sparks)-1))
+		}
+		if n < 0 {
+			n = 0
+		} else if n >= len(sparks) {
+			n = len(sparks) - 1
+		}
+		bars[i] = sparks[n]
+	}
+	return string(bars)
+}
+
+func lastSample(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	return samples[len(samples)-1]
+}
+
+func maxSample(samples []float64) float64 {
+	max := 0.0
+	for _, v := range samples {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+// formatLoad formats a load average, or "" if there are no samples.
+func formatLoad(samples []float64, v float64) string {
+	if len(samples) == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// formatMB formats a size in MB, or "" if it's 0, as when there are no
+// samples.
+func formatMB(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.0f MB", v)
+}
@@ -0,0 +1,48 @@
+package hk
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadDynoMetrics(t *testing.T) {
+	log := `2014-01-13T21:20:37+00:00 heroku[web.1]: source=web.1 dyno=heroku.1.a sample#load_avg_1m=0.20 sample#load_avg_5m=0.10 sample#load_avg_15m=0.05
+2014-01-13T21:20:37+00:00 heroku[web.1]: source=web.1 dyno=heroku.1.a sample#memory_total=256.00MB sample#memory_rss=250.00MB sample#memory_quota=512.00MB
+2014-01-13T21:20:40+00:00 app[web.1]: sample#memory_total=1.00MB from the app, not Heroku
+2014-01-13T21:20:45+00:00 heroku[router]: at=info method=GET path="/" dyno=web.1 status=200
+2014-01-13T21:20:57+00:00 heroku[web.1]: source=web.1 dyno=heroku.1.a sample#load_avg_1m=0.40 sample#load_avg_5m=0.20 sample#load_avg_15m=0.10
+2014-01-13T21:20:57+00:00 heroku[web.1]: source=web.1 dyno=heroku.1.a sample#memory_total=300.50MB sample#memory_rss=290.00MB sample#memory_quota=512.00MB
+2014-01-13T21:20:58+00:00 heroku[worker.1]: source=worker.1 dyno=heroku.1.b sample#load_avg_1m=1.00
+`
+	got, err := readDynoMetrics(bufio.NewScanner(strings.NewReader(log)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []dynoMetrics{
+		{Dyno: "web.1", Load: []float64{0.2, 0.4}, Memory: []float64{256, 300.5}, Quota: 512},
+		{Dyno: "worker.1", Load: []float64{1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readDynoMetrics = %+v, want %+v", got, want)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		samples []float64
+		max     float64
+		want    string
+	}{
+		{[]float64{0, 1, 2, 3, 4, 5, 6, 7}, 7, "▁▂▃▄▅▆▇█"},
+		{[]float64{1, 2}, 0, "▁▁"},
+		{[]float64{10}, 5, "█"},
+		{nil, 1, ""},
+	}
+	for _, test := range tests {
+		if got := sparkline(test.samples, test.max); got != test.want {
+			t.Errorf("sparkline(%v, %v) = %q, want %q", test.samples, test.max, got, test.want)
+		}
+	}
+}
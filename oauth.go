@@ -0,0 +1,284 @@
+package hk
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// oauthIdURL is the Heroku identity service, which authorizes OAuth
+// clients and issues their tokens.
+const oauthIdURL = "https://id.heroku.com"
+
+// An oauthToken is an access token from the browser login flow, along with
+// what's needed to refresh it. Access tokens are kept in .netrc like tokens
+// from password logins, and the rest in $HOME/.hk/oauth.json; or both are
+// kept in an account profile saved while logged in with the token.
+type oauthToken struct {
+	Host         string    `json:"host"`
+	Login        string    `json:"login"`
+	AccessToken  string    `json:"-"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// oauthClient returns the OAuth client ID and secret used for browser
+// logins, from the config file. Browser logins are unavailable if they're
+// unset.
+func oauthClient() (id, secret string) {
+	return configValue("oauth.client-id"), configValue("oauth.client-secret")
+}
+
+// oauthRedirectAddr is the local address that receives the OAuth callback.
+// The OAuth client's redirect URI must be http://<addr>/callback.
+func oauthRedirectAddr() string {
+	if s := configValue("oauth.redirect-addr"); s != "" {
+		return s
+	}
+	return "127.0.0.1:5050"
+}
+
+// browserLogin authorizes hk in a web browser and returns the new token.
+func browserLogin() (*oauthToken, error) {
+	id, secret := oauthClient()
+	if id == "" || secret == "" {
+		return nil, fmt.Errorf("browser login needs oauth.client-id and oauth.client-secret in %s", configPath())
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	state := hex.EncodeToString(b)
+
+	l, err := net.Listen("tcp", oauthRedirectAddr())
+	if err != nil {
+		return nil, err
+	}
+	defer l.Close()
+	codes := make(chan string, 1)
+	go http.Serve(l, oauthCallbackHandler(state, codes))
+
+	authURL := oauthIdURL + "/oauth/authorize?" + url.Values{
+		"client_id":     {id},
+		"response_type": {"code"},
+		"scope":         {"global"},
+		"state":         {state},
+	}.Encode()
+	fmt.Fprintf(os.Stderr, "Opening %s in your browser...\n", authURL)
+	openURL(authURL)
+
+	var code string
+	select {
+	case code = <-codes:
+	case <-time.After(5 * time.Minute):
+		return nil, errors.New("timed out waiting for browser login")
+	}
+	return requestOAuthToken(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"client_secret": {secret},
+	})
+}
+
+// oauthCallbackHandler serves the OAuth redirect, sending the authorization
+// code on codes once a request with the expected state arrives.
+func oauthCallbackHandler(state string, codes chan<- string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/callback" || q.Get("state") != state {
+			http.Error(w, "Invalid login callback.", http.StatusBadRequest)
+			return
+		}
+		if e := q.Get("error"); e != "" {
+			http.Error(w, "Login failed: "+e, http.StatusForbidden)
+			return
+		}
+		fmt.Fprintln(w, "Logged in to hk. You can close this window.")
+		select {
+		case codes <- q.Get("code"):
+		default:
+		}
+	})
+}
+
+// requestOAuthToken exchanges a grant for an access token, and looks up the
+// account it belongs to.
+func requestOAuthToken(form url.Values) (*oauthToken, error) {
+	res, err := http.PostForm(oauthIdURL+"/oauth/token", form)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("token request failed: %s", res.Status)
+	}
+	var v struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&v); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(apiURL)
+	if err != nil {
+		return nil, err
+	}
+	c := *apiClient
+	c.Username, c.Password = "", v.AccessToken
+	account, err := c.AccountInfo()
+	if err != nil {
+		return nil, err
+	}
+	return &oauthToken{
+		Host:         strings.Split(u.Host, ":")[0],
+		Login:        account.Email,
+		AccessToken:  v.AccessToken,
+		RefreshToken: v.RefreshToken,
+		ExpiresAt:    time.Now().Add(time.Duration(v.ExpiresIn) * time.Second),
+	}, nil
+}
+
+func oauthTokenPath() string {
+	return filepath.Join(hkHome(), "oauth.json")
+}
+
+// loadOAuthTokens returns the tokens of browser logins saved in .netrc,
+// keyed by API host. Tokens of account profiles are kept with the profile.
+func loadOAuthTokens() (map[string]*oauthToken, error) {
+	tokens := make(map[string]*oauthToken)
+	b, err := ioutil.ReadFile(oauthTokenPath())
+	if os.IsNotExist(err) {
+		return tokens, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		// older versions kept a single token
+		var t oauthToken
+		if json.Unmarshal(b, &t) != nil {
+			return nil, fmt.Errorf("%s: %s", oauthTokenPath(), err)
+		}
+		tokens = map[string]*oauthToken{t.Host: &t}
+	}
+	return tokens, nil
+}
+
+func saveOAuthTokens(tokens map[string]*oauthToken) error {
+	b, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hkHome(), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(oauthTokenPath(), b, 0600)
+}
+
+// saveOAuthToken stores t's access token in .netrc and its refresh token in
+// hk's home directory.
+func saveOAuthToken(t *oauthToken) error {
+	if err := saveCreds(t.Host, t.Login, t.AccessToken); err != nil {
+		return err
+	}
+	tokens, err := loadOAuthTokens()
+	if err != nil {
+		return err
+	}
+	tokens[t.Host] = t
+	return saveOAuthTokens(tokens)
+}
+
+// removeOAuthToken forgets the refresh token of the browser login in
+// .netrc for host.
+func removeOAuthToken(host string) error {
+	tokens, err := loadOAuthTokens()
+	if err != nil || tokens[host] == nil {
+		return err
+	}
+	delete(tokens, host)
+	return saveOAuthTokens(tokens)
+}
+
+// usedOAuthToken returns the browser login token of the credentials in
+// use for host: those of the current account profile, or else those in
+// .netrc. It returns nil if they aren't from a browser login.
+func usedOAuthToken(host string) *oauthToken {
+	var t *oauthToken
+	if name := currentAccount(); name != "" {
+		accounts, err := loadAccounts()
+		if err != nil {
+			return nil
+		}
+		t = accounts[name].OAuth
+	} else {
+		tokens, err := loadOAuthTokens()
+		if err != nil {
+			return nil
+		}
+		t = tokens[host]
+	}
+	if t == nil || t.RefreshToken == "" || t.Host != host || t.Login != apiClient.Username {
+		return nil
+	}
+	return t
+}
+
+// refreshOAuthToken replaces the access token in use when it's from a
+// browser login and is about to expire. The new token is saved where the
+// old one was, in the current account profile or in .netrc, and given to
+// every client. It does nothing for password logins.
+func refreshOAuthToken() {
+	u, err := url.Parse(apiURL)
+	if err != nil || u.User != nil {
+		return
+	}
+	t := usedOAuthToken(strings.Split(u.Host, ":")[0])
+	if t == nil || time.Until(t.ExpiresAt) > time.Minute {
+		return
+	}
+	_, secret := oauthClient()
+	nt, err := requestOAuthToken(url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {t.RefreshToken},
+		"client_secret": {secret},
+	})
+	if err == nil {
+		if nt.RefreshToken == "" {
+			nt.RefreshToken = t.RefreshToken
+		}
+		err = saveRefreshedToken(nt)
+	}
+	if err != nil {
+		printWarning("refreshing login token: %s. Run 'hk login' to log in again.", err)
+		return
+	}
+	setClientCreds(nt.Login, nt.AccessToken)
+}
+
+// saveRefreshedToken saves t in place of the token it refreshed.
+func saveRefreshedToken(t *oauthToken) error {
+	name := currentAccount()
+	if name == "" {
+		return saveOAuthToken(t)
+	}
+	accounts, err := loadAccounts()
+	if err != nil {
+		return err
+	}
+	a := accounts[name]
+	a.Login, a.Password, a.OAuth = t.Login, t.AccessToken, t
+	accounts[name] = a
+	return saveAccounts(accounts)
+}
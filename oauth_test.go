@@ -0,0 +1,80 @@
+package hk
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestOAuthCallbackHandler(t *testing.T) {
+	codes := make(chan string, 1)
+	h := oauthCallbackHandler("s3cret", codes)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/callback?state=wrong&code=abc", http.StatusBadRequest},
+		{"/other?state=s3cret&code=abc", http.StatusBadRequest},
+		{"/callback?state=s3cret&error=access_denied", http.StatusForbidden},
+		{"/callback?state=s3cret&code=abc", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.path, w.Code, tt.status)
+		}
+	}
+	select {
+	case code := <-codes:
+		if code != "abc" {
+			t.Errorf("code = %q, want abc", code)
+		}
+	default:
+		t.Errorf("no code received")
+	}
+}
+
+func TestLoadOAuthTokens(t *testing.T) {
+	home, err := ioutil.TempDir("", "hk-oauth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", home)
+
+	// older versions kept a single token
+	if err := os.MkdirAll(hkHome(), 0700); err != nil {
+		t.Fatal(err)
+	}
+	old := `{"host": "api.heroku.com", "login": "user@test.com", "refresh_token": "r1"}`
+	if err := ioutil.WriteFile(oauthTokenPath(), []byte(old), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := loadOAuthTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok := tokens["api.heroku.com"]; tok == nil || tok.RefreshToken != "r1" {
+		t.Fatalf("old token file => %v, want the token for api.heroku.com", tokens)
+	}
+
+	tokens["api.example.com"] = &oauthToken{Host: "api.example.com", Login: "ops@example.com", RefreshToken: "r2"}
+	if err := saveOAuthTokens(tokens); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeOAuthToken("api.heroku.com"); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err = loadOAuthTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens["api.example.com"] == nil || tokens["api.example.com"].RefreshToken != "r2" {
+		t.Errorf("after removing api.heroku.com => %v, want only api.example.com", tokens)
+	}
+}
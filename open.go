@@ -1,6 +1,4 @@
-package main
-
-import "os"
+package hk
 
 var cmdOpen = &Command{
 	Run:      runOpen,
@@ -11,10 +9,10 @@ var cmdOpen = &Command{
 	Long:     `Open opens the app in a web browser. (Assumes cedar.)`,
 }
 
-func runOpen(cmd *Command, args []string) {
+func runOpen(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	must(openURL("https://" + mustApp() + ".herokuapp.com/"))
+	must(openURL("https://" + ctx.MustApp() + ".herokuapp.com/"))
 }
@@ -0,0 +1,130 @@
+package hk
+
+import (
+	"net/url"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdOrgs = &Command{
+	Run:      runOrgs,
+	Usage:    "orgs [-j]",
+	Category: "org",
+	Short:    "list organizations" + extra,
+	Long: `
+Lists the organizations you belong to, with your role in each. Your
+default organization is marked with a *.
+
+Example:
+
+    $ hk orgs
+    acme     admin
+    initech  member  *
+`,
+}
+
+func init() {
+	registerList(cmdOrgs, organization{})
+}
+
+func runOrgs(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	orgs, err := listOrgs()
+	must(err)
+	if printList(ctx.Stdout, orgs) {
+		return
+	}
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, o := range orgs {
+		mark := ""
+		if o.Default {
+			mark = "*"
+		}
+		listRec(w, o.Name, o.Role, mark)
+	}
+}
+
+var cmdOrgApps = &Command{
+	Run:      runOrgApps,
+	Usage:    "org-apps [-j] <org>",
+	Category: "org",
+	Short:    "list apps in an organization" + extra,
+	Long: `
+Lists the apps in an organization, in the same form as 'hk apps'.
+
+Example:
+
+    $ hk org-apps acme
+    acme-api  acme@herokumanager…  org admin  Jan 2 12:34
+    acme-web  acme@herokumanager…  org admin  Jan 2 12:34
+`,
+}
+
+func init() {
+	registerList(cmdOrgApps, heroku.App{})
+}
+
+func runOrgApps(ctx *Context, args []string) {
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	var apps []heroku.App
+	must(ctx.Client.Get(&apps, "/organizations/"+url.QueryEscape(args[0])+"/apps"))
+	rel, err := loadAppRelation()
+	must(err)
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	printAppList(w, apps, rel)
+}
+
+// listOrgs returns the organizations the user belongs to, sorted by name.
+func listOrgs() ([]organization, error) {
+	var orgs []organization
+	if err := client.Get(&orgs, "/organizations"); err != nil {
+		return nil, err
+	}
+	sort.Sort(orgsByName(orgs))
+	return orgs, nil
+}
+
+type orgsByName []organization
+
+func (a orgsByName) Len() int           { return len(a) }
+func (a orgsByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a orgsByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+// createOrgApp creates an app owned by org. Name and region are optional,
+// as for AppCreate.
+func createOrgApp(org string, opts *heroku.AppCreateOpts) (*heroku.App, error) {
+	body := struct {
+		Organization string  `json:"organization"`
+		Name         *string `json:"name,omitempty"`
+		Region       *string `json:"region,omitempty"`
+		Stack        *string `json:"stack,omitempty"`
+	}{org, opts.Name, opts.Region, opts.Stack}
+	var app heroku.App
+	if err := client.Post(&app, "/organizations/apps", body); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// transferAppToOrg moves an app into org. Unlike transfers to a user, it
+// takes effect right away, without being accepted.
+func transferAppToOrg(appname, org string) (*heroku.App, error) {
+	body := struct {
+		Owner string `json:"owner"`
+	}{org}
+	var app heroku.App
+	if err := client.Patch(&app, "/organizations/apps/"+appname, body); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
@@ -0,0 +1,55 @@
+package hk
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bgentry/heroku-go"
+)
+
+func TestOrgAppRequests(t *testing.T) {
+	type request struct {
+		method, path string
+		body         map[string]interface{}
+	}
+	var got []request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		b, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(b, &body)
+		got = append(got, request{r.Method, r.URL.Path, body})
+		w.Write([]byte(`{"name": "acme-api"}`))
+	}))
+	defer ts.Close()
+	defer func(c API) { client = c }(client)
+	client = &heroku.Client{URL: ts.URL}
+
+	name := "acme-api"
+	app, err := createOrgApp("acme", &heroku.AppCreateOpts{Name: &name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Name != "acme-api" {
+		t.Errorf("app.Name = %q", app.Name)
+	}
+	if _, err := transferAppToOrg("myapp", "acme"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d requests, want 2", len(got))
+	}
+	if r := got[0]; r.method != "POST" || r.path != "/organizations/apps" ||
+		r.body["organization"] != "acme" || r.body["name"] != "acme-api" {
+		t.Errorf("create request = %+v", r)
+	}
+	if _, ok := got[0].body["region"]; ok {
+		t.Errorf("create request has region without -r: %+v", got[0].body)
+	}
+	if r := got[1]; r.method != "PATCH" || r.path != "/organizations/apps/myapp" || r.body["owner"] != "acme" {
+		t.Errorf("transfer request = %+v", r)
+	}
+}
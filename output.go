@@ -0,0 +1,33 @@
+package hk
+
+import (
+	"io"
+
+	"github.com/heroku/hk/output"
+)
+
+// flagJSON is set by the -j and --json flags of list commands. When it's
+// set, those commands print JSON on stdout instead of tabular output.
+var flagJSON bool
+
+// listPrinter takes the lists printed by the running command. dispatch
+// sets it up for each command run.
+var listPrinter = &output.Printer{}
+
+// registerList records with the output layer that cmd lists values of v's
+// type, and gives cmd the -j and --json flags.
+func registerList(cmd *Command, v interface{}) {
+	output.Register(cmd.Name(), v)
+	cmd.Flag.BoolVar(&flagJSON, "j", false, "print JSON")
+	cmd.Flag.BoolVar(&flagJSON, "json", false, "print JSON")
+}
+
+// printList hands list, what the running command lists, to listPrinter,
+// which prints it on w as JSON with --json, or passes it to the program
+// running the command with Run. It reports whether it did either; if not,
+// the caller prints list as a table.
+func printList(w io.Writer, list interface{}) bool {
+	ok, err := listPrinter.List(w, list)
+	must(err)
+	return ok
+}
@@ -0,0 +1,15 @@
+// Package output is the shared output layer of hk's list commands.
+//
+// Each command that lists things registers the type of what it lists
+// once, by command name. A Printer then takes each list a command
+// produces, and either prints it as JSON for scripts, hands it to a
+// program running the command in-process, or leaves the command to print
+// it as a table:
+//
+//	output.Register("apps", heroku.App{})
+//	...
+//	p := &output.Printer{Command: "apps", JSON: true}
+//	if ok, err := p.List(os.Stdout, apps); !ok && err == nil {
+//		// print apps as a table
+//	}
+package output
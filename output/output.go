@@ -0,0 +1,107 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// types are the registered element types of each command's lists. A nil
+// type means the command's results have no fixed type.
+var types = make(map[string]reflect.Type)
+
+// Register records that the command called name lists values of v's type,
+// such as heroku.App{}. A nil v registers a command whose results have no
+// fixed type, such as raw API responses. Register panics if name is
+// already registered; commands register once, when hk starts.
+func Register(name string, v interface{}) {
+	if _, ok := types[name]; ok {
+		panic("output: " + name + " registered twice")
+	}
+	types[name] = reflect.TypeOf(v)
+}
+
+// Registered reports whether the command called name is registered.
+func Registered(name string) bool {
+	_, ok := types[name]
+	return ok
+}
+
+// Type returns the element type registered for the command called name,
+// or nil if it has none.
+func Type(name string) reflect.Type {
+	return types[name]
+}
+
+// Commands returns the names of the registered commands, sorted.
+func Commands() []string {
+	var names []string
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// A Printer takes the lists printed by one run of a command.
+type Printer struct {
+	// Command is the name the command registered with.
+	Command string
+
+	// JSON makes List print lists as JSON.
+	JSON bool
+
+	// Result, if set, is given each list instead of it being printed. It's
+	// for programs that run hk's commands and want their results as
+	// values rather than text.
+	Result func(v interface{})
+}
+
+// Active reports whether List will take lists rather than leaving them to
+// be printed as tables.
+func (p *Printer) Active() bool {
+	return p.JSON || p.Result != nil
+}
+
+// List takes list, a slice of the command's registered type. It passes it
+// to p.Result if that's set, or else prints it on w as JSON if p.JSON is
+// set. It reports whether it did either; if not, the caller prints list
+// as a table. It returns an error if list isn't of the registered type.
+func (p *Printer) List(w io.Writer, list interface{}) (bool, error) {
+	t, ok := types[p.Command]
+	if !ok {
+		return false, fmt.Errorf("output: %s isn't registered", p.Command)
+	}
+	rv := reflect.ValueOf(list)
+	if t != nil && (rv.Kind() != reflect.Slice || rv.Type().Elem() != t) {
+		return false, fmt.Errorf("output: %s lists %v, not %T", p.Command, t, list)
+	}
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		list = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+	switch {
+	case p.Result != nil:
+		p.Result(list)
+		return true, nil
+	case p.JSON:
+		return true, PrintJSON(w, list)
+	}
+	return false, nil
+}
+
+// PrintJSON writes v to w as indented JSON. A nil slice is written as an
+// empty array, not null, so that empty lists look the same to scripts
+// as lists with items.
+func PrintJSON(w io.Writer, v interface{}) error {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.IsNil() {
+		v = reflect.MakeSlice(rv.Type(), 0, 0).Interface()
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
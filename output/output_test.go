@@ -0,0 +1,53 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+type item struct {
+	Name string `json:"name"`
+}
+
+func init() {
+	Register("items", item{})
+	Register("raw", nil)
+}
+
+func TestPrinterList(t *testing.T) {
+	var buf bytes.Buffer
+	p := &Printer{Command: "items"}
+	if ok, err := p.List(&buf, []item{{"a"}}); ok || err != nil || buf.Len() != 0 {
+		t.Errorf("without JSON => %t, %v, %q, want it left to the caller", ok, err, buf.String())
+	}
+
+	p.JSON = true
+	var none []item
+	if ok, err := p.List(&buf, none); !ok || err != nil {
+		t.Fatalf("with JSON => %t, %v", ok, err)
+	}
+	if got := buf.String(); got != "[]\n" {
+		t.Errorf("nil list => %q, want %q", got, "[]\n")
+	}
+
+	var got interface{}
+	p.Result = func(v interface{}) { got = v }
+	buf.Reset()
+	if ok, err := p.List(&buf, []item{{"a"}}); !ok || err != nil || buf.Len() != 0 {
+		t.Errorf("with Result => %t, %v, %q, want it passed on unprinted", ok, err, buf.String())
+	}
+	if items, ok := got.([]item); !ok || len(items) != 1 || items[0].Name != "a" {
+		t.Errorf("Result got %#v, want []item{{a}}", got)
+	}
+
+	if _, err := p.List(&buf, []string{"a"}); err == nil {
+		t.Errorf("expected error for a list of the wrong type")
+	}
+	if _, err := (&Printer{Command: "missing", JSON: true}).List(&buf, []item{}); err == nil {
+		t.Errorf("expected error for an unregistered command")
+	}
+	raw := &Printer{Command: "raw", JSON: true}
+	if ok, err := raw.List(&buf, map[string]interface{}{"a": 1}); !ok || err != nil {
+		t.Errorf("untyped command => %t, %v, want it printed", ok, err)
+	}
+}
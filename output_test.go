@@ -0,0 +1,44 @@
+package hk
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/output"
+)
+
+func TestPrintList(t *testing.T) {
+	defer func(p *output.Printer) { listPrinter = p }(listPrinter)
+	var buf bytes.Buffer
+	var addons []heroku.Addon
+
+	listPrinter = &output.Printer{Command: cmdAddons.Name()}
+	if printList(&buf, addons) {
+		t.Errorf("without --json, printList printed %q", buf.String())
+	}
+	listPrinter.JSON = true
+	if !printList(&buf, addons) {
+		t.Fatalf("with --json, printList left the list to be printed")
+	}
+	if got := buf.String(); got != "[]\n" {
+		t.Errorf("printList(nil slice) => %q, want %q", got, "[]\n")
+	}
+}
+
+func TestListCommandsHaveJSONFlags(t *testing.T) {
+	byName := make(map[string]*Command)
+	for _, cmd := range commands {
+		byName[cmd.Name()] = cmd
+	}
+	for _, name := range output.Commands() {
+		cmd := byName[name]
+		if cmd == nil {
+			t.Errorf("%s is registered with the output layer, but isn't a command", name)
+			continue
+		}
+		if cmd.Flag.Lookup("j") == nil || cmd.Flag.Lookup("json") == nil {
+			t.Errorf("%s has no -j or --json flag", name)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package hk
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/heroku/hk/term"
+)
+
+// pagerAllowed is set by main, so that programs embedding hk never have
+// their output sent to a pager.
+var pagerAllowed bool
+
+var flagNoPager bool
+
+// pagedCommands are the commands whose output can run to many screens.
+// When stdout is a terminal, their output goes through a pager.
+var pagedCommands = []*Command{
+	cmdApps,
+	cmdChangelog,
+	cmdEnv,
+	cmdReleaseDiff,
+	cmdReleases,
+	cmdResource,
+	cmdScaleHistory,
+}
+
+func init() {
+	for _, cmd := range pagedCommands {
+		cmd.Flag.BoolVar(&flagNoPager, "no-pager", false, "don't page output")
+	}
+}
+
+func isPaged(cmd *Command) bool {
+	for _, c := range pagedCommands {
+		if c == cmd {
+			return true
+		}
+	}
+	return false
+}
+
+// pagerCommand returns the pager to use: the pager config setting, or
+// $PAGER, or less. It returns nil if paging is turned off.
+func pagerCommand() []string {
+	s := configValue("pager")
+	if s == "" {
+		s = os.Getenv("PAGER")
+	}
+	if s == "" {
+		s = "less"
+	}
+	if s == "false" || s == "cat" {
+		return nil
+	}
+	return strings.Fields(s)
+}
+
+// startPager sends os.Stdout through a pager, when it's a terminal and
+// paging isn't turned off. Like git, it sets LESS=FRX if LESS is unset,
+// so output that fits on one screen is printed as is. The returned func
+// waits for the pager to exit and restores os.Stdout; exit calls it
+// too.
+func startPager() (stop func()) {
+	noop := func() {}
+	if flagNoPager || !term.IsTerminal(os.Stdout) {
+		return noop
+	}
+	args := pagerCommand()
+	if args == nil {
+		return noop
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return noop
+	}
+	c := exec.Command(args[0], args[1:]...)
+	c.Stdin, c.Stdout, c.Stderr = r, os.Stdout, os.Stderr
+	if os.Getenv("LESS") == "" {
+		c.Env = append(os.Environ(), "LESS=FRX")
+	}
+	if err := c.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return noop
+	}
+	r.Close()
+
+	stdout, prevExit := os.Stdout, exit
+	os.Stdout = w
+	stop = func() {
+		os.Stdout, exit = stdout, prevExit
+		w.Close()
+		c.Wait()
+	}
+	exit = func(code int) {
+		stop()
+		prevExit(code)
+	}
+	return stop
+}
@@ -1,6 +1,8 @@
-package main
+package hk
 
 import (
+	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"os/exec"
@@ -45,14 +47,14 @@ Examples:
 `,
 }
 
-func runPgInfo(cmd *Command, args []string) {
+func runPgInfo(ctx *Context, args []string) {
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	appname := mustApp()
+	appname := ctx.MustApp()
 	// list all addons
-	addons, err := client.AddonList(appname, nil)
+	addons, err := ctx.Client.AddonList(appname, nil)
 	must(err)
 
 	// locate specific addon
@@ -73,14 +75,14 @@ func runPgInfo(cmd *Command, args []string) {
 	confch := make(chan map[string]string, 1)
 	errch := make(chan error, 1)
 	go func(appname string) {
-		if config, err := client.ConfigVarInfo(appname); err != nil {
+		if config, err := ctx.Client.ConfigVarInfo(appname); err != nil {
 			errch <- err
 		} else {
 			confch <- config
 		}
 	}(appname)
 
-	db := pgclient.NewDB(addon.ProviderId, addon.Plan.Name)
+	db := ctx.PG.NewDB(addon.ProviderId, addon.Plan.Name)
 	info, err := db.Info()
 	must(err)
 
@@ -91,11 +93,11 @@ func runPgInfo(cmd *Command, args []string) {
 	}
 
 	addonMap := newPgAddonMap(addons, appConf)
-	printPgInfo(addonName, info, &addonMap)
+	printPgInfo(ctx.Stdout, addonName, info, &addonMap)
 }
 
-func printPgInfo(name string, info postgresql.DBInfo, addonMap *pgAddonMap) {
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+func printPgInfo(out io.Writer, name string, info postgresql.DBInfo, addonMap *pgAddonMap) {
+	w := tabwriter.NewWriter(out, 1, 2, 2, ' ', 0)
 	defer w.Flush()
 
 	listRec(w, "Name:", name)
@@ -125,11 +127,14 @@ func printPgInfo(name string, info postgresql.DBInfo, addonMap *pgAddonMap) {
 	}
 }
 
-var commandNamePsql string
+var (
+	commandNamePsql string
+	filePsql        string
+)
 
 var cmdPsql = &Command{
 	Run:      runPsql,
-	Usage:    "psql [-c <command>] [<dbname>]",
+	Usage:    "psql [-c <command> | -f <file>] [<dbname>]",
 	NeedsApp: true,
 	Category: "pg",
 	Short:    "open a psql shell to a Heroku Postgres database" + extra,
@@ -137,6 +142,18 @@ var cmdPsql = &Command{
 Psql opens a PostgreSQL shell to a Heroku Postgres database
 using the locally-installed psql command.
 
+The database may be given as an add-on name (with or without the
+heroku-postgresql- prefix) or as the name of an env var holding its
+URL. It defaults to DATABASE_URL.
+
+With -c or -f, psql runs the given SQL instead of opening a shell,
+and prints the results as aligned columns.
+
+Options:
+
+    -c, --command <command>  SQL command to run
+    -f, --file <file>        file of SQL commands to run
+
 Examples:
 
     $ hk psql
@@ -149,76 +166,214 @@ Examples:
     $ hk psql crimson
     ...
 
-    $ hk psql heroku-postgresql-crimson
+    $ hk psql HEROKU_POSTGRESQL_CRIMSON_URL
     ...
+
+    $ hk psql -c "select id, email from users limit 2"
+    id  email
+    1   user@test.com
+    2   user2@test.com
 `,
 }
 
 func init() {
-	cmdPsql.Flag.StringVar(&commandNamePsql, "c", "", "SQL command to run")
+	for _, name := range []string{"c", "command"} {
+		cmdPsql.Flag.StringVar(&commandNamePsql, name, "", "SQL command to run")
+	}
+	for _, name := range []string{"f", "file"} {
+		cmdPsql.Flag.StringVar(&filePsql, name, "", "file of SQL commands to run")
+	}
 }
 
-func runPsql(cmd *Command, args []string) {
-	if len(args) > 1 {
-		cmd.printUsage()
-		os.Exit(2)
+func runPsql(ctx *Context, args []string) {
+	if len(args) > 1 || (commandNamePsql != "" && filePsql != "") {
+		ctx.printUsage()
+		exit(2)
 	}
-
-	configName := "DATABASE_URL"
+	var dbname string
 	if len(args) == 1 {
-		configName = dbNameToPgEnv(args[0])
+		dbname = args[0]
+	}
+	psqlArgs, pgenv := psqlConn(ctx, ctx.MustApp(), dbname)
+
+	if commandNamePsql == "" && filePsql == "" {
+		if err := runInteractive("psql", psqlArgs, pgenv); err != nil {
+			printFatal("Error running psql: %s", err)
+		}
+		return
 	}
-	appname := mustApp()
 
-	// Make sure psql is installed
-	if _, err := exec.LookPath("psql"); err != nil {
-		printFatal("Local psql command not found. For help installing psql, see http://devcenter.heroku.com/articles/local-postgresql")
+	// run non-interactively with unaligned, tab-separated output, and
+	// realign it ourselves
+	psqlArgs = append(psqlArgs, "-X", "-A", "-F", "\t", "-P", "footer=off", "-v", "ON_ERROR_STOP=1")
+	if commandNamePsql != "" {
+		psqlArgs = append(psqlArgs, "-c", commandNamePsql)
+	} else {
+		psqlArgs = append(psqlArgs, "-f", filePsql)
+	}
+	c := exec.Command("psql", psqlArgs...)
+	c.Env = pgenv
+	c.Stdin = os.Stdin
+	c.Stderr = ctx.Stderr
+	out, err := c.Output()
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		fmt.Fprintln(w, line)
+	}
+	w.Flush()
+	if err != nil {
+		exit(1)
 	}
+}
+
+// psqlConn returns the arguments and environment that connect psql to a
+// database of appname, given as for 'hk psql', or DATABASE_URL if dbname
+// is empty. It exits if psql isn't installed.
+func psqlConn(ctx *Context, appname, dbname string) (args, env []string) {
+	mustHavePgCommand("psql")
+	c := herokuPgConn(ctx, appname, dbname)
+	return append(c.args(), "-d", c.Name), c.env()
+}
 
-	// fetch app's config to get the URL
-	config, err := client.ConfigVarInfo(appname)
+// mustHavePgCommand exits unless the named PostgreSQL client command,
+// such as psql or pg_dump, is installed.
+func mustHavePgCommand(name string) {
+	if _, err := exec.LookPath(name); err != nil {
+		printFatal("Local %s command not found. For help installing it, see http://devcenter.heroku.com/articles/local-postgresql", name)
+	}
+}
+
+// A pgConn is what the PostgreSQL client commands need to connect to a
+// database. Empty fields are left to the commands' defaults.
+type pgConn struct {
+	User, Password, Host, Port, Name string
+	SSL                              bool
+}
+
+// herokuPgConn returns the connection to a database of appname, given as
+// for 'hk psql', or DATABASE_URL if dbname is empty.
+func herokuPgConn(ctx *Context, appname, dbname string) pgConn {
+	return tunnelPgConn(resolvePgConn(ctx, appname, dbname))
+}
+
+// resolvePgConn is like herokuPgConn, but returns the connection to the
+// database server itself, even when a proxy is set.
+func resolvePgConn(ctx *Context, appname, dbname string) pgConn {
+	// fetch app's config and addons to find the URL
+	config, err := ctx.Client.ConfigVarInfo(appname)
 	must(err)
+	configName := "DATABASE_URL"
+	if dbname != "" {
+		addons, err := ctx.Client.AddonList(appname, nil)
+		must(err)
+		var ok bool
+		if configName, ok = resolvePgConfigVar(dbname, config, addons); !ok {
+			printFatal("no database %s on %s", dbname, appname)
+		}
+	}
 
 	// get URL
 	urlstr, exists := config[configName]
 	if !exists {
 		printFatal("Env %s not found", configName)
 	}
-	u, err := url.Parse(urlstr)
+	c, err := parsePgURL(urlstr)
 	if err != nil {
-		printFatal("Invalid URL at env " + configName)
+		printFatal("Invalid URL at env %s: %s", configName, err)
 	}
+	c.SSL = true
+	return c
+}
 
+// parsePgURL returns the connection described by a postgres:// URL.
+func parsePgURL(s string) (pgConn, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return pgConn{}, err
+	}
 	// handle custom port
 	hostname := u.Host
-	portnum := 5432
+	port := "5432"
 	if colIndex := strings.Index(u.Host, ":"); colIndex != -1 {
-		hostname = u.Host[:colIndex]
-		portnum, err = strconv.Atoi(u.Host[colIndex+1:])
-		if err != nil {
-			printFatal("Invalid port in %s: %s", configName, u.Host[colIndex+1:])
+		hostname, port = u.Host[:colIndex], u.Host[colIndex+1:]
+		if _, err := strconv.Atoi(port); err != nil {
+			return pgConn{}, fmt.Errorf("invalid port %s", port)
 		}
 	}
+	c := pgConn{Host: hostname, Port: port, Name: strings.TrimPrefix(u.Path, "/")}
+	if u.User != nil {
+		c.User = u.User.Username()
+		c.Password, _ = u.User.Password()
+	}
+	return c, nil
+}
 
-	// construct and run psql command
-	psqlArgs := []string{
-		"psql",
-		"-U", u.User.Username(),
-		"-h", hostname,
-		"-p", strconv.Itoa(portnum),
+// args returns the -U, -h, and -p arguments for c. The database name is
+// left to the caller, since commands take it in different ways.
+func (c pgConn) args() []string {
+	var args []string
+	for _, a := range [][2]string{{"-U", c.User}, {"-h", c.Host}, {"-p", c.Port}} {
+		if a[1] != "" {
+			args = append(args, a[0], a[1])
+		}
 	}
-	if commandNamePsql != "" {
-		psqlArgs = append(psqlArgs, "-c")
-		psqlArgs = append(psqlArgs, commandNamePsql)
+	return args
+}
+
+// env returns the environment for a command connecting with c, which
+// passes the password out of sight of other users' ps.
+func (c pgConn) env() []string {
+	env := os.Environ()
+	if c.Password != "" {
+		env = append(env, "PGPASSWORD="+c.Password)
 	}
-	psqlArgs = append(psqlArgs, u.Path[1:])
+	if c.SSL {
+		env = append(env, "PGSSLMODE=require")
+	}
+	return env
+}
 
-	pgenv := os.Environ()
-	pass, _ := u.User.Password()
-	pgenv = append(pgenv, "PGPASSWORD="+pass)
-	pgenv = append(pgenv, "PGSSLMODE=require")
+// psqlQuery runs query with psql, connected by args and env, and returns
+// the rows of its result.
+func psqlQuery(args, env []string, query string) ([][]string, error) {
+	args = append(args, "-X", "-A", "-t", "-F", "\t", "-v", "ON_ERROR_STOP=1", "-c", query)
+	c := exec.Command("psql", args...)
+	c.Env = env
+	var stderr strings.Builder
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, err
+	}
+	var rows [][]string
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		if line != "" {
+			rows = append(rows, strings.Split(line, "\t"))
+		}
+	}
+	return rows, nil
+}
 
-	if err := runCommand("psql", psqlArgs, pgenv); err != nil {
-		printFatal("Error running psql: %s", err)
+// resolvePgConfigVar returns the env var holding the URL of the database
+// named name, which is either an env var (DATABASE_URL or just DATABASE),
+// or a Heroku Postgres add-on name (heroku-postgresql-crimson or crimson).
+func resolvePgConfigVar(name string, config map[string]string, addons []heroku.Addon) (string, bool) {
+	for _, v := range []string{name, strings.ToUpper(name) + "_URL"} {
+		if _, ok := config[v]; ok {
+			return v, true
+		}
+	}
+	addonName := ensurePrefix(name, hpgAddonName()+"-")
+	for _, addon := range addons {
+		if (addon.Name == name || addon.Name == addonName) && len(addon.ConfigVars) > 0 {
+			return addon.ConfigVars[0], true
+		}
+	}
+	if v := dbNameToPgEnv(name); config[v] != "" {
+		return v, true
 	}
+	return "", false
 }
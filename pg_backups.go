@@ -0,0 +1,268 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heroku/hk/postgresql"
+)
+
+var cmdPgBackups = &Command{
+	Run:      runPgBackups,
+	Usage:    "pg-backups",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "list Heroku Postgres backups and restores" + extra,
+	Long: `
+Pg-backups lists an app's Heroku Postgres backups and restores. Shows
+the name, status, time created, size, and the database it came from
+or went to.
+
+Example:
+
+    $ hk pg-backups
+    b003  Completed  Jan 14 12:31  27.0 MB  DATABASE
+    b004  Running    Jan 15 08:02  3.1 MB   DATABASE
+    r001  Completed  Jan 15 09:44  27.0 MB  HEROKU_POSTGRESQL_CRIMSON
+`,
+}
+
+func runPgBackups(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	app := ctx.PG.NewApp(ctx.MustApp())
+	transfers, err := app.Transfers()
+	must(err)
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, t := range transfers {
+		db := t.ToName
+		if t.IsBackup() {
+			db = t.FromName
+		}
+		created := t.CreatedAt
+		if ct, err := t.Created(); err == nil {
+			created = prettyTime{ct}.String()
+		}
+		listRec(w, t.Name(), t.Status(), created, prettySize(t.ProcessedBytes), db)
+	}
+}
+
+var cmdPgBackupCapture = &Command{
+	Run:      runPgBackupCapture,
+	Usage:    "pg-backup-capture [<dbname>]",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "back up a Heroku Postgres database" + extra,
+	Long: `
+Pg-backup-capture backs up a Heroku Postgres database (the one in
+DATABASE_URL by default), and waits for the backup to finish.
+
+Example:
+
+    $ hk pg-backup-capture crimson
+    Backing up heroku-postgresql-crimson to b005... done, 27.0 MB.
+`,
+}
+
+func runPgBackupCapture(ctx *Context, args []string) {
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	db, addonName, err := findPgDB(appname, strings.Join(args, ""))
+	must(err)
+	t, err := db.CaptureBackup()
+	must(err)
+	fmt.Fprintf(os.Stderr, "Backing up %s to %s... ", addonName, t.Name())
+	t = waitTransfer(appname, t)
+	fmt.Fprintf(os.Stderr, "%s, %s.\n", strings.ToLower(t.Status()), prettySize(t.ProcessedBytes))
+	if t.Status() != "Completed" {
+		exit(1)
+	}
+}
+
+var cmdPgBackupDownload = &Command{
+	Run:      runPgBackupDownload,
+	Usage:    "pg-backup-download [-o <file>] [<backup>]",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "download a Heroku Postgres backup" + extra,
+	Long: `
+Pg-backup-download downloads a backup (the latest completed backup
+by default), showing its progress. The file can be loaded into a
+local database with pg_restore.
+
+Options:
+
+    -o <file>  file to write (default latest.dump)
+
+Example:
+
+    $ hk pg-backup-download b004
+    Downloading b004 to latest.dump... 100% (27.0 MB of 27.0 MB)
+`,
+}
+
+var flagPgBackupOutput string
+
+func init() {
+	cmdPgBackupDownload.Flag.StringVar(&flagPgBackupOutput, "o", "latest.dump", "output file")
+}
+
+func runPgBackupDownload(ctx *Context, args []string) {
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	app := ctx.PG.NewApp(ctx.MustApp())
+	t, err := findBackup(&app, strings.Join(args, ""))
+	must(err)
+	u, err := app.TransferPublicURL(t.Num)
+	must(err)
+
+	res, err := http.Get(u)
+	must(err)
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		printFatal("download failed: %s", res.Status)
+	}
+	f, err := os.Create(flagPgBackupOutput)
+	must(err)
+	defer f.Close()
+
+	label := fmt.Sprintf("Downloading %s to %s...", t.Name(), flagPgBackupOutput)
+	pw := &progressWriter{w: f, label: label, total: res.ContentLength}
+	_, err = io.Copy(pw, res.Body)
+	if !accessibleOutput {
+		fmt.Fprintln(os.Stderr)
+	}
+	must(err)
+}
+
+var cmdPgBackupRestore = &Command{
+	Run:      runPgBackupRestore,
+	Usage:    "pg-backup-restore --confirm <app> <backup or url> [<dbname>]",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "restore a Heroku Postgres backup" + extra,
+	Long: `
+Pg-backup-restore replaces the contents of a Heroku Postgres
+database (the one in DATABASE_URL by default) with a backup of this
+app, or with a pg_dump file at a public URL. It waits for the
+restore to finish.
+
+All data in the database is overwritten, so the app name must be
+given with --confirm.
+
+Options:
+
+    --confirm <app>  name of the app, to confirm the restore
+
+Examples:
+
+    $ hk pg-backup-restore --confirm myapp b004
+    Restoring b004 to heroku-postgresql-crimson... done.
+
+    $ hk pg-backup-restore --confirm myapp https://example.com/my.dump crimson
+    Restoring https://example.com/my.dump to heroku-postgresql-crimson... done.
+`,
+}
+
+var flagPgRestoreConfirm string
+
+func init() {
+	cmdPgBackupRestore.Flag.StringVar(&flagPgRestoreConfirm, "confirm", "", "app name")
+}
+
+func runPgBackupRestore(ctx *Context, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	if flagPgRestoreConfirm != appname {
+		printFatal("restoring overwrites all data in the database. Run again with --confirm %s.", appname)
+	}
+	db, addonName, err := findPgDB(appname, strings.Join(args[1:], ""))
+	must(err)
+
+	source := args[0]
+	backupURL := source
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		app := ctx.PG.NewApp(appname)
+		t, err := findBackup(&app, source)
+		must(err)
+		source = t.Name()
+		backupURL, err = app.TransferPublicURL(t.Num)
+		must(err)
+	}
+
+	t, err := db.Restore(backupURL)
+	must(err)
+	fmt.Fprintf(os.Stderr, "Restoring %s to %s... ", source, addonName)
+	t = waitTransfer(appname, t)
+	fmt.Fprintf(os.Stderr, "%s.\n", strings.ToLower(t.Status()))
+	if t.Status() != "Completed" {
+		exit(1)
+	}
+}
+
+// findBackup returns the backup with the given name (e.g. b004), or the
+// latest completed backup if name is empty.
+func findBackup(app *postgresql.App, name string) (*postgresql.Transfer, error) {
+	if name != "" {
+		num, err := strconv.Atoi(strings.TrimLeft(name, "b"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid backup name %q", name)
+		}
+		t, err := app.Transfer(num)
+		if err != nil {
+			return nil, err
+		}
+		if !t.IsBackup() {
+			return nil, fmt.Errorf("%s is not a backup", name)
+		}
+		return &t, nil
+	}
+	transfers, err := app.Transfers()
+	if err != nil {
+		return nil, err
+	}
+	var latest *postgresql.Transfer
+	for i := range transfers {
+		t := &transfers[i]
+		if t.IsBackup() && t.Status() == "Completed" && (latest == nil || t.Num > latest.Num) {
+			latest = t
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("%s has no completed backups", app.Name)
+	}
+	return latest, nil
+}
+
+// waitTransfer polls a transfer until it's done and returns its final
+// state.
+func waitTransfer(appname string, t postgresql.Transfer) postgresql.Transfer {
+	app := pgclient.NewApp(appname)
+	for !t.Done() {
+		time.Sleep(3 * time.Second)
+		var err error
+		t, err = app.Transfer(t.Num)
+		if err != nil {
+			fmt.Fprintln(os.Stderr)
+			printFatal(err.Error())
+		}
+	}
+	return t
+}
@@ -0,0 +1,121 @@
+package hk
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var cmdPgConnectionString = &Command{
+	Run:      runPgConnectionString,
+	Usage:    "pg-connection-string [--format url|rails|django|jdbc|dsn] [<dbname>]",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "print a database's credentials for a framework's config" + extra,
+	Long: `
+Pg-connection-string prints the credentials of a Heroku Postgres
+database in the form a framework's config file expects, so they
+don't have to be copied out of the URL by hand. The database is
+given as for 'hk psql', and defaults to DATABASE_URL.
+
+Heroku Postgres credentials change when they're rotated or the
+database moves, so use the output for local work against the
+database, not in config deployed to Heroku, which should read
+DATABASE_URL.
+
+Options:
+
+    --format <format>  one of:
+                         url     a postgres:// URL (the default)
+                         rails   a config/database.yml entry
+                         django  a settings.py DATABASES entry
+                         jdbc    a JDBC URL, for Java and Kotlin
+                         dsn     a libpq key=value string, for Go,
+                                 psycopg, and others
+
+Examples:
+
+    $ hk pg-connection-string --format jdbc
+    jdbc:postgresql://ec2-1.compute-1.amazonaws.com:5432/d1a2b3?user=u4c5d6&password=p7e8f9&sslmode=require
+
+    $ hk pg-connection-string --format rails crimson
+    production:
+      adapter: postgresql
+      encoding: unicode
+      host: "ec2-1.compute-1.amazonaws.com"
+      port: 5432
+      database: "d1a2b3"
+      username: "u4c5d6"
+      password: "p7e8f9"
+      sslmode: require
+`,
+}
+
+var flagPgConnFormat string
+
+func init() {
+	cmdPgConnectionString.Flag.StringVar(&flagPgConnFormat, "format", "url", "output format")
+}
+
+func runPgConnectionString(ctx *Context, args []string) {
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	if _, err := formatPgConnection(pgConn{}, flagPgConnFormat); err != nil {
+		printFatal(err.Error())
+	}
+	c := resolvePgConn(ctx, ctx.MustApp(), optionalArg(args, 0))
+	s, err := formatPgConnection(c, flagPgConnFormat)
+	must(err)
+	fmt.Fprint(ctx.Stdout, s)
+}
+
+// formatPgConnection returns the credentials in c in the given format.
+func formatPgConnection(c pgConn, format string) (string, error) {
+	sslmode := "prefer"
+	if c.SSL {
+		sslmode = "require"
+	}
+	switch format {
+	case "url":
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(c.User, c.Password),
+			Host:     c.Host + ":" + c.Port,
+			Path:     "/" + c.Name,
+			RawQuery: "sslmode=" + sslmode,
+		}
+		return u.String() + "\n", nil
+	case "rails":
+		return fmt.Sprintf("production:\n  adapter: postgresql\n  encoding: unicode\n"+
+			"  host: %s\n  port: %s\n  database: %s\n  username: %s\n  password: %s\n  sslmode: %s\n",
+			strconv.Quote(c.Host), c.Port, strconv.Quote(c.Name), strconv.Quote(c.User), strconv.Quote(c.Password), sslmode), nil
+	case "django":
+		return fmt.Sprintf("DATABASES = {\n    'default': {\n        'ENGINE': 'django.db.backends.postgresql',\n"+
+			"        'NAME': %s,\n        'USER': %s,\n        'PASSWORD': %s,\n        'HOST': %s,\n        'PORT': '%s',\n"+
+			"        'OPTIONS': {'sslmode': '%s'},\n    }\n}\n",
+			strconv.Quote(c.Name), strconv.Quote(c.User), strconv.Quote(c.Password), strconv.Quote(c.Host), c.Port, sslmode), nil
+	case "jdbc":
+		query := "user=" + url.QueryEscape(c.User) + "&password=" + url.QueryEscape(c.Password) + "&sslmode=" + sslmode
+		return fmt.Sprintf("jdbc:postgresql://%s:%s/%s?%s\n", c.Host, c.Port, url.PathEscape(c.Name), query), nil
+	case "dsn":
+		var fields []string
+		for _, f := range [][2]string{{"host", c.Host}, {"port", c.Port}, {"dbname", c.Name}, {"user", c.User}, {"password", c.Password}, {"sslmode", sslmode}} {
+			fields = append(fields, f[0]+"="+dsnQuote(f[1]))
+		}
+		return strings.Join(fields, " ") + "\n", nil
+	}
+	return "", fmt.Errorf("unknown format %s: use url, rails, django, jdbc, or dsn", format)
+}
+
+// dsnQuote quotes a libpq connection string value if it's empty or has
+// spaces, quotes, or backslashes.
+func dsnQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, ` '\`) {
+		return s
+	}
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return "'" + strings.Replace(s, `'`, `\'`, -1) + "'"
+}
@@ -0,0 +1,222 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+var cmdPgCopy = &Command{
+	Run:      runPgCopy,
+	Usage:    "pg-copy --confirm <app> <source> <target>",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "copy one Heroku Postgres database into another" + extra,
+	Long: `
+Pg-copy copies the contents of one Heroku Postgres database into
+another, using the locally-installed pg_dump and pg_restore
+commands, and shows how much data has been copied.
+
+Both databases are given as for 'hk psql'. The target is a database
+of this app. The source is too, unless it's given as <app>::<dbname>
+to copy from another app's database.
+
+All data in the target database is overwritten, so the app name must
+be given with --confirm.
+
+Options:
+
+    --confirm <app>  name of the app, to confirm the copy
+
+Examples:
+
+    $ hk pg-copy --confirm myapp DATABASE_URL crimson
+    Copying DATABASE_URL to crimson... 27.0 MB
+    Copied DATABASE_URL to crimson.
+
+    $ hk pg-copy -a myapp-staging --confirm myapp-staging myapp::DATABASE DATABASE
+    Copying myapp::DATABASE to DATABASE... 27.0 MB
+    Copied myapp::DATABASE to DATABASE.
+`,
+}
+
+var cmdPgPull = &Command{
+	Run:      runPgPull,
+	Usage:    "pg-pull <dbname> <localdb>",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "copy a Heroku Postgres database to a local database" + extra,
+	Long: `
+Pg-pull copies a Heroku Postgres database, given as for 'hk psql',
+into a new local database, using the locally-installed createdb,
+pg_dump, and pg_restore commands, and shows how much data has been
+copied.
+
+The local database is given as a name, or as a postgres:// URL for a
+server that isn't the default. It's created first, so pg-pull never
+overwrites an existing database.
+
+Examples:
+
+    $ hk pg-pull DATABASE_URL myapp_dev
+    Copying DATABASE_URL to myapp_dev... 27.0 MB
+    Copied DATABASE_URL to myapp_dev.
+
+    $ hk pg-pull crimson postgres://localhost:5433/myapp_dev
+    ...
+`,
+}
+
+var cmdPgPush = &Command{
+	Run:      runPgPush,
+	Usage:    "pg-push <localdb> <dbname>",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "copy a local database to a Heroku Postgres database" + extra,
+	Long: `
+Pg-push copies a local database into a Heroku Postgres database,
+given as for 'hk psql', using the locally-installed psql, pg_dump,
+and pg_restore commands, and shows how much data has been copied.
+
+The local database is given as a name, or as a postgres:// URL for a
+server that isn't the default. The Heroku Postgres database must be
+empty, so pg-push never overwrites data.
+
+Example:
+
+    $ hk pg-push myapp_dev crimson
+    Copying myapp_dev to crimson... 27.0 MB
+    Copied myapp_dev to crimson.
+`,
+}
+
+var flagPgCopyConfirm string
+
+func init() {
+	cmdPgCopy.Flag.StringVar(&flagPgCopyConfirm, "confirm", "", "app name")
+}
+
+func runPgCopy(ctx *Context, args []string) {
+	if len(args) != 2 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	if flagPgCopyConfirm != appname {
+		printFatal("copying overwrites all data in the target database. Run again with --confirm %s.", appname)
+	}
+	sourceApp, sourceDB := appname, args[0]
+	if i := strings.Index(args[0], "::"); i >= 0 {
+		sourceApp, sourceDB = args[0][:i], args[0][i+2:]
+	}
+	from := herokuPgConn(ctx, sourceApp, sourceDB)
+	to := herokuPgConn(ctx, appname, args[1])
+	if from.User == to.User && from.Name == to.Name {
+		printFatal("%s and %s are the same database", args[0], args[1])
+	}
+	must(pgTransfer(args[0], args[1], from, to, "--clean", "--if-exists"))
+}
+
+func runPgPull(ctx *Context, args []string) {
+	if len(args) != 2 {
+		ctx.printUsage()
+		exit(2)
+	}
+	mustHavePgCommand("createdb")
+	from := herokuPgConn(ctx, ctx.MustApp(), args[0])
+	to := localPgConn(args[1])
+	c := exec.Command("createdb", append(to.args(), to.Name)...)
+	c.Env = to.env()
+	c.Stdout, c.Stderr = os.Stderr, os.Stderr
+	if err := c.Run(); err != nil {
+		printFatal("couldn't create local database %s: %s", to.Name, err)
+	}
+	must(pgTransfer(args[0], args[1], from, to))
+}
+
+func runPgPush(ctx *Context, args []string) {
+	if len(args) != 2 {
+		ctx.printUsage()
+		exit(2)
+	}
+	from := localPgConn(args[0])
+	psqlArgs, pgenv := psqlConn(ctx, ctx.MustApp(), args[1])
+	rows, err := psqlQuery(psqlArgs, pgenv, `SELECT count(*) FROM information_schema.tables
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema')`)
+	if err != nil {
+		printFatal(err.Error())
+	}
+	if len(rows) != 1 || rows[0][0] != "0" {
+		printFatal("%s is not empty. Push only to an empty database.", args[1])
+	}
+	to := herokuPgConn(ctx, ctx.MustApp(), args[1])
+	must(pgTransfer(args[0], args[1], from, to))
+}
+
+// localPgConn returns the connection to a local database given as a name
+// or a postgres:// URL.
+func localPgConn(s string) pgConn {
+	if !strings.HasPrefix(s, "postgres://") && !strings.HasPrefix(s, "postgresql://") {
+		return pgConn{Name: s}
+	}
+	c, err := parsePgURL(s)
+	if err != nil {
+		printFatal("invalid database URL %s: %s", s, err)
+	}
+	return c
+}
+
+// pgTransfer copies the database at from into the one at to, piping
+// pg_dump into pg_restore, which is also given restoreFlags. It shows on
+// stderr how much data has been copied.
+func pgTransfer(fromName, toName string, from, to pgConn, restoreFlags ...string) error {
+	mustHavePgCommand("pg_dump")
+	mustHavePgCommand("pg_restore")
+	dump := exec.Command("pg_dump", append(from.args(), "--format=custom", "--no-owner", "--no-acl", from.Name)...)
+	dump.Env = from.env()
+	dump.Stderr = os.Stderr
+	restoreArgs := append(to.args(), "--no-owner", "--no-acl", "-d", to.Name)
+	restore := exec.Command("pg_restore", append(restoreArgs, restoreFlags...)...)
+	restore.Env = to.env()
+	restore.Stdout, restore.Stderr = os.Stderr, os.Stderr
+
+	dumpOut, err := dump.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	restoreIn, err := restore.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := dump.Start(); err != nil {
+		return err
+	}
+	if err := restore.Start(); err != nil {
+		dump.Process.Kill()
+		dump.Wait()
+		return err
+	}
+
+	label := fmt.Sprintf("Copying %s to %s...", fromName, toName)
+	pw := &progressWriter{w: restoreIn, label: label, total: -1}
+	_, copyErr := io.Copy(pw, dumpOut)
+	restoreIn.Close()
+	if !accessibleOutput {
+		fmt.Fprintln(os.Stderr)
+	}
+	dumpErr := dump.Wait()
+	restoreErr := restore.Wait()
+	switch {
+	case dumpErr != nil:
+		return fmt.Errorf("pg_dump failed: %s", dumpErr)
+	case restoreErr != nil:
+		return fmt.Errorf("pg_restore failed: %s", restoreErr)
+	case copyErr != nil:
+		return copyErr
+	}
+	log.Printf("Copied %s to %s.", fromName, toName)
+	return nil
+}
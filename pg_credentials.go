@@ -0,0 +1,208 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heroku/hk/term"
+)
+
+var cmdPgCredentials = &Command{
+	Run:      runPgCredentials,
+	Usage:    "pg-credentials [<dbname>]",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "list Heroku Postgres database credentials" + extra,
+	Long: `
+Pg-credentials lists the credentials of a Heroku Postgres database
+(the one in DATABASE_URL by default), with the database users that
+carry each one and how many connections each user has open. While a
+credential is being rotated, it has both its old and new users.
+
+Example:
+
+    $ hk pg-credentials crimson
+    default   active    u3kd8e0a1b2c3    active    12
+    analyst   rotating  u9f1c2d3e4f5a    revoking  2
+                        u0a1b2c3d4e5f    active    0
+`,
+}
+
+func runPgCredentials(ctx *Context, args []string) {
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	db, _, err := findPgDB(ctx.MustApp(), optionalArg(args, 0))
+	must(err)
+	creds, err := db.Credentials()
+	must(err)
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, c := range creds {
+		if len(c.Users) == 0 {
+			listRec(w, c.Name, c.State)
+		}
+		for i, u := range c.Users {
+			name, state := c.Name, c.State
+			if i > 0 {
+				name, state = "", ""
+			}
+			listRec(w, name, state, u.User, u.State, u.Connections)
+		}
+	}
+}
+
+var cmdPgCredentialsRotate = &Command{
+	Run:      runPgCredentialsRotate,
+	Usage:    "pg-credentials-rotate [--name <credential> | --all] [--force] [-y] [<dbname>]",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "rotate Heroku Postgres database credentials" + extra,
+	Long: `
+Pg-credentials-rotate replaces the password of a Heroku Postgres
+database's default credential, or of the credential given by
+--name, or of all its credentials with --all. Heroku Postgres
+updates the add-on's env vars, restarting the app; pg-credentials-
+rotate then waits for the new URL and updates any other env vars
+that held a copy of the old one, such as a URL set by hand for a
+worker library.
+
+Connections using the old password stay open until they close,
+unless --force is given, which ends them right away. At a terminal,
+pg-credentials-rotate asks first unless -y is given.
+
+Options:
+
+    --name <credential>  credential to rotate (default "default")
+    --all                rotate every credential
+    --force              end connections using the old passwords
+    -y, --yes            don't ask for confirmation
+
+Examples:
+
+    $ hk pg-credentials-rotate
+    Rotate the default credential of heroku-postgresql-crimson on myapp? [y/N] y
+    Rotated the default credential of heroku-postgresql-crimson.
+    Updated QUE_DATABASE_URL.
+
+    $ hk pg-credentials-rotate --all --force -y crimson
+    Rotated all credentials of heroku-postgresql-crimson.
+`,
+}
+
+var (
+	flagPgCredName  string
+	flagPgCredAll   bool
+	flagPgCredForce bool
+	flagPgCredYes   bool
+)
+
+func init() {
+	cmdPgCredentialsRotate.Flag.StringVar(&flagPgCredName, "name", "default", "credential to rotate")
+	cmdPgCredentialsRotate.Flag.BoolVar(&flagPgCredAll, "all", false, "rotate every credential")
+	cmdPgCredentialsRotate.Flag.BoolVar(&flagPgCredForce, "force", false, "end connections using the old passwords")
+	cmdPgCredentialsRotate.Flag.BoolVar(&flagPgCredYes, "y", false, "don't ask for confirmation")
+	cmdPgCredentialsRotate.Flag.BoolVar(&flagPgCredYes, "yes", false, "don't ask for confirmation")
+}
+
+// pgCredentialWait is how long pg-credentials-rotate waits for a rotated
+// URL to reach the app's env vars, polling every pgCredentialPoll.
+var (
+	pgCredentialWait = 2 * time.Minute
+	pgCredentialPoll = 2 * time.Second
+)
+
+func runPgCredentialsRotate(ctx *Context, args []string) {
+	if len(args) > 1 || (flagPgCredAll && flagPgCredName != "default") {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	db, addonName, err := findPgDB(appname, optionalArg(args, 0))
+	must(err)
+	what := fmt.Sprintf("the %s credential", flagPgCredName)
+	if flagPgCredAll {
+		what = "all credentials"
+	}
+	if !flagPgCredYes && term.IsTerminal(os.Stdin) {
+		mustConfirm(fmt.Sprintf("Rotate %s of %s on %s?", what, addonName, appname))
+	}
+
+	// Only the default credential's URL is in the add-on's env vars, so
+	// it's the only one other env vars could have copied.
+	addon, err := ctx.Client.AddonInfo(appname, addonName)
+	must(err)
+	config, err := ctx.Client.ConfigVarInfo(appname)
+	must(err)
+	var oldURL string
+	if len(addon.ConfigVars) > 0 {
+		oldURL = config[addon.ConfigVars[0]]
+	}
+
+	if flagPgCredAll {
+		must(db.RotateCredentials(flagPgCredForce))
+	} else {
+		must(db.RotateCredential(flagPgCredName, flagPgCredForce))
+	}
+	log.Printf("Rotated %s of %s.", what, addonName)
+	if oldURL == "" || (!flagPgCredAll && flagPgCredName != "default") {
+		return
+	}
+
+	newURL, config := waitConfigVarChange(appname, addon.ConfigVars[0], oldURL)
+	if newURL == "" {
+		printWarning("%s didn't change within %s. Check env vars holding the old URL with 'hk env'.", addon.ConfigVars[0], pgCredentialWait)
+		return
+	}
+	updates := dependentEnvUpdates(config, addon.ConfigVars, oldURL, newURL)
+	if len(updates) == 0 {
+		return
+	}
+	_, err = ctx.Client.ConfigVarUpdate(appname, updates)
+	must(err)
+	var names []string
+	for k := range updates {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	log.Printf("Updated %s.", strings.Join(names, ", "))
+}
+
+// waitConfigVarChange polls an app's env vars until name no longer has
+// the value old, and returns its new value with the rest of the env vars.
+// It returns "" if name hasn't changed within pgCredentialWait.
+func waitConfigVarChange(appname, name, old string) (string, map[string]string) {
+	for deadline := time.Now().Add(pgCredentialWait); time.Now().Before(deadline); time.Sleep(pgCredentialPoll) {
+		config, err := client.ConfigVarInfo(appname)
+		must(err)
+		if v := config[name]; v != old {
+			return v, config
+		}
+	}
+	return "", nil
+}
+
+// dependentEnvUpdates returns the changes that replace oldURL with newURL
+// in the env vars that contain it, other than the add-on's own vars in
+// owned, which Heroku Postgres updates itself.
+func dependentEnvUpdates(config map[string]string, owned []string, oldURL, newURL string) map[string]*string {
+	skip := make(map[string]bool)
+	for _, k := range owned {
+		skip[k] = true
+	}
+	updates := make(map[string]*string)
+	for k, v := range config {
+		if skip[k] || !strings.Contains(v, oldURL) {
+			continue
+		}
+		nv := strings.Replace(v, oldURL, newURL, -1)
+		updates[k] = &nv
+	}
+	return updates
+}
@@ -0,0 +1,104 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/heroku/hk/postgresql"
+	"github.com/mgutz/ansi"
+)
+
+var cmdPgDiagnose = &Command{
+	Run:      runPgDiagnose,
+	Usage:    "pg-diagnose [<dbname>]",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "check a Heroku Postgres database for common problems" + extra,
+	Long: `
+Pg-diagnose runs the standard diagnostic checks on a Heroku Postgres
+database, using the locally-installed psql command, and reports
+each as green, yellow, or red, with the rows behind any problem.
+It checks for long-running queries, connections idle in a
+transaction, queries blocked on locks, table bloat, a low cache hit
+rate, and large tables read mostly by sequential scans.
+
+The database is given as for 'hk psql', and defaults to
+DATABASE_URL. Pg-diagnose exits with status 1 if any check is red.
+
+Examples:
+
+    $ hk pg-diagnose
+    GREEN   Long-running queries  no queries running over 5 minutes
+    YELLOW  Idle in transaction   1 connection idle in a transaction over 1 minute
+              pid    idle for  last query
+              31337  00:04:12  UPDATE accounts SET balance = balance - 10 WHERE id = 7
+    GREEN   Blocked queries       no queries waiting on locks
+    GREEN   Bloat                 no tables with over 20% dead rows
+    GREEN   Cache hit rate        99% or more of reads hit the cache
+    GREEN   Index usage           large tables are read by index 95% of the time or more
+`,
+}
+
+func runPgDiagnose(ctx *Context, args []string) {
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	var dbname string
+	if len(args) == 1 {
+		dbname = args[0]
+	}
+	psqlArgs, pgenv := psqlConn(ctx, ctx.MustApp(), dbname)
+
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	failed := false
+	for _, check := range postgresql.DiagnoseChecks {
+		rows, err := psqlQuery(psqlArgs, pgenv, check.Query)
+		var status postgresql.CheckStatus
+		var summary string
+		if err != nil {
+			status, summary = postgresql.Skipped, "couldn't run check: "+err.Error()
+		} else {
+			status, summary = check.Evaluate(rows)
+		}
+		failed = failed || status == postgresql.Red
+		fmt.Fprintf(w, "%s %s\t%s\n", formatCheckStatus(status), check.Name, summary)
+		if status == postgresql.Yellow || status == postgresql.Red {
+			w.Flush()
+			printCheckRows(ctx.Stdout, check.Columns, rows)
+		}
+	}
+	w.Flush()
+	if failed {
+		exit(1)
+	}
+}
+
+// formatCheckStatus returns status as an upper-case label, colored to
+// match. Labels are padded to the same width before they're colored, so
+// the color codes don't upset a tabwriter's alignment.
+func formatCheckStatus(status postgresql.CheckStatus) string {
+	label := fmt.Sprintf("%-7s", strings.ToUpper(string(status)))
+	switch status {
+	case postgresql.Green:
+		return ansi.Color(label, "green")
+	case postgresql.Yellow:
+		return ansi.Color(label, "yellow")
+	case postgresql.Red:
+		return ansi.Color(label, "red")
+	}
+	return label
+}
+
+// printCheckRows prints the rows found by a check, indented under it,
+// with a header naming the columns.
+func printCheckRows(out io.Writer, columns []string, rows [][]string) {
+	w := tabwriter.NewWriter(out, 1, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "          "+strings.Join(columns, "\t"))
+	for _, r := range rows {
+		fmt.Fprintln(w, "          "+strings.Join(r, "\t"))
+	}
+	w.Flush()
+}
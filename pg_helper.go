@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"fmt"
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/postgresql"
 )
 
 // the names of heroku postgres addons vary in dev environments
@@ -97,3 +98,32 @@ func newPgAddonMap(addons []heroku.Addon, appConf map[string]string) pgAddonMap
 	}
 	return pgAddonMap{m, appConf}
 }
+
+// findPgDB returns the named Heroku Postgres database on an app, along with
+// its add-on name. If name is empty, the database in DATABASE_URL is used.
+func findPgDB(appname, name string) (postgresql.DB, string, error) {
+	addons, err := client.AddonList(appname, nil)
+	if err != nil {
+		return postgresql.DB{}, "", err
+	}
+	var addonName string
+	if name == "" {
+		config, err := client.ConfigVarInfo(appname)
+		if err != nil {
+			return postgresql.DB{}, "", err
+		}
+		addonMap := newPgAddonMap(addons, config)
+		var ok bool
+		if addonName, ok = addonMap.FindAddonFromValue(config["DATABASE_URL"]); !ok {
+			return postgresql.DB{}, "", fmt.Errorf("DATABASE_URL is not a %s database on %s", hpgAddonName(), appname)
+		}
+	} else {
+		addonName = ensurePrefix(name, hpgAddonName()+"-")
+	}
+	for _, addon := range addons {
+		if addon.Name == addonName {
+			return pgclient.NewDB(addon.ProviderId, addon.Plan.Name), addonName, nil
+		}
+	}
+	return postgresql.DB{}, "", fmt.Errorf("addon %s not found", addonName)
+}
@@ -0,0 +1,203 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/heroku/hk/term"
+)
+
+var cmdPgPs = &Command{
+	Run:      runPgPs,
+	Usage:    "pg-ps [--verbose] [<dbname>]",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "show the queries running on a Heroku Postgres database" + extra,
+	Long: `
+Pg-ps shows the queries running on a Heroku Postgres database, using
+the locally-installed psql command: each backend's pid, its state,
+how long its query has run, and the query, longest-running first.
+Idle connections are left out unless --verbose is given.
+
+The database is given as for 'hk psql', and defaults to
+DATABASE_URL. Stop a query with 'hk pg-kill'.
+
+Options:
+
+    --verbose  show idle connections too
+
+Examples:
+
+    $ hk pg-ps
+    pid    state                duration  query
+    31337  active               00:12:03  SELECT count(*) FROM events WHERE ...
+    31512  idle in transaction  00:00:41  UPDATE accounts SET balance = ...
+`,
+}
+
+var flagPgPsVerbose bool
+
+func init() {
+	cmdPgPs.Flag.BoolVar(&flagPgPsVerbose, "verbose", false, "show idle connections too")
+}
+
+func runPgPs(ctx *Context, args []string) {
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	psqlArgs, pgenv := psqlConn(ctx, ctx.MustApp(), optionalArg(args, 0))
+	idle := "AND state <> 'idle'"
+	if flagPgPsVerbose {
+		idle = ""
+	}
+	rows, err := psqlQuery(psqlArgs, pgenv, `SELECT pid, state, date_trunc('second', now() - query_start), left(regexp_replace(query, '\s+', ' ', 'g'), 80)
+		FROM pg_stat_activity
+		WHERE pid <> pg_backend_pid() AND datname = current_database() `+idle+`
+		ORDER BY query_start`)
+	if err != nil {
+		printFatal(err.Error())
+	}
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	listRec(w, "pid", "state", "duration", "query")
+	for _, r := range rows {
+		vals := make([]interface{}, len(r))
+		for i, v := range r {
+			vals[i] = v
+		}
+		listRec(w, vals...)
+	}
+}
+
+var cmdPgKill = &Command{
+	Run:      runPgKill,
+	Usage:    "pg-kill [-f] <pid> [<dbname>]",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "stop a query on a Heroku Postgres database" + extra,
+	Long: `
+Pg-kill cancels the query a backend of a Heroku Postgres database is
+running, given the backend's pid from 'hk pg-ps'. With -f, it ends
+the backend's connection instead, which also rolls back its open
+transaction.
+
+The database is given as for 'hk psql', and defaults to
+DATABASE_URL.
+
+Options:
+
+    -f  end the connection, not just the query
+
+Examples:
+
+    $ hk pg-kill 31337
+    Canceled the query of backend 31337.
+
+    $ hk pg-kill -f 31512
+    Terminated backend 31512.
+`,
+}
+
+var flagPgKillForce bool
+
+func init() {
+	cmdPgKill.Flag.BoolVar(&flagPgKillForce, "f", false, "end the connection")
+}
+
+func runPgKill(ctx *Context, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		ctx.printUsage()
+		exit(2)
+	}
+	pid, err := strconv.Atoi(args[0])
+	if err != nil {
+		printFatal("invalid pid %q", args[0])
+	}
+	psqlArgs, pgenv := psqlConn(ctx, ctx.MustApp(), optionalArg(args, 1))
+	fn, msg := "pg_cancel_backend", "Canceled the query of backend %d."
+	if flagPgKillForce {
+		fn, msg = "pg_terminate_backend", "Terminated backend %d."
+	}
+	rows, err := psqlQuery(psqlArgs, pgenv, fmt.Sprintf("SELECT %s(%d)", fn, pid))
+	if err != nil {
+		printFatal(err.Error())
+	}
+	if len(rows) != 1 || rows[0][0] != "t" {
+		printFatal("no backend with pid %d.", pid)
+	}
+	log.Printf(msg, pid)
+}
+
+var cmdPgKillAll = &Command{
+	Run:      runPgKillAll,
+	Usage:    "pg-killall [-y] [<dbname>]",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "end every connection to a Heroku Postgres database" + extra,
+	Long: `
+Pg-killall ends every connection to a Heroku Postgres database but
+its own, rolling back their open transactions. Apps reconnect as
+they need to. At a terminal, it asks first unless -y is given.
+
+The database is given as for 'hk psql', and defaults to
+DATABASE_URL.
+
+Options:
+
+    -y, --yes  don't ask for confirmation
+
+Examples:
+
+    $ hk pg-killall
+    End every connection to DATABASE_URL on myapp? [y/N] y
+    Terminated 14 backends.
+`,
+}
+
+var flagPgKillAllYes bool
+
+func init() {
+	cmdPgKillAll.Flag.BoolVar(&flagPgKillAllYes, "y", false, "don't ask for confirmation")
+	cmdPgKillAll.Flag.BoolVar(&flagPgKillAllYes, "yes", false, "don't ask for confirmation")
+}
+
+func runPgKillAll(ctx *Context, args []string) {
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	dbname := optionalArg(args, 0)
+	psqlArgs, pgenv := psqlConn(ctx, appname, dbname)
+	if !flagPgKillAllYes && term.IsTerminal(os.Stdin) {
+		if dbname == "" {
+			dbname = "DATABASE_URL"
+		}
+		mustConfirm(fmt.Sprintf("End every connection to %s on %s?", dbname, appname))
+	}
+	rows, err := psqlQuery(psqlArgs, pgenv, `SELECT pg_terminate_backend(pid)
+		FROM pg_stat_activity
+		WHERE pid <> pg_backend_pid() AND datname = current_database()`)
+	if err != nil {
+		printFatal(err.Error())
+	}
+	n := 0
+	for _, r := range rows {
+		if r[0] == "t" {
+			n++
+		}
+	}
+	log.Printf("Terminated %d backends.", n)
+}
+
+// optionalArg returns args[i], or "" if there are too few args.
+func optionalArg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdPgWait = &Command{
+	Run:      runPgWait,
+	Usage:    "pg-wait [--interval <duration>] [--timeout <duration>] [<dbname>]",
+	NeedsApp: true,
+	Category: "pg",
+	Short:    "wait for Heroku Postgres databases to be available" + extra,
+	Long: `
+Pg-wait waits until a Heroku Postgres database is available: done
+being provisioned, and caught up if it's a follower or fork. Without
+a database name, it waits for all of the app's databases. It's
+useful after adding a database, follower, or fork, and during
+maintenance.
+
+Pg-wait exits with status 0 once every database is available, and
+status 1 if one isn't before the timeout, or its status can't be
+read, so scripts can wait on it.
+
+Options:
+
+    --interval <duration>  time between checks (default 5s)
+    --timeout <duration>   time to wait, or 0 to wait as long as it
+                           takes (default 0)
+
+Examples:
+
+    $ hk pg-wait
+    heroku-postgresql-crimson is available.
+    heroku-postgresql-copper is available.
+
+    $ hk pg-wait --timeout 30m copper
+    heroku-postgresql-copper is available.
+`,
+}
+
+var (
+	flagPgWaitInterval time.Duration
+	flagPgWaitTimeout  time.Duration
+)
+
+func init() {
+	cmdPgWait.Flag.DurationVar(&flagPgWaitInterval, "interval", 5*time.Second, "time between checks")
+	cmdPgWait.Flag.DurationVar(&flagPgWaitTimeout, "timeout", 0, "time to wait")
+}
+
+func runPgWait(ctx *Context, args []string) {
+	if len(args) > 1 || flagPgWaitInterval <= 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	addons, err := ctx.Client.AddonList(appname, nil)
+	must(err)
+	dbs := pgWaitAddons(addons, optionalArg(args, 0))
+	if len(dbs) == 0 {
+		if len(args) == 1 {
+			printFatal("addon %s not found", ensurePrefix(args[0], hpgAddonName()+"-"))
+		}
+		printFatal("%s has no %s databases", appname, hpgAddonName())
+	}
+
+	var deadline time.Time
+	if flagPgWaitTimeout > 0 {
+		deadline = time.Now().Add(flagPgWaitTimeout)
+	}
+	for _, addon := range dbs {
+		if err := waitPgAvailable(addon, deadline); err != nil {
+			printFatal(err.Error())
+		}
+		log.Printf("%s is available.", addon.Name)
+	}
+}
+
+// pgWaitAddons returns the Heroku Postgres add-ons among addons that
+// pg-wait should wait for: the one named name, or all if name is empty.
+func pgWaitAddons(addons []heroku.Addon, name string) []heroku.Addon {
+	prefix := hpgAddonName() + "-"
+	var dbs []heroku.Addon
+	for _, a := range addons {
+		if !strings.HasPrefix(a.Name, prefix) {
+			continue
+		}
+		if name == "" || a.Name == name || a.Name == prefix+name {
+			dbs = append(dbs, a)
+		}
+	}
+	return dbs
+}
+
+// waitPgAvailable polls the wait status of a database every
+// flagPgWaitInterval until it's available, showing what it's waiting on
+// with a spinner. A zero deadline means there's no time limit.
+func waitPgAvailable(addon heroku.Addon, deadline time.Time) error {
+	db := pgclient.NewDB(addon.ProviderId, addon.Plan.Name)
+	var s *spinner
+	var message string
+	defer func() {
+		if s != nil {
+			s.stop()
+		}
+	}()
+	for {
+		ws, err := db.WaitStatus()
+		if err != nil {
+			return fmt.Errorf("%s: %s", addon.Name, err)
+		}
+		if !ws.Waiting {
+			return nil
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return fmt.Errorf("%s is still not available after %s: %s", addon.Name, flagPgWaitTimeout, ws.Message)
+		}
+		if s == nil || ws.Message != message {
+			if s != nil {
+				s.stop()
+			}
+			message = ws.Message
+			s = newSpinner(fmt.Sprintf("Waiting for %s (%s)", addon.Name, message))
+		}
+		s.sleep(flagPgWaitInterval)
+	}
+}
@@ -0,0 +1,313 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heroku/hk/hkclient"
+)
+
+// pipelineStages lists the stages of a pipeline, in promotion order.
+var pipelineStages = []string{"review", "development", "staging", "production"}
+
+var cmdPipelines = &Command{
+	Run:      runPipelines,
+	Usage:    "pipelines [-j]",
+	Category: "pipeline",
+	Short:    "list pipelines" + extra,
+	Long: `
+Lists pipelines. Shows the name of each pipeline and the time it
+was created.
+
+Options:
+
+    -j, --json  print pipelines as JSON
+
+Example:
+
+    $ hk pipelines
+    myapp     Jan 2 12:34
+    otherapp  Jan 3 08:12
+`,
+}
+
+func init() {
+	registerList(cmdPipelines, hkclient.Pipeline{})
+}
+
+func runPipelines(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	pipelines, err := ext().PipelineList(nil)
+	must(err)
+	sort.Sort(pipelinesByName(pipelines))
+	if printList(ctx.Stdout, pipelines) {
+		return
+	}
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, p := range pipelines {
+		listRec(w, p.Name, prettyTime{p.CreatedAt})
+	}
+}
+
+var cmdPipelineInfo = &Command{
+	Run:      runPipelineInfo,
+	Usage:    "pipeline-info <pipeline>",
+	Category: "pipeline",
+	Short:    "show pipeline stages and apps" + extra,
+	Long: `
+Shows the apps in each stage of a pipeline.
+
+Example:
+
+    $ hk pipeline-info myapp
+    staging     myapp-staging
+    production  myapp
+    production  myapp-eu
+`,
+}
+
+func runPipelineInfo(ctx *Context, args []string) {
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	couplings, err := pipelineCouplings(args[0])
+	must(err)
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, c := range couplings {
+		listRec(w, c.Stage, c.App.Name)
+	}
+}
+
+var cmdPipelineCreate = &Command{
+	Run:      runPipelineCreate,
+	Usage:    "pipeline-create <name>",
+	Category: "pipeline",
+	Short:    "create a pipeline" + extra,
+	Long: `
+Creates a pipeline. Add apps to it with 'hk pipeline-add'.
+
+Example:
+
+    $ hk pipeline-create myapp
+    Created pipeline myapp.
+`,
+}
+
+func runPipelineCreate(ctx *Context, args []string) {
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	p, err := ext().PipelineCreate(args[0])
+	must(err)
+	log.Printf("Created pipeline %s.", p.Name)
+}
+
+var cmdPipelineAdd = &Command{
+	Run:      runPipelineAdd,
+	Usage:    "pipeline-add [--stage <stage>] <pipeline>",
+	NeedsApp: true,
+	Category: "pipeline",
+	Short:    "add an app to a pipeline" + extra,
+	Long: `
+Adds an app to a stage of a pipeline.
+
+Options:
+
+    --stage <stage>  one of review, development, staging, or
+                     production (default staging)
+
+Example:
+
+    $ hk pipeline-add -a myapp-staging --stage staging myapp
+    Added myapp-staging to myapp as staging.
+`,
+}
+
+var flagPipelineStage string
+
+func init() {
+	cmdPipelineAdd.Flag.StringVar(&flagPipelineStage, "stage", "staging", "pipeline stage")
+}
+
+func runPipelineAdd(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	if stringsIndex(pipelineStages, flagPipelineStage) < 0 {
+		printFatal("invalid stage %q, expected one of %s", flagPipelineStage, strings.Join(pipelineStages, ", "))
+	}
+	p, err := ext().PipelineInfo(args[0])
+	must(err)
+	c, err := ext().PipelineCouplingCreate(appname, p.Id, flagPipelineStage)
+	must(err)
+	log.Printf("Added %s to %s as %s.", appname, p.Name, c.Stage)
+}
+
+var cmdPipelineRemove = &Command{
+	Run:      runPipelineRemove,
+	Usage:    "pipeline-remove",
+	NeedsApp: true,
+	Category: "pipeline",
+	Short:    "remove an app from its pipeline" + extra,
+	Long: `
+Removes an app from the pipeline it belongs to.
+
+Example:
+
+    $ hk pipeline-remove -a myapp-staging
+    Removed myapp-staging from myapp.
+`,
+}
+
+func runPipelineRemove(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	c, err := ext().PipelineCouplingInfoByApp(appname)
+	must(err)
+	must(ext().PipelineCouplingDelete(c.Id))
+	log.Printf("Removed %s from %s.", appname, c.Pipeline.Name)
+}
+
+var cmdPipelinePromote = &Command{
+	Run:      runPipelinePromote,
+	Usage:    "pipeline-promote [<target-app>...]",
+	NeedsApp: true,
+	Category: "pipeline",
+	Short:    "promote an app's slug down its pipeline" + extra,
+	Long: `
+Pipeline-promote releases the slug running on an app to the apps in
+the next stage of its pipeline (e.g. from staging to production).
+Target apps may be named to promote to only some of them.
+
+Example:
+
+    $ hk pipeline-promote -a myapp-staging
+    Promoted myapp-staging to myapp (succeeded).
+    Promoted myapp-staging to myapp-eu (succeeded).
+`,
+}
+
+func runPipelinePromote(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	c, err := ext().PipelineCouplingInfoByApp(appname)
+	must(err)
+	couplings, err := pipelineCouplings(c.Pipeline.Id)
+	must(err)
+
+	downstream, err := downstreamApps(couplings, c.Stage)
+	if err != nil {
+		printFatal("can't promote %s: %s", appname, err)
+	}
+	targets := downstream
+	if len(args) > 0 {
+		targets = nil
+		for _, t := range downstream {
+			if stringsIndex(args, t.App.Name) >= 0 {
+				targets = append(targets, t)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		printFatal("no apps downstream of %s in pipeline %s", appname, c.Pipeline.Name)
+	}
+	for _, t := range targets {
+		mustNotBeDeployLocked(t.App.Name)
+	}
+
+	var targetIds []string
+	for _, t := range targets {
+		targetIds = append(targetIds, t.App.Id)
+	}
+	promotion, err := ext().PipelinePromotionCreate(c.Pipeline.Id, c.App.Id, targetIds)
+	must(err)
+	for promotion.Status == "pending" {
+		time.Sleep(2 * time.Second)
+		promotion, err = ext().PipelinePromotionInfo(promotion.Id)
+		must(err)
+	}
+	results, err := ext().PipelinePromotionTargetList(promotion.Id)
+	must(err)
+	names := make(map[string]string)
+	for _, t := range targets {
+		names[t.App.Id] = t.App.Name
+	}
+	failed := false
+	for _, r := range results {
+		msg := fmt.Sprintf("Promoted %s to %s (%s)", appname, names[r.App.Id], r.Status)
+		if r.ErrorMessage != nil && *r.ErrorMessage != "" {
+			msg += ": " + *r.ErrorMessage
+		}
+		log.Println(msg + ".")
+		failed = failed || r.Status != "succeeded"
+	}
+	if failed {
+		exit(1)
+	}
+}
+
+// pipelineCouplings returns the apps in a pipeline, sorted by stage and
+// then app name.
+func pipelineCouplings(pipelineIdentity string) ([]hkclient.PipelineCoupling, error) {
+	couplings, err := ext().PipelineCouplingListByPipeline(pipelineIdentity)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(couplingsByStage(couplings))
+	return couplings, nil
+}
+
+// downstreamApps returns the couplings in the first stage after stage that
+// has any apps. It returns an error if stage isn't one hk knows the order
+// of, rather than guess which stages come after it.
+func downstreamApps(couplings []hkclient.PipelineCoupling, stage string) ([]hkclient.PipelineCoupling, error) {
+	i := stringsIndex(pipelineStages, stage)
+	if i < 0 {
+		return nil, fmt.Errorf("unknown pipeline stage %q, expected one of %s", stage, strings.Join(pipelineStages, ", "))
+	}
+	for _, next := range pipelineStages[i+1:] {
+		var apps []hkclient.PipelineCoupling
+		for _, c := range couplings {
+			if c.Stage == next {
+				apps = append(apps, c)
+			}
+		}
+		if len(apps) > 0 {
+			return apps, nil
+		}
+	}
+	return nil, nil
+}
+
+type pipelinesByName []hkclient.Pipeline
+
+func (a pipelinesByName) Len() int           { return len(a) }
+func (a pipelinesByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a pipelinesByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+type couplingsByStage []hkclient.PipelineCoupling
+
+func (a couplingsByStage) Len() int      { return len(a) }
+func (a couplingsByStage) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a couplingsByStage) Less(i, j int) bool {
+	si, sj := stringsIndex(pipelineStages, a[i].Stage), stringsIndex(pipelineStages, a[j].Stage)
+	if si != sj {
+		return si < sj
+	}
+	return a[i].App.Name < a[j].App.Name
+}
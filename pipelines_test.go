@@ -0,0 +1,68 @@
+package hk
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/heroku/hk/hkclient"
+)
+
+func testCoupling(stage, app string) hkclient.PipelineCoupling {
+	var c hkclient.PipelineCoupling
+	c.Stage = stage
+	c.App.Id, c.App.Name = app+"-id", app
+	return c
+}
+
+func couplingApps(couplings []hkclient.PipelineCoupling) []string {
+	var apps []string
+	for _, c := range couplings {
+		apps = append(apps, c.App.Name)
+	}
+	return apps
+}
+
+func TestDownstreamApps(t *testing.T) {
+	couplings := []hkclient.PipelineCoupling{
+		testCoupling("review", "myapp-pr-1"),
+		testCoupling("development", "myapp-dev"),
+		testCoupling("production", "myapp"),
+		testCoupling("production", "myapp-eu"),
+	}
+	tests := []struct {
+		stage   string
+		want    []string
+		wantErr bool
+	}{
+		{"review", []string{"myapp-dev"}, false},
+		{"development", []string{"myapp", "myapp-eu"}, false}, // staging is empty
+		{"staging", []string{"myapp", "myapp-eu"}, false},
+		{"production", nil, false},
+		{"qa", nil, true},
+		{"", nil, true},
+	}
+	for i, test := range tests {
+		got, err := downstreamApps(couplings, test.stage)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%d. downstreamApps(%q) error = %v, want error %v", i, test.stage, err, test.wantErr)
+		}
+		if apps := couplingApps(got); !reflect.DeepEqual(apps, test.want) {
+			t.Errorf("%d. downstreamApps(%q) = %q, want %q", i, test.stage, apps, test.want)
+		}
+	}
+}
+
+func TestCouplingsByStage(t *testing.T) {
+	couplings := []hkclient.PipelineCoupling{
+		testCoupling("production", "myapp-eu"),
+		testCoupling("staging", "myapp-staging"),
+		testCoupling("production", "myapp"),
+		testCoupling("review", "myapp-pr-1"),
+	}
+	sort.Sort(couplingsByStage(couplings))
+	want := []string{"myapp-pr-1", "myapp-staging", "myapp", "myapp-eu"}
+	if apps := couplingApps(couplings); !reflect.DeepEqual(apps, want) {
+		t.Errorf("sorted = %q, want %q", apps, want)
+	}
+}
@@ -0,0 +1,137 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// A Transfer is a backup of a database, or a restore of a backup into a
+// database.
+type Transfer struct {
+	UUID           string `json:"uuid"`
+	Num            int    `json:"num"`
+	FromName       string `json:"from_name"`
+	FromType       string `json:"from_type"`
+	ToName         string `json:"to_name"`
+	ToType         string `json:"to_type"`
+	SourceBytes    int64  `json:"source_bytes"`
+	ProcessedBytes int64  `json:"processed_bytes"`
+	Succeeded      bool   `json:"succeeded"`
+	CreatedAt      string `json:"created_at"`
+	StartedAt      string `json:"started_at"`
+	FinishedAt     string `json:"finished_at"`
+	CanceledAt     string `json:"canceled_at"`
+}
+
+// transferTimeFormat is the layout of the times in a Transfer.
+const transferTimeFormat = "2006-01-02 15:04:05 -0700"
+
+// Created returns the time the transfer was created.
+func (t *Transfer) Created() (time.Time, error) {
+	return time.Parse(transferTimeFormat, t.CreatedAt)
+}
+
+// IsBackup reports whether the transfer is a backup, as opposed to a
+// restore.
+func (t *Transfer) IsBackup() bool {
+	return t.ToType == "gof3r"
+}
+
+// Name returns the transfer's short name, such as b004 for a backup or r002
+// for a restore.
+func (t *Transfer) Name() string {
+	prefix := "r"
+	if t.IsBackup() {
+		prefix = "b"
+	}
+	return fmt.Sprintf("%s%03d", prefix, t.Num)
+}
+
+// Status returns "Pending", "Running", "Completed", "Failed", or "Canceled".
+func (t *Transfer) Status() string {
+	switch {
+	case t.CanceledAt != "":
+		return "Canceled"
+	case t.FinishedAt != "" && t.Succeeded:
+		return "Completed"
+	case t.FinishedAt != "":
+		return "Failed"
+	case t.StartedAt != "":
+		return "Running"
+	}
+	return "Pending"
+}
+
+// Done reports whether the transfer has finished, successfully or not.
+func (t *Transfer) Done() bool {
+	return t.FinishedAt != "" || t.CanceledAt != ""
+}
+
+// An App is a Heroku app, for requests about all of its databases. Create
+// one with Client.NewApp.
+type App struct {
+	Name string
+
+	client *Client
+	ctx    context.Context
+}
+
+// NewApp creates a new App struct initialized with this Client.
+func (c *Client) NewApp(name string) App {
+	return App{Name: name, client: c}
+}
+
+// WithContext returns a copy of a whose requests are canceled when ctx is
+// done.
+func (a *App) WithContext(ctx context.Context) *App {
+	a2 := *a
+	a2.ctx = ctx
+	return &a2
+}
+
+func (a *App) req(meth, path string, body, v interface{}) error {
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	u := a.client.rootURL() + "/apps/" + a.Name + "/transfers" + path
+	return a.client.do(ctx, meth, u, body, v)
+}
+
+// Transfers returns the app's backups and restores.
+func (a *App) Transfers() (ts []Transfer, err error) {
+	err = a.req("GET", "", nil, &ts)
+	return
+}
+
+// Transfer returns the app's transfer with the given number.
+func (a *App) Transfer(num int) (t Transfer, err error) {
+	err = a.req("GET", "/"+strconv.Itoa(num), nil, &t)
+	return
+}
+
+// TransferPublicURL returns a URL that a backup can be downloaded from for
+// a short time.
+func (a *App) TransferPublicURL(num int) (string, error) {
+	var v struct {
+		URL string `json:"url"`
+	}
+	err := a.req("POST", "/"+strconv.Itoa(num)+"/actions/public-url", nil, &v)
+	return v.URL, err
+}
+
+// CaptureBackup starts a backup of the database.
+func (d *DB) CaptureBackup() (t Transfer, err error) {
+	err = d.req("POST", "/backups", nil, &t)
+	return
+}
+
+// Restore starts replacing the database's contents with the backup at
+// backupURL.
+func (d *DB) Restore(backupURL string) (t Transfer, err error) {
+	body := map[string]string{"backup_url": backupURL}
+	err = d.req("POST", "/restores", body, &t)
+	return
+}
@@ -0,0 +1,70 @@
+package postgresql
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransferStatus(t *testing.T) {
+	tests := []struct {
+		t      Transfer
+		name   string
+		status string
+	}{
+		{Transfer{Num: 4, ToType: "gof3r"}, "b004", "Pending"},
+		{Transfer{Num: 4, ToType: "gof3r", StartedAt: "x"}, "b004", "Running"},
+		{Transfer{Num: 12, ToType: "pg_restore", StartedAt: "x", FinishedAt: "x", Succeeded: true}, "r012", "Completed"},
+		{Transfer{Num: 1, ToType: "gof3r", StartedAt: "x", FinishedAt: "x"}, "b001", "Failed"},
+		{Transfer{Num: 1, ToType: "gof3r", CanceledAt: "x"}, "b001", "Canceled"},
+	}
+	for i, tt := range tests {
+		if name := tt.t.Name(); name != tt.name {
+			t.Errorf("%d. Name() = %q, want %q", i, name, tt.name)
+		}
+		if status := tt.t.Status(); status != tt.status {
+			t.Errorf("%d. Status() = %q, want %q", i, status, tt.status)
+		}
+	}
+}
+
+func TestAppTransfersPath(t *testing.T) {
+	var gotPath string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Write([]byte(`[{"num": 3, "to_type": "gof3r"}]`))
+	}))
+	defer ts.Close()
+	c := &Client{URL: ts.URL + DefaultAPIPath}
+	app := c.NewApp("myapp")
+	transfers, err := app.Transfers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "/client/v11/apps/myapp/transfers" {
+		t.Errorf("request path = %q", gotPath)
+	}
+	if len(transfers) != 1 || transfers[0].Name() != "b003" {
+		t.Errorf("unexpected transfers %+v", transfers)
+	}
+}
+
+func TestDBRestoreBody(t *testing.T) {
+	var body map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(b, &body)
+		w.Write([]byte(`{"num": 2}`))
+	}))
+	defer ts.Close()
+	c := &Client{URL: ts.URL}
+	db := c.NewDB("resource123@heroku.com", "standard-tengu")
+	if _, err := db.Restore("https://example.com/backup.dump"); err != nil {
+		t.Fatal(err)
+	}
+	if body["backup_url"] != "https://example.com/backup.dump" {
+		t.Errorf("unexpected request body %v", body)
+	}
+}
@@ -1,6 +1,8 @@
 package postgresql
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -61,21 +63,26 @@ type Client struct {
 	AdditionalHeaders http.Header
 }
 
+// Get sends a GET request to path and decodes the response into v.
 func (c *Client) Get(isStarterPlan bool, path string, v interface{}) error {
 	return c.APIReq(isStarterPlan, "GET", path, v)
 }
 
+// Post sends a POST request to path and decodes the response into v.
 func (c *Client) Post(isStarterPlan bool, path string, v interface{}) error {
 	return c.APIReq(isStarterPlan, "POST", path, v)
 }
 
+// Put sends a PUT request to path and decodes the response into v.
 func (c *Client) Put(isStarterPlan bool, path string, v interface{}) error {
 	return c.APIReq(isStarterPlan, "PUT", path, v)
 }
 
-// Creates a new DB struct initialized with this Client.
+// Creates a new DB struct initialized with this Client. The id is the
+// database add-on's provider ID, and plan is its plan name, with or without
+// the "heroku-postgresql:" prefix.
 func (c *Client) NewDB(id, plan string) DB {
-	return DB{Id: id, Plan: strings.TrimLeft(plan, "heroku-postgresql:"), client: c}
+	return DB{Id: id, Plan: strings.TrimPrefix(plan, "heroku-postgresql:"), client: c}
 }
 
 // Generates an HTTP request for the Heroku Postgres API, but does not
@@ -92,8 +99,17 @@ func (c *Client) NewDB(id, plan string) DB {
 // and false otherwise (as defined in DB.IsStarterPlan() ). Method is the HTTP
 // method of this request, and path is the HTTP path.
 func (c *Client) NewRequest(isStarterPlan bool, method, path string) (*http.Request, error) {
-	var rbody io.Reader
+	return c.NewRequestContext(context.Background(), isStarterPlan, method, path)
+}
+
+// NewRequestContext is like NewRequest, but the request is canceled when ctx
+// is done.
+func (c *Client) NewRequestContext(ctx context.Context, isStarterPlan bool, method, path string) (*http.Request, error) {
+	return c.newRequest(ctx, method, c.apiURL(isStarterPlan)+path, nil)
+}
 
+// apiURL returns the base URL for database requests.
+func (c *Client) apiURL(isStarterPlan bool) string {
 	apiURL := strings.TrimRight(c.URL, "/")
 	if isStarterPlan {
 		apiURL = strings.TrimRight(c.StarterURL, "/")
@@ -105,11 +121,34 @@ func (c *Client) NewRequest(isStarterPlan bool, method, path string) (*http.Requ
 			apiURL = DefaultAPIURL
 		}
 	}
-	req, err := http.NewRequest(method, apiURL+path, rbody)
+	return apiURL
+}
+
+// rootURL returns the base URL for requests that aren't about a single
+// database, such as an app's backups. These are always made to the
+// production API.
+func (c *Client) rootURL() string {
+	return strings.TrimSuffix(c.apiURL(false), "/databases")
+}
+
+// newRequest creates a request to u. If body is not nil, it's sent as JSON.
+func (c *Client) newRequest(ctx context.Context, method, u string, body interface{}) (*http.Request, error) {
+	var rbody io.Reader
+	if body != nil {
+		j, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		rbody = bytes.NewReader(j)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rbody)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	req.Header.Set("Request-Id", uuid.New())
 	useragent := c.UserAgent
 	if useragent == "" {
@@ -123,11 +162,27 @@ func (c *Client) NewRequest(isStarterPlan bool, method, path string) (*http.Requ
 	return req, nil
 }
 
+// do sends a request to u and decodes the response into v, as described in
+// DoReq.
+func (c *Client) do(ctx context.Context, method, u string, body, v interface{}) error {
+	req, err := c.newRequest(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+	return c.DoReq(req, v)
+}
+
 // Sends a Heroku Postgres API request and decodes the response into v. As
 // described in DoReq(), the type of v determines how to handle the response
 // body.
 func (c *Client) APIReq(isStarterPlan bool, meth, path string, v interface{}) error {
-	req, err := c.NewRequest(isStarterPlan, meth, path)
+	return c.APIReqContext(context.Background(), isStarterPlan, meth, path, v)
+}
+
+// APIReqContext is like APIReq, but the request is canceled when ctx is
+// done.
+func (c *Client) APIReqContext(ctx context.Context, isStarterPlan bool, meth, path string, v interface{}) error {
+	req, err := c.NewRequestContext(ctx, isStarterPlan, meth, path)
 	if err != nil {
 		return err
 	}
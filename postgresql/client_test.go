@@ -2,6 +2,7 @@ package postgresql
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -215,6 +216,33 @@ func TestNewDB(t *testing.T) {
 	if db.Plan != "dev" {
 		t.Errorf("DB.Plan expected %s, got %s", "dev", db.Plan)
 	}
+	db = c.NewDB(testId, "heroku-postgresql:standard-tengu")
+	if db.Plan != "standard-tengu" {
+		t.Errorf("DB.Plan expected %s, got %s", "standard-tengu", db.Plan)
+	}
+}
+
+func TestDBWithContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"waiting?": true, "message": "preparing"}`))
+	}))
+	defer ts.Close()
+	c := &Client{URL: ts.URL}
+	db := c.NewDB("resource123@heroku.com", "heroku-postgresql:standard-tengu")
+
+	ws, err := db.WaitStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ws.Waiting || ws.Message != "preparing" {
+		t.Errorf("unexpected wait status %+v", ws)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := db.WithContext(ctx).WaitStatus(); err == nil {
+		t.Error("expected error from canceled context")
+	}
 }
 
 // test helpers
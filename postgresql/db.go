@@ -1,16 +1,26 @@
 package postgresql
 
 import (
+	"context"
+	"net/url"
 	"strings"
 	"time"
 )
 
+// A DB is a Heroku Postgres database. Create one with Client.NewDB.
 type DB struct {
-	Id     string
-	Plan   string
+	// Id is the database add-on's provider ID.
+	Id string
+
+	// Plan is the database's plan name, without the "heroku-postgresql:"
+	// prefix.
+	Plan string
+
 	client *Client
+	ctx    context.Context
 }
 
+// DBInfo describes the state of a database.
 type DBInfo struct {
 	AvailableForIngress   bool   `json:"available_for_ingress"`
 	CreatedAt             string `json:"created_at"`
@@ -33,19 +43,45 @@ type DBInfo struct {
 	TargetTransaction     string    `json:"target_transaction"`
 }
 
+// An InfoEntry is one line of human-readable database info, such as
+// "Status" or "Followers". When ResolveDBName is set, Values holds resource
+// names that callers should resolve to add-on names before display.
 type InfoEntry struct {
 	Name          string
 	ResolveDBName bool `json:"resolve_db_name"`
 	Values        []interface{}
 }
 
+// WithContext returns a copy of d whose requests are canceled when ctx is
+// done.
+func (d *DB) WithContext(ctx context.Context) *DB {
+	d2 := *d
+	d2.ctx = ctx
+	return &d2
+}
+
+func (d *DB) context() context.Context {
+	if d.ctx == nil {
+		return context.Background()
+	}
+	return d.ctx
+}
+
+func (d *DB) req(meth, path string, body, v interface{}) error {
+	u := d.client.apiURL(d.IsStarterPlan()) + "/" + d.Id + path
+	return d.client.do(d.context(), meth, u, body, v)
+}
+
+// Info returns the current state of the database.
 func (d *DB) Info() (dbi DBInfo, err error) {
-	err = d.client.Get(d.IsStarterPlan(), "/"+d.Id, &dbi)
+	err = d.req("GET", "", nil, &dbi)
 	return
 }
 
+// Ingress opens the database to connections from outside Heroku for a
+// short time.
 func (d *DB) Ingress() error {
-	return d.client.Put(d.IsStarterPlan(), "/"+d.Id+"/ingress", nil)
+	return d.req("PUT", "/ingress", nil, nil)
 }
 
 // Whether the DB is a starter plan and should communicate with the starter API.
@@ -55,22 +91,67 @@ func (d *DB) IsStarterPlan() bool {
 	return strings.HasSuffix(d.Plan, "dev") || strings.HasSuffix(d.Plan, "basic")
 }
 
+// Reset destroys all data in the database.
 func (d *DB) Reset() error {
-	return d.client.Put(d.IsStarterPlan(), "/"+d.Id+"/reset", nil)
+	return d.req("PUT", "/reset", nil, nil)
+}
+
+// A Credential is a named role for connecting to a database. Its Users
+// are the database users that carry it: usually one, but two while a
+// rotation is replacing the old user with a new one.
+type Credential struct {
+	UUID     string           `json:"uuid"`
+	Name     string           `json:"name"`
+	State    string           `json:"state"`
+	Database string           `json:"database"`
+	Host     string           `json:"host"`
+	Port     int              `json:"port"`
+	Users    []CredentialUser `json:"credentials"`
+}
+
+// A CredentialUser is one database user of a Credential.
+type CredentialUser struct {
+	User        string `json:"user"`
+	Password    string `json:"password"`
+	State       string `json:"state"`
+	Connections int    `json:"connections"`
+}
+
+// Credentials returns the database's credentials.
+func (d *DB) Credentials() (cs []Credential, err error) {
+	err = d.req("GET", "/credentials", nil, &cs)
+	return
+}
+
+// RotateCredential replaces the password of the named credential. Unless
+// force is set, connections using the old password are left open until
+// they close.
+func (d *DB) RotateCredential(name string, force bool) error {
+	body := map[string]bool{"forced": force}
+	return d.req("POST", "/credentials/"+url.PathEscape(name)+"/credentials_rotation", body, nil)
 }
 
-func (d *DB) RotateCredentials() error {
-	return d.client.Post(d.IsStarterPlan(), "/"+d.Id+"/credentials_rotation", nil)
+// RotateCredentials replaces the passwords of all of the database's
+// credentials, as RotateCredential does for one.
+func (d *DB) RotateCredentials(force bool) error {
+	body := map[string]bool{"forced": force}
+	return d.req("POST", "/credentials_rotation", body, nil)
 }
 
+// Unfollow stops a follower database from following its leader.
 func (d *DB) Unfollow() error {
-	return d.client.Put(d.IsStarterPlan(), "/"+d.Id+"/unfollow", nil)
+	return d.req("PUT", "/unfollow", nil, nil)
 }
 
+// WaitStatus reports whether a database is still being provisioned or
+// otherwise changed.
 type WaitStatus struct {
+	Waiting bool   `json:"waiting?"`
+	Message string `json:"message"`
 }
 
+// WaitStatus returns the database's wait status.
 func (d *DB) WaitStatus() (ws WaitStatus, err error) {
-	err = d.client.Get(d.IsStarterPlan(), "/"+d.Id+"/wait_status", &ws)
+	err = d.req("GET", "/wait_status", nil, &ws)
 	return
 }
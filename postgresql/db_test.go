@@ -2,6 +2,8 @@ package postgresql
 
 import (
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 	"time"
@@ -29,7 +31,7 @@ func TestIsStarterPlan(t *testing.T) {
 	for _, st := range starterTests {
 		db := DB{Plan: st.plan}
 		if db.IsStarterPlan() != st.isStarter {
-			t.Errorf("expected isStarter=%b for %s", st.isStarter, st.plan)
+			t.Errorf("expected isStarter=%t for %s", st.isStarter, st.plan)
 		}
 	}
 }
@@ -193,3 +195,37 @@ func TestDBInfo(t *testing.T) {
 		t.Errorf("expected TargetTransaction=%s, got %s", "5", dbi.TargetTransaction)
 	}
 }
+
+func TestDBCredentials(t *testing.T) {
+	var gotPath string
+	var body map[string]bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.Method + " " + r.URL.Path
+		if r.Method == "POST" {
+			json.NewDecoder(r.Body).Decode(&body)
+			return
+		}
+		w.Write([]byte(`[{"name": "default", "state": "active", "credentials": [{"user": "u1", "state": "active", "connections": 3}]}]`))
+	}))
+	defer ts.Close()
+	c := &Client{URL: ts.URL}
+	db := c.NewDB("resource123@heroku.com", "standard-tengu")
+
+	creds, err := db.Credentials()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "GET /resource123@heroku.com/credentials" {
+		t.Errorf("request = %q", gotPath)
+	}
+	if len(creds) != 1 || creds[0].Name != "default" || len(creds[0].Users) != 1 || creds[0].Users[0].Connections != 3 {
+		t.Errorf("unexpected credentials %+v", creds)
+	}
+
+	if err := db.RotateCredential("analyst", true); err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "POST /resource123@heroku.com/credentials/analyst/credentials_rotation" || !body["forced"] {
+		t.Errorf("request = %q with body %v", gotPath, body)
+	}
+}
@@ -0,0 +1,152 @@
+package postgresql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// A CheckStatus is the outcome of a diagnostic check.
+type CheckStatus string
+
+const (
+	Green   CheckStatus = "green"   // nothing to worry about
+	Yellow  CheckStatus = "yellow"  // worth a look
+	Red     CheckStatus = "red"     // needs attention now
+	Skipped CheckStatus = "skipped" // no data to judge by
+)
+
+// A Check is one of the standard diagnostic checks of a database. Query
+// is SQL to run on the database, and Evaluate judges the rows it returns,
+// each row a slice of column values as text.
+type Check struct {
+	Name     string
+	Query    string
+	Columns  []string // names of the columns Query returns, for display
+	Evaluate func(rows [][]string) (CheckStatus, string)
+}
+
+// DiagnoseChecks are the checks run by a diagnosis, in the order they're
+// reported.
+var DiagnoseChecks = []Check{
+	{
+		Name: "Long-running queries",
+		Query: `SELECT pid, date_trunc('second', now() - query_start), left(regexp_replace(query, '\s+', ' ', 'g'), 60)
+			FROM pg_stat_activity
+			WHERE state NOT IN ('idle', 'idle in transaction') AND pid <> pg_backend_pid()
+				AND now() - query_start > interval '5 minutes'
+			ORDER BY query_start`,
+		Columns: []string{"pid", "duration", "query"},
+		Evaluate: func(rows [][]string) (CheckStatus, string) {
+			if len(rows) == 0 {
+				return Green, "no queries running over 5 minutes"
+			}
+			return Red, plural(len(rows), "query", "queries") + " running over 5 minutes"
+		},
+	},
+	{
+		Name: "Idle in transaction",
+		Query: `SELECT pid, date_trunc('second', now() - state_change), left(regexp_replace(query, '\s+', ' ', 'g'), 60)
+			FROM pg_stat_activity
+			WHERE state = 'idle in transaction' AND now() - state_change > interval '1 minute'
+			ORDER BY state_change`,
+		Columns: []string{"pid", "idle for", "last query"},
+		Evaluate: func(rows [][]string) (CheckStatus, string) {
+			if len(rows) == 0 {
+				return Green, "no connections idle in a transaction over 1 minute"
+			}
+			return Yellow, plural(len(rows), "connection", "connections") + " idle in a transaction over 1 minute"
+		},
+	},
+	{
+		Name: "Blocked queries",
+		Query: `SELECT a.pid, date_trunc('second', now() - a.query_start), left(regexp_replace(a.query, '\s+', ' ', 'g'), 60)
+			FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid
+			WHERE NOT l.granted
+			ORDER BY a.query_start`,
+		Columns: []string{"pid", "waiting", "query"},
+		Evaluate: func(rows [][]string) (CheckStatus, string) {
+			if len(rows) == 0 {
+				return Green, "no queries waiting on locks"
+			}
+			return Red, plural(len(rows), "query", "queries") + " waiting on locks"
+		},
+	},
+	{
+		Name: "Bloat",
+		Query: `SELECT relname, n_dead_tup, round(n_dead_tup::numeric / (n_live_tup + n_dead_tup), 2)
+			FROM pg_stat_user_tables
+			WHERE n_dead_tup > 10000 AND n_dead_tup > 0.2 * (n_live_tup + n_dead_tup)
+			ORDER BY n_dead_tup DESC`,
+		Columns: []string{"table", "dead rows", "dead ratio"},
+		Evaluate: func(rows [][]string) (CheckStatus, string) {
+			status := Green
+			for _, r := range rows {
+				if ratio, err := strconv.ParseFloat(r[2], 64); err == nil && ratio > 0.5 {
+					status = Red
+				} else if status == Green {
+					status = Yellow
+				}
+			}
+			if status == Green {
+				return Green, "no tables with over 20% dead rows"
+			}
+			return status, plural(len(rows), "table", "tables") + " with over 20% dead rows"
+		},
+	},
+	{
+		Name: "Cache hit rate",
+		Query: `SELECT round(sum(heap_blks_hit) / nullif(sum(heap_blks_hit) + sum(heap_blks_read), 0), 4),
+				(SELECT round(sum(idx_blks_hit) / nullif(sum(idx_blks_hit) + sum(idx_blks_read), 0), 4) FROM pg_statio_user_indexes)
+			FROM pg_statio_user_tables`,
+		Columns: []string{"table hit rate", "index hit rate"},
+		Evaluate: func(rows [][]string) (CheckStatus, string) {
+			if len(rows) != 1 {
+				return Skipped, "no table reads yet"
+			}
+			status := Skipped
+			for _, v := range rows[0] {
+				rate, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					continue
+				}
+				switch {
+				case rate < 0.95:
+					status = Red
+				case rate < 0.99 && status != Red:
+					status = Yellow
+				case status == Skipped:
+					status = Green
+				}
+			}
+			switch status {
+			case Skipped:
+				return Skipped, "no table reads yet"
+			case Green:
+				return Green, "99% or more of reads hit the cache"
+			}
+			return status, "under 99% of reads hit the cache"
+		},
+	},
+	{
+		Name: "Index usage",
+		Query: `SELECT relname, round(100.0 * coalesce(idx_scan, 0) / (seq_scan + coalesce(idx_scan, 0))), n_live_tup
+			FROM pg_stat_user_tables
+			WHERE seq_scan + coalesce(idx_scan, 0) > 0 AND n_live_tup > 10000
+				AND coalesce(idx_scan, 0) < 0.95 * (seq_scan + coalesce(idx_scan, 0))
+			ORDER BY n_live_tup DESC`,
+		Columns: []string{"table", "% index scans", "rows"},
+		Evaluate: func(rows [][]string) (CheckStatus, string) {
+			if len(rows) == 0 {
+				return Green, "large tables are read by index 95% of the time or more"
+			}
+			return Yellow, plural(len(rows), "table", "tables") + " over 10000 rows mostly read by sequential scans"
+		},
+	},
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, one)
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
@@ -0,0 +1,39 @@
+package postgresql
+
+import "testing"
+
+func findCheck(t *testing.T, name string) Check {
+	for _, c := range DiagnoseChecks {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("no check named %s", name)
+	return Check{}
+}
+
+func TestDiagnoseChecks(t *testing.T) {
+	tests := []struct {
+		check string
+		rows  [][]string
+		want  CheckStatus
+	}{
+		{"Long-running queries", nil, Green},
+		{"Long-running queries", [][]string{{"31337", "00:06:00", "SELECT 1"}}, Red},
+		{"Idle in transaction", [][]string{{"31337", "00:04:12", "UPDATE accounts"}}, Yellow},
+		{"Blocked queries", nil, Green},
+		{"Bloat", [][]string{{"events", "20000", "0.30"}}, Yellow},
+		{"Bloat", [][]string{{"events", "20000", "0.30"}, {"logs", "90000", "0.75"}}, Red},
+		{"Cache hit rate", [][]string{{"0.9990", "0.9995"}}, Green},
+		{"Cache hit rate", [][]string{{"0.9800", "0.9995"}}, Yellow},
+		{"Cache hit rate", [][]string{{"0.9990", "0.9000"}}, Red},
+		{"Cache hit rate", [][]string{{"", ""}}, Skipped},
+		{"Index usage", [][]string{{"events", "12", "50000"}}, Yellow},
+	}
+	for _, tt := range tests {
+		got, _ := findCheck(t, tt.check).Evaluate(tt.rows)
+		if got != tt.want {
+			t.Errorf("%s with %q = %s, want %s", tt.check, tt.rows, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+// Package postgresql is a client for the Heroku Postgres API.
+//
+// A Client holds credentials and HTTP settings, and is safe to share. A DB
+// identifies one database by its add-on provider ID and plan, which decides
+// whether requests go to the production or the starter API:
+//
+//	c := &postgresql.Client{Username: user, Password: apiKey}
+//	db := c.NewDB(addon.ProviderId, addon.Plan.Name)
+//	info, err := db.Info()
+//
+// Requests made through a DB returned by WithContext are canceled when the
+// context is done:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	info, err := db.WithContext(ctx).Info()
+package postgresql
@@ -0,0 +1,83 @@
+package hk
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var (
+	flagSetPrebootAware bool
+	flagSetTimeout      time.Duration
+)
+
+func init() {
+	cmdSet.Flag.BoolVar(&flagSetPrebootAware, "preboot-aware", false, "wait for web dynos to serve the new release")
+	cmdSet.Flag.DurationVar(&flagSetTimeout, "timeout", 10*time.Minute, "time to wait for web dynos to serve the new release")
+}
+
+// how often to check on web dynos during a preboot cycle
+const prebootPollInterval = 5 * time.Second
+
+// prebootEnabled reports whether the preboot feature is enabled on an app.
+func prebootEnabled(appname string) (bool, error) {
+	f, err := client.AppFeatureInfo(appname, "preboot")
+	if err != nil {
+		return false, err
+	}
+	return f.Enabled, nil
+}
+
+// waitWebDynosLive waits up to timeout until every web dyno of an app runs
+// the app's latest release and is up, and returns that release's version.
+// With preboot, the old dynos keep serving until the new ones are up, and
+// are then shut down, so once no dyno from an older release is left the
+// new release is what's serving.
+func waitWebDynosLive(appname string, timeout time.Duration) (int, error) {
+	rel, err := latestRelease(appname)
+	if err != nil {
+		return 0, err
+	}
+	s := newSpinner(fmt.Sprintf("Waiting for web dynos to serve v%d", rel.Version))
+	defer s.stop()
+	deadline := time.Now().Add(timeout)
+	for {
+		dynos, err := listDynos(appname, dynoFilter{})
+		if err != nil {
+			return 0, err
+		}
+		live, err := webDynosLive(dynos, rel.Version)
+		if err != nil || live {
+			return rel.Version, err
+		}
+		if time.Now().After(deadline) {
+			return 0, fmt.Errorf("web dynos weren't all serving v%d after %s", rel.Version, timeout)
+		}
+		s.sleep(prebootPollInterval)
+	}
+}
+
+// webDynosLive reports whether dynos include web dynos, all of which are
+// up and run release version or later. It returns an error if a web dyno
+// on that release crashed.
+func webDynosLive(dynos []heroku.Dyno, version int) (bool, error) {
+	live := false
+	for _, d := range dynos {
+		if d.Type != "web" {
+			continue
+		}
+		if d.Release.Version < version {
+			return false, nil
+		}
+		switch d.State {
+		case "crashed":
+			return false, fmt.Errorf("%s crashed on v%d", d.Name, d.Release.Version)
+		case "up":
+			live = true
+		default:
+			return false, nil
+		}
+	}
+	return live, nil
+}
@@ -0,0 +1,33 @@
+package hk
+
+import (
+	"testing"
+
+	"github.com/bgentry/heroku-go"
+)
+
+func TestWebDynosLive(t *testing.T) {
+	dyno := func(name, typ, state string, version int) heroku.Dyno {
+		d := heroku.Dyno{Name: name, Type: typ, State: state}
+		d.Release.Version = version
+		return d
+	}
+	tests := []struct {
+		dynos   []heroku.Dyno
+		live    bool
+		wantErr bool
+	}{
+		{nil, false, false},
+		{[]heroku.Dyno{dyno("web.1", "web", "up", 124), dyno("web.2", "web", "up", 125)}, true, false},
+		{[]heroku.Dyno{dyno("web.1", "web", "up", 123), dyno("web.1", "web", "starting", 124)}, false, false},
+		{[]heroku.Dyno{dyno("web.1", "web", "up", 124), dyno("worker.1", "worker", "starting", 123)}, true, false},
+		{[]heroku.Dyno{dyno("web.1", "web", "crashed", 124)}, false, true},
+		{[]heroku.Dyno{dyno("worker.1", "worker", "up", 124)}, false, false},
+	}
+	for i, test := range tests {
+		live, err := webDynosLive(test.dynos, 124)
+		if live != test.live || (err != nil) != test.wantErr {
+			t.Errorf("%d. webDynosLive => %v, %v, want %v (error %v)", i, live, err, test.live, test.wantErr)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/heroku/hk/term"
+)
+
+// A spinner shows that hk is waiting on something, by redrawing a
+// spinning line on stderr. When stderr isn't a terminal, or output is
+// accessible, it prints its label once instead.
+type spinner struct {
+	label string
+	frame int
+	live  bool
+}
+
+func newSpinner(label string) *spinner {
+	s := &spinner{label: label, live: term.IsTerminal(os.Stderr) && !accessibleOutput}
+	if !s.live {
+		fmt.Fprintln(os.Stderr, label+"...")
+	}
+	return s
+}
+
+// sleep pauses for d, turning the spinner as it goes.
+func (s *spinner) sleep(d time.Duration) {
+	if !s.live {
+		time.Sleep(d)
+		return
+	}
+	const step = 100 * time.Millisecond
+	for end := time.Now().Add(d); time.Now().Before(end); {
+		fmt.Fprintf(os.Stderr, "\r%s... %c", s.label, `|/-\`[s.frame%4])
+		s.frame++
+		time.Sleep(step)
+	}
+}
+
+// stop erases the spinner's line.
+func (s *spinner) stop() {
+	if s.live {
+		fmt.Fprintf(os.Stderr, "\r%*s\r", len(s.label)+5, "")
+	}
+}
+
+// A progressWriter writes to w, showing how much has been written on
+// stderr.
+type progressWriter struct {
+	w     io.Writer
+	label string
+	total int64 // or -1 if unknown
+	n     int64
+	last  time.Time
+
+	quarter int64 // quarters reported, for accessible output
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.n += int64(n)
+	if accessibleOutput {
+		p.writeLines()
+		return n, err
+	}
+	if time.Since(p.last) > 100*time.Millisecond || p.n == p.total {
+		p.last = time.Now()
+		if p.total > 0 {
+			fmt.Fprintf(os.Stderr, "\r%s %3d%% (%s of %s)", p.label, p.n*100/p.total, prettySize(p.n), prettySize(p.total))
+		} else {
+			fmt.Fprintf(os.Stderr, "\r%s %s", p.label, prettySize(p.n))
+		}
+	}
+	return n, err
+}
+
+// writeLines reports progress for accessible output: a line at each
+// quarter of the total, or each 10 seconds if the total is unknown.
+func (p *progressWriter) writeLines() {
+	if p.total > 0 {
+		if q := p.n * 4 / p.total; q > p.quarter {
+			p.quarter = q
+			fmt.Fprintf(os.Stderr, "%s %d%% (%s of %s)\n", p.label, q*25, prettySize(p.n), prettySize(p.total))
+		}
+	} else if time.Since(p.last) > 10*time.Second {
+		p.last = time.Now()
+		fmt.Fprintf(os.Stderr, "%s %s\n", p.label, prettySize(p.n))
+	}
+}
@@ -0,0 +1,291 @@
+package hk
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+)
+
+// proxyURL is the proxy that hk's connections go through, or nil to
+// leave HTTP requests to the HTTPS_PROXY environment variable and to
+// connect to databases directly. It's set by initNetwork.
+var proxyURL *url.URL
+
+// networkProxy returns the proxy set by the current account profile, or
+// else by the proxy setting in the config file, or nil if neither sets
+// one. The proxy may be a socks5:// URL, such as the one that 'ssh -D'
+// listens on, or an http:// URL for a proxy that supports CONNECT.
+func networkProxy() (*url.URL, error) {
+	var s string
+	if name := currentAccount(); name != "" {
+		if accounts, err := loadAccounts(); err == nil {
+			s = accounts[name].Proxy
+		}
+	}
+	if s == "" {
+		s = configValue("proxy")
+	}
+	if s == "" {
+		return nil, nil
+	}
+	return parseProxyURL(s)
+}
+
+func parseProxyURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "socks5", "socks5h", "http":
+	default:
+		return nil, fmt.Errorf("unsupported proxy %s: use socks5:// or http://", s)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("proxy %s has no host", s)
+	}
+	return u, nil
+}
+
+// initNetwork sends hk's HTTP requests, including the updater's, through
+// the proxy from networkProxy, if one is set, or else leaves them to
+// HTTPS_PROXY. It must run before any HTTP client copies
+// http.DefaultTransport.
+func initNetwork() {
+	u, err := networkProxy()
+	if err != nil {
+		printFatal("proxy: %s", err)
+	}
+	proxyURL = u
+	if u == nil {
+		http.DefaultTransport.(*http.Transport).Proxy = http.ProxyFromEnvironment
+		return
+	}
+	http.DefaultTransport.(*http.Transport).Proxy = http.ProxyURL(u)
+}
+
+// dialProxy connects to addr through the proxy at u.
+func dialProxy(u *url.URL, addr string) (net.Conn, error) {
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "http" {
+		err = httpConnect(conn, u, addr)
+	} else {
+		err = socks5Connect(conn, u, addr)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %s", u.Host, err)
+	}
+	return conn, nil
+}
+
+// httpConnect asks the HTTP proxy on conn to connect it to addr.
+func httpConnect(conn net.Conn, u *url.URL, addr string) error {
+	req := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + pass))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+	// The server on the other end waits for the client to speak first,
+	// so nothing past the response header is buffered and lost here.
+	res, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		return fmt.Errorf("CONNECT %s: %s", addr, res.Status)
+	}
+	return nil
+}
+
+// socks5Errors are the messages for the failure codes of a SOCKS5 reply,
+// from RFC 1928.
+var socks5Errors = []string{
+	1: "general failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+// socks5Connect asks the SOCKS5 proxy on conn to connect it to addr. The
+// host name is always sent for the proxy to resolve, as 'ssh -D' and
+// bastion hosts that can see private DNS expect.
+func socks5Connect(conn net.Conn, u *url.URL, addr string) error {
+	host, portstr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portstr)
+	if err != nil || len(host) > 255 {
+		return fmt.Errorf("invalid address %s", addr)
+	}
+
+	method := byte(0) // no authentication
+	if u.User != nil {
+		method = 2 // username and password
+	}
+	if _, err := conn.Write([]byte{5, 1, method}); err != nil {
+		return err
+	}
+	var b [4]byte
+	if _, err := io.ReadFull(conn, b[:2]); err != nil {
+		return err
+	}
+	if b[0] != 5 || b[1] != method {
+		return errors.New("proxy refused authentication method")
+	}
+	if method == 2 {
+		user := u.User.Username()
+		pass, _ := u.User.Password()
+		if len(user) > 255 || len(pass) > 255 {
+			return errors.New("proxy username or password too long")
+		}
+		msg := append([]byte{1, byte(len(user))}, user...)
+		msg = append(append(msg, byte(len(pass))), pass...)
+		if _, err := conn.Write(msg); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(conn, b[:2]); err != nil {
+			return err
+		}
+		if b[1] != 0 {
+			return errors.New("proxy authentication failed")
+		}
+	}
+
+	msg := append([]byte{5, 1, 0, 3, byte(len(host))}, host...)
+	msg = append(msg, byte(port>>8), byte(port))
+	if _, err := conn.Write(msg); err != nil {
+		return err
+	}
+	if _, err := io.ReadFull(conn, b[:4]); err != nil {
+		return err
+	}
+	if b[1] != 0 {
+		if int(b[1]) < len(socks5Errors) {
+			return fmt.Errorf("connecting to %s: %s", addr, socks5Errors[b[1]])
+		}
+		return fmt.Errorf("connecting to %s: error %d", addr, b[1])
+	}
+	// skip the bound address and port
+	var n int
+	switch b[3] {
+	case 1:
+		n = net.IPv4len
+	case 4:
+		n = net.IPv6len
+	case 3:
+		if _, err := io.ReadFull(conn, b[:1]); err != nil {
+			return err
+		}
+		n = int(b[0])
+	default:
+		return fmt.Errorf("unknown address type %d in proxy reply", b[3])
+	}
+	_, err = io.CopyN(ioutil.Discard, conn, int64(n)+2)
+	return err
+}
+
+// tunnelPgConn returns c rerouted through the proxy, if one is set, for
+// commands such as psql that can't use a proxy themselves.
+func tunnelPgConn(c pgConn) pgConn {
+	c.Host, c.Port = tunnelAddr(c.Host, c.Port)
+	return c
+}
+
+// tunnelAddr returns the local address that commands should connect to
+// in order to reach host and port through the proxy, or host and port
+// unchanged if there's no proxy. hk forwards each connection to the local
+// address through the proxy for as long as hk runs.
+func tunnelAddr(host, port string) (string, string) {
+	if proxyURL == nil {
+		return host, port
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	must(err)
+	addr := net.JoinHostPort(host, port)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go forwardConn(conn, addr)
+		}
+	}()
+	return "127.0.0.1", strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+}
+
+// runInteractive runs command, such as psql, with args and env, attached
+// to the terminal. Like runCommand, it replaces hk with the command,
+// except when hk must keep running to forward the command's connections
+// through the proxy. Then it waits for the command and exits with its
+// status.
+func runInteractive(command string, args, env []string) error {
+	if proxyURL == nil {
+		return runCommand(command, append([]string{command}, args...), env)
+	}
+	// Interrupts are for the command, not for hk.
+	signal.Ignore(os.Interrupt)
+	c := exec.Command(command, args...)
+	c.Env = env
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := c.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exit(exitErr.ExitCode())
+	}
+	if err != nil {
+		return err
+	}
+	exit(0)
+	return nil
+}
+
+// forwardConn copies data both ways between conn and addr, reached
+// through the proxy, until either side closes.
+func forwardConn(conn net.Conn, addr string) {
+	defer conn.Close()
+	remote, err := dialProxy(proxyURL, addr)
+	if err != nil {
+		printError(err.Error())
+		return
+	}
+	defer remote.Close()
+	done := make(chan bool, 2)
+	go func() {
+		io.Copy(remote, conn)
+		done <- true
+	}()
+	go func() {
+		io.Copy(conn, remote)
+		done <- true
+	}()
+	<-done
+}
@@ -0,0 +1,167 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/hkclient"
+)
+
+var cmdPush = &Command{
+	Run:      runPush,
+	Usage:    "push [--gate <path or url>] [--gate-timeout <duration>] [<ref>]",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "git push and wait for the deploy" + extra,
+	Long: `
+Push runs git push to deploy a git ref (default HEAD) to the app,
+then waits for the build it starts and the resulting release,
+showing any release phase output. With --gate, push then polls the
+app's health check as 'hk gate' does. Push exits nonzero if the
+push, build, release, or health check fails.
+
+The ref is pushed to the app's master branch, using the git remote
+for the app if there is one, or else the app's git URL.
+
+Options:
+
+    --gate <path or url>        health check to pass after the release,
+                                a path relative to the app's web URL
+                                or a full URL
+    --gate-timeout <duration>   give up on the health check after this
+                                long (default 2m)
+
+Examples:
+
+    $ hk push
+    ...
+    remote: Verifying deploy... done.
+    To git@heroku.com:myapp.git
+       3ae20c2..0fda0ae  HEAD -> master
+    Deployed myapp v12.
+
+    $ hk push --gate /healthz release-1.4
+    ...
+    Deployed myapp v13.
+    Health check passed: https://myapp.herokuapp.com/healthz returned 200.
+`,
+}
+
+var (
+	flagPushGate        string
+	flagPushGateTimeout time.Duration
+)
+
+func init() {
+	cmdPush.Flag.StringVar(&flagPushGate, "gate", "", "health check path or URL")
+	cmdPush.Flag.DurationVar(&flagPushGateTimeout, "gate-timeout", 2*time.Minute, "time to wait for the health check to pass")
+}
+
+// how much earlier than the push a build may appear to start, to allow
+// for clock skew
+const pushBuildSkew = time.Minute
+
+func runPush(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	ref := "HEAD"
+	if len(args) == 1 {
+		ref = args[0]
+	}
+	mustNotBeDeployLocked(appname)
+
+	commit := resolveGitCommit(ref)
+	start := time.Now()
+	git := exec.Command("git", "push", pushRemote(appname), ref+":refs/heads/master")
+	git.Stdout, git.Stderr = os.Stderr, os.Stderr
+	pushErr := git.Run()
+
+	latest, err := latestBuild(appname)
+	must(err)
+	b, err := pushBuild(latest, start, commit, pushErr)
+	if err != nil {
+		printFatal(err.Error())
+	}
+	if b == nil {
+		log.Printf("No new build for %s.", appname)
+		return
+	}
+	for b.Status == "pending" {
+		time.Sleep(2 * time.Second)
+		b, err = ext().BuildInfo(appname, b.Id)
+		must(err)
+	}
+	if b.Status != "succeeded" {
+		printFatal("Build %s.", b.Status)
+	}
+	if b.Release == nil {
+		log.Printf("Deployed %s.", appname)
+	} else {
+		waitRelease(ctx.Stdout, appname, b.Release.Id)
+		rel, err := ctx.Client.ReleaseInfo(appname, b.Release.Id)
+		must(err)
+		log.Printf("Deployed %s v%d.", appname, rel.Version)
+	}
+
+	if flagPushGate != "" {
+		u, err := gateURL(appname, flagPushGate)
+		must(err)
+		if err := healthGate(u, 200, flagPushGateTimeout, 5*time.Second); err != nil {
+			printFatal(err.Error())
+		}
+		log.Printf("Health check passed: %s returned 200.", u)
+	}
+}
+
+// pushBuild returns the build that a push of commit, begun at start,
+// started, given b, the app's latest build. It returns nil if the push
+// started no build, as when the app already runs commit. If git push
+// failed with pushErr, a new build counts only if it's of commit, since
+// git can fail after the app accepted the push; otherwise pushBuild
+// returns the push's error rather than mistake someone else's build for
+// this one.
+func pushBuild(b *hkclient.Build, start time.Time, commit string, pushErr error) (*hkclient.Build, error) {
+	fresh := b != nil && !b.CreatedAt.Before(start.Add(-pushBuildSkew))
+	if pushErr != nil && !(fresh && b.SourceBlob.Version == commit) {
+		return nil, fmt.Errorf("git push failed: %s", pushErr)
+	}
+	if !fresh {
+		return nil, nil
+	}
+	return b, nil
+}
+
+// pushRemote returns the git remote for appname, or its git URL if no
+// remote points at it.
+func pushRemote(appname string) string {
+	if flagRemote != "" {
+		return flagRemote
+	}
+	remotes, _ := gitRemotes()
+	for remote, app := range remotes {
+		if app == appname {
+			return remote
+		}
+	}
+	return gitURLPre() + appname + gitURLSuf
+}
+
+// latestBuild returns the app's most recently created build, or nil if it
+// has none.
+func latestBuild(appname string) (*hkclient.Build, error) {
+	builds, err := ext().BuildList(appname, &heroku.ListRange{Field: "created_at", Max: 1, Descending: true})
+	if err != nil {
+		return nil, err
+	}
+	if len(builds) == 0 {
+		return nil, nil
+	}
+	return &builds[0], nil
+}
@@ -0,0 +1,47 @@
+package hk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/heroku/hk/hkclient"
+)
+
+func TestPushBuild(t *testing.T) {
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	build := func(age time.Duration, version string) *hkclient.Build {
+		b := &hkclient.Build{Id: version, CreatedAt: start.Add(-age)}
+		b.SourceBlob.Version = version
+		return b
+	}
+	pushErr := errors.New("exit status 1")
+	tests := []struct {
+		b       *hkclient.Build
+		pushErr error
+		want    string // id of the build returned, or "" for none
+		wantErr bool
+	}{
+		{build(-time.Minute, "abc"), nil, "abc", false},
+		{build(30*time.Second, "other"), nil, "other", false}, // within clock skew
+		{build(time.Hour, "abc"), nil, "", false},
+		{nil, nil, "", false},
+		{build(-time.Minute, "abc"), pushErr, "abc", false},
+		{build(-time.Minute, "other"), pushErr, "", true},
+		{build(time.Hour, "abc"), pushErr, "", true},
+		{nil, pushErr, "", true},
+	}
+	for i, test := range tests {
+		b, err := pushBuild(test.b, start, "abc", test.pushErr)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%d. error = %v, want error %v", i, err, test.wantErr)
+		}
+		got := ""
+		if b != nil {
+			got = b.Id
+		}
+		if got != test.want {
+			t.Errorf("%d. build = %q, want %q", i, got, test.want)
+		}
+	}
+}
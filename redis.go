@@ -0,0 +1,393 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bgentry/heroku-go"
+)
+
+// redisService is the add-on service of Heroku Redis databases.
+const redisService = "heroku-redis"
+
+var cmdRedisInfo = &Command{
+	Run:      runRedisInfo,
+	Usage:    "redis-info [<name>]",
+	NeedsApp: true,
+	Category: "redis",
+	Short:    "show Heroku Redis database info" + extra,
+	Long: `
+Redis-info shows general information about an app's Heroku Redis
+databases, or just the one given, by add-on name or by the name of
+an env var holding its URL.
+
+Examples:
+
+    $ hk redis-info
+    Name:                redis-cubed-4321
+    Env Vars:            REDIS_URL
+    Plan:                premium-0
+    Version:             6.2.3
+    Status:              available
+    Created:             2014-01-13 21:20 UTC
+    Maintenance:         not required
+    Maintenance window:  Sundays 14:30 to 18:30 UTC
+
+    $ hk redis-info REDIS_URL
+    ...
+`,
+}
+
+func runRedisInfo(ctx *Context, args []string) {
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	addons, err := ctx.Client.AddonList(appname, nil)
+	must(err)
+	dbs := redisAddons(addons)
+	if len(args) == 1 {
+		a, err := pickRedisAddon(addons, args[0])
+		if err != nil {
+			printFatal(err.Error())
+		}
+		dbs = []heroku.Addon{*a}
+	}
+	if len(dbs) == 0 {
+		printFatal("%s has no %s databases", appname, redisService)
+	}
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for i, a := range dbs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		db := ctx.Redis.NewDB(a.Name)
+		info, err := db.Info()
+		must(err)
+		listRec(w, "Name:", a.Name)
+		listRec(w, "Env Vars:", strings.Join(a.ConfigVars, ", "))
+		for _, ie := range info.Info {
+			if len(ie.Values) == 0 {
+				listRec(w, ie.Name+":", "none")
+			}
+			for n, val := range ie.Values {
+				label := ie.Name + ":"
+				if n != 0 {
+					label = ""
+				}
+				listRec(w, label, val)
+			}
+		}
+	}
+}
+
+var cmdRedisCli = &Command{
+	Run:      runRedisCli,
+	Usage:    "redis-cli [<name>]",
+	NeedsApp: true,
+	Category: "redis",
+	Short:    "open a redis-cli shell to a Heroku Redis database" + extra,
+	Long: `
+Redis-cli opens a shell to a Heroku Redis database using the
+locally-installed redis-cli command. The database is given as for
+'hk redis-info', and may be left out if the app has only one.
+
+Example:
+
+    $ hk redis-cli
+    ec2-34-200-1-1.compute-1.amazonaws.com:11339> PING
+    PONG
+`,
+}
+
+func runRedisCli(ctx *Context, args []string) {
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	if _, err := exec.LookPath("redis-cli"); err != nil {
+		printFatal("Local redis-cli command not found. For help installing it, see https://redis.io/docs/getting-started/")
+	}
+	appname := ctx.MustApp()
+	a := mustFindRedisAddon(ctx, appname, optionalArg(args, 0))
+	if len(a.ConfigVars) == 0 {
+		printFatal("%s has no env vars", a.Name)
+	}
+	config, err := ctx.Client.ConfigVarInfo(appname)
+	must(err)
+	cliArgs, env, err := redisCliConn(config[a.ConfigVars[0]])
+	if err != nil {
+		printFatal("Invalid URL at env %s: %s", a.ConfigVars[0], err)
+	}
+	if err := runInteractive("redis-cli", cliArgs, env); err != nil {
+		printFatal("Error running redis-cli: %s", err)
+	}
+}
+
+// redisCliConn returns the arguments and environment that connect
+// redis-cli to the database at a redis:// or rediss:// URL.
+func redisCliConn(s string) (args, env []string, err error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return nil, nil, fmt.Errorf("not a redis URL")
+	}
+	port := u.Port()
+	if port == "" {
+		port = "6379"
+	}
+	host, port := tunnelAddr(u.Hostname(), port)
+	args = []string{"-h", host, "-p", port}
+	if u.Scheme == "rediss" {
+		// Heroku Redis uses self-signed certificates.
+		args = append(args, "--tls", "--insecure")
+	}
+	env = os.Environ()
+	if u.User != nil {
+		// Older Heroku Redis URLs have a placeholder user, h.
+		if user := u.User.Username(); user != "" && user != "h" {
+			args = append(args, "--user", user)
+		}
+		if pass, ok := u.User.Password(); ok {
+			env = append(env, "REDISCLI_AUTH="+pass)
+		}
+	}
+	return args, env, nil
+}
+
+var cmdRedisMaintenance = &Command{
+	Run:      runRedisMaintenance,
+	Usage:    "redis-maintenance [--window <day> <time> | --run [-f]] [<name>]",
+	NeedsApp: true,
+	Category: "redis",
+	Short:    "show or schedule Heroku Redis maintenance" + extra,
+	Long: `
+Redis-maintenance shows whether a Heroku Redis database needs
+maintenance, and when it's scheduled. The database is given as for
+'hk redis-info', and may be left out if the app has only one.
+
+With --window, it sets the weekly window that maintenance is done in,
+given as a day and a UTC start time. With --run, it starts any needed
+maintenance now; the app must be in maintenance mode first (see 'hk
+maintenance-enable'), unless -f is given.
+
+Options:
+
+    --window <day> <time>  set the maintenance window, e.g. "Sunday 14:30"
+    --run                  start maintenance now
+    -f                     run maintenance without maintenance mode
+
+Examples:
+
+    $ hk redis-maintenance
+    Maintenance required. Scheduled for 2014-01-19 14:30 UTC.
+
+    $ hk redis-maintenance --window "Sunday 14:30"
+    Maintenance window for redis-cubed-4321 is now Sundays 14:30 to 18:30 UTC.
+`,
+}
+
+var (
+	flagRedisWindow string
+	flagRedisRun    bool
+	flagRedisForce  bool
+)
+
+func init() {
+	cmdRedisMaintenance.Flag.StringVar(&flagRedisWindow, "window", "", "maintenance window")
+	cmdRedisMaintenance.Flag.BoolVar(&flagRedisRun, "run", false, "start maintenance now")
+	cmdRedisMaintenance.Flag.BoolVar(&flagRedisForce, "f", false, "run without maintenance mode")
+}
+
+func runRedisMaintenance(ctx *Context, args []string) {
+	if len(args) > 1 || (flagRedisWindow != "" && flagRedisRun) || (flagRedisForce && !flagRedisRun) {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	a := mustFindRedisAddon(ctx, appname, optionalArg(args, 0))
+	db := ctx.Redis.NewDB(a.Name)
+	switch {
+	case flagRedisWindow != "":
+		window, err := db.SetMaintenanceWindow(flagRedisWindow)
+		must(err)
+		log.Printf("Maintenance window for %s is now %s.", a.Name, window)
+	case flagRedisRun:
+		if !flagRedisForce {
+			app, err := ctx.Client.AppInfo(appname)
+			must(err)
+			if !app.Maintenance {
+				printFatal("%s is not in maintenance mode. Run 'hk maintenance-enable' first, or use -f.", appname)
+			}
+		}
+		msg, err := db.RunMaintenance()
+		must(err)
+		log.Println(msg)
+	default:
+		msg, err := db.Maintenance()
+		must(err)
+		fmt.Fprintln(ctx.Stdout, msg)
+	}
+}
+
+var cmdRedisTimeout = &Command{
+	Run:      runRedisTimeout,
+	Usage:    "redis-timeout <seconds> [<name>]",
+	NeedsApp: true,
+	Category: "redis",
+	Short:    "set the idle timeout of a Heroku Redis database" + extra,
+	Long: `
+Redis-timeout sets how long a Heroku Redis database keeps idle
+connections open, in seconds. 0 keeps them open forever. The
+database is given as for 'hk redis-info', and may be left out if the
+app has only one.
+
+Example:
+
+    $ hk redis-timeout 60
+    Timeout for redis-cubed-4321 set to 60 seconds.
+    Connections idle for more than 60 seconds will be closed.
+`,
+}
+
+func runRedisTimeout(ctx *Context, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		ctx.printUsage()
+		exit(2)
+	}
+	seconds, err := strconv.Atoi(args[0])
+	if err != nil || seconds < 0 {
+		printFatal("invalid timeout %q: give a number of seconds", args[0])
+	}
+	a := mustFindRedisAddon(ctx, ctx.MustApp(), optionalArg(args, 1))
+	db := ctx.Redis.NewDB(a.Name)
+	settings, err := db.UpdateConfig(map[string]interface{}{"timeout": seconds})
+	must(err)
+	log.Printf("Timeout for %s set to %d seconds.", a.Name, seconds)
+	if d := settings["timeout"].Desc; d != "" {
+		log.Println(d)
+	}
+}
+
+var cmdRedisMaxmemoryPolicy = &Command{
+	Run:      runRedisMaxmemoryPolicy,
+	Usage:    "redis-maxmemory-policy <policy> [<name>]",
+	NeedsApp: true,
+	Category: "redis",
+	Short:    "set the eviction policy of a Heroku Redis database" + extra,
+	Long: `
+Redis-maxmemory-policy sets which keys a Heroku Redis database
+evicts when it's out of memory. The database is given as for 'hk
+redis-info', and may be left out if the app has only one.
+
+Policies:
+
+    noeviction       evict nothing, and return errors on writes
+    allkeys-lru      evict the least recently used keys
+    volatile-lru     evict the least recently used keys with an expiry
+    allkeys-lfu      evict the least frequently used keys
+    volatile-lfu     evict the least frequently used keys with an expiry
+    allkeys-random   evict random keys
+    volatile-random  evict random keys with an expiry
+    volatile-ttl     evict the keys with an expiry that expire soonest
+
+Example:
+
+    $ hk redis-maxmemory-policy allkeys-lru
+    Maxmemory policy for redis-cubed-4321 set to allkeys-lru.
+`,
+}
+
+// redisMaxmemoryPolicies are the eviction policies Redis supports.
+var redisMaxmemoryPolicies = []string{
+	"noeviction",
+	"allkeys-lru",
+	"volatile-lru",
+	"allkeys-lfu",
+	"volatile-lfu",
+	"allkeys-random",
+	"volatile-random",
+	"volatile-ttl",
+}
+
+func runRedisMaxmemoryPolicy(ctx *Context, args []string) {
+	if len(args) < 1 || len(args) > 2 {
+		ctx.printUsage()
+		exit(2)
+	}
+	policy := args[0]
+	if stringsIndex(redisMaxmemoryPolicies, policy) < 0 {
+		printFatal("unknown policy %s: use one of %s", policy, strings.Join(redisMaxmemoryPolicies, ", "))
+	}
+	a := mustFindRedisAddon(ctx, ctx.MustApp(), optionalArg(args, 1))
+	db := ctx.Redis.NewDB(a.Name)
+	_, err := db.UpdateConfig(map[string]interface{}{"maxmemory_policy": policy})
+	must(err)
+	log.Printf("Maxmemory policy for %s set to %s.", a.Name, policy)
+}
+
+// redisAddons returns the Heroku Redis add-ons among addons.
+func redisAddons(addons []heroku.Addon) []heroku.Addon {
+	var dbs []heroku.Addon
+	for _, a := range addons {
+		if service, _ := splitProviderAndPlan(a.Plan.Name); service == redisService {
+			dbs = append(dbs, a)
+		}
+	}
+	return dbs
+}
+
+// pickRedisAddon returns the Heroku Redis add-on among addons with the
+// given add-on name or env var, such as REDIS_URL or just REDIS. If name
+// is empty, there must be only one.
+func pickRedisAddon(addons []heroku.Addon, name string) (*heroku.Addon, error) {
+	dbs := redisAddons(addons)
+	if name == "" {
+		switch len(dbs) {
+		case 0:
+			return nil, fmt.Errorf("no %s databases", redisService)
+		case 1:
+			return &dbs[0], nil
+		}
+		var names []string
+		for _, a := range dbs {
+			names = append(names, a.Name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("more than one %s database: give one of %s", redisService, strings.Join(names, ", "))
+	}
+	for i, a := range dbs {
+		if a.Name == name {
+			return &dbs[i], nil
+		}
+		for _, v := range a.ConfigVars {
+			if v == name || v == strings.ToUpper(name)+"_URL" {
+				return &dbs[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no %s database %s", redisService, name)
+}
+
+// mustFindRedisAddon is pickRedisAddon for an app's add-ons, but exits
+// on error.
+func mustFindRedisAddon(ctx *Context, appname, name string) *heroku.Addon {
+	addons, err := ctx.Client.AddonList(appname, nil)
+	must(err)
+	a, err := pickRedisAddon(addons, name)
+	if err != nil {
+		printFatal("%s: %s", appname, err)
+	}
+	return a
+}
@@ -0,0 +1,33 @@
+package redis
+
+import (
+	"runtime"
+
+	"github.com/heroku/hk/addonapi"
+)
+
+const (
+	Version          = "0.0.1"
+	DefaultAPIURL    = "https://redis-api.heroku.com/redis/v0/databases"
+	DefaultUserAgent = "heroku-redis-go/" + Version + " (" + runtime.GOOS + "; " + runtime.GOARCH + ")"
+)
+
+var defaults = addonapi.Defaults{URL: DefaultAPIURL, UserAgent: DefaultUserAgent}
+
+// A Client is a Heroku Redis API client. Its zero value is a usable client
+// that uses default settings for the Heroku Redis API. Its fields, such as
+// its credentials and HTTP client, are those of addonapi.Client.
+type Client struct {
+	addonapi.Client
+}
+
+// NewDB returns a DB for the Heroku Redis add-on with the given name.
+func (c *Client) NewDB(name string) DB {
+	return DB{Name: name, client: c}
+}
+
+// do sends a request to path, below the API URL, and decodes the response
+// into v unless v is nil. If body is not nil, it's sent as JSON.
+func (c *Client) do(method, path string, body, v interface{}) error {
+	return c.Do(defaults, method, path, body, v)
+}
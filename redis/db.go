@@ -0,0 +1,79 @@
+package redis
+
+import "net/url"
+
+// A DB is a Heroku Redis database. Create one with Client.NewDB.
+type DB struct {
+	// Name is the database's add-on name.
+	Name string
+
+	client *Client
+}
+
+// DBInfo describes a database.
+type DBInfo struct {
+	Info        []InfoEntry `json:"info"`
+	ResourceURL string      `json:"resource_url"`
+	Plan        string      `json:"plan"`
+}
+
+// An InfoEntry is one line of human-readable database info, such as
+// "Version" or "Maintenance window".
+type InfoEntry struct {
+	Name   string        `json:"name"`
+	Values []interface{} `json:"values"`
+}
+
+// A Setting is the value of one of a database's config settings, with a
+// description of what the value means.
+type Setting struct {
+	Value interface{} `json:"value"`
+	Desc  string      `json:"desc"`
+}
+
+func (d *DB) req(method, path string, body, v interface{}) error {
+	return d.client.do(method, "/"+url.PathEscape(d.Name)+path, body, v)
+}
+
+// Info returns the database's info.
+func (d *DB) Info() (dbi DBInfo, err error) {
+	err = d.req("GET", "", nil, &dbi)
+	return
+}
+
+// UpdateConfig changes the database's config settings, such as "timeout"
+// or "maxmemory_policy", and returns the settings that were changed.
+func (d *DB) UpdateConfig(settings map[string]interface{}) (s map[string]Setting, err error) {
+	err = d.req("PATCH", "/config", settings, &s)
+	return
+}
+
+// Maintenance returns a description of the database's maintenance
+// status: whether maintenance is needed, and when it's scheduled.
+func (d *DB) Maintenance() (string, error) {
+	var v struct {
+		Message string `json:"message"`
+	}
+	err := d.req("GET", "/maintenance", nil, &v)
+	return v.Message, err
+}
+
+// RunMaintenance starts any maintenance the database needs now, rather
+// than in its maintenance window.
+func (d *DB) RunMaintenance() (string, error) {
+	var v struct {
+		Message string `json:"message"`
+	}
+	err := d.req("POST", "/maintenance", nil, &v)
+	return v.Message, err
+}
+
+// SetMaintenanceWindow sets the weekly window in which maintenance is
+// done, given as a day and a UTC start time, such as "Sunday 14:30".
+func (d *DB) SetMaintenanceWindow(window string) (string, error) {
+	var v struct {
+		Window string `json:"window"`
+	}
+	err := d.req("PUT", "/maintenance_window", map[string]string{"description": window}, &v)
+	return v.Window, err
+}
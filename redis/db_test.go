@@ -0,0 +1,70 @@
+package redis
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/heroku/hk/addonapi"
+)
+
+func TestDBInfo(t *testing.T) {
+	var gotPath, gotUser string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.Method + " " + r.URL.Path
+		gotUser, _, _ = r.BasicAuth()
+		w.Write([]byte(`{"info": [{"name": "Version", "values": ["6.2.3"]}], "plan": "premium-0"}`))
+	}))
+	defer ts.Close()
+	c := &Client{Client: addonapi.Client{URL: ts.URL + "/redis/v0/databases", Username: "user@example.com"}}
+	db := c.NewDB("redis-cubed-4321")
+	info, err := db.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "GET /redis/v0/databases/redis-cubed-4321" || gotUser != "user@example.com" {
+		t.Errorf("request = %q as %q", gotPath, gotUser)
+	}
+	if len(info.Info) != 1 || info.Info[0].Name != "Version" || info.Plan != "premium-0" {
+		t.Errorf("unexpected info %+v", info)
+	}
+}
+
+func TestDBUpdateConfig(t *testing.T) {
+	var body map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" || r.URL.Path != "/redis-cubed-4321/config" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"timeout": {"value": 60, "desc": "Idle connections close after 60 seconds."}}`))
+	}))
+	defer ts.Close()
+	c := &Client{Client: addonapi.Client{URL: ts.URL}}
+	db := c.NewDB("redis-cubed-4321")
+	s, err := db.UpdateConfig(map[string]interface{}{"timeout": 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body["timeout"] != float64(60) {
+		t.Errorf("request body = %v", body)
+	}
+	if s["timeout"].Desc != "Idle connections close after 60 seconds." {
+		t.Errorf("unexpected settings %+v", s)
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(422)
+		w.Write([]byte(`{"message": "Invalid maxmemory policy."}`))
+	}))
+	defer ts.Close()
+	c := &Client{Client: addonapi.Client{URL: ts.URL}}
+	db := c.NewDB("redis-cubed-4321")
+	_, err := db.UpdateConfig(map[string]interface{}{"maxmemory_policy": "bogus"})
+	if err == nil || err.Error() != "Invalid maxmemory policy." {
+		t.Errorf("error = %v", err)
+	}
+}
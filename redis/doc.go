@@ -0,0 +1,9 @@
+// Package redis is a client for the Heroku Redis API.
+//
+// A Client holds credentials and HTTP settings, and is safe to share. A DB
+// identifies one Redis database by its add-on name:
+//
+//	c := &redis.Client{Client: addonapi.Client{Username: user, Password: apiKey}}
+//	db := c.NewDB("redis-cubed-4321")
+//	info, err := db.Info()
+package redis
@@ -1,7 +1,6 @@
-package main
+package hk
 
 import (
-	"os"
 	"text/tabwriter"
 )
 
@@ -21,15 +20,15 @@ Examples:
 `,
 }
 
-func runRegions(cmd *Command, args []string) {
+func runRegions(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	regions, err := client.RegionList(nil)
+	regions, err := ctx.Client.RegionList(nil)
 	must(err)
 
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
 	defer w.Flush()
 
 	for _, r := range regions {
@@ -0,0 +1,157 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdReleaseDiff = &Command{
+	Run:      runReleaseDiff,
+	Usage:    "release-diff [--show-values] <version> <version>",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "show what changed between two releases" + extra,
+	Long: `
+Release-diff compares two releases. It shows the env vars that were
+added (+), removed (-), or changed (~), the slug and git commit each
+release runs, with the commits between them if they are in the git
+repo in the current directory, and the add-on changes made by the
+releases in between.
+
+Env var values are hidden unless --show-values is given.
+
+Options:
+
+    --show-values  show the values of changed env vars
+
+Examples:
+
+    $ hk release-diff v120 v123
+    Env:
+      + REDIS_URL
+      ~ RACK_ENV
+      - LEGACY_MODE
+    Slug:     1d0c3a9e-c6a1-4f9a-8d3b-0f7e5c6a2b1d → 98765432-82ba-10ba-fedc-8d206789d062
+    Commits:  0fda0ae..3ae20c2
+      3ae20c2  Bob Test  Add signup form
+    Add-ons:
+      v121  Attach REDIS resource
+
+    $ hk release-diff --show-values v122 v123
+    Env:
+      ~ RACK_ENV: staging → production
+`,
+}
+
+var flagReleaseDiffValues bool
+
+func init() {
+	cmdReleaseDiff.Flag.BoolVar(&flagReleaseDiffValues, "show-values", false, "show env var values")
+}
+
+func runReleaseDiff(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 2 {
+		ctx.printUsage()
+		exit(2)
+	}
+	from, err := ctx.Client.ReleaseInfo(appname, strings.TrimPrefix(args[0], "v"))
+	must(err)
+	to, err := ctx.Client.ReleaseInfo(appname, strings.TrimPrefix(args[1], "v"))
+	must(err)
+	if from.Version > to.Version {
+		from, to = to, from
+	}
+
+	fromCommit, toCommit, ok := printReleaseChanges(ctx.Stdout, appname, from, to, flagReleaseDiffValues)
+	if ok {
+		out, err := exec.Command("git", "log", "--format=%h%x09%an%x09%s", fromCommit+".."+toCommit).Output()
+		if err == nil {
+			w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+			for _, e := range parseChangelog(out) {
+				listRec(w, "  "+e.Commit, e.Author, e.Subject)
+			}
+			w.Flush()
+		}
+	}
+
+	var addonRels []heroku.Release
+	must(eachReleasePage(appname, releasePageSize, func(page []heroku.Release) bool {
+		for _, r := range page {
+			if r.Version <= from.Version {
+				return false
+			}
+			if r.Version <= to.Version && isAddonChange(r.Description) {
+				addonRels = append(addonRels, r)
+			}
+		}
+		return true
+	}))
+	if len(addonRels) > 0 {
+		sort.Sort(hreleasesByVersion(addonRels))
+		fmt.Fprintln(ctx.Stdout, "Add-ons:")
+		w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+		for _, r := range addonRels {
+			listRec(w, fmt.Sprintf("  v%d", r.Version), r.Description)
+		}
+		w.Flush()
+	}
+}
+
+// printReleaseChanges prints the env vars, slug, and git commit that
+// differ between releases from and to. If the commits differ, it returns
+// them with ok set.
+func printReleaseChanges(w io.Writer, appname string, from, to *heroku.Release, showValues bool) (fromCommit, toCommit string, ok bool) {
+	var fromEnv, toEnv map[string]string
+	must(client.Get(&fromEnv, "/apps/"+appname+"/releases/"+from.Id+"/config-vars"))
+	must(client.Get(&toEnv, "/apps/"+appname+"/releases/"+to.Id+"/config-vars"))
+	if changes := envDiff(fromEnv, toEnv); len(changes) > 0 {
+		fmt.Fprintln(w, "Env:")
+		for _, c := range changes {
+			fmt.Fprintln(w, "  "+formatEnvChange(c, fromEnv, toEnv, showValues))
+		}
+	}
+
+	if from.Slug != nil && to.Slug != nil && from.Slug.Id != to.Slug.Id {
+		fmt.Fprintf(w, "Slug:     %s → %s\n", from.Slug.Id, to.Slug.Id)
+	}
+	fromCommit, ferr := releaseCommit(appname, from)
+	toCommit, terr := releaseCommit(appname, to)
+	if ferr != nil || terr != nil || fromCommit == toCommit {
+		return "", "", false
+	}
+	fmt.Fprintf(w, "Commits:  %s..%s\n", fromCommit, toCommit)
+	return fromCommit, toCommit, true
+}
+
+// formatEnvChange describes c, a change from env old to env new, with
+// the values involved if showValues is set.
+func formatEnvChange(c envChange, old, new map[string]string, showValues bool) string {
+	s := fmt.Sprintf("%c %s", c.Op, c.Name)
+	if !showValues {
+		return s
+	}
+	switch c.Op {
+	case '+':
+		return s + "=" + new[c.Name]
+	case '-':
+		return s + "=" + old[c.Name]
+	}
+	return s + ": " + old[c.Name] + " → " + new[c.Name]
+}
+
+// addonChangeRE matches the descriptions of releases created by adding,
+// removing, or attaching add-ons, e.g. "Add heroku-redis:mini add-on" or
+// "Detach DATABASE resource".
+var addonChangeRE = regexp.MustCompile(`^(Add|Remove|Update|Upgrade|Attach|Detach) .+ (add-on|resource)\b`)
+
+func isAddonChange(desc string) bool {
+	return addonChangeRE.MatchString(desc)
+}
@@ -0,0 +1,44 @@
+package hk
+
+import (
+	"testing"
+)
+
+func TestFormatEnvChange(t *testing.T) {
+	old := map[string]string{"RACK_ENV": "staging", "LEGACY_MODE": "1"}
+	new := map[string]string{"RACK_ENV": "production", "REDIS_URL": "redis://h:6379"}
+	tests := []struct {
+		c          envChange
+		showValues bool
+		want       string
+	}{
+		{envChange{'~', "RACK_ENV"}, false, "~ RACK_ENV"},
+		{envChange{'~', "RACK_ENV"}, true, "~ RACK_ENV: staging → production"},
+		{envChange{'+', "REDIS_URL"}, true, "+ REDIS_URL=redis://h:6379"},
+		{envChange{'-', "LEGACY_MODE"}, true, "- LEGACY_MODE=1"},
+	}
+	for _, test := range tests {
+		if got := formatEnvChange(test.c, old, new, test.showValues); got != test.want {
+			t.Errorf("formatEnvChange(%c %s, %t) => %q, want %q", test.c.Op, test.c.Name, test.showValues, got, test.want)
+		}
+	}
+}
+
+func TestIsAddonChange(t *testing.T) {
+	tests := []struct {
+		desc string
+		want bool
+	}{
+		{"Add heroku-redis:mini add-on", true},
+		{"Remove heroku-postgresql:hobby-dev add-on", true},
+		{"Attach DATABASE resource", true},
+		{"Detach HEROKU_POSTGRESQL_RED resource", true},
+		{"Deploy 0fda0ae", false},
+		{"Set RACK_ENV config vars", false},
+	}
+	for _, test := range tests {
+		if got := isAddonChange(test.desc); got != test.want {
+			t.Errorf("isAddonChange(%q) => %t, want %t", test.desc, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// the most marks drawn on one line of a release graph
+const releaseGraphWidth = 50
+
+// printReleaseGraph draws a timeline of rels, sorted by version, with one
+// line per day, or per week if weekly is set. Periods with no releases
+// are drawn as empty lines, so gaps in deploy cadence stand out.
+func printReleaseGraph(w io.Writer, rels []*Release, weekly bool) {
+	if len(rels) == 0 {
+		return
+	}
+	period := func(t time.Time) time.Time {
+		t = t.UTC()
+		d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		if weekly {
+			// weeks start on Monday
+			d = d.AddDate(0, 0, -(int(d.Weekday())+6)%7)
+		}
+		return d
+	}
+	step := 1
+	if weekly {
+		step = 7
+	}
+
+	start, end := period(rels[0].CreatedAt), period(rels[len(rels)-1].CreatedAt)
+	i := 0
+	for p := start; !p.After(end); p = p.AddDate(0, 0, step) {
+		var marks []byte
+		deploys, rollbacks := 0, 0
+		for ; i < len(rels) && period(rels[i].CreatedAt).Equal(p); i++ {
+			switch {
+			case isDeploy(rels[i].Description):
+				marks = append(marks, '#')
+				deploys++
+			case strings.HasPrefix(rels[i].Description, "Rollback to "):
+				marks = append(marks, 'R')
+				rollbacks++
+			default:
+				marks = append(marks, '.')
+			}
+		}
+		bar := string(marks)
+		if len(bar) > releaseGraphWidth {
+			bar = bar[:releaseGraphWidth-1] + "+"
+		}
+		listRec(w, p.Format("2006-01-02"), bar, releaseGraphCounts(deploys, rollbacks))
+	}
+}
+
+func releaseGraphCounts(deploys, rollbacks int) string {
+	var counts []string
+	if deploys > 0 {
+		counts = append(counts, plural(deploys, "deploy"))
+	}
+	if rollbacks > 0 {
+		counts = append(counts, plural(rollbacks, "rollback"))
+	}
+	return strings.Join(counts, ", ")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
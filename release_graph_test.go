@@ -0,0 +1,54 @@
+package hk
+
+import (
+	"bytes"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+func TestPrintReleaseGraph(t *testing.T) {
+	rel := func(desc, created string) *Release {
+		at, err := time.Parse(time.RFC3339, created)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return newRelease(&heroku.Release{Description: desc, CreatedAt: at})
+	}
+	rels := []*Release{
+		rel("Deploy 3ae20c2", "2013-06-11T09:00:00Z"),
+		rel("Set RACK_ENV config vars", "2013-06-11T10:00:00Z"),
+		rel("Deploy 0fda0ae", "2013-06-11T18:14:40Z"),
+		rel("Deploy 1c2d3e4", "2013-06-13T18:14:40Z"),
+		rel("Rollback to v2", "2013-06-13T18:31:02Z"),
+		rel("Deploy 5f6a7b8", "2013-06-18T08:00:00Z"),
+	}
+	tests := []struct {
+		weekly bool
+		want   string
+	}{
+		{false, "" +
+			"2013-06-11  #.#  2 deploys\n" +
+			"2013-06-12       \n" +
+			"2013-06-13  #R   1 deploy, 1 rollback\n" +
+			"2013-06-14       \n" +
+			"2013-06-15       \n" +
+			"2013-06-16       \n" +
+			"2013-06-17       \n" +
+			"2013-06-18  #    1 deploy\n"},
+		{true, "" +
+			"2013-06-10  #.##R  3 deploys, 1 rollback\n" +
+			"2013-06-17  #      1 deploy\n"},
+	}
+	for _, test := range tests {
+		var buf bytes.Buffer
+		w := tabwriter.NewWriter(&buf, 1, 2, 2, ' ', 0)
+		printReleaseGraph(w, rels, test.weekly)
+		w.Flush()
+		if buf.String() != test.want {
+			t.Errorf("weekly=%t:\n%s\nwant:\n%s", test.weekly, buf.String(), test.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package hk
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+var flagReleaseNoWait bool
+
+func init() {
+	for _, cmd := range []*Command{cmdSet, cmdUnset, cmdRollback, cmdAddonAdd} {
+		cmd.Flag.BoolVar(&flagReleaseNoWait, "no-wait", false, "don't wait for the release to finish")
+	}
+}
+
+// releaseStatus is the part of a release that heroku-go doesn't expose:
+// whether its release phase has finished, and where to read its output.
+type releaseStatus struct {
+	Id              string `json:"id"`
+	Version         int    `json:"version"`
+	Status          string `json:"status"`
+	OutputStreamURL string `json:"output_stream_url"`
+}
+
+// waitLatestRelease waits for the release created by a config or add-on
+// change. Releases at or below version after predate the change, so
+// there is nothing to wait for.
+func waitLatestRelease(w io.Writer, appname string, after int) {
+	if flagReleaseNoWait {
+		return
+	}
+	rel, err := latestRelease(appname)
+	must(err)
+	if rel.Version > after {
+		waitRelease(w, appname, rel.Id)
+	}
+}
+
+// waitRelease copies the release phase output of a release to w and
+// polls until the release finishes. It exits if the release fails.
+func waitRelease(w io.Writer, appname, id string) {
+	if flagReleaseNoWait {
+		return
+	}
+	var rel releaseStatus
+	must(client.Get(&rel, "/apps/"+appname+"/releases/"+id))
+	if rel.Status == "pending" && rel.OutputStreamURL != "" {
+		res, err := http.Get(rel.OutputStreamURL)
+		must(err)
+		_, err = io.Copy(w, res.Body)
+		res.Body.Close()
+		must(err)
+	}
+	for rel.Status == "pending" {
+		time.Sleep(2 * time.Second)
+		must(client.Get(&rel, "/apps/"+appname+"/releases/"+id))
+	}
+	if rel.Status == "failed" {
+		printFatal("Release v%d failed. Run 'hk log' for details.", rel.Version)
+	}
+}
@@ -0,0 +1,34 @@
+package hk
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/heroku/hk/hktest"
+)
+
+func TestSetWaitsForRelease(t *testing.T) {
+	tests := []struct {
+		args   []string
+		status string
+		want   int
+	}{
+		{[]string{"set", "-a", "myapp", "FOO=bar"}, "succeeded", 0},
+		{[]string{"set", "-a", "myapp", "FOO=bar"}, "failed", 1},
+		{[]string{"set", "-a", "myapp", "--no-wait", "FOO=bar"}, "failed", 0},
+	}
+	for _, test := range tests {
+		rel := json.RawMessage(`{"id":"rel-11","version":11,"status":"` + test.status + `"}`)
+		srv := hktest.NewServer(
+			hktest.Fixture{Method: "PATCH", Path: "/apps/myapp/config-vars", Body: json.RawMessage(`{}`)},
+			hktest.Fixture{Method: "GET", Path: "/apps/myapp/releases", Body: json.RawMessage(`[` + string(rel) + `]`)},
+			hktest.Fixture{Method: "GET", Path: "/apps/myapp/releases/rel-11", Body: rel},
+		)
+		_, status := runTestCommand(t, srv, test.args...)
+		srv.Close()
+		if status != test.want {
+			t.Errorf("hk %v with %s release: status = %d, want %d", test.args, test.status, status, test.want)
+		}
+	}
+	flagReleaseNoWait = false
+}
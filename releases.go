@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"fmt"
@@ -6,18 +6,20 @@ import (
 	"log"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/term"
 )
 
 var releaseCount int
 
 var cmdReleases = &Command{
 	Run:      runReleases,
-	Usage:    "releases [<version>...]",
+	Usage:    "releases [-j] [-n <limit> | --all] [--since <time>] [--until <time>] [--by <email>] [--desc-match <pattern>] [--columns <columns> | --graph [--per day|week]] [<version>...]",
 	NeedsApp: true,
 	Category: "release",
 	Short:    "list releases",
@@ -26,6 +28,40 @@ Lists releases. Shows the version of the release (e.g. v1), who
 made the release, git commit id, time of the release, and
 description.
 
+Options:
+
+    -j, --json              print releases as JSON
+    -n <limit>              max number of recent releases to display
+    --all                   display every release, however many
+    --since <time>          only show releases made at or after time
+    --until <time>          only show releases made before time
+    --by <email>            only show releases made by this user. The
+                            part of the email before the @ is enough.
+    --desc-match <pattern>  only show releases whose description
+                            matches the pattern, where * matches any
+                            text and ? matches any single character
+    --columns <columns>     comma-separated list of columns to show,
+                            in order. Available columns are version,
+                            who (abbreviated email), email (full
+                            email), commit, when (short time), time
+                            (full UTC timestamp), desc, and id. The
+                            default is version,who,commit,when,desc.
+    --graph                 draw a timeline of the releases instead of
+                            listing them. Each release is a # for a
+                            deploy, an R for a rollback, or a . for
+                            anything else.
+    --per <day|week>        with --graph, the period of each line
+                            (default day)
+    --no-pager              don't send long output through a pager
+                            (see PAGER in 'hk help environ')
+
+Filters are applied as releases are fetched, so -n limits the number
+of matching releases shown.
+
+Times for --since and --until are RFC 3339 timestamps, dates in the
+form 2014-01-31 (midnight UTC), or durations before now, such as 12h
+or 30d.
+
 Examples:
 
     $ hk releases
@@ -40,38 +76,119 @@ Examples:
     $ hk releases 1 3
     v1  bob@test.com  3ae20c2  Jun 12 18:28  Deploy 3ae20c2
     v3  john@me.com            Jun 13 18:31  Rollback to v2
+
+    $ hk releases --columns version,email,time -n 1
+    v3  john@me.com  2013-06-13T18:31:02Z
+
+    $ hk releases --by john --desc-match 'Deploy *'
+    v2  john@me.com   0fda0ae  Jun 13 18:14  Deploy 0fda0ae
+
+    $ hk releases --all --since 2013-06-13 --columns version,email,time,desc
+    v2  john@me.com  2013-06-13T18:14:40Z  Deploy 0fda0ae
+    v3  john@me.com  2013-06-13T18:31:02Z  Rollback to v2
+
+    $ hk releases --all --since 30d --graph
+    2013-06-11  #.#  2 deploys
+    2013-06-12
+    2013-06-13  #R   1 deploy, 1 rollback
 `,
 }
 
+func init() {
+	registerList(cmdReleases, (*Release)(nil))
+}
+
+var (
+	flagReleaseColumns   string
+	flagReleaseBy        string
+	flagReleaseDescMatch string
+	flagReleaseAll       bool
+	flagReleaseSince     string
+	flagReleaseUntil     string
+	flagReleaseGraph     bool
+	flagReleaseGraphPer  string
+)
+
 func init() {
 	cmdReleases.Flag.IntVar(&releaseCount, "n", 30, "max number of recent releases to display")
+	cmdReleases.Flag.StringVar(&flagReleaseBy, "by", "", "only show releases by this user")
+	cmdReleases.Flag.StringVar(&flagReleaseDescMatch, "desc-match", "", "only show releases with matching descriptions")
+	cmdReleases.Flag.BoolVar(&flagReleaseAll, "all", false, "display all releases")
+	cmdReleases.Flag.StringVar(&flagReleaseSince, "since", "", "only show releases made at or after this time")
+	cmdReleases.Flag.StringVar(&flagReleaseUntil, "until", "", "only show releases made before this time")
+	cmdReleases.Flag.BoolVar(&flagReleaseGraph, "graph", false, "draw a timeline of releases")
+	cmdReleases.Flag.StringVar(&flagReleaseGraphPer, "per", "day", "period of each line of the graph")
+	cmdReleases.Flag.StringVar(&flagReleaseColumns, "columns", strings.Join(defaultReleaseColumns, ","), "columns to display")
 }
 
-func runReleases(cmd *Command, versions []string) {
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+func runReleases(ctx *Context, versions []string) {
+	cols, err := parseReleaseColumns(flagReleaseColumns)
+	if err != nil {
+		printError(err.Error())
+		ctx.printUsage()
+		exit(2)
+	}
+	releaseColumns = cols
+	if flagReleaseGraphPer != "day" && flagReleaseGraphPer != "week" {
+		printError("--per must be day or week")
+		ctx.printUsage()
+		exit(2)
+	}
+
+	filter := releaseFilter{by: flagReleaseBy, descPattern: flagReleaseDescMatch}
+	now := time.Now()
+	if flagReleaseSince != "" {
+		if filter.since, err = parseReleaseTime(flagReleaseSince, now); err != nil {
+			printError(err.Error())
+			ctx.printUsage()
+			exit(2)
+		}
+	}
+	if flagReleaseUntil != "" {
+		if filter.until, err = parseReleaseTime(flagReleaseUntil, now); err != nil {
+			printError(err.Error())
+			ctx.printUsage()
+			exit(2)
+		}
+	}
+	limit := releaseCount
+	if flagReleaseAll {
+		limit = -1
+	}
+
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
 	defer w.Flush()
-	listReleases(w, versions)
+	listReleases(w, versions, filter, limit)
 }
 
-func listReleases(w io.Writer, versions []string) {
+// listReleases prints the given release versions, or if there are none,
+// up to limit of the app's most recent releases that match filter. A
+// negative limit prints every matching release.
+func listReleases(w io.Writer, versions []string, filter releaseFilter, limit int) {
 	appname := mustApp()
 	if len(versions) == 0 {
-		hrels, err := client.ReleaseList(appname, &heroku.ListRange{
-			Field:      "version",
-			Max:        releaseCount,
-			Descending: true,
+		pageSize := limit
+		if limit < 0 || !filter.empty() {
+			pageSize = releasePageSize
+		}
+		var rels []*Release
+		err := eachReleasePage(appname, pageSize, func(page []heroku.Release) bool {
+			for i := range page {
+				if len(rels) == limit {
+					return false
+				}
+				if !filter.since.IsZero() && page[i].CreatedAt.Before(filter.since) {
+					// pages are newest first, so the rest are older still
+					return false
+				}
+				if filter.match(&page[i]) {
+					rels = append(rels, newRelease(&page[i]))
+				}
+			}
+			return limit < 0 || len(rels) < limit
 		})
 		must(err)
-		rels := make([]*Release, len(hrels))
-		for i := range hrels {
-			rels[i] = newRelease(&hrels[i])
-		}
-		sort.Sort(releasesByVersion(rels))
-		gitDescribe(rels)
-		abbrevEmailReleases(rels)
-		for _, r := range rels {
-			listRelease(w, r)
-		}
+		printReleases(w, rels)
 		return
 	}
 
@@ -101,14 +218,100 @@ func listReleases(w io.Writer, versions []string) {
 			}
 		}
 	}
+	printReleases(w, rels)
+}
+
+func printReleases(w io.Writer, rels []*Release) {
 	sort.Sort(releasesByVersion(rels))
 	gitDescribe(rels)
+	if printList(w, rels) {
+		return
+	}
+	if flagReleaseGraph {
+		printReleaseGraph(w, rels, flagReleaseGraphPer == "week")
+		return
+	}
 	abbrevEmailReleases(rels)
 	for _, r := range rels {
 		listRelease(w, r)
 	}
 }
 
+// the number of releases fetched per API request when filtering
+const releasePageSize = 200
+
+// eachReleasePage fetches the app's releases a page at a time, newest first,
+// and calls fn with each page until fn returns false or there are no more
+// releases.
+func eachReleasePage(appname string, pageSize int, fn func([]heroku.Release) bool) error {
+	lr := &heroku.ListRange{Field: "version", Max: pageSize, Descending: true}
+	prev := 0
+	for {
+		rels, err := client.ReleaseList(appname, lr)
+		if err != nil {
+			return err
+		}
+		sort.Sort(sort.Reverse(hreleasesByVersion(rels)))
+		if len(rels) == 0 || !fn(rels) || len(rels) < pageSize {
+			return nil
+		}
+		oldest := rels[len(rels)-1].Version
+		if oldest <= 1 || (prev != 0 && oldest >= prev) {
+			// done, or the API ignored the range and paging would repeat
+			return nil
+		}
+		prev = oldest
+		// a range starts from its first id in the order listed, so with
+		// order=desc the next page starts just below the oldest so far
+		lr.FirstId = strconv.Itoa(oldest - 1)
+	}
+}
+
+// releaseFilter matches releases by user, description, and creation
+// time. Empty fields match all releases.
+type releaseFilter struct {
+	by          string
+	descPattern string
+	since       time.Time
+	until       time.Time
+}
+
+func (f releaseFilter) empty() bool {
+	return f.by == "" && f.descPattern == "" && f.since.IsZero() && f.until.IsZero()
+}
+
+func (f releaseFilter) match(r *heroku.Release) bool {
+	if !f.since.IsZero() && r.CreatedAt.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && !r.CreatedAt.Before(f.until) {
+		return false
+	}
+	if f.by != "" {
+		email := strings.ToLower(r.User.Email)
+		by := strings.ToLower(f.by)
+		if email != by && !strings.HasPrefix(email, by+"@") {
+			return false
+		}
+	}
+	return f.descPattern == "" || globMatch(f.descPattern, r.Description)
+}
+
+// parseReleaseTime parses s as an RFC 3339 timestamp, a UTC date, or a
+// duration before now.
+func parseReleaseTime(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if d, err := parseLongDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected a timestamp, date, or duration", s)
+}
+
 func abbrevEmailReleases(rels []*Release) {
 	domains := make(map[string]int)
 	for _, r := range rels {
@@ -130,14 +333,41 @@ func abbrevEmailReleases(rels []*Release) {
 	}
 }
 
+var defaultReleaseColumns = []string{"version", "who", "commit", "when", "desc"}
+
+// releaseColumns are the columns printed by listRelease.
+var releaseColumns = defaultReleaseColumns
+
+// releaseColumnFuncs maps each release column name to the function that
+// formats it.
+var releaseColumnFuncs = map[string]func(r *Release) interface{}{
+	"version": func(r *Release) interface{} { return fmt.Sprintf("v%d", r.Version) },
+	"who":     func(r *Release) interface{} { return abbrev(r.Who, 10) },
+	"email":   func(r *Release) interface{} { return r.User.Email },
+	"commit":  func(r *Release) interface{} { return abbrev(r.Commit, 10) },
+	"when":    func(r *Release) interface{} { return prettyTime{r.CreatedAt} },
+	"time":    func(r *Release) interface{} { return r.CreatedAt.UTC().Format(time.RFC3339) },
+	"desc":    func(r *Release) interface{} { return r.Description },
+	"id":      func(r *Release) interface{} { return r.Id },
+}
+
+func parseReleaseColumns(s string) ([]string, error) {
+	cols := strings.Split(s, ",")
+	for i, c := range cols {
+		cols[i] = strings.ToLower(strings.TrimSpace(c))
+		if _, ok := releaseColumnFuncs[cols[i]]; !ok {
+			return nil, fmt.Errorf("unknown release column %q", c)
+		}
+	}
+	return cols, nil
+}
+
 func listRelease(w io.Writer, r *Release) {
-	listRec(w,
-		fmt.Sprintf("v%d", r.Version),
-		abbrev(r.Who, 10),
-		abbrev(r.Commit, 10),
-		prettyTime{r.CreatedAt},
-		r.Description,
-	)
+	vals := make([]interface{}, len(releaseColumns))
+	for i, c := range releaseColumns {
+		vals[i] = releaseColumnFuncs[c](r)
+	}
+	listRec(w, vals...)
 }
 
 type releasesByVersion []*Release
@@ -171,50 +401,268 @@ Examples:
 `,
 }
 
-func runReleaseInfo(cmd *Command, args []string) {
-	appname := mustApp()
+func runReleaseInfo(ctx *Context, args []string) {
+	appname := ctx.MustApp()
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	ver := strings.TrimPrefix(args[0], "v")
-	rel, err := client.ReleaseInfo(appname, ver)
+	rel, err := ctx.Client.ReleaseInfo(appname, ver)
 	must(err)
 
-	fmt.Printf("Version:  v%d\n", rel.Version)
-	fmt.Printf("By:       %s\n", rel.User.Email)
-	fmt.Printf("Change:   %s\n", rel.Description)
-	fmt.Printf("When:     %s\n", rel.CreatedAt.UTC().Format(time.RFC3339))
-	fmt.Printf("Id:       %s\n", rel.Id)
-	fmt.Printf("Slug:     %s\n", rel.Slug.Id)
+	fmt.Fprintf(ctx.Stdout, "Version:  v%d\n", rel.Version)
+	fmt.Fprintf(ctx.Stdout, "By:       %s\n", rel.User.Email)
+	fmt.Fprintf(ctx.Stdout, "Change:   %s\n", rel.Description)
+	fmt.Fprintf(ctx.Stdout, "When:     %s\n", rel.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(ctx.Stdout, "Id:       %s\n", rel.Id)
+	fmt.Fprintf(ctx.Stdout, "Slug:     %s\n", rel.Slug.Id)
+}
+
+var cmdReleaseOpen = &Command{
+	Run:      runReleaseOpen,
+	Usage:    "release-open [--print-url] <version>",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "open a release in the dashboard" + extra,
+	Long: `
+Release-open opens a release's activity page in the Heroku
+Dashboard in your default web browser.
+
+Options:
+
+    --print-url  print the URL instead of opening it
+
+Examples:
+
+    $ hk release-open v116
+
+    $ hk release-open --print-url v116
+    https://dashboard.heroku.com/apps/myapp/activity/releases/abcd1234-5678-def0-8190-12347060474d
+`,
+}
+
+var flagReleaseOpenPrint bool
+
+func init() {
+	cmdReleaseOpen.Flag.BoolVar(&flagReleaseOpenPrint, "print-url", false, "print the URL instead of opening it")
+}
+
+func runReleaseOpen(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	rel, err := ctx.Client.ReleaseInfo(appname, strings.TrimPrefix(args[0], "v"))
+	must(err)
+	u := releaseDashboardURL(appname, rel.Id)
+	if flagReleaseOpenPrint {
+		fmt.Fprintln(ctx.Stdout, u)
+		return
+	}
+	must(openURL(u))
+}
+
+func releaseDashboardURL(appname, releaseId string) string {
+	return "https://dashboard.heroku.com/apps/" + appname + "/activity/releases/" + releaseId
 }
 
 var cmdRollback = &Command{
 	Run:      runRollback,
-	Usage:    "rollback <version>",
+	Usage:    "rollback [-y] [--no-wait] [--to-previous | --to-commit <commit> | <version>]",
 	NeedsApp: true,
 	Category: "release",
 	Short:    "roll back to a previous release",
 	Long: `
 Rollback re-releases an app at an older version. This action
 creates a new release based on the older release, then restarts
-the app's dynos on the new release.
+the app's dynos on the new release. If the app has a release
+phase, its output is shown as it runs, and rollback exits
+non-zero if the release fails.
+
+With no version, rollback lists the recent releases and asks which
+one to roll back to. When run from a terminal, rollback shows the
+env var, slug, and commit changes it will make, and asks for
+confirmation before rolling back.
+
+Options:
+
+    -y, --yes             don't ask for confirmation
+    --no-wait             don't show release phase output or wait
+                          for the release to finish
+    --to-previous         roll back to the release before the
+                          current one
+    --to-commit <commit>  roll back to the most recent release that
+                          deployed the given git commit. The commit
+                          may be a full or abbreviated SHA, or any
+                          name understood by the local git repo.
 
 Examples:
 
     $ hk rollback v4
+    Env:
+      + LEGACY_MODE
+    Commits:  3ae20c2..0fda0ae
+    Roll back myapp from v6 to v4? [y/N] y
     Rolled back myapp to v4 as v7.
+
+    $ hk rollback -y --to-previous
+    Rolled back myapp to v6 as v8.
+
+    $ hk rollback --to-commit 0fda0ae
+    Rolled back myapp to v5 as v8.
 `,
 }
 
-func runRollback(cmd *Command, args []string) {
-	appname := mustApp()
-	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+var (
+	flagRollbackCommit   string
+	flagRollbackPrevious bool
+	flagRollbackYes      bool
+)
+
+func init() {
+	cmdRollback.Flag.StringVar(&flagRollbackCommit, "to-commit", "", "git commit to roll back to")
+	cmdRollback.Flag.BoolVar(&flagRollbackPrevious, "to-previous", false, "roll back to the previous release")
+	cmdRollback.Flag.BoolVar(&flagRollbackYes, "y", false, "don't ask for confirmation")
+	cmdRollback.Flag.BoolVar(&flagRollbackYes, "yes", false, "don't ask for confirmation")
+}
+
+// the number of releases listed to choose from when rollback is given
+// no version
+const rollbackChoices = 10
+
+func runRollback(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	interactive := term.IsTerminal(os.Stdin)
+	var ver string
+	switch {
+	case flagRollbackCommit != "" && !flagRollbackPrevious && len(args) == 0:
+		rel, err := findReleaseByCommit(appname, resolveGitCommit(flagRollbackCommit))
+		must(err)
+		ver = strconv.Itoa(rel.Version)
+	case flagRollbackPrevious && flagRollbackCommit == "" && len(args) == 0:
+		cur, err := latestRelease(appname)
+		must(err)
+		if cur.Version <= 1 {
+			printFatal("%s has no release before v%d.", appname, cur.Version)
+		}
+		ver = strconv.Itoa(cur.Version - 1)
+	case flagRollbackCommit == "" && !flagRollbackPrevious && len(args) == 1:
+		ver = strings.TrimPrefix(args[0], "v")
+	case flagRollbackCommit == "" && !flagRollbackPrevious && len(args) == 0 && interactive:
+		ver = chooseRollbackVersion(ctx.Stdout)
+	default:
+		ctx.printUsage()
+		exit(2)
 	}
-	ver := strings.TrimPrefix(args[0], "v")
-	rel, err := client.ReleaseRollback(appname, ver)
+	if interactive && !flagRollbackYes {
+		cur, err := latestRelease(appname)
+		must(err)
+		target, err := ctx.Client.ReleaseInfo(appname, ver)
+		must(err)
+		printReleaseChanges(ctx.Stdout, appname, cur, target, false)
+		mustConfirm(fmt.Sprintf("Roll back %s from v%d to v%d?", appname, cur.Version, target.Version))
+	}
+	rel, err := ctx.Client.ReleaseRollback(appname, ver)
 	must(err)
+	waitRelease(ctx.Stdout, appname, rel.Id)
 	log.Printf("Rolled back %s to v%s as v%d.\n", appname, ver, rel.Version)
 }
+
+// chooseRollbackVersion lists the app's recent releases on out and returns
+// the version read from stdin.
+func chooseRollbackVersion(out io.Writer) string {
+	w := tabwriter.NewWriter(out, 1, 2, 2, ' ', 0)
+	listReleases(w, nil, releaseFilter{}, rollbackChoices)
+	w.Flush()
+	fmt.Fprint(os.Stderr, "Roll back to version: ")
+	var answer string
+	fmt.Scanln(&answer)
+	ver := strings.TrimPrefix(strings.TrimSpace(answer), "v")
+	if ver == "" {
+		printFatal("Canceled.")
+	}
+	if _, err := strconv.Atoi(ver); err != nil {
+		printFatal("invalid version %q", answer)
+	}
+	return ver
+}
+
+// the number of releases searched when looking up a release by commit
+const releaseSearchMax = 100
+
+// findReleaseByCommit returns the most recent release that deployed the
+// given commit. Deploy descriptions are checked first, then the commit
+// recorded on each release's slug. Each slug is looked up at most once, and
+// not at all if a deploy description already gave its commit.
+func findReleaseByCommit(appname, commit string) (*heroku.Release, error) {
+	rels, err := client.ReleaseList(appname, &heroku.ListRange{
+		Field:      "version",
+		Max:        releaseSearchMax,
+		Descending: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(hreleasesByVersion(rels)))
+	checked := make(map[string]bool) // slug ids known not to match
+	for i := range rels {
+		if isDeploy(rels[i].Description) {
+			if commitMatches(rels[i].Description[len("Deploy "):], commit) {
+				return &rels[i], nil
+			}
+			if rels[i].Slug != nil {
+				checked[rels[i].Slug.Id] = true
+			}
+		}
+	}
+	for i := range rels {
+		if rels[i].Slug == nil || checked[rels[i].Slug.Id] {
+			continue
+		}
+		slug, err := client.SlugInfo(appname, rels[i].Slug.Id)
+		if err != nil {
+			return nil, err
+		}
+		if slug.Commit != nil && commitMatches(*slug.Commit, commit) {
+			return &rels[i], nil
+		}
+		checked[rels[i].Slug.Id] = true
+	}
+	return nil, fmt.Errorf("none of the last %d releases of %s deployed commit %s", len(rels), appname, commit)
+}
+
+// commitMatches reports whether two commit ids, either of which may be
+// abbreviated, refer to the same commit.
+func commitMatches(a, b string) bool {
+	if len(a) < 4 || len(b) < 4 {
+		return false
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return strings.HasPrefix(b, a)
+}
+
+type hreleasesByVersion []heroku.Release
+
+func (a hreleasesByVersion) Len() int           { return len(a) }
+func (a hreleasesByVersion) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a hreleasesByVersion) Less(i, j int) bool { return a[i].Version < a[j].Version }
+
+// latestRelease returns the app's most recent release.
+func latestRelease(appname string) (*heroku.Release, error) {
+	rels, err := client.ReleaseList(appname, &heroku.ListRange{
+		Field:      "version",
+		Max:        1,
+		Descending: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rels) == 0 {
+		return nil, fmt.Errorf("%s has no releases", appname)
+	}
+	return &rels[0], nil
+}
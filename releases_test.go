@@ -0,0 +1,184 @@
+package hk
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var commitMatchesTests = []struct {
+	a, b string
+	want bool
+}{
+	{"0fda0ae", "0fda0ae", true},
+	{"0fda0ae", "0fda0ae9c1b2d3e4f5a6b7c8d9e0f1a2b3c4d5e6", true},
+	{"0fda0ae9c1b2d3e4f5a6b7c8d9e0f1a2b3c4d5e6", "0fda", true},
+	{"0fda0ae", "3ae20c2", false},
+	{"0fd", "0fda0ae", false},
+	{"", "0fda0ae", false},
+}
+
+func TestCommitMatches(t *testing.T) {
+	for i, ct := range commitMatchesTests {
+		if got := commitMatches(ct.a, ct.b); got != ct.want {
+			t.Errorf("%d. commitMatches(%q, %q) => %t, want %t", i, ct.a, ct.b, got, ct.want)
+		}
+	}
+}
+
+func TestParseReleaseColumns(t *testing.T) {
+	cols, err := parseReleaseColumns("version, Email,time")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"version", "email", "time"}
+	if len(cols) != len(want) {
+		t.Fatalf("parseReleaseColumns => %v, want %v", cols, want)
+	}
+	for i := range want {
+		if cols[i] != want[i] {
+			t.Errorf("parseReleaseColumns => %v, want %v", cols, want)
+		}
+	}
+
+	if _, err := parseReleaseColumns("version,bogus"); err == nil {
+		t.Errorf("expected error for unknown column")
+	}
+}
+
+var parseReleaseTimeTests = []struct {
+	in   string
+	want string
+	err  bool
+}{
+	{"2014-01-13T21:20:57Z", "2014-01-13T21:20:57Z", false},
+	{"2014-01-13", "2014-01-13T00:00:00Z", false},
+	{"12h", "2014-02-01T00:00:00Z", false},
+	{"30d", "2014-01-02T12:00:00Z", false},
+	{"yesterday", "", true},
+}
+
+func TestParseReleaseTime(t *testing.T) {
+	now := time.Date(2014, 2, 1, 12, 0, 0, 0, time.UTC)
+	for i, pt := range parseReleaseTimeTests {
+		got, err := parseReleaseTime(pt.in, now)
+		if (err != nil) != pt.err {
+			t.Errorf("%d. parseReleaseTime(%q).err => %v, want error %t", i, pt.in, err, pt.err)
+			continue
+		}
+		if !pt.err && got.UTC().Format(time.RFC3339) != pt.want {
+			t.Errorf("%d. parseReleaseTime(%q) => %s, want %s", i, pt.in, got.UTC().Format(time.RFC3339), pt.want)
+		}
+	}
+}
+
+// fakeReleaseSlugs is an API with the given releases, whose slugs'
+// commits are in commits. It counts SlugInfo calls.
+type fakeReleaseSlugs struct {
+	API
+	rels      []heroku.Release
+	commits   map[string]string
+	slugInfos int
+}
+
+func (f *fakeReleaseSlugs) ReleaseList(appIdentity string, lr *heroku.ListRange) ([]heroku.Release, error) {
+	return f.rels, nil
+}
+
+func (f *fakeReleaseSlugs) SlugInfo(appIdentity, slugIdentity string) (*heroku.Slug, error) {
+	f.slugInfos++
+	commit := f.commits[slugIdentity]
+	return &heroku.Slug{Id: slugIdentity, Commit: &commit}, nil
+}
+
+func TestFindReleaseByCommit(t *testing.T) {
+	defer func(c API) { client = c }(client)
+	rel := func(version int, desc, slug string) heroku.Release {
+		r := heroku.Release{Version: version, Description: desc}
+		r.Slug = &struct {
+			Id string `json:"id"`
+		}{slug}
+		return r
+	}
+	fake := &fakeReleaseSlugs{
+		rels: []heroku.Release{
+			rel(1, "Slug push", "slug-a"),
+			rel(2, "Deploy 3ae20c2", "slug-b"),
+			rel(3, "Set FOO config vars", "slug-b"),
+			rel(4, "Rollback to v1", "slug-a"),
+			rel(5, "Set BAR config vars", "slug-a"),
+		},
+		commits: map[string]string{"slug-a": "0fda0ae9c1b2", "slug-b": "3ae20c2d4e5f"},
+	}
+	client = fake
+
+	r, err := findReleaseByCommit("myapp", "3ae20c2d4e5f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != 2 || fake.slugInfos != 0 {
+		t.Errorf("deployed commit => v%d after %d slug lookups, want v2 after 0", r.Version, fake.slugInfos)
+	}
+
+	fake.slugInfos = 0
+	r, err = findReleaseByCommit("myapp", "0fda0ae")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Version != 5 || fake.slugInfos != 1 {
+		t.Errorf("pushed commit => v%d after %d slug lookups, want v5 after 1", r.Version, fake.slugInfos)
+	}
+
+	fake.slugInfos = 0
+	if _, err = findReleaseByCommit("myapp", "9999999"); err == nil || fake.slugInfos != 1 {
+		t.Errorf("unknown commit => %v after %d slug lookups, want error after 1", err, fake.slugInfos)
+	}
+}
+
+// fakeReleasePages is an API with releases 1 to n, listed as the API
+// pages them by version range.
+type fakeReleasePages struct {
+	API
+	n     int
+	calls int
+}
+
+func (f *fakeReleasePages) ReleaseList(appIdentity string, lr *heroku.ListRange) ([]heroku.Release, error) {
+	f.calls++
+	start := f.n
+	if lr.FirstId != "" {
+		start, _ = strconv.Atoi(lr.FirstId)
+	}
+	var rels []heroku.Release
+	for v := start; v >= 1 && len(rels) < lr.Max; v-- {
+		rels = append(rels, heroku.Release{Version: v})
+	}
+	return rels, nil
+}
+
+func TestEachReleasePage(t *testing.T) {
+	defer func(c API) { client = c }(client)
+	fake := &fakeReleasePages{n: 25}
+	client = fake
+
+	var got []int
+	err := eachReleasePage("myapp", 10, func(page []heroku.Release) bool {
+		for _, r := range page {
+			got = append(got, r.Version)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 25 || fake.calls != 3 {
+		t.Fatalf("got %d releases in %d calls, want 25 in 3", len(got), fake.calls)
+	}
+	for i, v := range got {
+		if v != 25-i {
+			t.Errorf("%d. version => %d, want %d", i, v, 25-i)
+		}
+	}
+}
@@ -1,8 +1,7 @@
-package main
+package hk
 
 import (
 	"log"
-	"os"
 
 	"github.com/bgentry/heroku-go"
 )
@@ -21,13 +20,13 @@ Example:
 `,
 }
 
-func runRename(cmd *Command, args []string) {
+func runRename(ctx *Context, args []string) {
 	if len(args) != 2 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	oldname, newname := args[0], args[1]
-	app, err := client.AppUpdate(oldname, &heroku.AppUpdateOpts{Name: &newname})
+	app, err := ctx.Client.AppUpdate(oldname, &heroku.AppUpdateOpts{Name: &newname})
 	must(err)
 	log.Printf("Renamed %s to %s.", oldname, app.Name)
 	log.Println("Ensure you update your git remote URL.")
@@ -0,0 +1,255 @@
+package hk
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+var cmdResource = &Command{
+	Run:      runResource,
+	Usage:    "resource [-j] [-a <app>] <type> list|get|create [<identity>...] [<param>=<value>...]",
+	Category: "hk",
+	Short:    "list, show, or create any API resource" + extra,
+	Long: `
+Resource works with any resource described by the API's JSON schema,
+including ones hk has no command for yet. See 'hk api --list' for the
+resource types and the parameters they accept.
+
+The identities needed by the resource's path are given in order,
+e.g. an app and then a domain for 'hk resource domain get'. An app
+identity may be left out to use the app given by -a or the git
+remote. Parameters for create are given as <param>=<value>, where a
+value that is valid JSON, such as a number, true, or an object, is
+sent as that JSON value, and anything else is sent as a string.
+
+Options:
+
+    -j, --json  print the API's response as JSON
+    -a <app>    the app to use when its identity is left out
+
+Examples:
+
+    $ hk resource -a myapp domain list
+    id                                    hostname             kind
+    01234567-89ab-cdef-0123-456789abcdef  myapp.herokuapp.com  heroku
+    fedcba98-7654-3210-fedc-ba9876543210  www.example.com      custom
+
+    $ hk resource domain get myapp www.example.com
+    cname:       www.example.com.herokudns.com
+    created_at:  2014-01-13T21:20:57Z
+    hostname:    www.example.com
+    id:          fedcba98-7654-3210-fedc-ba9876543210
+    kind:        custom
+
+    $ hk resource -a myapp domain create hostname=api.example.com
+    cname:     api.example.com.herokudns.com
+    hostname:  api.example.com
+    ...
+`,
+}
+
+func init() {
+	registerList(cmdResource, nil)
+}
+
+func init() {
+	cmdResource.Flag.StringVar(&flagApp, "a", "", "app name")
+	cmdResource.Flag.StringVar(&flagRemote, "r", "", "git remote of app")
+}
+
+// resourceActions maps each resource action to the schema link relation
+// that implements it.
+var resourceActions = map[string]string{
+	"list":   "instances",
+	"get":    "self",
+	"create": "create",
+}
+
+func runResource(ctx *Context, args []string) {
+	if len(args) < 2 {
+		ctx.printUsage()
+		exit(2)
+	}
+	typ, action, args := args[0], args[1], args[2:]
+	rel, ok := resourceActions[action]
+	if !ok {
+		ctx.printUsage()
+		exit(2)
+	}
+	schema, err := loadAPISchema()
+	must(err)
+	def, ok := schema.Definitions[typ]
+	if !ok {
+		printFatal("no resource named %s in the API schema, see 'hk api --list'", typ)
+	}
+	var link *apiLink
+	for i := range def.Links {
+		if def.Links[i].Rel == rel {
+			link = &def.Links[i]
+			break
+		}
+	}
+	if link == nil {
+		printFatal("%s has no %s endpoint in the API schema", typ, action)
+	}
+
+	var ids []string
+	var body map[string]interface{}
+	for _, arg := range args {
+		i := strings.Index(arg, "=")
+		if i < 0 {
+			ids = append(ids, arg)
+			continue
+		}
+		if action != "create" {
+			ctx.printUsage()
+			exit(2)
+		}
+		if body == nil {
+			body = make(map[string]interface{})
+		}
+		body[arg[:i]] = resourceParam(arg[i+1:])
+	}
+	vars := link.vars()
+	if len(ids) == len(vars)-1 && vars[0] == "app" {
+		if a, err := ctx.App(); err == nil && a != "" {
+			ids = append([]string{a}, ids...)
+		}
+	}
+	if len(ids) != len(vars) {
+		printError("%s %s needs identities for: %s", typ, action, strings.Join(vars, ", "))
+		exit(2)
+	}
+
+	var res interface{}
+	if body != nil {
+		must(ctx.Client.APIReq(&res, link.Method, link.expand(ids), body))
+	} else {
+		must(ctx.Client.APIReq(&res, link.Method, link.expand(ids), nil))
+	}
+	if printList(ctx.Stdout, res) {
+		return
+	}
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	switch res := res.(type) {
+	case []interface{}:
+		listResources(w, res)
+	case map[string]interface{}:
+		fields := make(map[string]string)
+		flattenResource("", res, fields)
+		var keys []string
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			listRec(w, k+":", fields[k])
+		}
+	default:
+		fmt.Fprintln(w, formatResourceValue(res))
+	}
+}
+
+// resourceParam returns the value to send for a parameter given on the
+// command line: s as JSON if it is valid JSON, or else s itself.
+func resourceParam(s string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err == nil {
+		return v
+	}
+	return s
+}
+
+// listResources prints a table of objs, with a header row naming the
+// columns. The columns are the top-level fields that have scalar values,
+// starting with name and id.
+func listResources(w *tabwriter.Writer, objs []interface{}) {
+	seen := make(map[string]bool)
+	var cols []string
+	for _, o := range objs {
+		m, _ := o.(map[string]interface{})
+		for k, v := range m {
+			switch v.(type) {
+			case map[string]interface{}, []interface{}:
+				continue
+			}
+			if !seen[k] {
+				seen[k] = true
+				cols = append(cols, k)
+			}
+		}
+	}
+	sort.Sort(resourceColumns(cols))
+	if len(cols) == 0 {
+		return
+	}
+	header := make([]interface{}, len(cols))
+	for i, c := range cols {
+		header[i] = c
+	}
+	listRec(w, header...)
+	for _, o := range objs {
+		m, _ := o.(map[string]interface{})
+		vals := make([]interface{}, len(cols))
+		for i, c := range cols {
+			vals[i] = formatResourceValue(m[c])
+		}
+		listRec(w, vals...)
+	}
+}
+
+// resourceColumns sorts column names alphabetically, except that name and
+// id come first.
+type resourceColumns []string
+
+func (a resourceColumns) Len() int      { return len(a) }
+func (a resourceColumns) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a resourceColumns) Less(i, j int) bool {
+	ri, rj := resourceColumnRank(a[i]), resourceColumnRank(a[j])
+	if ri != rj {
+		return ri < rj
+	}
+	return a[i] < a[j]
+}
+
+func resourceColumnRank(col string) int {
+	switch col {
+	case "name":
+		return 0
+	case "id":
+		return 1
+	}
+	return 2
+}
+
+// flattenResource adds the fields of v to fields, naming nested fields
+// by their path, e.g. "app.name".
+func flattenResource(prefix string, v map[string]interface{}, fields map[string]string) {
+	for k, x := range v {
+		if m, ok := x.(map[string]interface{}); ok {
+			flattenResource(prefix+k+".", m, fields)
+			continue
+		}
+		fields[prefix+k] = formatResourceValue(x)
+	}
+}
+
+// formatResourceValue formats a JSON value for a table: strings as they
+// are, null as nothing, and anything else as JSON.
+func formatResourceValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
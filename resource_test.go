@@ -0,0 +1,45 @@
+package hk
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"text/tabwriter"
+)
+
+func TestListResources(t *testing.T) {
+	var objs []interface{}
+	err := json.Unmarshal([]byte(`[
+		{"kind": "heroku", "id": "d1", "hostname": "myapp.herokuapp.com", "app": {"name": "myapp"}},
+		{"kind": "custom", "id": "d2", "hostname": "www.example.com", "cname": null, "name": "www"}
+	]`), &objs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 1, 2, 2, ' ', 0)
+	listResources(w, objs)
+	w.Flush()
+	want := "" +
+		"name  id  cname  hostname             kind\n" +
+		"      d1         myapp.herokuapp.com  heroku\n" +
+		"www   d2         www.example.com      custom\n"
+	if buf.String() != want {
+		t.Errorf("listResources =>\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestFlattenResource(t *testing.T) {
+	var obj map[string]interface{}
+	err := json.Unmarshal([]byte(`{"id": "d1", "app": {"name": "myapp"}, "size": 2, "tags": ["a"]}`), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := make(map[string]string)
+	flattenResource("", obj, fields)
+	want := map[string]string{"id": "d1", "app.name": "myapp", "size": "2", "tags": `["a"]`}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("flattenResource => %v, want %v", fields, want)
+	}
+}
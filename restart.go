@@ -1,20 +1,37 @@
-package main
+package hk
 
 import (
+	"fmt"
 	"log"
-	"os"
 	"strings"
+	"time"
+
+	"github.com/bgentry/heroku-go"
 )
 
 var cmdRestart = &Command{
 	Run:      runRestart,
-	Usage:    "restart [<type or name>]",
+	Usage:    "restart [--rolling [--batch <n>] [--wait <duration>] [--timeout <duration>]] [<type or name>]",
 	NeedsApp: true,
 	Category: "dyno",
 	Short:    "restart dynos",
 	Long: `
 Restart all app dynos, all dynos of a specific type, or a single dyno.
 
+With --rolling, dynos are restarted in batches, and each batch must
+be up again before the next is restarted, so some dynos of each type
+keep serving throughout. If a restarted dyno crashes, or isn't up
+before the timeout, the rolling restart stops.
+
+Options:
+
+    --rolling             restart dynos in batches instead of all at once
+    --batch <n>           number of dynos per batch (default 1)
+    --wait <duration>     time to wait between batches, after the
+                          batch is up (default 30s)
+    --timeout <duration>  time to wait for each batch to be up
+                          (default 5m)
+
 Examples:
 
     $ hk restart
@@ -25,22 +42,47 @@ Examples:
 
     $ hk restart web.1
     Restarted web.1 dyno on myapp.
+
+    $ hk restart --rolling --batch 2 --wait 1m web
+    Restarted web.1, web.2 (batch 1 of 2), up after 12s.
+    Restarted web.3, web.4 (batch 2 of 2), up after 9s.
+    Restarted web dynos for myapp.
 `,
 }
 
-func runRestart(cmd *Command, args []string) {
-	appname := mustApp()
-	if len(args) > 1 {
-		cmd.printUsage()
-		os.Exit(2)
+var (
+	flagRestartRolling bool
+	flagRestartBatch   int
+	flagRestartWait    time.Duration
+	flagRestartTimeout time.Duration
+)
+
+func init() {
+	cmdRestart.Flag.BoolVar(&flagRestartRolling, "rolling", false, "restart dynos in batches")
+	cmdRestart.Flag.IntVar(&flagRestartBatch, "batch", 1, "number of dynos per batch")
+	cmdRestart.Flag.DurationVar(&flagRestartWait, "wait", 30*time.Second, "time to wait between batches")
+	cmdRestart.Flag.DurationVar(&flagRestartTimeout, "timeout", 5*time.Minute, "time to wait for each batch to be up")
+}
+
+func runRestart(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) > 1 || flagRestartBatch < 1 {
+		ctx.printUsage()
+		exit(2)
 	}
 
 	target := "all"
-	if len(args) == 1 {
+	switch {
+	case flagRestartRolling:
+		if len(args) == 1 {
+			target = args[0]
+		}
+		rollingRestart(appname, args, flagRestartBatch, flagRestartWait, flagRestartTimeout)
+	case len(args) == 1:
 		target = args[0]
-		must(client.DynoRestart(appname, target))
-	} else {
-		must(client.DynoRestartAll(appname))
+		must(ctx.Client.DynoRestart(appname, target))
+	default:
+		must(ctx.Client.DynoRestartAll(appname))
 	}
 
 	switch {
@@ -50,3 +92,56 @@ func runRestart(cmd *Command, args []string) {
 		log.Printf("Restarted %s dynos for %s.", target, appname)
 	}
 }
+
+// rollingRestart restarts the dynos matching names in batches of size
+// batch. After each batch it waits up to timeout for the batch to be up,
+// and then pauses for wait.
+func rollingRestart(appname string, names []string, batch int, wait, timeout time.Duration) {
+	dynos := findDynos(appname, names)
+	nbatches := (len(dynos) + batch - 1) / batch
+	for i := 0; i < nbatches; i++ {
+		if i > 0 {
+			time.Sleep(wait)
+		}
+		var restarted []heroku.Dyno
+		var batchNames []string
+		start := time.Now()
+		for j := i * batch; j < len(dynos) && j < (i+1)*batch; j++ {
+			must(client.DynoRestart(appname, dynos[j].Name))
+			restarted = append(restarted, dynos[j])
+			batchNames = append(batchNames, dynos[j].Name)
+		}
+		if err := waitDynosUp(appname, restarted, timeout); err != nil {
+			printFatal("%s. Stopped the rolling restart after batch %d of %d.", err, i+1, nbatches)
+		}
+		log.Printf("Restarted %s (batch %d of %d), up after %s.", strings.Join(batchNames, ", "), i+1, nbatches, time.Since(start).Round(time.Second))
+	}
+}
+
+// how often to check on dynos waiting to come up after a restart
+const dynoUpPollInterval = 2 * time.Second
+
+// waitDynosUp waits until each of dynos has been replaced by a dyno that is
+// up. A dyno counts as replaced once it was updated later than it was in
+// dynos, so local and API clocks needn't agree.
+func waitDynosUp(appname string, dynos []heroku.Dyno, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for _, old := range dynos {
+		for {
+			d, err := client.DynoInfo(appname, old.Name)
+			if err == nil && d.UpdatedAt.After(old.UpdatedAt) {
+				if d.State == "up" {
+					break
+				}
+				if d.State == "crashed" {
+					return fmt.Errorf("%s crashed after restarting", d.Name)
+				}
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("%s wasn't up %s after restarting", old.Name, timeout)
+			}
+			time.Sleep(dynoUpPollInterval)
+		}
+	}
+	return nil
+}
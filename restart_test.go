@@ -0,0 +1,52 @@
+package hk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+// fakeDynos is an API whose DynoInfo reports each dyno's states in
+// turn, one per call, repeating the last.
+type fakeDynos struct {
+	API
+	states map[string][]heroku.Dyno
+}
+
+func (f *fakeDynos) DynoInfo(appIdentity, dynoIdentity string) (*heroku.Dyno, error) {
+	s := f.states[dynoIdentity]
+	d := s[0]
+	if len(s) > 1 {
+		f.states[dynoIdentity] = s[1:]
+	}
+	return &d, nil
+}
+
+func TestWaitDynosUp(t *testing.T) {
+	defer func(c API) { client = c }(client)
+	before := time.Date(2014, 1, 13, 21, 0, 0, 0, time.UTC)
+	after := before.Add(time.Minute)
+	old := []heroku.Dyno{{Name: "web.1", State: "up", UpdatedAt: before}}
+
+	client = &fakeDynos{states: map[string][]heroku.Dyno{
+		"web.1": {{Name: "web.1", State: "starting", UpdatedAt: after}, {Name: "web.1", State: "up", UpdatedAt: after}},
+	}}
+	if err := waitDynosUp("myapp", old, time.Minute); err != nil {
+		t.Errorf("waitDynosUp => %v, want nil", err)
+	}
+
+	client = &fakeDynos{states: map[string][]heroku.Dyno{
+		"web.1": {{Name: "web.1", State: "crashed", UpdatedAt: after}},
+	}}
+	if err := waitDynosUp("myapp", old, time.Minute); err == nil {
+		t.Errorf("waitDynosUp with crashed dyno => nil, want error")
+	}
+
+	client = &fakeDynos{states: map[string][]heroku.Dyno{
+		"web.1": {{Name: "web.1", State: "up", UpdatedAt: before}},
+	}}
+	if err := waitDynosUp("myapp", old, 0); err == nil {
+		t.Errorf("waitDynosUp with unreplaced dyno => nil, want error")
+	}
+}
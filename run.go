@@ -1,13 +1,15 @@
-package main
+package hk
 
 import (
 	"bufio"
 	"crypto/tls"
 	"io"
 	"log"
+	"net"
 	"net/url"
 	"os"
 	"os/signal"
+	"regexp"
 	"strconv"
 	"strings"
 	"syscall"
@@ -18,12 +20,13 @@ import (
 
 var (
 	detachedRun bool
+	tailRun     bool
 	dynoSize    string
 )
 
 var cmdRun = &Command{
 	Run:      runRun,
-	Usage:    "run [-s <size>] [-d] <command> [<argument>...]",
+	Usage:    "run [-s <size>] [-d [--tail]] <command> [<argument>...]",
 	NeedsApp: true,
 	Category: "dyno",
 	Short:    "run a process in a dyno",
@@ -32,8 +35,10 @@ Run a process on Heroku
 
 Options:
 
-    -s <size>  set the size for this dyno (e.g. 2X)
-    -d         run in detached mode instead of attached to terminal
+    -s <size>         set the size for this dyno (e.g. 2X)
+    -d, --detached    run in detached mode instead of attached to
+                      terminal
+    --tail            with -d, stream the dyno's log until it exits
 
 Examples:
 
@@ -48,19 +53,27 @@ Examples:
 
     $ hk run -d bin/my_worker
     Ran ` + "`" + `bin/my_worker` + "`" + ` on myapp as run.4321, detached.
+
+    $ hk run -d --tail rake db:migrate
+    Ran ` + "`" + `rake db:migrate` + "`" + ` on myapp as run.8765, detached.
+    2013-10-17T00:17:35.066089+00:00 heroku[run.8765]: Starting process with command ` + "`" + `rake db:migrate` + "`" + `
+    2013-10-17T00:17:36.123456+00:00 app[run.8765]: Migrating to AddIndexToUsers
+    2013-10-17T00:17:40.654321+00:00 heroku[run.8765]: State changed from up to complete
 `,
 }
 
 func init() {
 	cmdRun.Flag.BoolVar(&detachedRun, "d", false, "detached")
+	cmdRun.Flag.BoolVar(&detachedRun, "detached", false, "detached")
+	cmdRun.Flag.BoolVar(&tailRun, "tail", false, "stream logs of detached dyno")
 	cmdRun.Flag.StringVar(&dynoSize, "s", "", "dyno size")
 }
 
-func runRun(cmd *Command, args []string) {
-	appname := mustApp()
-	if len(args) == 0 {
-		cmd.printUsage()
-		os.Exit(2)
+func runRun(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) == 0 || (tailRun && !detachedRun) {
+		ctx.printUsage()
+		exit(2)
 	}
 
 	cols, err := term.Cols()
@@ -84,53 +97,71 @@ func runRun(cmd *Command, args []string) {
 	}
 	if dynoSize != "" {
 		if !strings.HasSuffix(dynoSize, "X") {
-			cmd.printUsage()
-			os.Exit(2)
+			ctx.printUsage()
+			exit(2)
 		}
 		opts.Size = &dynoSize
 	}
 
 	command := strings.Join(args, " ")
-	dyno, err := client.DynoCreate(appname, command, &opts)
+	dyno, err := ctx.Client.DynoCreate(appname, command, &opts)
 	must(err)
 
 	if detachedRun {
 		log.Printf("Ran `%s` on %s as %s, detached.", dyno.Command, appname, dyno.Name)
+		if tailRun {
+			tail, lines := true, 100
+			opts := heroku.LogSessionCreateOpts{Dyno: &dyno.Name, Tail: &tail, Lines: &lines}
+			streamLog(ctx.Stdout, appname, &opts, logFilter{dyno: dyno.Name}, dynoExited(dyno.Name))
+		}
 		return
 	}
 	log.Printf("Running `%s` on %s as %s:", dyno.Command, appname, dyno.Name)
 
-	u, err := url.Parse(*dyno.AttachURL)
+	cn, br, err := dialDyno(*dyno.AttachURL)
 	if err != nil {
 		printFatal(err.Error())
 	}
+	defer cn.Close()
+	pipeDyno(ctx.Stdout, cn, br)
+}
 
+// dialDyno connects to an attached dyno's rendezvous URL. It returns the
+// connection, for input, and a reader of the dyno's output.
+func dialDyno(attachURL string) (net.Conn, *bufio.Reader, error) {
+	u, err := url.Parse(attachURL)
+	if err != nil {
+		return nil, nil, err
+	}
 	cn, err := tls.Dial("tcp", u.Host, nil)
 	if err != nil {
-		printFatal(err.Error())
+		return nil, nil, err
 	}
-	defer cn.Close()
-
 	br := bufio.NewReader(cn)
-
-	_, err = io.WriteString(cn, u.Path[1:]+"\r\n")
-	if err != nil {
-		printFatal(err.Error())
+	if _, err = io.WriteString(cn, u.Path[1:]+"\r\n"); err != nil {
+		cn.Close()
+		return nil, nil, err
 	}
-
+	// skip the rendezvous server's greeting line
 	for {
 		_, pre, err := br.ReadLine()
 		if err != nil {
-			printFatal(err.Error())
+			cn.Close()
+			return nil, nil, err
 		}
 		if !pre {
 			break
 		}
 	}
+	return cn, br, nil
+}
 
-	if term.IsTerminal(os.Stdin) && term.IsTerminal(os.Stdout) {
-		err = term.MakeRaw(os.Stdin)
-		if err != nil {
+// pipeDyno connects stdin and w to an attached dyno until either side
+// closes. At a terminal, interrupt and quit signals are forwarded to the
+// dyno.
+func pipeDyno(w io.Writer, cn net.Conn, br *bufio.Reader) {
+	if f, ok := w.(*os.File); ok && term.IsTerminal(os.Stdin) && term.IsTerminal(f) {
+		if err := term.MakeRaw(os.Stdin); err != nil {
 			printFatal(err.Error())
 		}
 		defer term.Restore(os.Stdin)
@@ -158,9 +189,19 @@ func runRun(cmd *Command, args []string) {
 		errc <- err
 	}
 
-	go cp(os.Stdout, br)
+	go cp(w, br)
 	go cp(cn, os.Stdin)
-	if err = <-errc; err != nil {
+	if err := <-errc; err != nil {
 		printFatal(err.Error())
 	}
 }
+
+// dynoExited returns a streamLog until function that stops at the log line
+// recording that dyno finished, from whatever state it was in: a dyno that
+// fails while booting goes from starting to crashed.
+func dynoExited(dyno string) func(line string) bool {
+	re := regexp.MustCompile(`\sheroku\[` + regexp.QuoteMeta(dyno) + `\]: State changed from \w+ to (complete|crashed)\b`)
+	return func(line string) bool {
+		return re.MatchString(line)
+	}
+}
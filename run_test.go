@@ -0,0 +1,23 @@
+package hk
+
+import "testing"
+
+func TestDynoExited(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"2013-10-17T00:17:40.654321+00:00 heroku[run.8765]: State changed from up to complete", true},
+		{"2013-10-17T00:17:40.654321+00:00 heroku[run.8765]: State changed from up to crashed", true},
+		{"2013-10-17T00:17:40.654321+00:00 heroku[run.8765]: State changed from starting to crashed", true},
+		{"2013-10-17T00:17:40.654321+00:00 heroku[run.8765]: State changed from starting to up", false},
+		{"2013-10-17T00:17:40.654321+00:00 heroku[run.87651]: State changed from up to complete", false},
+		{"2013-10-17T00:17:40.654321+00:00 app[run.8765]: State changed from up to complete", false},
+	}
+	exited := dynoExited("run.8765")
+	for i, tt := range tests {
+		if got := exited(tt.line); got != tt.want {
+			t.Errorf("%d. dynoExited(%q) => %t, want %t", i, tt.line, got, tt.want)
+		}
+	}
+}
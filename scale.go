@@ -1,28 +1,46 @@
-package main
+package hk
 
 import (
+	"bufio"
 	"errors"
+	"io"
 	"log"
-	"os"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/bgentry/heroku-go"
 )
 
 var cmdScale = &Command{
 	Run:      runScale,
-	Usage:    "scale <type>=[<qty>]:[<size>]...",
+	Usage:    "scale [-q] [<type>=[[+|-]<qty>]:[<size>]...]",
 	NeedsApp: true,
 	Category: "dyno",
 	Short:    "change dyno quantities and sizes",
 	Long: `
 Scale changes the quantity of dynos (horizontal scale) and/or the
 dyno size (vertical scale) for each process type. Note that
-changing dyno size will restart all dynos of that type.
+changing dyno size will restart all dynos of that type. A quantity
+starting with + or - changes the current quantity by that many
+dynos. All changes are made in one update.
 
-Example:
+With no arguments, scale shows the current formation: each process
+type's quantity and size, with an estimate of its monthly cost.
+
+Options:
+
+    -q  don't suggest next steps when scaling fails
+
+Examples:
+
+    $ hk scale
+    web     2  Standard-1X  $50/mo
+    worker  1  Standard-2X  $50/mo
+    total   3               $100/mo
 
     $ hk scale web=2
     Scaled myapp to web=2:1X.
@@ -32,33 +50,50 @@ Example:
 
     $ hk scale web=PX worker=1X
     Scaled myapp to web=2:PX, worker=5:1X.
+
+    $ hk scale web=+1 worker=-2:standard-2x
+    Scaled myapp to web=3:PX, worker=3:STANDARD-2X.
 `,
 }
 
-// takes args of the form "web=1", "worker=3X", web=4:2X etc
-func runScale(cmd *Command, args []string) {
-	appname := mustApp()
+// takes args of the form "web=1", "worker=3X", web=4:2X, web=+1 etc
+func runScale(ctx *Context, args []string) {
+	appname := ctx.MustApp()
 	if len(args) == 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		formations, err := ctx.Client.FormationList(appname, nil)
+		must(err)
+		sort.Sort(formationsByType(formations))
+		w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+		defer w.Flush()
+		listFormations(w, formations)
+		return
 	}
 	todo := make([]heroku.FormationBatchUpdateOpts, len(args))
 	types := make(map[string]bool)
+	var current map[string]int
 	for i, arg := range args {
-		pstype, qty, size, err := parseScaleArg(arg)
+		pstype, qty, delta, size, err := parseScaleArg(arg)
 		if err != nil {
-			cmd.printUsage()
-			os.Exit(2)
+			ctx.printUsage()
+			exit(2)
 		}
 		if _, exists := types[pstype]; exists {
 			// can only specify each process type once
-			cmd.printUsage()
-			os.Exit(2)
+			ctx.printUsage()
+			exit(2)
 		}
 		types[pstype] = true
 
+		if delta {
+			if current == nil {
+				current = formationQuantities(appname)
+			}
+			if qty += current[pstype]; qty < 0 {
+				printFatal("can't scale %s below 0 dynos.", pstype)
+			}
+		}
 		opt := heroku.FormationBatchUpdateOpts{Process: pstype}
-		if qty != -1 {
+		if qty != -1 || delta {
 			opt.Quantity = &qty
 		}
 		if size != "" {
@@ -67,8 +102,12 @@ func runScale(cmd *Command, args []string) {
 		todo[i] = opt
 	}
 
-	formations, err := client.FormationBatchUpdate(appname, todo)
-	must(err)
+	formations, err := ctx.Client.FormationBatchUpdate(appname, todo)
+	if err != nil {
+		printError(err.Error())
+		printTip("See https://devcenter.heroku.com/articles/dyno-types for the dyno sizes available.")
+		exit(1)
+	}
 
 	sortedFormations := formationsByType(formations)
 	sort.Sort(sortedFormations)
@@ -83,9 +122,57 @@ func runScale(cmd *Command, args []string) {
 	log.Printf("Scaled %s to %s.", appname, strings.Join(results, ", "))
 }
 
+// formationQuantities returns the number of dynos of each process type.
+func formationQuantities(appname string) map[string]int {
+	formations, err := client.FormationList(appname, nil)
+	must(err)
+	m := make(map[string]int)
+	for _, f := range formations {
+		m[f.Type] = f.Quantity
+	}
+	return m
+}
+
+// dynoMonthlyCost is the list price in US dollars of running one dyno of
+// each size for a month. Sizes billed some other way, such as eco dynos,
+// aren't listed.
+var dynoMonthlyCost = map[string]int{
+	"1X":                36,
+	"2X":                72,
+	"PX":                576,
+	"BASIC":             7,
+	"HOBBY":             7,
+	"STANDARD-1X":       25,
+	"STANDARD-2X":       50,
+	"PERFORMANCE-M":     250,
+	"PERFORMANCE-L":     500,
+	"PERFORMANCE-L-RAM": 500,
+	"PERFORMANCE-XL":    750,
+	"PERFORMANCE-2XL":   1500,
+}
+
+// listFormations prints each formation's type, quantity, size, and
+// estimated monthly cost, followed by the totals.
+func listFormations(w io.Writer, formations []heroku.Formation) {
+	dynos, total := 0, 0
+	for _, f := range formations {
+		cost := ""
+		if c, ok := dynoMonthlyCost[strings.ToUpper(f.Size)]; ok {
+			cost = "$" + strconv.Itoa(c*f.Quantity) + "/mo"
+			total += c * f.Quantity
+		}
+		dynos += f.Quantity
+		listRec(w, f.Type, f.Quantity, f.Size, cost)
+	}
+	listRec(w, "total", dynos, "", "$"+strconv.Itoa(total)+"/mo")
+}
+
 var errInvalidScaleArg = errors.New("invalid argument")
 
-func parseScaleArg(arg string) (pstype string, qty int, size string, err error) {
+// parseScaleArg parses a scale argument. A qty of -1 means the quantity
+// is unchanged, unless delta is set, in which case qty is a change to the
+// current quantity.
+func parseScaleArg(arg string) (pstype string, qty int, delta bool, size string, err error) {
 	qty = -1
 	iEquals := strings.IndexRune(arg, '=')
 	if fields := strings.Fields(arg); len(fields) > 1 || iEquals == -1 {
@@ -100,34 +187,161 @@ func parseScaleArg(arg string) (pstype string, qty int, size string, err error)
 		return
 	}
 
-	if iColon := strings.IndexRune(rem, ':'); iColon == -1 {
-		if iX := strings.IndexRune(rem, 'X'); iX == -1 {
-			qty, err = strconv.Atoi(rem)
-			if err != nil {
-				return pstype, -1, "", errInvalidScaleArg
-			}
-		} else {
-			size = rem
+	qtyPart, sizePart := rem, ""
+	if iColon := strings.IndexRune(rem, ':'); iColon != -1 {
+		qtyPart, sizePart = rem[:iColon], rem[iColon+1:]
+	} else if !isScaleQuantity(rem) {
+		if !strings.ContainsAny(rem, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") || rem[0] == '+' || rem[0] == '-' {
+			return pstype, -1, false, "", errInvalidScaleArg
 		}
-	} else {
-		if iColon > 0 {
-			qty, err = strconv.Atoi(rem[:iColon])
-			if err != nil {
-				return pstype, -1, "", errInvalidScaleArg
-			}
+		qtyPart, sizePart = "", rem
+	}
+	if qtyPart != "" {
+		if !isScaleQuantity(qtyPart) {
+			return pstype, -1, false, "", errInvalidScaleArg
 		}
-		if len(rem) > iColon+1 {
-			size = rem[iColon+1:]
+		delta = qtyPart[0] == '+' || qtyPart[0] == '-'
+		qty, err = strconv.Atoi(strings.TrimPrefix(qtyPart, "+"))
+		if err != nil {
+			return pstype, -1, false, "", errInvalidScaleArg
 		}
 	}
-	if err != nil || qty == -1 && size == "" {
+	size = sizePart
+	if qty == -1 && !delta && size == "" {
 		err = errInvalidScaleArg
 	}
 	return
 }
 
+// isScaleQuantity reports whether s is a quantity, optionally signed.
+func isScaleQuantity(s string) bool {
+	if s != "" && (s[0] == '+' || s[0] == '-') {
+		s = s[1:]
+	}
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 type formationsByType []heroku.Formation
 
 func (f formationsByType) Len() int           { return len(f) }
 func (f formationsByType) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
 func (f formationsByType) Less(i, j int) bool { return f[i].Type < f[j].Type }
+
+var cmdScaleHistory = &Command{
+	Run:      runScaleHistory,
+	Usage:    "scale-history [-n <lines>]",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "show when dyno formation changed" + extra,
+	Long: `
+Scale-history reconstructs a timeline of formation changes for an
+app. Shows the time of each change, who made it (if known), and the
+change itself.
+
+The Heroku API doesn't record scaling as a release, so the timeline
+is built from the "Scale to" lines the API writes to the app's log,
+plus the last time each process type's formation was updated. The
+log only keeps recent lines, so older changes may be missing.
+
+Options:
+
+    -n <lines>  number of log lines to look for changes in
+                (default 1500)
+
+Example:
+
+    $ hk scale-history
+    Jan 10 12:00  bob  Scale to web=3, worker=1
+    Jan 12 10:02       worker=5:2X (current)
+    Jan 13 18:31       web=2:1X (current)
+`,
+}
+
+var flagScaleHistoryLines int
+
+func init() {
+	cmdScaleHistory.Flag.IntVar(&flagScaleHistoryLines, "n", 1500, "number of log lines")
+}
+
+func runScaleHistory(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+
+	formations, err := ctx.Client.FormationList(appname, nil)
+	must(err)
+	source, dyno := "heroku", "api"
+	body := openLog(appname, &heroku.LogSessionCreateOpts{Lines: &flagScaleHistoryLines, Source: &source, Dyno: &dyno})
+	defer body.Close()
+	scales, err := readScaleLines(bufio.NewScanner(body))
+	must(err)
+
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	listScaleEvents(w, scaleEvents(scales, formations))
+}
+
+type scaleEvent struct {
+	When   time.Time
+	Who    string
+	Change string
+}
+
+// e.g. "2014-01-10T12:00:00.123456+00:00 heroku[api]: Scale to web=3, worker=1 by bob@example.com"
+var scaleLineRE = regexp.MustCompile(`^(\S+) heroku\[api\]: (Scale to [\w-]+=\S.*?)(?: by (\S+@\S+))?$`)
+
+// readScaleLines returns the formation changes in the API's log lines read
+// by s. Who is the user's email without its domain.
+func readScaleLines(s *bufio.Scanner) ([]scaleEvent, error) {
+	var events []scaleEvent
+	for s.Scan() {
+		m := scaleLineRE.FindStringSubmatch(s.Text())
+		if m == nil {
+			continue
+		}
+		when, err := time.Parse(time.RFC3339, m[1])
+		if err != nil {
+			continue
+		}
+		events = append(events, scaleEvent{when, strings.SplitN(m[3], "@", 2)[0], m[2]})
+	}
+	return events, s.Err()
+}
+
+// scaleEvents merges scaling changes and current formations into a single
+// timeline, oldest first.
+func scaleEvents(scales []scaleEvent, formations []heroku.Formation) []scaleEvent {
+	events := append([]scaleEvent(nil), scales...)
+	for _, f := range formations {
+		change := f.Type + "=" + strconv.Itoa(f.Quantity) + ":" + f.Size + " (current)"
+		events = append(events, scaleEvent{f.UpdatedAt, "", change})
+	}
+	sort.Sort(scaleEventsByTime(events))
+	return events
+}
+
+func listScaleEvents(w io.Writer, events []scaleEvent) {
+	for _, e := range events {
+		listRec(w,
+			prettyTime{e.When},
+			abbrev(e.Who, 10),
+			e.Change,
+		)
+	}
+}
+
+type scaleEventsByTime []scaleEvent
+
+func (a scaleEventsByTime) Len() int           { return len(a) }
+func (a scaleEventsByTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a scaleEventsByTime) Less(i, j int) bool { return a[i].When.Before(a[j].When) }
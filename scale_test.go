@@ -1,42 +1,59 @@
-package main
+package hk
 
 import (
+	"bufio"
+	"bytes"
+	"strings"
 	"testing"
+	"text/tabwriter"
+
+	"github.com/bgentry/heroku-go"
 )
 
 var parseScaleTests = []struct {
 	in     string
 	pstype string
 	qty    int
+	delta  bool
 	size   string
 	err    error
 }{
-	{"web=5", "web", 5, "", nil},
-	{"bg_worker=50:1X", "bg_worker", 50, "1X", nil},
-	{"bg_worker=50:PX", "bg_worker", 50, "PX", nil},
-	{"web=:2X", "web", -1, "2X", nil},
-	{"web=:PX", "web", -1, "PX", nil},
-	{"web=1X", "web", -1, "1X", nil},
-	{"web=1x", "web", -1, "1X", nil},
-	{"web=PX", "web", -1, "PX", nil},
-	{"web=px", "web", -1, "PX", nil},
-	{"web=1X:5", "web", -1, "", errInvalidScaleArg},
-	{"web=PX:5", "web", -1, "", errInvalidScaleArg},
-	{"web", "", -1, "", errInvalidScaleArg},
-	{"web=", "web", -1, "", errInvalidScaleArg},
-	{"web =", "", -1, "", errInvalidScaleArg},
-	{"web=1X: 5", "", -1, "", errInvalidScaleArg},
+	{"web=5", "web", 5, false, "", nil},
+	{"bg_worker=50:1X", "bg_worker", 50, false, "1X", nil},
+	{"bg_worker=50:PX", "bg_worker", 50, false, "PX", nil},
+	{"web=:2X", "web", -1, false, "2X", nil},
+	{"web=:PX", "web", -1, false, "PX", nil},
+	{"web=1X", "web", -1, false, "1X", nil},
+	{"web=1x", "web", -1, false, "1X", nil},
+	{"web=PX", "web", -1, false, "PX", nil},
+	{"web=px", "web", -1, false, "PX", nil},
+	{"web=1X:5", "web", -1, false, "", errInvalidScaleArg},
+	{"web=PX:5", "web", -1, false, "", errInvalidScaleArg},
+	{"web", "", -1, false, "", errInvalidScaleArg},
+	{"web=", "web", -1, false, "", errInvalidScaleArg},
+	{"web =", "", -1, false, "", errInvalidScaleArg},
+	{"web=1X: 5", "", -1, false, "", errInvalidScaleArg},
+	{"web=2:standard-2x", "web", 2, false, "STANDARD-2X", nil},
+	{"web=performance-m", "web", -1, false, "PERFORMANCE-M", nil},
+	{"web=+1", "web", 1, true, "", nil},
+	{"web=-1", "web", -1, true, "", nil},
+	{"web=+2:standard-1x", "web", 2, true, "STANDARD-1X", nil},
+	{"web=+", "web", -1, false, "", errInvalidScaleArg},
+	{"web=1-", "web", -1, false, "", errInvalidScaleArg},
 }
 
 func TestParseScaleArg(t *testing.T) {
 	for i, pt := range parseScaleTests {
-		pstype, qty, size, err := parseScaleArg(pt.in)
+		pstype, qty, delta, size, err := parseScaleArg(pt.in)
 		if pstype != pt.pstype {
 			t.Errorf("%d. parseScaleArg(%q).pstype => %q, want %q", i, pt.in, pstype, pt.pstype)
 		}
 		if qty != pt.qty {
 			t.Errorf("%d. parseScaleArg(%q).qty => %d, want %d", i, pt.in, qty, pt.qty)
 		}
+		if delta != pt.delta {
+			t.Errorf("%d. parseScaleArg(%q).delta => %t, want %t", i, pt.in, delta, pt.delta)
+		}
 		if size != pt.size {
 			t.Errorf("%d. parseScaleArg(%q).size => %q, want %q", i, pt.in, size, pt.size)
 		}
@@ -45,3 +62,59 @@ func TestParseScaleArg(t *testing.T) {
 		}
 	}
 }
+
+func TestListFormations(t *testing.T) {
+	formations := []heroku.Formation{
+		{Type: "web", Quantity: 2, Size: "Standard-1X"},
+		{Type: "worker", Quantity: 1, Size: "Standard-2X"},
+		{Type: "clock", Quantity: 1, Size: "Eco"},
+	}
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 1, 2, 2, ' ', 0)
+	listFormations(w, formations)
+	w.Flush()
+	want := "" +
+		"web     2  Standard-1X  $50/mo\n" +
+		"worker  1  Standard-2X  $50/mo\n" +
+		"clock   1  Eco          \n" +
+		"total   4               $100/mo\n"
+	if buf.String() != want {
+		t.Errorf("listFormations =>\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+var scaleLineTests = []struct {
+	line   string
+	ok     bool
+	who    string
+	change string
+}{
+	{"2014-01-10T12:00:00.123456+00:00 heroku[api]: Scale to web=3, worker=1 by bob@example.com", true, "bob", "Scale to web=3, worker=1"},
+	{"2014-01-10T12:00:00+00:00 heroku[api]: Scale to web=2:Standard-2X", true, "", "Scale to web=2:Standard-2X"},
+	{"2014-01-10T12:00:00+00:00 heroku[api]: Set SCALYR_API_KEY config vars by bob@example.com", false, "", ""},
+	{"2014-01-10T12:00:00+00:00 heroku[api]: Deploy 3ae20c2 by bob@example.com", false, "", ""},
+	{"2014-01-10T12:00:00+00:00 app[web.1]: Scale to web=3 by mallory@example.com", false, "", ""},
+	{"2014-01-10T12:00:00+00:00 heroku[web.1]: State changed from up to down", false, "", ""},
+}
+
+func TestReadScaleLines(t *testing.T) {
+	for i, st := range scaleLineTests {
+		events, err := readScaleLines(bufio.NewScanner(strings.NewReader(st.line)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !st.ok {
+			if len(events) != 0 {
+				t.Errorf("%d. got %v, want no changes", i, events)
+			}
+			continue
+		}
+		if len(events) != 1 {
+			t.Errorf("%d. got %d changes, want 1", i, len(events))
+			continue
+		}
+		if e := events[0]; e.Who != st.who || e.Change != st.change || e.When.Year() != 2014 {
+			t.Errorf("%d. got %+v, want who %q, change %q", i, e, st.who, st.change)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heroku/hk/scheduler"
+)
+
+var cmdSchedule = &Command{
+	Run:      runSchedule,
+	Usage:    "schedule",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "list scheduled jobs" + extra,
+	Long: `
+Schedule lists the jobs that the Heroku Scheduler add-on runs on an
+app, with how often each runs, its dyno size, and when it last ran and
+will next run. Times are in UTC.
+
+Example:
+
+    $ hk schedule
+    id                                    every  at     size         last run              next run              command
+    5d9c7b1a-2f3e-4a6b-8c9d-0e1f2a3b4c5d  day    08:30  Standard-1X  2014-01-13T08:30:04Z  2014-01-14T08:30:00Z  rake cleanup
+    6e0d8c2b-3a4f-5b7c-9d0e-1f2a3b4c5d6e  10m           Standard-1X  2014-01-13T21:20:02Z  2014-01-13T21:30:00Z  bin/sync-feeds
+`,
+}
+
+func runSchedule(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	mustHaveScheduler(ctx, appname)
+	jobs, err := ctx.Scheduler.Jobs(appname)
+	must(err)
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	listRec(w, "id", "every", "at", "size", "last run", "next run", "command")
+	for _, j := range jobs {
+		listRec(w, j.Id, scheduleEvery[j.Frequency], j.At, j.DynoSize, formatRunTime(j.LastRun), formatRunTime(j.NextRun), j.Command)
+	}
+}
+
+func formatRunTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
+
+var cmdScheduleAdd = &Command{
+	Run:      runScheduleAdd,
+	Usage:    "schedule-add --every 10m|hour|day [--at <time>] [-s <size>] <command>",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "add a scheduled job" + extra,
+	Long: `
+Schedule-add adds a job to the Heroku Scheduler add-on, which runs
+the command in a one-off dyno every ten minutes, hour, or day.
+
+With --at, an hourly job runs at the given minute past the hour,
+e.g. :20, and a daily job at the given time of day in UTC, e.g.
+08:30.
+
+Options:
+
+    --every <period>  how often to run: 10m, hour, or day
+    --at <time>       when in the period to run, :MM or HH:MM
+    -s <size>         size of the dyno to run in (e.g. Standard-2X)
+
+Examples:
+
+    $ hk schedule-add --every day --at 08:30 rake cleanup
+    Scheduled ` + "`" + `rake cleanup` + "`" + ` on myapp every day at 08:30.
+
+    $ hk schedule-add --every 10m bin/sync-feeds
+    Scheduled ` + "`" + `bin/sync-feeds` + "`" + ` on myapp every 10m.
+`,
+}
+
+var cmdScheduleUpdate = &Command{
+	Run:      runScheduleUpdate,
+	Usage:    "schedule-update [--every 10m|hour|day] [--at <time>] [-s <size>] [--command <command>] <id>",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "change a scheduled job" + extra,
+	Long: `
+Schedule-update changes how often a scheduled job runs, when, in
+what size of dyno, or what it runs. Settings that aren't given are
+left as they are.
+
+Options:
+
+    --every <period>     how often to run: 10m, hour, or day
+    --at <time>          when in the period to run, :MM or HH:MM
+    -s <size>            size of the dyno to run in (e.g. Standard-2X)
+    --command <command>  command to run
+
+Example:
+
+    $ hk schedule-update --every hour --at :20 5d9c7b1a-2f3e-4a6b-8c9d-0e1f2a3b4c5d
+    Changed job 5d9c7b1a-2f3e-4a6b-8c9d-0e1f2a3b4c5d on myapp: every hour at :20.
+`,
+}
+
+var cmdScheduleRemove = &Command{
+	Run:      runScheduleRemove,
+	Usage:    "schedule-remove <id>",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "remove a scheduled job" + extra,
+	Long: `
+Schedule-remove removes a job from the Heroku Scheduler add-on.
+
+Example:
+
+    $ hk schedule-remove 5d9c7b1a-2f3e-4a6b-8c9d-0e1f2a3b4c5d
+    Removed job 5d9c7b1a-2f3e-4a6b-8c9d-0e1f2a3b4c5d from myapp.
+`,
+}
+
+var (
+	flagScheduleEvery   string
+	flagScheduleAt      string
+	flagScheduleSize    string
+	flagScheduleCommand string
+)
+
+func init() {
+	for _, cmd := range []*Command{cmdScheduleAdd, cmdScheduleUpdate} {
+		cmd.Flag.StringVar(&flagScheduleEvery, "every", "", "how often to run")
+		cmd.Flag.StringVar(&flagScheduleAt, "at", "", "when in the period to run")
+		cmd.Flag.StringVar(&flagScheduleSize, "s", "", "dyno size")
+	}
+	cmdScheduleUpdate.Flag.StringVar(&flagScheduleCommand, "command", "", "command to run")
+}
+
+// scheduleFrequencies maps --every values to Scheduler frequencies, and
+// scheduleEvery maps them back.
+var (
+	scheduleFrequencies = map[string]string{
+		"10m":  scheduler.EveryTenMinutes,
+		"hour": scheduler.EveryHour,
+		"day":  scheduler.EveryDay,
+	}
+	scheduleEvery = map[string]string{
+		scheduler.EveryTenMinutes: "10m",
+		scheduler.EveryHour:       "hour",
+		scheduler.EveryDay:        "day",
+	}
+)
+
+var (
+	hourlyAtRE = regexp.MustCompile(`^:[0-5]\d$`)
+	dailyAtRE  = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+)
+
+// scheduleJobOpts returns the job settings given by flags. every is the
+// job's current --every value, if it has one, to check --at against.
+func scheduleJobOpts(every string) (scheduler.JobOpts, error) {
+	var opts scheduler.JobOpts
+	if flagScheduleEvery != "" {
+		freq, ok := scheduleFrequencies[flagScheduleEvery]
+		if !ok {
+			return opts, fmt.Errorf("--every must be 10m, hour, or day, not %s", flagScheduleEvery)
+		}
+		opts.Frequency = &freq
+		every = flagScheduleEvery
+	}
+	if flagScheduleAt != "" {
+		switch {
+		case every == "hour" && hourlyAtRE.MatchString(flagScheduleAt):
+		case every == "day" && dailyAtRE.MatchString(flagScheduleAt):
+		case every == "hour":
+			return opts, fmt.Errorf("--at for hourly jobs is a minute past the hour, e.g. :20")
+		case every == "day":
+			return opts, fmt.Errorf("--at for daily jobs is a time of day in UTC, e.g. 08:30")
+		default:
+			return opts, fmt.Errorf("--at needs --every hour or --every day")
+		}
+		opts.At = &flagScheduleAt
+	}
+	if flagScheduleSize != "" {
+		opts.DynoSize = &flagScheduleSize
+	}
+	if flagScheduleCommand != "" {
+		opts.Command = &flagScheduleCommand
+	}
+	return opts, nil
+}
+
+func runScheduleAdd(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) == 0 || flagScheduleEvery == "" {
+		ctx.printUsage()
+		exit(2)
+	}
+	opts, err := scheduleJobOpts("")
+	if err != nil {
+		printFatal(err.Error())
+	}
+	command := strings.Join(args, " ")
+	opts.Command = &command
+	mustHaveScheduler(ctx, appname)
+	j, err := ctx.Scheduler.CreateJob(appname, opts)
+	must(err)
+	log.Printf("Scheduled `%s` on %s %s.", j.Command, appname, describeJobSchedule(j))
+}
+
+func runScheduleUpdate(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	id := args[0]
+	mustHaveScheduler(ctx, appname)
+	every := ""
+	if flagScheduleAt != "" && flagScheduleEvery == "" {
+		j := mustFindJob(ctx, appname, id)
+		every = scheduleEvery[j.Frequency]
+	}
+	opts, err := scheduleJobOpts(every)
+	if err != nil {
+		printFatal(err.Error())
+	}
+	if opts == (scheduler.JobOpts{}) {
+		ctx.printUsage()
+		exit(2)
+	}
+	j, err := ctx.Scheduler.UpdateJob(appname, id, opts)
+	must(err)
+	log.Printf("Changed job %s on %s: %s.", id, appname, describeJobSchedule(j))
+}
+
+func runScheduleRemove(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	mustHaveScheduler(ctx, appname)
+	must(ctx.Scheduler.DeleteJob(appname, args[0]))
+	log.Printf("Removed job %s from %s.", args[0], appname)
+}
+
+// describeJobSchedule describes when j runs, e.g. "every day at 08:30".
+func describeJobSchedule(j *scheduler.Job) string {
+	s := "every " + scheduleEvery[j.Frequency]
+	if j.At != "" && j.Frequency != scheduler.EveryTenMinutes {
+		s += " at " + j.At
+	}
+	return s
+}
+
+// mustFindJob returns the job of appname with the given id.
+func mustFindJob(ctx *Context, appname, id string) *scheduler.Job {
+	jobs, err := ctx.Scheduler.Jobs(appname)
+	must(err)
+	for i := range jobs {
+		if jobs[i].Id == id {
+			return &jobs[i]
+		}
+	}
+	printFatal("No job %s on %s. See 'hk schedule'.", id, appname)
+	return nil
+}
+
+// mustHaveScheduler exits unless appname has the Heroku Scheduler add-on.
+func mustHaveScheduler(ctx *Context, appname string) {
+	addons, err := ctx.Client.AddonList(appname, nil)
+	must(err)
+	for _, a := range addons {
+		if strings.HasPrefix(a.Plan.Name, "scheduler:") {
+			return
+		}
+	}
+	printFatal("%s doesn't have the Heroku Scheduler add-on. Add it with 'hk addon-add scheduler'.", appname)
+}
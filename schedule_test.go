@@ -0,0 +1,44 @@
+package hk
+
+import (
+	"testing"
+
+	"github.com/heroku/hk/scheduler"
+)
+
+func TestScheduleJobOpts(t *testing.T) {
+	defer func() { flagScheduleEvery, flagScheduleAt, flagScheduleSize, flagScheduleCommand = "", "", "", "" }()
+	tests := []struct {
+		every, at, current string
+		freq               string
+		ok                 bool
+	}{
+		{"day", "08:30", "", scheduler.EveryDay, true},
+		{"hour", ":20", "", scheduler.EveryHour, true},
+		{"10m", "", "", scheduler.EveryTenMinutes, true},
+		{"", ":40", "hour", "", true},
+		{"week", "", "", "", false},
+		{"hour", "08:30", "", "", false},
+		{"day", ":20", "", "", false},
+		{"day", "24:00", "", "", false},
+		{"10m", ":20", "", "", false},
+		{"", "08:30", "10m", "", false},
+	}
+	for _, test := range tests {
+		flagScheduleEvery, flagScheduleAt = test.every, test.at
+		opts, err := scheduleJobOpts(test.current)
+		if (err == nil) != test.ok {
+			t.Errorf("--every %q --at %q on a %q job: error %v, want ok %v", test.every, test.at, test.current, err, test.ok)
+			continue
+		}
+		if !test.ok {
+			continue
+		}
+		if freq := opts.Frequency; (freq == nil) != (test.freq == "") || (freq != nil && *freq != test.freq) {
+			t.Errorf("--every %q => frequency %v, want %q", test.every, freq, test.freq)
+		}
+		if at := opts.At; (at == nil) != (test.at == "") || (at != nil && *at != test.at) {
+			t.Errorf("--at %q => at %v", test.at, at)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package scheduler
+
+import (
+	"runtime"
+
+	"github.com/heroku/hk/addonapi"
+)
+
+const (
+	Version          = "0.0.1"
+	DefaultAPIURL    = "https://cron.heroku.com"
+	DefaultUserAgent = "heroku-scheduler-go/" + Version + " (" + runtime.GOOS + "; " + runtime.GOARCH + ")"
+)
+
+var defaults = addonapi.Defaults{URL: DefaultAPIURL, UserAgent: DefaultUserAgent}
+
+// A Client is a Heroku Scheduler API client. Its zero value is a usable
+// client that uses default settings for the Heroku Scheduler API. Its
+// fields, such as its credentials and HTTP client, are those of
+// addonapi.Client.
+type Client struct {
+	addonapi.Client
+}
+
+// do sends a request to path, below the API URL, and decodes the response
+// into v unless v is nil. If body is not nil, it's sent as JSON.
+func (c *Client) do(method, path string, body, v interface{}) error {
+	return c.Do(defaults, method, path, body, v)
+}
@@ -0,0 +1,8 @@
+// Package scheduler is a client for the Heroku Scheduler API, which
+// manages the jobs that the Scheduler add-on runs in one-off dynos.
+//
+// A Client holds credentials and HTTP settings, and is safe to share:
+//
+//	c := &scheduler.Client{Client: addonapi.Client{Username: user, Password: apiKey}}
+//	jobs, err := c.Jobs("myapp")
+package scheduler
@@ -0,0 +1,68 @@
+package scheduler
+
+import "time"
+
+// How often a job runs.
+const (
+	EveryTenMinutes = "every_ten_minutes"
+	EveryHour       = "every_hour"
+	EveryDay        = "every_day"
+)
+
+// A Job is a command that Heroku Scheduler runs in a one-off dyno on a
+// schedule.
+type Job struct {
+	// unique identifier of job
+	Id string `json:"id"`
+
+	// command run by the job
+	Command string `json:"command"`
+
+	// size of the dyno the job runs in, e.g. Standard-1X
+	DynoSize string `json:"dyno_size"`
+
+	// how often the job runs: EveryTenMinutes, EveryHour, or EveryDay
+	Frequency string `json:"frequency"`
+
+	// when in the period the job runs, in UTC: "HH:MM" for daily jobs,
+	// ":MM" for hourly ones, and empty for jobs run every ten minutes
+	At string `json:"at"`
+
+	// when the job last ran, if it has
+	LastRun *time.Time `json:"last_run"`
+
+	// when the job will next run
+	NextRun *time.Time `json:"next_run"`
+}
+
+// JobOpts are the settings of a job to create or change. Nil fields are
+// left as they are, or to their defaults for new jobs.
+type JobOpts struct {
+	Command   *string `json:"command,omitempty"`
+	DynoSize  *string `json:"dyno_size,omitempty"`
+	Frequency *string `json:"frequency,omitempty"`
+	At        *string `json:"at,omitempty"`
+}
+
+// Jobs lists an app's jobs.
+func (c *Client) Jobs(app string) ([]Job, error) {
+	var jobs []Job
+	return jobs, c.do("GET", "/apps/"+app+"/jobs", nil, &jobs)
+}
+
+// CreateJob adds a job to an app.
+func (c *Client) CreateJob(app string, opts JobOpts) (*Job, error) {
+	var j Job
+	return &j, c.do("POST", "/apps/"+app+"/jobs", opts, &j)
+}
+
+// UpdateJob changes a job of an app.
+func (c *Client) UpdateJob(app, id string, opts JobOpts) (*Job, error) {
+	var j Job
+	return &j, c.do("PATCH", "/apps/"+app+"/jobs/"+id, opts, &j)
+}
+
+// DeleteJob removes a job from an app.
+func (c *Client) DeleteJob(app, id string) error {
+	return c.do("DELETE", "/apps/"+app+"/jobs/"+id, nil, nil)
+}
@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/heroku/hk/addonapi"
+)
+
+func TestJobs(t *testing.T) {
+	var gotPath, gotUser string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.Method + " " + r.URL.Path
+		gotUser, _, _ = r.BasicAuth()
+		w.Write([]byte(`[{"id": "j1", "command": "rake cleanup", "dyno_size": "Standard-1X", "frequency": "every_day", "at": "08:30", "next_run": "2014-01-14T08:30:00Z"}]`))
+	}))
+	defer ts.Close()
+	c := &Client{Client: addonapi.Client{URL: ts.URL, Username: "user@example.com"}}
+	jobs, err := c.Jobs("myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotPath != "GET /apps/myapp/jobs" || gotUser != "user@example.com" {
+		t.Errorf("request = %q as %q", gotPath, gotUser)
+	}
+	if len(jobs) != 1 || jobs[0].Frequency != EveryDay || jobs[0].At != "08:30" || jobs[0].LastRun != nil || jobs[0].NextRun == nil {
+		t.Errorf("unexpected jobs %+v", jobs)
+	}
+}
+
+func TestUpdateJob(t *testing.T) {
+	var body map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "PATCH" || r.URL.Path != "/apps/myapp/jobs/j1" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(`{"id": "j1", "frequency": "every_hour", "at": ":20"}`))
+	}))
+	defer ts.Close()
+	c := &Client{Client: addonapi.Client{URL: ts.URL}}
+	freq, at := EveryHour, ":20"
+	j, err := c.UpdateJob("myapp", "j1", JobOpts{Frequency: &freq, At: &at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(body) != 2 || body["frequency"] != EveryHour || body["at"] != ":20" {
+		t.Errorf("body = %v, want only frequency and at", body)
+	}
+	if j.At != ":20" {
+		t.Errorf("job = %+v", j)
+	}
+}
@@ -0,0 +1,407 @@
+package hk
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/hkclient"
+	"github.com/heroku/hk/term"
+)
+
+var cmdSetup = &Command{
+	Run:      runSetup,
+	Usage:    "setup [-r <region>] [--org <org>] [--remote <name>] [--no-deploy | --tarball <url>] [<name>] [<env>=<value>...]",
+	Category: "app",
+	Short:    "create an app from its app.json" + extra,
+	Long: `
+Setup creates an app as described by the app.json in the current
+directory: with its buildpacks, add-ons, and env vars. It then
+deploys the current directory, as 'hk deploy' does, and runs the
+app.json's postdeploy script in a one-off dyno. A git remote is added
+for the new app, as with 'hk create'.
+
+Env vars in app.json get their value, in order of preference, from an
+<env>=<value> argument, the app.json's value, or its generator (a
+"secret" generator makes a random 64-character hex string). A
+required env var with no value is asked for at a terminal, and
+otherwise setup exits before creating anything.
+
+With --tarball, the app is instead set up by Heroku from a tarball
+of the app's source at a URL, which must include the app.json. The
+build and postdeploy output are shown as they run.
+
+Options:
+
+    -r <region>      region to create the app in
+    --org <org>      organization to create the app in
+    --remote <name>  name of the git remote to add (default heroku)
+    --no-deploy      don't deploy or run the postdeploy script
+    --tarball <url>  set up the app from the source tarball at url
+
+Examples:
+
+    $ hk setup myapp
+    Created myapp.
+    Set buildpacks on myapp to heroku/ruby.
+    Added heroku-postgresql:hobby-dev to myapp as postgresql-round-4217.
+    Set 4 env vars on myapp.
+    ...
+    Deployed myapp v5.
+    Running ` + "`" + `bin/rake db:seed` + "`" + ` on myapp as run.4321:
+    2014-01-13T21:20:58.123456+00:00 app[run.4321]: Seeded 12 users.
+    Set up myapp.
+
+    $ hk setup --tarball https://github.com/acme/api/tarball/main acme-api RACK_ENV=staging
+    Setting up acme-api...
+    ...
+    Set up acme-api: https://acme-api.herokuapp.com/
+`,
+}
+
+var (
+	flagSetupRegion   string
+	flagSetupOrg      string
+	flagSetupRemote   string
+	flagSetupNoDeploy bool
+	flagSetupTarball  string
+)
+
+func init() {
+	cmdSetup.Flag.StringVar(&flagSetupRegion, "r", "", "region name")
+	cmdSetup.Flag.StringVar(&flagSetupOrg, "org", "", "organization name")
+	cmdSetup.Flag.StringVar(&flagSetupRemote, "remote", "heroku", "git remote name")
+	cmdSetup.Flag.BoolVar(&flagSetupNoDeploy, "no-deploy", false, "don't deploy or run the postdeploy script")
+	cmdSetup.Flag.StringVar(&flagSetupTarball, "tarball", "", "source tarball URL")
+}
+
+// how often to check on an app setup
+const appSetupPollInterval = 3 * time.Second
+
+func runSetup(ctx *Context, args []string) {
+	var name string
+	overrides := make(map[string]string)
+	for _, arg := range args {
+		if i := strings.Index(arg, "="); i >= 0 {
+			overrides[arg[:i]] = arg[i+1:]
+			continue
+		}
+		if name != "" {
+			ctx.printUsage()
+			exit(2)
+		}
+		name = arg
+	}
+	if flagSetupNoDeploy && flagSetupTarball != "" {
+		ctx.printUsage()
+		exit(2)
+	}
+	if flagSetupTarball != "" {
+		setupFromTarball(ctx.Stdout, name, flagSetupTarball, overrides)
+		return
+	}
+
+	m, err := readAppManifest("app.json")
+	if err != nil {
+		printFatal(err.Error())
+	}
+	var prompt func(string, appManifestEnv) (string, error)
+	if term.IsTerminal(os.Stdin) {
+		prompt = promptEnvValue
+	}
+	env, err := m.resolveEnv(overrides, prompt)
+	if err != nil {
+		printFatal(err.Error())
+	}
+
+	var opts heroku.AppCreateOpts
+	if flagSetupRegion != "" {
+		opts.Region = &flagSetupRegion
+	}
+	if name != "" {
+		opts.Name = &name
+	}
+	var app *heroku.App
+	if flagSetupOrg != "" {
+		app, err = createOrgApp(flagSetupOrg, &opts)
+	} else {
+		app, err = ctx.Client.AppCreate(&opts)
+	}
+	must(err)
+	exec.Command("git", "remote", "add", flagSetupRemote, app.GitURL).Run()
+	log.Printf("Created %s.", app.Name)
+	if err := setupApp(ctx.Stdout, app.Name, m, env); err != nil {
+		printFatal("%s %s is only partly set up; destroy it with 'hk destroy %s'.", err, app.Name, app.Name)
+	}
+	log.Printf("Set up %s.", app.Name)
+}
+
+// setupApp gives a new app the buildpacks, add-ons, and env vars of its
+// manifest, then deploys the current directory to it and runs the
+// manifest's postdeploy script, unless flagSetupNoDeploy is set.
+func setupApp(w io.Writer, appname string, m *appManifest, env map[string]string) error {
+	if len(m.Buildpacks) > 0 {
+		var urls []string
+		for _, b := range m.Buildpacks {
+			urls = append(urls, b.URL)
+		}
+		if err := setBuildpacks(appname, urls); err != nil {
+			return err
+		}
+		log.Printf("Set buildpacks on %s to %s.", appname, strings.Join(urls, ", "))
+	}
+	for _, a := range m.Addons {
+		var opts *heroku.AddonCreateOpts
+		if len(a.Options) > 0 {
+			opts = &heroku.AddonCreateOpts{Config: &a.Options}
+		}
+		addon, err := client.AddonCreate(appname, a.Plan, opts)
+		if err != nil {
+			return fmt.Errorf("adding %s: %s", a.Plan, err)
+		}
+		log.Printf("Added %s to %s as %s.", addon.Plan.Name, appname, addon.Name)
+	}
+	if len(env) > 0 {
+		config := make(map[string]*string, len(env))
+		for k, v := range env {
+			v := v
+			config[k] = &v
+		}
+		if _, err := client.ConfigVarUpdate(appname, config); err != nil {
+			return err
+		}
+		log.Printf("Set %d env vars on %s.", len(env), appname)
+	}
+	if flagSetupNoDeploy {
+		if m.Scripts.Postdeploy != "" {
+			printTip("After deploying, run the postdeploy script with 'hk run -a %s %s'.", appname, m.Scripts.Postdeploy)
+		}
+		return nil
+	}
+
+	b, err := buildDir(w, appname, ".", dirGitCommit("."))
+	if err != nil {
+		return err
+	}
+	if b.Release != nil {
+		rel, err := client.ReleaseInfo(appname, b.Release.Id)
+		if err != nil {
+			return err
+		}
+		log.Printf("Deployed %s v%d.", appname, rel.Version)
+	}
+	if m.Scripts.Postdeploy != "" {
+		attach := false
+		dyno, err := client.DynoCreate(appname, m.Scripts.Postdeploy, &heroku.DynoCreateOpts{Attach: &attach})
+		if err != nil {
+			return err
+		}
+		log.Printf("Running `%s` on %s as %s:", dyno.Command, appname, dyno.Name)
+		tail, lines := true, 100
+		opts := heroku.LogSessionCreateOpts{Dyno: &dyno.Name, Tail: &tail, Lines: &lines}
+		streamLog(w, appname, &opts, logFilter{dyno: dyno.Name}, dynoExited(dyno.Name))
+	}
+	return nil
+}
+
+// setupFromTarball sets up an app with the app-setups API, which reads
+// the app.json from the tarball at url. It shows the build output, and
+// waits for the setup to finish.
+func setupFromTarball(w io.Writer, name, url string, overrides map[string]string) {
+	var opts hkclient.AppSetupCreateOpts
+	if name != "" {
+		opts.App.Name = &name
+	}
+	if flagSetupRegion != "" {
+		opts.App.Region = &flagSetupRegion
+	}
+	if flagSetupOrg != "" {
+		opts.App.Organization = &flagSetupOrg
+	}
+	opts.SourceBlob.URL = url
+	if len(overrides) > 0 {
+		opts.Overrides.Env = overrides
+	}
+	s, err := ext().AppSetupCreate(opts)
+	must(err)
+	log.Printf("Setting up %s...", s.App.Name)
+	streamed := false
+	for s.Status == "pending" {
+		if !streamed && s.Build != nil && s.Build.OutputStreamURL != "" {
+			streamed = true
+			if res, err := http.Get(s.Build.OutputStreamURL); err == nil {
+				io.Copy(w, res.Body)
+				res.Body.Close()
+			}
+		}
+		time.Sleep(appSetupPollInterval)
+		s, err = ext().AppSetupInfo(s.Id)
+		must(err)
+	}
+	if s.Postdeploy != nil {
+		fmt.Fprint(w, s.Postdeploy.Output)
+	}
+	if s.Status == "failed" {
+		for _, e := range s.ManifestErrors {
+			printError("app.json: %s", e)
+		}
+		printFatal("Setting up %s failed: %s", s.App.Name, s.FailureMessage)
+	}
+	exec.Command("git", "remote", "add", flagSetupRemote, gitURLPre()+s.App.Name+".git").Run()
+	log.Printf("Set up %s: %s", s.App.Name, s.ResolvedSuccessURL)
+}
+
+// An appManifest is an app.json, which describes how to set up an app.
+type appManifest struct {
+	Name       string                    `json:"name"`
+	Env        map[string]appManifestEnv `json:"env"`
+	Addons     []appManifestAddon        `json:"addons"`
+	Buildpacks []struct {
+		URL string `json:"url"`
+	} `json:"buildpacks"`
+	Scripts struct {
+		Postdeploy string `json:"postdeploy"`
+	} `json:"scripts"`
+}
+
+// An appManifestEnv is an env var in an app.json, given either as its
+// value or as an object describing it.
+type appManifestEnv struct {
+	Description string `json:"description"`
+	Value       string `json:"value"`
+	Required    *bool  `json:"required"`
+	Generator   string `json:"generator"`
+}
+
+func (e *appManifestEnv) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &e.Value); err == nil {
+		return nil
+	}
+	type env appManifestEnv
+	return json.Unmarshal(b, (*env)(e))
+}
+
+// required reports whether the env var must have a value. Env vars are
+// required unless app.json says otherwise.
+func (e appManifestEnv) required() bool {
+	return e.Required == nil || *e.Required
+}
+
+// An appManifestAddon is an add-on in an app.json, given either as its
+// plan or as an object with the plan and its provisioning options.
+type appManifestAddon struct {
+	Plan    string            `json:"plan"`
+	Options map[string]string `json:"-"`
+}
+
+func (a *appManifestAddon) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, &a.Plan); err == nil {
+		return nil
+	}
+	var v struct {
+		Plan    string                 `json:"plan"`
+		Options map[string]interface{} `json:"options"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	a.Plan = v.Plan
+	for k, o := range v.Options {
+		if a.Options == nil {
+			a.Options = make(map[string]string)
+		}
+		a.Options[k] = fmt.Sprint(o)
+	}
+	return nil
+}
+
+func readAppManifest(name string) (*appManifest, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var m appManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+	return &m, nil
+}
+
+// resolveEnv returns the values of the manifest's env vars, taking them
+// from overrides, the manifest, or a generator. A required env var with
+// no value is asked for with prompt, which may be nil. Overrides of env
+// vars that aren't in the manifest are included as well.
+func (m *appManifest) resolveEnv(overrides map[string]string, prompt func(name string, e appManifestEnv) (string, error)) (map[string]string, error) {
+	env := make(map[string]string)
+	var names []string
+	for name := range m.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var missing []string
+	for _, name := range names {
+		e := m.Env[name]
+		v, ok := overrides[name]
+		switch {
+		case ok:
+		case e.Value != "":
+			v = e.Value
+		case e.Generator == "secret":
+			b := make([]byte, 32)
+			if _, err := rand.Read(b); err != nil {
+				return nil, err
+			}
+			v = hex.EncodeToString(b)
+		case e.Generator != "":
+			return nil, fmt.Errorf("unknown generator %q for %s in app.json", e.Generator, name)
+		case !e.required():
+			continue
+		case prompt != nil:
+			var err error
+			if v, err = prompt(name, e); err != nil {
+				return nil, err
+			}
+		default:
+			missing = append(missing, name)
+			continue
+		}
+		env[name] = v
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("app.json requires values for %s; give them as <env>=<value> arguments", strings.Join(missing, ", "))
+	}
+	for name, v := range overrides {
+		if _, ok := m.Env[name]; !ok {
+			env[name] = v
+		}
+	}
+	return env, nil
+}
+
+// promptEnvValue asks for the value of a required env var on stdin.
+func promptEnvValue(name string, e appManifestEnv) (string, error) {
+	if e.Description != "" {
+		fmt.Fprintf(os.Stderr, "%s (%s): ", name, e.Description)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", name)
+	}
+	v, err := stdin.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %s", name, err)
+	}
+	if v = strings.TrimSpace(v); v == "" {
+		return "", fmt.Errorf("no value given for %s", name)
+	}
+	return v, nil
+}
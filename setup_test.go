@@ -0,0 +1,67 @@
+package hk
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const testAppManifest = `{
+  "name": "acme-api",
+  "env": {
+    "RACK_ENV": "production",
+    "SECRET_TOKEN": {"description": "signs cookies", "generator": "secret"},
+    "SMTP_HOST": {"description": "mail server"},
+    "SENTRY_DSN": {"description": "error reporting", "required": false}
+  },
+  "addons": ["heroku-redis:mini", {"plan": "heroku-postgresql:standard-0", "options": {"fork": "acme-db", "fast": true}}],
+  "buildpacks": [{"url": "heroku/ruby"}],
+  "scripts": {"postdeploy": "bin/rake db:seed"}
+}`
+
+func TestAppManifest(t *testing.T) {
+	var m appManifest
+	if err := json.Unmarshal([]byte(testAppManifest), &m); err != nil {
+		t.Fatal(err)
+	}
+	wantAddons := []appManifestAddon{
+		{Plan: "heroku-redis:mini"},
+		{Plan: "heroku-postgresql:standard-0", Options: map[string]string{"fork": "acme-db", "fast": "true"}},
+	}
+	if !reflect.DeepEqual(m.Addons, wantAddons) {
+		t.Errorf("addons = %+v, want %+v", m.Addons, wantAddons)
+	}
+	if len(m.Buildpacks) != 1 || m.Buildpacks[0].URL != "heroku/ruby" || m.Scripts.Postdeploy != "bin/rake db:seed" {
+		t.Errorf("manifest = %+v", m)
+	}
+
+	if _, err := m.resolveEnv(nil, nil); err == nil {
+		t.Errorf("resolveEnv with SMTP_HOST missing => nil error")
+	}
+	env, err := m.resolveEnv(map[string]string{"SMTP_HOST": "smtp.example.com", "EXTRA": "1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(env["SECRET_TOKEN"]) != 64 {
+		t.Errorf("SECRET_TOKEN = %q, want 64 hex digits", env["SECRET_TOKEN"])
+	}
+	delete(env, "SECRET_TOKEN")
+	want := map[string]string{"RACK_ENV": "production", "SMTP_HOST": "smtp.example.com", "EXTRA": "1"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("resolveEnv => %v, want %v", env, want)
+	}
+
+	var prompted []string
+	prompt := func(name string, e appManifestEnv) (string, error) {
+		prompted = append(prompted, name)
+		if e.Description != "mail server" {
+			return "", errors.New("wrong description")
+		}
+		return "smtp.example.com", nil
+	}
+	env, err = m.resolveEnv(nil, prompt)
+	if err != nil || env["SMTP_HOST"] != "smtp.example.com" || !reflect.DeepEqual(prompted, []string{"SMTP_HOST"}) {
+		t.Errorf("resolveEnv with prompt => %v, %v; prompted for %v", env, err, prompted)
+	}
+}
@@ -0,0 +1,222 @@
+package hk
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/hkclient"
+)
+
+var cmdSlugPush = &Command{
+	Run:      runSlugPush,
+	Usage:    "slug-push [--procfile <file>] [--commit <commit>] <dir or tarball>",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "release a prebuilt directory or slug" + extra,
+	Long: `
+Slug-push releases an app that's already built, such as by CI,
+without git or buildpacks. Given a directory, it packs the directory
+into a slug as the app would be laid out in a dyno, at /app. Given a
+gzipped tarball, it uses that as the slug as it is; its files must be
+under ./app/.
+
+The slug's process types are read from a Procfile: --procfile if
+given, or else the one at the top of the directory or tarball.
+
+Options:
+
+    --procfile <file>  Procfile to read process types from
+    --commit <commit>  git commit the slug was built from (default the
+                       current git commit, if any)
+
+Examples:
+
+    $ hk slug-push ./build
+    Uploading 48.2 MB... done.
+    Released slug 98765432-82ba-10ba-fedc-8d206789d062 to myapp v117.
+
+    $ hk slug-push --procfile Procfile slug.tgz
+    Uploading 48.2 MB... done.
+    Released slug 1d0c3a9e-c6a1-4f9a-8d3b-0f7e5c6a2b1d to myapp v118.
+`,
+}
+
+var (
+	flagSlugPushProcfile string
+	flagSlugPushCommit   string
+)
+
+func init() {
+	cmdSlugPush.Flag.StringVar(&flagSlugPushProcfile, "procfile", "", "Procfile to read process types from")
+	cmdSlugPush.Flag.StringVar(&flagSlugPushCommit, "commit", "", "git commit the slug was built from")
+}
+
+func runSlugPush(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	path := args[0]
+	fi, err := os.Stat(path)
+	must(err)
+	mustNotBeDeployLocked(appname)
+
+	var tarball *os.File
+	if fi.IsDir() {
+		files, err := slugFiles(path)
+		must(err)
+		tarball, err = ioutil.TempFile("", "hk-slug")
+		must(err)
+		defer os.Remove(tarball.Name())
+		must(writeTarball(tarball, path, "./app/", files))
+	} else {
+		tarball, err = os.Open(path)
+		must(err)
+	}
+	defer tarball.Close()
+
+	var procs map[string]string
+	switch {
+	case flagSlugPushProcfile != "":
+		procs, err = readProcfile(flagSlugPushProcfile)
+	case fi.IsDir():
+		procs, err = readProcfile(filepath.Join(path, "Procfile"))
+	default:
+		procs, err = tarballProcfile(tarball)
+	}
+	if os.IsNotExist(err) {
+		printFatal("No Procfile in %s. Give one with --procfile.", path)
+	}
+	must(err)
+	if len(procs) == 0 {
+		printFatal("The Procfile has no process types.")
+	}
+
+	_, err = tarball.Seek(0, 0)
+	must(err)
+	h := sha256.New()
+	size, err := io.Copy(h, tarball)
+	must(err)
+	checksum := "SHA256:" + hex.EncodeToString(h.Sum(nil))
+	opts := hkclient.SlugCreateOpts{ProcessTypes: procs, Checksum: &checksum}
+	commit := flagSlugPushCommit
+	if commit == "" {
+		if fi.IsDir() {
+			commit = dirGitCommit(path)
+		} else {
+			commit = dirGitCommit(".")
+		}
+	}
+	if commit != "" {
+		opts.Commit = &commit
+	}
+
+	slug, err := ext().SlugCreate(appname, opts)
+	must(err)
+	fmt.Fprintf(os.Stderr, "Uploading %s... ", prettySize(size))
+	_, err = tarball.Seek(0, 0)
+	must(err)
+	must(uploadSource(slug.Blob.URL, tarball, size))
+	fmt.Fprintln(os.Stderr, "done.")
+
+	desc := "Slug push"
+	if commit != "" {
+		desc = "Deploy " + abbrevCommit(commit)
+	}
+	rel, err := ctx.Client.ReleaseCreate(appname, slug.Id, &heroku.ReleaseCreateOpts{Description: &desc})
+	must(err)
+	log.Printf("Released slug %s to %s v%d.", slug.Id, appname, rel.Version)
+}
+
+// abbrevCommit shortens a git commit id to the 7 characters Heroku shows
+// in release descriptions.
+func abbrevCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// slugFiles lists the files in dir to put in a slug, relative to dir: all
+// of them but .git, since the directory is already built.
+func slugFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() && fi.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !fi.IsDir() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, rel)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// e.g. "web: bundle exec puma -C config/puma.rb"
+var procfileLineRE = regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.+)$`)
+
+func readProcfile(name string) (map[string]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseProcfile(f)
+}
+
+// parseProcfile returns the process types in a Procfile and their
+// commands.
+func parseProcfile(r io.Reader) (map[string]string, error) {
+	procs := make(map[string]string)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if m := procfileLineRE.FindStringSubmatch(strings.TrimSpace(s.Text())); m != nil {
+			procs[m[1]] = strings.TrimSpace(m[2])
+		}
+	}
+	return procs, s.Err()
+}
+
+// tarballProcfile returns the process types in the Procfile of a slug
+// tarball, at app/Procfile. It returns an error satisfying os.IsNotExist
+// if there isn't one.
+func tarballProcfile(r io.Reader) (map[string]string, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, os.ErrNotExist
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimPrefix(hdr.Name, "./") == "app/Procfile" {
+			return parseProcfile(tr)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package hk
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTarballProcfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hk-slug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	procfile := "web: bin/web -p $PORT\n# comment\n\nworker:bin/worker\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "Procfile"), []byte(procfile), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.bin"), []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files, err := slugFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeTarball(&buf, dir, "./app/", files); err != nil {
+		t.Fatal(err)
+	}
+
+	procs, err := tarballProcfile(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"web": "bin/web -p $PORT", "worker": "bin/worker"}
+	if !reflect.DeepEqual(procs, want) {
+		t.Errorf("tarballProcfile = %v, want %v", procs, want)
+	}
+
+	buf.Reset()
+	if err := writeTarball(&buf, dir, "./app/", []string{"app.bin"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tarballProcfile(&buf); !os.IsNotExist(err) {
+		t.Errorf("tarballProcfile without a Procfile: err = %v, want not exist", err)
+	}
+}
@@ -0,0 +1,260 @@
+package hk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/hkclient"
+)
+
+var cmdSlugs = &Command{
+	Run:      runSlugs,
+	Usage:    "slugs [-n <count>]",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "list recent slugs" + extra,
+	Long: `
+Slugs lists the slugs of an app's recent releases, newest first,
+with the latest release of each, its git commit, size, and when it
+was built. A slug released more than once, as by a rollback, is
+listed once.
+
+Options:
+
+    -n <count>  number of releases to look through (default 10)
+
+Example:
+
+    $ hk slugs
+    v116  98765432-82ba-10ba-fedc-8d206789d062  62b3059  48.2 MB  Jan 13 21:20
+    v115  1d0c3a9e-c6a1-4f9a-8d3b-0f7e5c6a2b1d  3ae20c2  48.1 MB  Jan 12 18:28
+`,
+}
+
+var flagSlugsCount int
+
+func init() {
+	cmdSlugs.Flag.IntVar(&flagSlugsCount, "n", 10, "number of releases")
+}
+
+func runSlugs(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 0 || flagSlugsCount < 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	rels, err := ctx.Client.ReleaseList(appname, &heroku.ListRange{
+		Field:      "version",
+		Max:        flagSlugsCount,
+		Descending: true,
+	})
+	must(err)
+	sort.Sort(sort.Reverse(hreleasesByVersion(rels)))
+
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	seen := make(map[string]bool)
+	for _, rel := range rels {
+		if rel.Slug == nil || seen[rel.Slug.Id] {
+			continue
+		}
+		seen[rel.Slug.Id] = true
+		slug, err := ext().SlugInfo(appname, rel.Slug.Id)
+		must(err)
+		listRec(w, "v"+strconv.Itoa(rel.Version), slug.Id, abbrevCommit(slugCommit(slug)), slugSize(slug), prettyTime{slug.CreatedAt})
+	}
+}
+
+var cmdSlugInfo = &Command{
+	Run:      runSlugInfo,
+	Usage:    "slug-info [<slug id> | <version>]",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "show slug info" + extra,
+	Long: `
+Slug-info shows what's in a slug: its git commit, size, stack,
+checksum, the buildpack's description of it, and its process types.
+The slug is given by its id or by the version of a release of it,
+e.g. v116; without either, it's the slug of the current release.
+
+Example:
+
+    $ hk slug-info v116
+    Id:         98765432-82ba-10ba-fedc-8d206789d062
+    Commit:     62b3059c4a8f7e0d1b2c3a4f5e6d7c8b9a0f1e2d
+    Size:       48.2 MB
+    Stack:      heroku-18
+    Checksum:   SHA256:4c2a7e...
+    Buildpack:  Ruby/Rails
+    Created:    2014-01-13T21:20:57Z
+    Processes:
+      web     bundle exec puma -C config/puma.rb
+      worker  bundle exec sidekiq
+`,
+}
+
+func runSlugInfo(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	slug := mustSlug(appname, strings.Join(args, ""))
+
+	desc := ""
+	if slug.BuildpackProvidedDescription != nil {
+		desc = *slug.BuildpackProvidedDescription
+	}
+	checksum := ""
+	if slug.Checksum != nil {
+		checksum = *slug.Checksum
+	}
+	fmt.Fprintf(ctx.Stdout, "Id:         %s\n", slug.Id)
+	fmt.Fprintf(ctx.Stdout, "Commit:     %s\n", slugCommit(slug))
+	fmt.Fprintf(ctx.Stdout, "Size:       %s\n", slugSize(slug))
+	fmt.Fprintf(ctx.Stdout, "Stack:      %s\n", slug.Stack.Name)
+	fmt.Fprintf(ctx.Stdout, "Checksum:   %s\n", checksum)
+	fmt.Fprintf(ctx.Stdout, "Buildpack:  %s\n", desc)
+	fmt.Fprintf(ctx.Stdout, "Created:    %s\n", slug.CreatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintln(ctx.Stdout, "Processes:")
+	var types []string
+	for t := range slug.ProcessTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, t := range types {
+		listRec(w, "  "+t, slug.ProcessTypes[t])
+	}
+}
+
+var cmdSlugDownload = &Command{
+	Run:      runSlugDownload,
+	Usage:    "slug-download [-o <file>] [<slug id> | <version>]",
+	NeedsApp: true,
+	Category: "release",
+	Short:    "download a slug" + extra,
+	Long: `
+Slug-download saves a slug, a gzipped tarball of the app as it runs
+in a dyno, to a file. The slug is given as for 'hk slug-info'. If
+Heroku has the slug's checksum, the download is checked against it.
+
+Options:
+
+    -o <file>  file to save the slug to (default <slug id>.tar.gz)
+
+Example:
+
+    $ hk slug-download -o app.tar.gz v116
+    Downloading 98765432-82ba-10ba-fedc-8d206789d062 to app.tar.gz... 100% (48.2 MB of 48.2 MB)
+    Saved slug 98765432-82ba-10ba-fedc-8d206789d062 to app.tar.gz.
+    $ tar -xzf app.tar.gz ./app/Procfile
+`,
+}
+
+var flagSlugOutput string
+
+func init() {
+	cmdSlugDownload.Flag.StringVar(&flagSlugOutput, "o", "", "output file")
+}
+
+func runSlugDownload(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) > 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	slug := mustSlug(appname, strings.Join(args, ""))
+	if slug.Blob.URL == "" {
+		printFatal("Slug %s has no blob to download.", slug.Id)
+	}
+	name := flagSlugOutput
+	if name == "" {
+		name = slug.Id + ".tar.gz"
+	}
+
+	res, err := http.Get(slug.Blob.URL)
+	must(err)
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		printFatal("download failed: %s", res.Status)
+	}
+	f, err := os.Create(name)
+	must(err)
+
+	label := fmt.Sprintf("Downloading %s to %s...", slug.Id, name)
+	pw := &progressWriter{w: f, label: label, total: res.ContentLength}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(pw, h), res.Body)
+	if !accessibleOutput {
+		fmt.Fprintln(os.Stderr)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	must(err)
+	if slug.Checksum != nil && strings.HasPrefix(*slug.Checksum, "SHA256:") {
+		if sum := "SHA256:" + hex.EncodeToString(h.Sum(nil)); sum != *slug.Checksum {
+			os.Remove(name)
+			printFatal("%s doesn't match the slug's checksum: got %s, want %s.", name, sum, *slug.Checksum)
+		}
+	}
+	log.Printf("Saved slug %s to %s.", slug.Id, name)
+}
+
+// mustSlug returns the slug given by arg: a slug id, the version of a
+// release, e.g. v116, or "" for the current release.
+func mustSlug(appname, arg string) *hkclient.Slug {
+	var rel *heroku.Release
+	var err error
+	switch {
+	case arg == "":
+		rel, err = latestRelease(appname)
+	case isReleaseVersion(arg):
+		rel, err = client.ReleaseInfo(appname, strings.TrimPrefix(arg, "v"))
+	default:
+		slug, err := ext().SlugInfo(appname, arg)
+		must(err)
+		return slug
+	}
+	must(err)
+	if rel.Slug == nil {
+		printFatal("%s v%d has no slug.", appname, rel.Version)
+	}
+	slug, err := ext().SlugInfo(appname, rel.Slug.Id)
+	must(err)
+	return slug
+}
+
+// isReleaseVersion reports whether s is a release version, such as v116
+// or 116.
+func isReleaseVersion(s string) bool {
+	_, err := strconv.Atoi(strings.TrimPrefix(s, "v"))
+	return err == nil
+}
+
+func slugCommit(slug *hkclient.Slug) string {
+	if slug.Commit == nil {
+		return ""
+	}
+	return *slug.Commit
+}
+
+func slugSize(slug *hkclient.Slug) string {
+	if slug.Size == nil {
+		return ""
+	}
+	return prettySize(*slug.Size)
+}
@@ -0,0 +1,61 @@
+package hk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/heroku/hk/hktest"
+)
+
+func TestSlugDownload(t *testing.T) {
+	blob := []byte("not really a tarball")
+	sum := sha256.Sum256(blob)
+	blobSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(blob)
+	}))
+	defer blobSrv.Close()
+
+	dir, err := ioutil.TempDir("", "hk-slug")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "app.tar.gz")
+
+	tests := []struct {
+		checksum string
+		status   int
+	}{
+		{"SHA256:" + hex.EncodeToString(sum[:]), 0},
+		{"SHA256:0000", 1},
+	}
+	for _, test := range tests {
+		srv := hktest.NewServer(
+			hktest.Fixture{Method: "GET", Path: "/apps/myapp/releases/116", Body: []byte(`{"version": 116, "slug": {"id": "s1"}}`)},
+			hktest.Fixture{
+				Method: "GET", Path: "/apps/myapp/slugs/s1",
+				Body: []byte(`{"id": "s1", "checksum": "` + test.checksum + `", "blob": {"method": "get", "url": "` + blobSrv.URL + `"}}`),
+			},
+		)
+		_, status := runTestCommand(t, srv, "slug-download", "-a", "myapp", "-o", name, "v116")
+		srv.Close()
+		flagSlugOutput = ""
+		if status != test.status {
+			t.Errorf("checksum %s: status = %d, want %d", test.checksum, status, test.status)
+			continue
+		}
+		b, err := ioutil.ReadFile(name)
+		if test.status == 0 && (err != nil || string(b) != string(blob)) {
+			t.Errorf("checksum %s: file = %q, %v, want %q", test.checksum, b, err, blob)
+		}
+		if test.status != 0 && !os.IsNotExist(err) {
+			t.Errorf("checksum %s: file left after a bad checksum", test.checksum)
+		}
+	}
+}
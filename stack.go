@@ -0,0 +1,137 @@
+package hk
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdStack = &Command{
+	Run:      runStack,
+	Usage:    "stack",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "show the app's stack" + extra,
+	Long: `
+Stack shows the stack the app runs on. If the next build will use a
+different stack (see 'hk stack-set'), that's shown too.
+
+Examples:
+
+    $ hk stack
+    heroku-20
+
+    $ hk stack
+    heroku-20 (next build: heroku-22)
+`,
+}
+
+func runStack(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	app, err := getAppStacks(ctx.MustApp())
+	must(err)
+	if app.BuildStack.Name != "" && app.BuildStack.Name != app.Stack.Name {
+		fmt.Fprintf(ctx.Stdout, "%s (next build: %s)\n", app.Stack.Name, app.BuildStack.Name)
+		return
+	}
+	fmt.Fprintln(ctx.Stdout, app.Stack.Name)
+}
+
+var cmdStacks = &Command{
+	Run:      runStacks,
+	Usage:    "stacks",
+	Category: "misc",
+	Short:    "list stacks" + extra,
+	Long: `
+Lists the stacks apps can run on, with their state.
+
+Example:
+
+    $ hk stacks
+    heroku-18  deprecated
+    heroku-20  supported
+    heroku-22  supported
+`,
+}
+
+func runStacks(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	stacks, err := ctx.Client.StackList(nil)
+	must(err)
+	sort.Sort(stacksByName(stacks))
+
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, s := range stacks {
+		listRec(w, s.Name, s.State)
+	}
+}
+
+type stacksByName []heroku.Stack
+
+func (a stacksByName) Len() int           { return len(a) }
+func (a stacksByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a stacksByName) Less(i, j int) bool { return a[i].Name < a[j].Name }
+
+var cmdStackSet = &Command{
+	Run:      runStackSet,
+	Usage:    "stack-set <stack>",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "set the stack for the next build" + extra,
+	Long: `
+Stack-set sets the stack the app's next build will use. The app
+keeps running on its current stack until then.
+
+Example:
+
+    $ hk stack-set heroku-22
+    Set myapp's stack to heroku-22. Deploy to start using it.
+`,
+}
+
+func runStackSet(ctx *Context, args []string) {
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	appname := ctx.MustApp()
+	must(setBuildStack(appname, args[0]))
+	log.Printf("Set %s's stack to %s. Deploy to start using it.", appname, args[0])
+}
+
+// setBuildStack sets the stack of the app's next build.
+func setBuildStack(appname, stack string) error {
+	body := struct {
+		BuildStack string `json:"build_stack"`
+	}{stack}
+	return client.Patch(nil, "/apps/"+appname, body)
+}
+
+// appStacks are the stacks of an app: the one it runs on, and the one
+// its next build will use.
+type appStacks struct {
+	Stack struct {
+		Name string `json:"name"`
+	} `json:"stack"`
+	BuildStack struct {
+		Name string `json:"name"`
+	} `json:"build_stack"`
+}
+
+func getAppStacks(appname string) (*appStacks, error) {
+	var app appStacks
+	if err := client.Get(&app, "/apps/"+appname); err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
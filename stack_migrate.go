@@ -0,0 +1,169 @@
+package hk
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/term"
+)
+
+var cmdStackMigrate = &Command{
+	Run:      runStackMigrate,
+	Usage:    "stack-migrate [--gate <path or url>] [--gate-timeout <duration>] [--keep] [-y] <stack> [<dir>]",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "try an app on a new stack, then switch to it" + extra,
+	Long: `
+Stack-migrate checks that an app works on a new stack before
+switching it. It:
+
+  1. warns about buildpacks that may not support the new stack
+  2. creates a throwaway app on the new stack, in the app's region,
+     with the app's buildpacks and env vars
+  3. builds the directory (the current directory by default) on the
+     throwaway app, as 'hk deploy' does
+  4. waits for the throwaway app to pass a health check
+  5. asks to set the app's stack, and destroys the throwaway app
+
+Env vars set by add-ons aren't copied, so the throwaway app doesn't
+touch the app's databases or other add-ons; the health check should
+pass without them. If any step fails, the app is left on its
+current stack. Switching the stack takes effect on the app's next
+deploy, as with 'hk stack-set'.
+
+Options:
+
+    --gate <path or url>        health check URL, or a path relative
+                                to the throwaway app's web URL
+                                (default /)
+    --gate-timeout <duration>   give up on the health check after
+                                this long (default 5m)
+    --keep                      don't destroy the throwaway app
+    -y, --yes                   switch without asking
+
+Example:
+
+    $ hk stack-migrate --gate /healthz heroku-22
+    warning: https://github.com/example/buildpack-ffmpeg isn't an official buildpack; check that it supports heroku-22.
+    Created sleepy-dawn-2714 on heroku-22 to try myapp.
+    Uploading 1.2 MB... done.
+    -----> Ruby app detected
+    ...
+    Health check passed: https://sleepy-dawn-2714.herokuapp.com/healthz returned 200.
+    Destroyed sleepy-dawn-2714.
+    myapp works on heroku-22. Switch its stack? [y/N] y
+    Set myapp's stack to heroku-22. Deploy to start using it.
+`,
+}
+
+var (
+	flagStackMigrateGate        string
+	flagStackMigrateGateTimeout time.Duration
+	flagStackMigrateKeep        bool
+	flagStackMigrateYes         bool
+)
+
+func init() {
+	cmdStackMigrate.Flag.StringVar(&flagStackMigrateGate, "gate", "/", "health check path or URL")
+	cmdStackMigrate.Flag.DurationVar(&flagStackMigrateGateTimeout, "gate-timeout", 5*time.Minute, "time to wait for the health check to pass")
+	cmdStackMigrate.Flag.BoolVar(&flagStackMigrateKeep, "keep", false, "don't destroy the throwaway app")
+	cmdStackMigrate.Flag.BoolVar(&flagStackMigrateYes, "y", false, "switch without asking")
+	cmdStackMigrate.Flag.BoolVar(&flagStackMigrateYes, "yes", false, "switch without asking")
+}
+
+func runStackMigrate(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) < 1 || len(args) > 2 {
+		ctx.printUsage()
+		exit(2)
+	}
+	stack, dir := args[0], "."
+	if len(args) == 2 {
+		dir = args[1]
+	}
+	mustNotBeDeployLocked(appname)
+
+	stacks, err := getAppStacks(appname)
+	must(err)
+	if stacks.Stack.Name == stack {
+		printFatal("%s already runs on %s.", appname, stack)
+	}
+	urls, err := listBuildpacks(appname)
+	must(err)
+	for _, u := range urls {
+		if !isOfficialBuildpack(u) {
+			printWarning("%s isn't an official buildpack; check that it supports %s.", u, stack)
+		}
+	}
+
+	app, err := ctx.Client.AppInfo(appname)
+	must(err)
+	if err := tryStack(ctx.Stdout, app, stack, urls, dir); err != nil {
+		printFatal("%s %s stays on %s.", err, appname, stacks.Stack.Name)
+	}
+
+	if !flagStackMigrateYes {
+		if !term.IsTerminal(os.Stdin) {
+			log.Printf("%s works on %s. Run 'hk stack-set %s' to switch it.", appname, stack, stack)
+			return
+		}
+		mustConfirm(fmt.Sprintf("%s works on %s. Switch its stack?", appname, stack))
+	}
+	must(setBuildStack(appname, stack))
+	log.Printf("Set %s's stack to %s. Deploy to start using it.", appname, stack)
+}
+
+// tryStack creates a throwaway app on stack, sets it up like app, builds
+// dir on it, and waits for it to pass the health check. The throwaway app
+// is destroyed when tryStack returns, whether or not it succeeded, unless
+// --keep is given.
+func tryStack(w io.Writer, app *heroku.App, stack string, buildpacks []string, dir string) error {
+	fork, err := client.AppCreate(&heroku.AppCreateOpts{Region: &app.Region.Name, Stack: &stack})
+	if err != nil {
+		return err
+	}
+	log.Printf("Created %s on %s to try %s.", fork.Name, stack, app.Name)
+	defer func() {
+		if flagStackMigrateKeep {
+			log.Printf("Kept %s; destroy it with 'hk destroy %s'.", fork.Name, fork.Name)
+		} else if err := client.AppDelete(fork.Name); err != nil {
+			printWarning("couldn't destroy %s: %s", fork.Name, err)
+		} else {
+			log.Printf("Destroyed %s.", fork.Name)
+		}
+	}()
+
+	if len(buildpacks) > 0 {
+		if err := setBuildpacks(fork.Name, buildpacks); err != nil {
+			return err
+		}
+	}
+	if _, err := copyAppConfig(app.Name, fork.Name); err != nil {
+		return err
+	}
+	if _, err := buildDir(w, fork.Name, dir, dirGitCommit(dir)); err != nil {
+		return err
+	}
+	u, err := gateURL(fork.Name, flagStackMigrateGate)
+	if err != nil {
+		return err
+	}
+	if err := healthGate(u, 200, flagStackMigrateGateTimeout, 5*time.Second); err != nil {
+		return fmt.Errorf("%s.", err)
+	}
+	log.Printf("Health check passed: %s returned 200.", u)
+	return nil
+}
+
+// isOfficialBuildpack reports whether u names one of Heroku's own
+// buildpacks, which support every current stack.
+func isOfficialBuildpack(u string) bool {
+	return strings.HasPrefix(u, "heroku/") ||
+		strings.HasPrefix(u, "https://github.com/heroku/heroku-buildpack-") ||
+		strings.HasPrefix(u, "https://buildpack-registry.s3.amazonaws.com/buildpacks/heroku/")
+}
@@ -0,0 +1,20 @@
+package hk
+
+import "testing"
+
+func TestIsOfficialBuildpack(t *testing.T) {
+	for _, tt := range []struct {
+		url  string
+		want bool
+	}{
+		{"heroku/ruby", true},
+		{"https://github.com/heroku/heroku-buildpack-nodejs", true},
+		{"https://github.com/heroku/heroku-buildpack-nodejs#v200", true},
+		{"https://github.com/example/buildpack-ffmpeg", false},
+		{"example/ffmpeg", false},
+	} {
+		if got := isOfficialBuildpack(tt.url); got != tt.want {
+			t.Errorf("isOfficialBuildpack(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
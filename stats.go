@@ -0,0 +1,297 @@
+package hk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/heroku/hk/term"
+)
+
+var cmdStats = &Command{
+	Run:      runStats,
+	Usage:    "stats [--weeks <n>] [--telemetry on|off]",
+	Category: "hk",
+	Short:    "show your hk usage" + extra,
+	Long: `
+Stats summarizes your own use of hk from the history log kept in
+~/.hk/history: the commands and apps you use most, and how many
+deploys (deploy, push, rollback, and promotions) you ran each week.
+The history log holds the time, command name, and app of each
+command, never other arguments. Set history = false in the config
+file to stop keeping it.
+
+Anonymous telemetry is off unless you turn it on. When it's on, hk
+sends the hk maintainers, at most once a week, how many times you
+ran each command, with hk's version and platform. App names,
+arguments, and account details are never sent. Turning it on shows
+the report it would send and asks you to confirm.
+
+Options:
+
+    --weeks <n>         number of weeks of deploys to show (default 8)
+    --telemetry on|off  turn anonymous telemetry on or off
+
+Examples:
+
+    $ hk stats
+    Commands:
+      log     112
+      dynos    64
+      deploy   23
+    Apps:
+      myapp            180
+      myapp-staging     41
+    Deploys per week:
+      Sep 28  ###   3
+      Oct  5  #     1
+      Oct 12  ####  4
+
+    $ hk stats --telemetry on
+    hk will send the hk maintainers this report at most once a week:
+    {"version":"...","platform":"linux-amd64","commands":{"dynos":3,"log":7}}
+    Turn on anonymous telemetry? [y/N] y
+    Turned on anonymous telemetry.
+`,
+}
+
+var (
+	flagStatsWeeks     int
+	flagStatsTelemetry string
+)
+
+func init() {
+	cmdStats.Flag.IntVar(&flagStatsWeeks, "weeks", 8, "weeks of deploys to show")
+	cmdStats.Flag.StringVar(&flagStatsTelemetry, "telemetry", "", "on or off")
+}
+
+// deployCommands are the commands stats counts as deploys.
+var deployCommands = map[string]bool{
+	"bluegreen-promote": true,
+	"deploy":            true,
+	"pipeline-promote":  true,
+	"push":              true,
+	"rollback":          true,
+}
+
+func runStats(ctx *Context, args []string) {
+	if len(args) != 0 || flagStatsWeeks < 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	switch flagStatsTelemetry {
+	case "":
+	case "on":
+		enableTelemetry(ctx.Stdout)
+		return
+	case "off":
+		must(saveTelemetryState(telemetryState{}))
+		log.Println("Turned off anonymous telemetry.")
+		return
+	default:
+		ctx.printUsage()
+		exit(2)
+	}
+
+	entries, err := readHistory()
+	must(err)
+	if len(entries) == 0 {
+		printFatal("no history yet in %s.", historyPath())
+	}
+	printStats(ctx.Stdout, entries, time.Now(), flagStatsWeeks)
+}
+
+// printStats prints the most used commands and apps in entries, and the
+// deploys in each of the weeks weeks up to now.
+func printStats(out io.Writer, entries []historyEntry, now time.Time, weeks int) {
+	commands := make(map[string]int)
+	apps := make(map[string]int)
+	for _, e := range entries {
+		commands[e.Command]++
+		if e.App != "" {
+			apps[e.App]++
+		}
+	}
+	w := tabwriter.NewWriter(out, 1, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range topCounts(commands, 10) {
+		fmt.Fprintf(w, "  %s\t%5d\n", c.name, c.n)
+	}
+	if len(apps) > 0 {
+		fmt.Fprintln(w, "Apps:")
+		for _, c := range topCounts(apps, 10) {
+			fmt.Fprintf(w, "  %s\t%5d\n", c.name, c.n)
+		}
+	}
+	w.Flush()
+
+	first := weekStart(now).AddDate(0, 0, -7*(weeks-1))
+	deploys := make([]int, weeks)
+	for _, e := range entries {
+		t := e.Time.In(now.Location())
+		if deployCommands[e.Command] && !t.Before(first) {
+			if i := int(weekStart(t).Sub(first).Hours()+12) / (7 * 24); i < weeks {
+				deploys[i]++
+			}
+		}
+	}
+	max := 0
+	for _, n := range deploys {
+		if n > max {
+			max = n
+		}
+	}
+	fmt.Fprintln(out, "Deploys per week:")
+	w = tabwriter.NewWriter(out, 1, 2, 2, ' ', 0)
+	for i, n := range deploys {
+		fmt.Fprintf(w, "  %s\t%s\t%d\n", first.AddDate(0, 0, 7*i).Format("Jan _2"), strings.Repeat("#", n), n)
+	}
+	w.Flush()
+}
+
+// weekStart returns midnight on the Sunday starting t's week.
+func weekStart(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d-int(t.Weekday()), 0, 0, 0, 0, t.Location())
+}
+
+type count struct {
+	name string
+	n    int
+}
+
+// topCounts returns the n largest counts in m, largest first.
+func topCounts(m map[string]int, n int) []count {
+	var counts []count
+	for name, c := range m {
+		counts = append(counts, count{name, c})
+	}
+	sort.Sort(countsByN(counts))
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+type countsByN []count
+
+func (a countsByN) Len() int      { return len(a) }
+func (a countsByN) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a countsByN) Less(i, j int) bool {
+	if a[i].n != a[j].n {
+		return a[i].n > a[j].n
+	}
+	return a[i].name < a[j].name
+}
+
+// telemetryURL is where reports are sent when telemetry is on.
+const telemetryURL = "https://hk.heroku.com/telemetry"
+
+// A telemetryReport is everything telemetry sends: how many times each
+// command was run since the last report.
+type telemetryReport struct {
+	Version  string         `json:"version"`
+	Platform string         `json:"platform"`
+	Commands map[string]int `json:"commands"`
+}
+
+// telemetryState records whether the user agreed to telemetry, and when
+// the last report was sent.
+type telemetryState struct {
+	Enabled  bool      `json:"enabled"`
+	LastSent time.Time `json:"last_sent"`
+}
+
+func telemetryPath() string {
+	return filepath.Join(hkHome(), "telemetry.json")
+}
+
+func loadTelemetryState() (telemetryState, error) {
+	var st telemetryState
+	b, err := ioutil.ReadFile(telemetryPath())
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	return st, json.Unmarshal(b, &st)
+}
+
+func saveTelemetryState(st telemetryState) error {
+	b, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(hkHome(), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(telemetryPath(), b, 0600)
+}
+
+// newTelemetryReport counts the commands in entries run after since.
+func newTelemetryReport(entries []historyEntry, since time.Time) telemetryReport {
+	r := telemetryReport{Version: Version, Platform: plat, Commands: make(map[string]int)}
+	for _, e := range entries {
+		if e.Time.After(since) {
+			r.Commands[e.Command]++
+		}
+	}
+	return r
+}
+
+// enableTelemetry shows the report telemetry would send, and turns
+// telemetry on if the user agrees. Consent can only be given at a
+// terminal.
+func enableTelemetry(w io.Writer) {
+	if !term.IsTerminal(os.Stdin) {
+		printFatal("turning on telemetry needs your confirmation; run it at a terminal.")
+	}
+	entries, err := readHistory()
+	must(err)
+	b, err := json.Marshal(newTelemetryReport(entries, time.Now().AddDate(0, 0, -7)))
+	must(err)
+	fmt.Fprintln(w, "hk will send the hk maintainers this report at most once a week:")
+	fmt.Fprintln(w, string(b))
+	mustConfirm("Turn on anonymous telemetry?")
+	must(saveTelemetryState(telemetryState{Enabled: true, LastSent: time.Now()}))
+	log.Println("Turned on anonymous telemetry.")
+}
+
+// maybeSendTelemetry sends a report of the commands run since the last
+// one, if the user turned telemetry on and a week has passed. Failures
+// are ignored; the report is tried again on the next run.
+func maybeSendTelemetry() {
+	st, err := loadTelemetryState()
+	if err != nil || !st.Enabled || time.Since(st.LastSent) < 7*24*time.Hour {
+		return
+	}
+	entries, err := readHistory()
+	if err != nil {
+		return
+	}
+	b, err := json.Marshal(newTelemetryReport(entries, st.LastSent))
+	if err != nil {
+		return
+	}
+	c := &http.Client{Timeout: 2 * time.Second}
+	res, err := c.Post(telemetryURL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode/100 == 2 {
+		st.LastSent = time.Now()
+		saveTelemetryState(st)
+	}
+}
@@ -0,0 +1,52 @@
+package hk
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestPrintStats(t *testing.T) {
+	now := time.Date(2014, 1, 15, 12, 0, 0, 0, time.UTC) // a Wednesday
+	day := 24 * time.Hour
+	entries := []historyEntry{
+		{now.Add(-20 * day), "deploy", "myapp"},
+		{now.Add(-8 * day), "log", "myapp"},
+		{now.Add(-8 * day), "push", "myapp"},
+		{now.Add(-7 * day), "rollback", "myapp"},
+		{now.Add(-1 * day), "log", "myapp-staging"},
+		{now.Add(-1 * day), "log", "myapp"},
+		{now, "apps", ""},
+	}
+	var buf bytes.Buffer
+	printStats(&buf, entries, now, 2)
+	want := `Commands:
+  log           3
+  apps          1
+  deploy        1
+  push          1
+  rollback      1
+Apps:
+  myapp              5
+  myapp-staging      1
+Deploys per week:
+  Jan  5  ##  2
+  Jan 12      0
+`
+	if buf.String() != want {
+		t.Errorf("printStats output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestNewTelemetryReport(t *testing.T) {
+	now := time.Date(2014, 1, 15, 12, 0, 0, 0, time.UTC)
+	entries := []historyEntry{
+		{now.Add(-48 * time.Hour), "log", "myapp"},
+		{now.Add(-time.Hour), "log", "myapp"},
+		{now, "dynos", "myapp"},
+	}
+	r := newTelemetryReport(entries, now.Add(-24*time.Hour))
+	if len(r.Commands) != 2 || r.Commands["log"] != 1 || r.Commands["dynos"] != 1 {
+		t.Errorf("report commands = %v, want map[dynos:1 log:1]", r.Commands)
+	}
+}
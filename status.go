@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"encoding/json"
@@ -44,10 +44,10 @@ type statusIssue struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
-func runStatus(cmd *Command, args []string) {
+func runStatus(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	herokuStatusHost := "status.heroku.com"
 	if e := os.Getenv("HEROKU_STATUS_HOST"); e != "" {
@@ -63,11 +63,21 @@ func runStatus(cmd *Command, args []string) {
 	err = json.NewDecoder(res.Body).Decode(&sr)
 	must(err)
 
-	fmt.Println("Production:  ", statusValueFromColor(sr.Status.Production))
-	fmt.Println("Development: ", statusValueFromColor(sr.Status.Development))
+	fmt.Fprintln(ctx.Stdout, "Production:  ", statusValueFromColor(sr.Status.Production))
+	fmt.Fprintln(ctx.Stdout, "Development: ", statusValueFromColor(sr.Status.Development))
 }
 
 func statusValueFromColor(color string) string {
+	if accessibleOutput {
+		switch color {
+		case "green":
+			return "[OK] No known issues at this time."
+		case "yellow":
+			return "[WARNING] Minor issues (yellow)."
+		case "red":
+			return "[ERROR] Major issues (red)."
+		}
+	}
 	if color == "green" {
 		return "No known issues at this time."
 	}
@@ -0,0 +1,52 @@
+package hk
+
+import (
+	"log"
+	"strings"
+)
+
+var cmdStop = &Command{
+	Run:      runStop,
+	Usage:    "stop <type or name>",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "stop dynos",
+	Long: `
+Stop a single dyno, or all dynos of a process type. Stopping a
+one-off dyno cancels its command. Dynos of a process type that is
+scaled up are started again by the platform; use 'hk scale' to
+keep them stopped.
+
+Examples:
+
+    $ hk stop run.4821
+    Stopped run.4821 dyno on myapp.
+
+    $ hk stop worker
+    Stopped worker dynos on myapp.
+`,
+}
+
+var cmdKill = &Command{
+	Run:      runStop,
+	Usage:    "kill <type or name>",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "stop dynos (alias for stop)" + extra,
+	Long:     `Kill is an alias for stop. See 'hk help stop'.`,
+}
+
+func runStop(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	target := args[0]
+	must(ctx.Client.Post(nil, "/apps/"+appname+"/dynos/"+target+"/actions/stop", nil))
+	if strings.Contains(target, ".") {
+		log.Printf("Stopped %s dyno on %s.", target, appname)
+	} else {
+		log.Printf("Stopped %s dynos on %s.", target, appname)
+	}
+}
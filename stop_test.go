@@ -0,0 +1,26 @@
+package hk
+
+import (
+	"testing"
+
+	"github.com/heroku/hk/hktest"
+)
+
+func TestStop(t *testing.T) {
+	srv := hktest.NewServer(
+		hktest.Fixture{Method: "POST", Path: "/apps/myapp/dynos/run.4821/actions/stop", Status: 202},
+		hktest.Fixture{Method: "POST", Path: "/apps/myapp/dynos/worker/actions/stop", Status: 202},
+	)
+	defer srv.Close()
+	for _, args := range [][]string{
+		{"stop", "-a", "myapp", "run.4821"},
+		{"kill", "-a", "myapp", "worker"},
+	} {
+		if _, status := runTestCommand(t, srv, args...); status != 0 {
+			t.Errorf("hk %v: status = %d, want 0", args, status)
+		}
+	}
+	if u := srv.Unmatched(); len(u) != 0 {
+		t.Errorf("unmatched requests: %v", u)
+	}
+}
@@ -40,6 +40,13 @@ func Lines() (int, error) {
 	return strconv.Atoi(cols)
 }
 
+// ClearScreen clears the terminal f and moves the cursor to its top left
+// corner.
+func ClearScreen(f *os.File) error {
+	_, err := f.WriteString("\x1b[H\x1b[2J")
+	return err
+}
+
 // helpers
 
 func stty(f *os.File, args ...string) *exec.Cmd {
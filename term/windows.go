@@ -30,3 +30,8 @@ func Cols() (int, error) {
 func Lines() (int, error) {
 	return 24, nil
 }
+
+// ClearScreen is a no-op on Windows. It returns nil.
+func ClearScreen(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,27 @@
+package hk
+
+import (
+	"log"
+	"os"
+
+	"github.com/heroku/hk/term"
+)
+
+var flagQuiet bool
+
+func init() {
+	for _, cmd := range []*Command{cmdCreate, cmdAddonAdd, cmdScale} {
+		cmd.Flag.BoolVar(&flagQuiet, "q", false, "don't suggest next steps")
+	}
+}
+
+// printTip suggests a next step after a command, such as how to deploy a
+// new app. Tips are only for people at a terminal: they are left out
+// when stderr isn't a terminal, when -q is given, or when the config file
+// sets tips = false.
+func printTip(message string, args ...interface{}) {
+	if flagQuiet || configValue("tips") == "false" || !term.IsTerminal(os.Stderr) {
+		return
+	}
+	log.Println(colorizeMessage("cyan", "tip:", message, args...))
+}
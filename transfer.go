@@ -1,83 +1,273 @@
-package main
+package hk
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"text/tabwriter"
+	"time"
 
 	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/term"
 )
 
 var cmdTransfer = &Command{
 	Run:      runTransfer,
-	Usage:    "transfer <email>",
+	Usage:    "transfer (<email> | --org <org>)",
 	NeedsApp: true,
 	Category: "app",
 	Short:    "transfer app ownership to a collaborator" + extra,
+	Long: `
+Transfer requests that a collaborator take ownership of the app.
+The transfer happens when they accept it with 'hk transfer-accept'.
+
+With --org, the app is moved into an organization you belong to
+right away instead.
+
+Examples:
+
+    $ hk transfer user@test.com
+    Requested transfer of myapp to user@test.com.
+
+    $ hk transfer --org acme
+    Transferred myapp to acme.
+`,
+}
+
+var flagTransferOrg string
+
+func init() {
+	cmdTransfer.Flag.StringVar(&flagTransferOrg, "org", "", "organization name")
 }
 
-func runTransfer(cmd *Command, args []string) {
-	appname := mustApp()
+func runTransfer(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if flagTransferOrg != "" {
+		if len(args) != 0 {
+			ctx.printUsage()
+			exit(2)
+		}
+		app, err := transferAppToOrg(appname, flagTransferOrg)
+		must(err)
+		log.Printf("Transferred %s to %s.", app.Name, flagTransferOrg)
+		return
+	}
 	if len(args) != 1 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
 	recipient := args[0]
-	xfer, err := client.AppTransferCreate(appname, recipient)
+	xfer, err := ctx.Client.AppTransferCreate(appname, recipient)
 	must(err)
 	log.Printf("Requested transfer of %s to %s.", xfer.App.Name, xfer.Recipient.Email)
 }
 
 var cmdTransfers = &Command{
 	Run:      runTransfers,
-	Usage:    "transfers",
+	Usage:    "transfers [--watch [--interval <duration>]]",
 	NeedsApp: true,
 	Category: "app",
 	Short:    "list existing app transfers" + extra,
+	Long: `
+Transfers lists the app transfers you're part of: inbound ones, to
+you, and outbound ones, from you. Each shows the other account, its
+state, and when it was requested. Pending inbound transfers wait
+for you to accept or decline them with 'hk transfer-accept' or 'hk
+transfer-decline'.
+
+With --watch, transfers keeps running, and prints each new inbound
+transfer as it's requested.
+
+Options:
+
+    --watch                 wait for new inbound transfers
+    --interval <duration>   how often to check for new transfers with
+                            --watch (default 30s)
+
+Examples:
+
+    $ hk transfers
+    myapp      in   from bob@example.com    pending   Jan 13 21:20
+    otherapp   out  to alice@example.com    accepted  Jan 10 09:02
+
+    $ hk transfers --watch
+    Waiting for inbound transfers.
+    Inbound transfer of newapp from bob@example.com.
+`,
 }
 
-func runTransfers(cmd *Command, args []string) {
-	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+var (
+	flagTransfersWatch    bool
+	flagTransfersInterval time.Duration
+)
+
+func init() {
+	cmdTransfers.Flag.BoolVar(&flagTransfersWatch, "watch", false, "wait for new inbound transfers")
+	cmdTransfers.Flag.DurationVar(&flagTransfersInterval, "interval", 30*time.Second, "how often to check for new transfers")
+}
+
+func runTransfers(ctx *Context, args []string) {
+	if len(args) != 0 || flagTransfersInterval <= 0 {
+		ctx.printUsage()
+		exit(2)
 	}
-	transfers, err := client.AppTransferList(nil)
+	account, err := ctx.Client.AccountInfo()
 	must(err)
+	transfers, err := ctx.Client.AppTransferList(nil)
+	must(err)
+	if flagTransfersWatch {
+		watchTransfers(account.Id, transfers, flagTransfersInterval)
+		return
+	}
 
-	w := tabwriter.NewWriter(os.Stdout, 1, 2, 2, ' ', 0)
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
 	defer w.Flush()
 	for i := range transfers {
-		listTransfer(w, transfers[i])
+		listTransfer(w, transfers[i], account.Id)
+	}
+}
+
+// watchTransfers polls for transfers every interval, printing those that
+// are pending to the account with id me and aren't in seen.
+func watchTransfers(me string, seen []heroku.AppTransfer, interval time.Duration) {
+	known := make(map[string]bool)
+	for _, t := range seen {
+		known[t.Id] = true
+	}
+	log.Println("Waiting for inbound transfers.")
+	for {
+		time.Sleep(interval)
+		transfers, err := client.AppTransferList(nil)
+		if err != nil {
+			printWarning("couldn't list transfers: %s", err)
+			continue
+		}
+		for _, t := range pendingInboundTransfers(transfers, me) {
+			if !known[t.Id] {
+				known[t.Id] = true
+				log.Printf("Inbound transfer of %s from %s.", t.App.Name, t.Owner.Email)
+			}
+		}
 	}
 }
 
-func listTransfer(w io.Writer, t heroku.AppTransfer) {
+// pendingInboundTransfers returns the transfers to the account with id me
+// that haven't been accepted or declined.
+func pendingInboundTransfers(transfers []heroku.AppTransfer, me string) []heroku.AppTransfer {
+	var in []heroku.AppTransfer
+	for _, t := range transfers {
+		if t.Recipient.Id == me && t.State == "pending" {
+			in = append(in, t)
+		}
+	}
+	return in
+}
+
+func listTransfer(w io.Writer, t heroku.AppTransfer, me string) {
+	dir, other := "out", "to "+t.Recipient.Email
+	if t.Recipient.Id == me {
+		dir, other = "in", "from "+t.Owner.Email
+	}
 	listRec(w,
 		t.App.Name,
-		abbrev(t.Owner.Email, 10),
-		abbrev(t.Recipient.Email, 10),
+		dir,
+		other,
 		t.State,
-		prettyTime{t.UpdatedAt},
+		prettyTime{t.CreatedAt},
 	)
 }
 
 var cmdTransferAccept = &Command{
 	Run:      runTransferAccept,
-	Usage:    "transfer-accept",
+	Usage:    "transfer-accept [--all [-y]]",
 	NeedsApp: true,
 	Category: "app",
 	Short:    "accept an inbound app transfer" + extra,
+	Long: `
+Transfer-accept accepts the pending transfer of the app to you.
+
+With --all, it accepts every pending inbound transfer instead. At a
+terminal, it lists them and asks for confirmation first, unless
+--yes is given.
+
+Options:
+
+    --all      accept all pending inbound transfers
+    -y, --yes  don't ask for confirmation
+
+Examples:
+
+    $ hk transfer-accept -a myapp
+    Accepted transfer of myapp from bob@example.com.
+
+    $ hk transfer-accept --all
+    myapp     from bob@example.com  Jan 13 21:20
+    otherapp  from bob@example.com  Jan 13 21:21
+    Accept 2 transfers? [y/N] y
+    Accepted transfer of myapp from bob@example.com.
+    Accepted transfer of otherapp from bob@example.com.
+`,
 }
 
-func runTransferAccept(cmd *Command, args []string) {
-	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+var (
+	flagTransferAcceptAll bool
+	flagTransferAcceptYes bool
+)
+
+func init() {
+	cmdTransferAccept.Flag.BoolVar(&flagTransferAcceptAll, "all", false, "accept all pending inbound transfers")
+	cmdTransferAccept.Flag.BoolVar(&flagTransferAcceptYes, "y", false, "don't ask for confirmation")
+	cmdTransferAccept.Flag.BoolVar(&flagTransferAcceptYes, "yes", false, "don't ask for confirmation")
+}
+
+func runTransferAccept(ctx *Context, args []string) {
+	if len(args) != 0 || (flagTransferAcceptYes && !flagTransferAcceptAll) {
+		ctx.printUsage()
+		exit(2)
+	}
+	if flagTransferAcceptAll {
+		acceptAllTransfers()
+		return
 	}
-	xfer, err := client.AppTransferUpdate(mustApp(), "accepted")
+	xfer, err := ctx.Client.AppTransferUpdate(ctx.MustApp(), "accepted")
+	must(err)
+	log.Printf("Accepted transfer of %s from %s.", xfer.App.Name, xfer.Owner.Email)
+}
+
+// acceptAllTransfers accepts every pending transfer to the current
+// account. It keeps going when one fails, and exits nonzero after if any
+// did.
+func acceptAllTransfers() {
+	account, err := client.AccountInfo()
 	must(err)
-	log.Printf("Accepted transfer of %s from %s.", xfer.App.Name, xfer.Recipient.Email)
+	transfers, err := client.AppTransferList(nil)
+	must(err)
+	in := pendingInboundTransfers(transfers, account.Id)
+	if len(in) == 0 {
+		log.Println("No pending inbound transfers.")
+		return
+	}
+	if !flagTransferAcceptYes && term.IsTerminal(os.Stdin) {
+		w := tabwriter.NewWriter(os.Stderr, 1, 2, 2, ' ', 0)
+		for _, t := range in {
+			listRec(w, t.App.Name, "from "+t.Owner.Email, prettyTime{t.CreatedAt})
+		}
+		w.Flush()
+		mustConfirm(fmt.Sprintf("Accept %d transfers?", len(in)))
+	}
+	failed := 0
+	for _, t := range in {
+		if _, err := client.AppTransferUpdate(t.Id, "accepted"); err != nil {
+			printError("couldn't accept transfer of %s: %s", t.App.Name, err)
+			failed++
+			continue
+		}
+		log.Printf("Accepted transfer of %s from %s.", t.App.Name, t.Owner.Email)
+	}
+	if failed > 0 {
+		printFatal("%d of %d transfers weren't accepted.", failed, len(in))
+	}
 }
 
 var cmdTransferDecline = &Command{
@@ -88,12 +278,12 @@ var cmdTransferDecline = &Command{
 	Short:    "decline an inbound app transfer" + extra,
 }
 
-func runTransferDecline(cmd *Command, args []string) {
+func runTransferDecline(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	xfer, err := client.AppTransferUpdate(mustApp(), "declined")
+	xfer, err := ctx.Client.AppTransferUpdate(ctx.MustApp(), "declined")
 	must(err)
 	log.Printf("Declined transfer of %s to %s.", xfer.App.Name, xfer.Recipient.Email)
 }
@@ -106,12 +296,12 @@ var cmdTransferCancel = &Command{
 	Short:    "cancel an outbound app transfer" + extra,
 }
 
-func runTransferCancel(cmd *Command, args []string) {
+func runTransferCancel(ctx *Context, args []string) {
 	if len(args) != 0 {
-		cmd.printUsage()
-		os.Exit(2)
+		ctx.printUsage()
+		exit(2)
 	}
-	appname := mustApp()
-	must(client.AppTransferDelete(appname))
+	appname := ctx.MustApp()
+	must(ctx.Client.AppTransferDelete(appname))
 	log.Printf("Canceled transfer of %s.", appname)
 }
@@ -0,0 +1,33 @@
+package hk
+
+import (
+	"testing"
+
+	"github.com/heroku/hk/hktest"
+)
+
+func TestTransferAcceptAll(t *testing.T) {
+	srv := hktest.NewServer(
+		hktest.Fixture{Method: "GET", Path: "/account", Body: []byte(`{"id": "me", "email": "me@example.com"}`)},
+		hktest.Fixture{
+			Method: "GET", Path: "/account/app-transfers",
+			Body: []byte(`[
+				{"id": "t1", "app": {"name": "myapp"}, "owner": {"id": "bob", "email": "bob@example.com"}, "recipient": {"id": "me", "email": "me@example.com"}, "state": "pending"},
+				{"id": "t2", "app": {"name": "otherapp"}, "owner": {"id": "me", "email": "me@example.com"}, "recipient": {"id": "bob", "email": "bob@example.com"}, "state": "pending"},
+				{"id": "t3", "app": {"name": "oldapp"}, "owner": {"id": "bob", "email": "bob@example.com"}, "recipient": {"id": "me", "email": "me@example.com"}, "state": "accepted"}
+			]`),
+		},
+		hktest.Fixture{Method: "PATCH", Path: "/account/app-transfers/t1", Body: []byte(`{"id": "t1", "state": "accepted"}`)},
+	)
+	defer srv.Close()
+	defer func() { flagTransferAcceptAll, flagTransferAcceptYes = false, false }()
+	if _, status := runTestCommand(t, srv, "transfer-accept", "-a", "myapp", "--all", "-y"); status != 0 {
+		t.Fatalf("status = %d, want 0", status)
+	}
+	if u := srv.Unmatched(); len(u) != 0 {
+		t.Errorf("unmatched requests: %v", u)
+	}
+	if n := len(srv.Requests()); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+}
@@ -0,0 +1,105 @@
+package hk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// twoFactorTransport answers two-factor auth challenges from the API by
+// asking for a code and sending the request again with it. The code is
+// kept and sent with later requests, so a command making several
+// sensitive requests only asks once.
+type twoFactorTransport struct {
+	base   http.RoundTripper
+	prompt func() (string, error)
+
+	mu   sync.Mutex
+	code string
+}
+
+func (t *twoFactorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	t.mu.Lock()
+	code := t.code
+	t.mu.Unlock()
+
+	res, err := t.base.RoundTrip(withTwoFactorCode(req, body, code))
+	if err != nil || !isTwoFactorChallenge(res) {
+		return res, err
+	}
+	res.Body.Close()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.code == code {
+		if t.code, err = t.prompt(); err != nil {
+			return nil, err
+		}
+	}
+	return t.base.RoundTrip(withTwoFactorCode(req, body, t.code))
+}
+
+// withTwoFactorCode returns a copy of req with the given body, sending
+// code in the Heroku-Two-Factor-Code header if it isn't empty.
+func withTwoFactorCode(req *http.Request, body []byte, code string) *http.Request {
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	if code != "" {
+		r.Header.Set("Heroku-Two-Factor-Code", code)
+	}
+	if body != nil {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	return r
+}
+
+// isTwoFactorChallenge reports whether res asks for a two-factor auth
+// code, either with the Heroku-Two-Factor-Required header or with a
+// "two_factor" error id. It leaves res.Body readable.
+func isTwoFactorChallenge(res *http.Response) bool {
+	if res.StatusCode != http.StatusUnauthorized && res.StatusCode != http.StatusForbidden {
+		return false
+	}
+	if res.Header.Get("Heroku-Two-Factor-Required") != "" {
+		return true
+	}
+	b, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = ioutil.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return false
+	}
+	var e struct{ Id string }
+	return json.Unmarshal(b, &e) == nil && e.Id == "two_factor"
+}
+
+// promptTwoFactorCode asks for a two-factor auth code on stdin.
+func promptTwoFactorCode() (string, error) {
+	fmt.Fprint(os.Stderr, "Enter two-factor auth code: ")
+	var code string
+	if _, err := fmt.Scanln(&code); err != nil {
+		return "", fmt.Errorf("reading two-factor auth code: %s", err)
+	}
+	if code = strings.TrimSpace(code); code == "" {
+		return "", errors.New("no two-factor auth code given")
+	}
+	return code, nil
+}
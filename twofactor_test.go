@@ -0,0 +1,97 @@
+package hk
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTwoFactorTransport(t *testing.T) {
+	var codes []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code := r.Header.Get("Heroku-Two-Factor-Code")
+		codes = append(codes, code)
+		body, _ := ioutil.ReadAll(r.Body)
+		switch {
+		case r.URL.Path == "/header" && code == "":
+			w.Header().Set("Heroku-Two-Factor-Required", "true")
+			w.WriteHeader(http.StatusUnauthorized)
+		case code == "":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"id": "two_factor", "message": "code required"}`))
+		default:
+			w.Write(body)
+		}
+	}))
+	defer ts.Close()
+
+	prompts := 0
+	tr := &twoFactorTransport{
+		base: http.DefaultTransport,
+		prompt: func() (string, error) {
+			prompts++
+			return "123456", nil
+		},
+	}
+	c := &http.Client{Transport: tr}
+
+	res, err := c.Post(ts.URL+"/body", "application/json", strings.NewReader(`{"a":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != 200 || string(b) != `{"a":1}` {
+		t.Errorf("retried response = %d %s, want 200 with the original body", res.StatusCode, b)
+	}
+
+	// the code is reused for later requests without asking again
+	res, err = c.Get(ts.URL + "/header")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Errorf("status = %d, want 200", res.StatusCode)
+	}
+	if prompts != 1 {
+		t.Errorf("prompted %d times, want 1", prompts)
+	}
+	want := []string{"", "123456", "123456"}
+	if strings.Join(codes, ",") != strings.Join(want, ",") {
+		t.Errorf("codes sent = %q, want %q", codes, want)
+	}
+}
+
+func TestIsTwoFactorChallenge(t *testing.T) {
+	tests := []struct {
+		status int
+		header string
+		body   string
+		want   bool
+	}{
+		{401, "true", "", true},
+		{403, "", `{"id":"two_factor"}`, true},
+		{403, "", `{"id":"forbidden"}`, false},
+		{200, "true", "", false},
+		{401, "", "not json", false},
+	}
+	for _, test := range tests {
+		res := &http.Response{
+			StatusCode: test.status,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+		}
+		if test.header != "" {
+			res.Header.Set("Heroku-Two-Factor-Required", test.header)
+		}
+		if got := isTwoFactorChallenge(res); got != test.want {
+			t.Errorf("isTwoFactorChallenge(%d, %q, %q) = %v, want %v", test.status, test.header, test.body, got, test.want)
+		}
+		if b, _ := ioutil.ReadAll(res.Body); string(b) != test.body {
+			t.Errorf("body after check = %q, want %q", b, test.body)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"bytes"
@@ -33,7 +33,7 @@ This command is unlisted, since users never have to run it directly.
 `,
 }
 
-func runUpdate(cmd *Command, args []string) {
+func runUpdate(ctx *Context, args []string) {
 	if updater == nil {
 		printFatal("Dev builds don't support auto-updates")
 	}
@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"fmt"
@@ -13,6 +13,6 @@ var cmdURL = &Command{
 	Long:     `Prints the web URL for the app.`,
 }
 
-func runURL(cmd *Command, args []string) {
-	fmt.Println("https://" + mustApp() + ".herokuapp.com/")
+func runURL(ctx *Context, args []string) {
+	fmt.Fprintln(ctx.Stdout, "https://"+ctx.MustApp()+".herokuapp.com/")
 }
@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"fmt"
@@ -9,7 +9,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -133,7 +135,8 @@ func printError(message string, args ...interface{}) {
 }
 
 func printFatal(message string, args ...interface{}) {
-	log.Fatal(colorizeMessage("red", "error:", message, args...))
+	log.Println(colorizeMessage("red", "error:", message, args...))
+	exit(1)
 }
 
 func printWarning(message string, args ...interface{}) {
@@ -141,6 +144,12 @@ func printWarning(message string, args ...interface{}) {
 }
 
 func colorizeMessage(color, prefix, message string, args ...interface{}) string {
+	if accessibleOutput {
+		if prefix != "" {
+			prefix = textMarker(prefix) + " "
+		}
+		return prefix + fmt.Sprintf(message, args...)
+	}
 	prefResult := ""
 	if prefix != "" {
 		prefResult = ansi.Color(prefix, color+"+b") + " " + ansi.ColorCode("reset")
@@ -194,6 +203,31 @@ func roundDur(d, k time.Duration) int {
 	return int((d + k/2 - 1) / k)
 }
 
+// mustConfirm asks question on stderr and exits unless the answer read
+// from stdin is yes.
+func mustConfirm(question string) {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	var answer string
+	fmt.Scanln(&answer)
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return
+	}
+	printFatal("Canceled.")
+}
+
+// parseLongDuration is time.ParseDuration, plus whole days, e.g. "21d".
+func parseLongDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %s", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
 func abbrev(s string, n int) string {
 	if len(s) > n {
 		return s[:n-1] + "…"
@@ -228,7 +262,7 @@ func openURL(url string) error {
 	default:
 		if _, err := exec.LookPath("xdg-open"); err != nil {
 			log.Println("xdg-open is required to open web pages on " + runtime.GOOS)
-			os.Exit(2)
+			exit(2)
 		}
 		command = "xdg-open"
 		args = []string{command, url}
@@ -241,13 +275,23 @@ func runCommand(command string, args, env []string) error {
 		p, err := exec.LookPath(command)
 		if err != nil {
 			log.Printf("Error finding path to %q: %s\n", command, err)
-			os.Exit(2)
+			exit(2)
 		}
 		command = p
 	}
 	return sysExec(command, args, env)
 }
 
+// globMatch reports whether s matches pattern, where * matches any
+// sequence of characters and ? matches any single character.
+func globMatch(pattern, s string) bool {
+	re := regexp.QuoteMeta(pattern)
+	re = strings.Replace(re, `\*`, ".*", -1)
+	re = strings.Replace(re, `\?`, ".", -1)
+	ok, _ := regexp.MatchString("^"+re+"$", s)
+	return ok
+}
+
 func stringsIndex(s []string, item string) int {
 	for i := range s {
 		if s[i] == item {
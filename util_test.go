@@ -1,10 +1,11 @@
-package main
+package hk
 
 import (
 	"log"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func init() {
@@ -49,3 +50,45 @@ func TestNetrcPath(t *testing.T) {
 	}
 	os.Setenv("NETRC_PATH", "")
 }
+
+var globMatchTests = []struct {
+	pattern, s string
+	want       bool
+}{
+	{"Deploy *", "Deploy 0fda0ae", true},
+	{"Deploy *", "Rollback to v2", false},
+	{"Rollback*", "Rollback to v2", true},
+	{"*config*", "Set FOO config vars", true},
+	{"v?", "v2", true},
+	{"v?", "v12", false},
+	{"a.b", "axb", false},
+	{"Deploy to a/b*", "Deploy to a/b/c", true},
+}
+
+func TestGlobMatch(t *testing.T) {
+	for i, gt := range globMatchTests {
+		if got := globMatch(gt.pattern, gt.s); got != gt.want {
+			t.Errorf("%d. globMatch(%q, %q) => %t, want %t", i, gt.pattern, gt.s, got, gt.want)
+		}
+	}
+}
+
+func TestParseLongDuration(t *testing.T) {
+	tests := []struct {
+		s    string
+		want time.Duration
+		ok   bool
+	}{
+		{"21d", 21 * 24 * time.Hour, true},
+		{"72h", 72 * time.Hour, true},
+		{"1h30m", 90 * time.Minute, true},
+		{"xd", 0, false},
+		{"soon", 0, false},
+	}
+	for _, test := range tests {
+		got, err := parseLongDuration(test.s)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("parseLongDuration(%q) = %v, %v, want %v (ok=%v)", test.s, got, err, test.want, test.ok)
+		}
+	}
+}
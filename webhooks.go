@@ -0,0 +1,306 @@
+package hk
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bgentry/heroku-go"
+	"github.com/heroku/hk/hkclient"
+)
+
+var cmdWebhooks = &Command{
+	Run:      runWebhooks,
+	Usage:    "webhooks [-j]",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "list webhooks" + extra,
+	Long: `
+Lists the webhooks on an app, with the level and the entities each
+is notified about.
+
+Options:
+
+    -j, --json  print webhooks as JSON
+
+Example:
+
+    $ hk webhooks
+    01234567-89ab-cdef-0123-456789abcdef  notify  api:release,dyno  https://ci.example.com/hooks/heroku
+`,
+}
+
+func init() {
+	registerList(cmdWebhooks, hkclient.AppWebhook{})
+}
+
+func runWebhooks(ctx *Context, args []string) {
+	if len(args) != 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	hooks, err := ext().AppWebhookList(ctx.MustApp(), nil)
+	must(err)
+	if printList(ctx.Stdout, hooks) {
+		return
+	}
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	for _, h := range hooks {
+		listRec(w, h.Id, h.Level, strings.Join(h.Include, ","), h.URL)
+	}
+}
+
+var cmdWebhookAdd = &Command{
+	Run:      runWebhookAdd,
+	Usage:    "webhook-add [--include <entities>] [--level notify|sync] [--secret <secret>] [--authorization <value>] <url>",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "add a webhook" + extra,
+	Long: `
+Adds a webhook to an app, so that url is sent a notification each
+time one of the included entities changes. Entities include
+api:release, api:build, api:addon, api:addon-attachment, api:app,
+api:domain, api:formation, api:dyno (one-off dynos being created),
+and dyno (dynos changing state).
+
+Sync webhooks are retried until they succeed; notify webhooks are
+tried once. With --secret, each notification is signed with the
+secret, in its Heroku-Webhook-Hmac-SHA256 header.
+
+Options:
+
+    --include <entities>     comma-separated entities to be notified
+                             about (default api:release)
+    --level <level>          notify or sync (default notify)
+    --secret <secret>        secret to sign notifications with
+    --authorization <value>  Authorization header to send with
+                             notifications
+
+Example:
+
+    $ hk webhook-add --include api:release,dyno https://ci.example.com/hooks/heroku
+    Added webhook 01234567-89ab-cdef-0123-456789abcdef to myapp.
+`,
+}
+
+var (
+	flagWebhookInclude       string
+	flagWebhookLevel         string
+	flagWebhookSecret        string
+	flagWebhookAuthorization string
+)
+
+func init() {
+	cmdWebhookAdd.Flag.StringVar(&flagWebhookInclude, "include", "api:release", "entities to be notified about")
+	cmdWebhookAdd.Flag.StringVar(&flagWebhookLevel, "level", "notify", "notify or sync")
+	for _, cmd := range []*Command{cmdWebhookAdd, cmdWebhookRedeliver} {
+		cmd.Flag.StringVar(&flagWebhookSecret, "secret", "", "secret to sign notifications with")
+		cmd.Flag.StringVar(&flagWebhookAuthorization, "authorization", "", "Authorization header value")
+	}
+}
+
+func runWebhookAdd(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 || (flagWebhookLevel != "notify" && flagWebhookLevel != "sync") {
+		ctx.printUsage()
+		exit(2)
+	}
+	opts := hkclient.AppWebhookCreateOpts{URL: args[0], Level: flagWebhookLevel}
+	for _, e := range strings.Split(flagWebhookInclude, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			opts.Include = append(opts.Include, e)
+		}
+	}
+	if flagWebhookSecret != "" {
+		opts.Secret = &flagWebhookSecret
+	}
+	if flagWebhookAuthorization != "" {
+		opts.Authorization = &flagWebhookAuthorization
+	}
+	hook, err := ext().AppWebhookCreate(appname, opts)
+	must(err)
+	log.Printf("Added webhook %s to %s.", hook.Id, appname)
+}
+
+var cmdWebhookRemove = &Command{
+	Run:      runWebhookRemove,
+	Usage:    "webhook-remove <id>",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "remove a webhook" + extra,
+	Long: `
+Removes a webhook from an app.
+
+Example:
+
+    $ hk webhook-remove 01234567-89ab-cdef-0123-456789abcdef
+    Removed webhook 01234567-89ab-cdef-0123-456789abcdef from myapp.
+`,
+}
+
+func runWebhookRemove(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	must(ext().AppWebhookDelete(appname, args[0]))
+	log.Printf("Removed webhook %s from %s.", args[0], appname)
+}
+
+var cmdWebhookDeliveries = &Command{
+	Run:      runWebhookDeliveries,
+	Usage:    "webhook-deliveries [-n <count>] [--status <status>]",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "list recent webhook deliveries" + extra,
+	Long: `
+Lists the latest deliveries of notifications to an app's webhooks,
+newest first, with the event delivered, the delivery's status, the
+number of attempts, and the result of the last attempt: the HTTP
+status the webhook responded with, or why it couldn't be reached.
+
+Options:
+
+    -n <count>         number of deliveries to list (default 20)
+    --status <status>  only list deliveries with this status: pending,
+                       scheduled, retrying, failed, or succeeded
+
+Example:
+
+    $ hk webhook-deliveries --status failed
+    9a8b7c6d-...  2014-01-13T21:20:57Z  api:release  failed  3  503
+    5e4f3a2b-...  2014-01-13T19:02:11Z  dyno         failed  1  timeout
+`,
+}
+
+var (
+	flagWebhookDeliveriesCount  int
+	flagWebhookDeliveriesStatus string
+)
+
+func init() {
+	cmdWebhookDeliveries.Flag.IntVar(&flagWebhookDeliveriesCount, "n", 20, "number of deliveries")
+	cmdWebhookDeliveries.Flag.StringVar(&flagWebhookDeliveriesStatus, "status", "", "delivery status")
+}
+
+func runWebhookDeliveries(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 0 || flagWebhookDeliveriesCount < 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	lr := &heroku.ListRange{Field: "created_at", Max: 1000, Descending: true}
+	if flagWebhookDeliveriesStatus == "" {
+		lr.Max = flagWebhookDeliveriesCount
+	}
+	deliveries, err := ext().AppWebhookDeliveryList(appname, lr)
+	must(err)
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	n := 0
+	for _, d := range deliveries {
+		if flagWebhookDeliveriesStatus != "" && d.Status != flagWebhookDeliveriesStatus {
+			continue
+		}
+		if n++; n > flagWebhookDeliveriesCount {
+			break
+		}
+		listRec(w, d.Id, d.CreatedAt.UTC().Format("2006-01-02T15:04:05Z"), d.Event.Include, d.Status, d.NumAttempts, lastAttemptResult(&d))
+	}
+}
+
+// lastAttemptResult describes the last attempt of a delivery: the HTTP
+// status its webhook responded with, or the error that kept it from
+// responding.
+func lastAttemptResult(d *hkclient.AppWebhookDelivery) string {
+	switch a := d.LastAttempt; {
+	case a == nil:
+		return ""
+	case a.Code != nil:
+		return strconv.Itoa(*a.Code)
+	case a.ErrorClass != nil:
+		return *a.ErrorClass
+	}
+	return ""
+}
+
+var cmdWebhookRedeliver = &Command{
+	Run:      runWebhookRedeliver,
+	Usage:    "webhook-redeliver [--secret <secret>] [--authorization <value>] <delivery id>",
+	NeedsApp: true,
+	Category: "app",
+	Short:    "send a webhook notification again" + extra,
+	Long: `
+Webhook-redeliver sends the event of a past delivery to its webhook
+again, such as after fixing the webhook's receiver. The notification
+is sent from hk, not from Heroku.
+
+Heroku doesn't reveal a webhook's secret or Authorization header, so
+give them again with --secret and --authorization for receivers that
+check them.
+
+Options:
+
+    --secret <secret>        secret to sign the notification with
+    --authorization <value>  Authorization header to send
+
+Example:
+
+    $ hk webhook-redeliver --secret s3cret 9a8b7c6d-5e4f-3a2b-1c0d-9e8f7a6b5c4d
+    Redelivered api:release event to https://ci.example.com/hooks/heroku: 200 OK.
+`,
+}
+
+func runWebhookRedeliver(ctx *Context, args []string) {
+	appname := ctx.MustApp()
+	if len(args) != 1 {
+		ctx.printUsage()
+		exit(2)
+	}
+	d, err := ext().AppWebhookDeliveryInfo(appname, args[0])
+	must(err)
+	hook, err := ext().AppWebhookInfo(appname, d.Webhook.Id)
+	must(err)
+	event, err := ext().AppWebhookEventInfo(appname, d.Event.Id)
+	must(err)
+
+	req, err := newWebhookRequest(hook.URL, d.Id, event.Payload, flagWebhookSecret, flagWebhookAuthorization)
+	must(err)
+	res, err := http.DefaultClient.Do(req)
+	must(err)
+	res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		printFatal("Redelivering %s event to %s failed: %s.", event.Include, hook.URL, res.Status)
+	}
+	log.Printf("Redelivered %s event to %s: %s.", event.Include, hook.URL, res.Status)
+}
+
+// newWebhookRequest returns a request that notifies a webhook at url of
+// payload, as Heroku does. If secret isn't empty, the request is signed
+// with it.
+func newWebhookRequest(url, deliveryId string, payload []byte, secret, authorization string) (*http.Request, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", hkAgent)
+	req.Header.Set("Heroku-Webhook-Id", deliveryId)
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(payload)
+		req.Header.Set("Heroku-Webhook-Hmac-SHA256", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return req, nil
+}
@@ -0,0 +1,50 @@
+package hk
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/heroku/hk/hktest"
+)
+
+func TestWebhookRedeliver(t *testing.T) {
+	var got *http.Request
+	var gotBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer receiver.Close()
+
+	payload := `{"action":"create","resource":"release","data":{"version":42}}`
+	srv := hktest.NewServer(
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/webhook-deliveries/d1", Body: json.RawMessage(`{"id": "d1", "status": "failed", "event": {"id": "e1", "include": "api:release"}, "webhook": {"id": "w1", "level": "notify"}}`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/webhooks/w1", Body: json.RawMessage(`{"id": "w1", "url": "` + receiver.URL + `/hook"}`)},
+		hktest.Fixture{Method: "GET", Path: "/apps/myapp/webhook-events/e1", Body: json.RawMessage(`{"id": "e1", "include": "api:release", "payload": ` + payload + `}`)},
+	)
+	defer srv.Close()
+	defer func() { flagWebhookSecret, flagWebhookAuthorization = "", "" }()
+	if _, status := runTestCommand(t, srv, "webhook-redeliver", "-a", "myapp", "--secret", "s3cret", "--authorization", "Bearer abc", "d1"); status != 0 {
+		t.Fatalf("status = %d, unmatched requests %v", status, srv.Unmatched())
+	}
+	if got == nil {
+		t.Fatal("webhook wasn't notified")
+	}
+	if string(gotBody) != payload || got.URL.Path != "/hook" {
+		t.Errorf("notified %s with %s, want /hook with %s", got.URL.Path, gotBody, payload)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(payload))
+	if sig := got.Header.Get("Heroku-Webhook-Hmac-SHA256"); sig != base64.StdEncoding.EncodeToString(mac.Sum(nil)) {
+		t.Errorf("signature = %q", sig)
+	}
+	if got.Header.Get("Authorization") != "Bearer abc" || got.Header.Get("Heroku-Webhook-Id") != "d1" {
+		t.Errorf("headers = %v", got.Header)
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package hk
 
 import (
 	"fmt"
@@ -20,6 +20,6 @@ To suppress the error message, run 'hk app 2>/dev/null'.
 `,
 }
 
-func runWhichApp(cmd *Command, args []string) {
-	fmt.Println(mustApp())
+func runWhichApp(ctx *Context, args []string) {
+	fmt.Fprintln(ctx.Stdout, ctx.MustApp())
 }
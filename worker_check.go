@@ -0,0 +1,138 @@
+package hk
+
+import (
+	"bufio"
+	"regexp"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+var cmdWorkerCheck = &Command{
+	Run:      runWorkerCheck,
+	Usage:    "worker-check [--type <type>] --log-pattern <regexp> [--within <duration>]",
+	NeedsApp: true,
+	Category: "dyno",
+	Short:    "check that worker dynos are logging heartbeats" + extra,
+	Long: `
+Worker-check looks for a liveness message from each dyno of a
+process type in the app's recent log, and exits with status 1 if
+any dyno hasn't logged one lately. It's meant to be run by cron or
+an external monitor for queue workers and other processes that
+don't serve HTTP.
+
+A dyno that started within the window is not counted as silent
+until it has had the whole window to log a heartbeat.
+
+Options:
+
+    --type <type>             process type to check (default worker)
+    --log-pattern <regexp>    message that shows a dyno is alive
+    --within <duration>       how recently each dyno must have
+                              logged the message (default 5m)
+    --lines <N>               number of recent log lines to search
+                              (default 1500)
+
+Example:
+
+    $ hk worker-check --type worker --log-pattern heartbeat --within 5m
+    worker.1  up  31s    ok
+    worker.2  up  never  SILENT
+    $ echo $?
+    1
+`,
+}
+
+var (
+	flagWorkerType    string
+	flagWorkerPattern string
+	flagWorkerWithin  time.Duration
+	flagWorkerLines   int
+)
+
+func init() {
+	cmdWorkerCheck.Flag.StringVar(&flagWorkerType, "type", "worker", "process type to check")
+	cmdWorkerCheck.Flag.StringVar(&flagWorkerPattern, "log-pattern", "", "regexp a live dyno logs")
+	cmdWorkerCheck.Flag.DurationVar(&flagWorkerWithin, "within", 5*time.Minute, "how recently dynos must have logged")
+	cmdWorkerCheck.Flag.IntVar(&flagWorkerLines, "lines", 1500, "number of recent log lines to search")
+}
+
+func runWorkerCheck(ctx *Context, args []string) {
+	if len(args) != 0 || flagWorkerPattern == "" || flagWorkerWithin <= 0 {
+		ctx.printUsage()
+		exit(2)
+	}
+	pattern, err := regexp.Compile(flagWorkerPattern)
+	if err != nil {
+		printFatal("invalid --log-pattern: %s", err)
+	}
+	appname := ctx.MustApp()
+	dynos := findDynos(appname, []string{flagWorkerType})
+	if len(dynos) == 0 {
+		printFatal("no %s dynos running on %s.", flagWorkerType, appname)
+	}
+
+	opts := heroku.LogSessionCreateOpts{Dyno: &flagWorkerType, Lines: &flagWorkerLines}
+	body := openLog(appname, &opts)
+	lastSeen, err := lastLogMatches(bufio.NewScanner(body), pattern)
+	body.Close()
+	must(err)
+
+	now := time.Now()
+	silent := 0
+	w := tabwriter.NewWriter(ctx.Stdout, 1, 2, 2, ' ', 0)
+	for i := range dynos {
+		d := &dynos[i]
+		seen := "never"
+		if t, ok := lastSeen[d.Name]; ok {
+			seen = prettyDuration{now.Sub(t)}.String()
+		}
+		status := "ok"
+		if dynoSilent(d, lastSeen[d.Name], flagWorkerWithin, now) {
+			status = "SILENT"
+			silent++
+		}
+		listRec(w, d.Name, d.State, seen, status)
+	}
+	w.Flush()
+	if silent > 0 {
+		exit(1)
+	}
+}
+
+// lastLogMatches returns the time of the last line from each dyno whose
+// message matches pattern.
+func lastLogMatches(s *bufio.Scanner, pattern *regexp.Regexp) (map[string]time.Time, error) {
+	last := make(map[string]time.Time)
+	for s.Scan() {
+		line := s.Text()
+		m := logLineRE.FindStringSubmatchIndex(line)
+		if m == nil || !pattern.MatchString(line[m[1]:]) {
+			continue
+		}
+		i := 0
+		for i < len(line) && line[i] != ' ' {
+			i++
+		}
+		t, err := time.Parse(time.RFC3339Nano, line[:i])
+		if err != nil {
+			continue
+		}
+		dyno := line[m[4]:m[5]]
+		if t.After(last[dyno]) {
+			last[dyno] = t
+		}
+	}
+	return last, s.Err()
+}
+
+// dynoSilent reports whether d has gone longer than within without
+// logging a heartbeat, last being the time of its latest one (zero if
+// none). Dynos that changed state within the window get the rest of it.
+func dynoSilent(d *heroku.Dyno, last time.Time, within time.Duration, now time.Time) bool {
+	if now.Sub(last) <= within {
+		return false
+	}
+	return now.Sub(d.UpdatedAt) > within
+}
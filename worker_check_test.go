@@ -0,0 +1,58 @@
+package hk
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bgentry/heroku-go"
+)
+
+func TestLastLogMatches(t *testing.T) {
+	log := `2013-10-17T00:17:30.000000+00:00 app[worker.1]: heartbeat
+2013-10-17T00:17:35.000000+00:00 app[worker.2]: processing job 42
+2013-10-17T00:17:40.000000+00:00 app[worker.1]: heartbeat
+2013-10-17T00:17:45.000000+00:00 heroku[worker.2]: State changed from up to crashed
+garbage heartbeat
+2013-10-17T00:17:50.000000+00:00 app[worker.3]: heartbeat ok
+`
+	last, err := lastLogMatches(bufio.NewScanner(strings.NewReader(log)), regexp.MustCompile("heartbeat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"worker.1": "2013-10-17T00:17:40Z",
+		"worker.3": "2013-10-17T00:17:50Z",
+	}
+	if len(last) != len(want) {
+		t.Errorf("got %d dynos, want %d: %v", len(last), len(want), last)
+	}
+	for dyno, ts := range want {
+		if got := last[dyno].UTC().Format(time.RFC3339); got != ts {
+			t.Errorf("last[%s] = %s, want %s", dyno, got, ts)
+		}
+	}
+}
+
+func TestDynoSilent(t *testing.T) {
+	now := time.Date(2013, 10, 17, 1, 0, 0, 0, time.UTC)
+	old := heroku.Dyno{UpdatedAt: now.Add(-time.Hour)}
+	fresh := heroku.Dyno{UpdatedAt: now.Add(-time.Minute)}
+	tests := []struct {
+		dyno heroku.Dyno
+		last time.Time
+		want bool
+	}{
+		{old, now.Add(-time.Minute), false},
+		{old, now.Add(-10 * time.Minute), true},
+		{old, time.Time{}, true},
+		{fresh, time.Time{}, false},
+	}
+	for i, test := range tests {
+		if got := dynoSilent(&test.dyno, test.last, 5*time.Minute, now); got != test.want {
+			t.Errorf("%d: dynoSilent = %v, want %v", i, got, test.want)
+		}
+	}
+}